@@ -0,0 +1,169 @@
+// Package archive writes captured requests in the well-known exchange formats (HTTP Archive, JSON Lines, cURL
+// commands and Postman collections), and reads them back (HTTP Archive and JSON Lines only). The requests are
+// streamed one by one, so the whole archive is never kept in memory.
+package archive
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"gh.tarampamp.am/webhook-tester/v2/internal/storage"
+)
+
+// Format is an archive format.
+type Format string
+
+const (
+	FormatHAR     Format = "har"     // HTTP Archive 1.2 (http://www.softwareishard.com/blog/har-12-spec/)
+	FormatJSONL   Format = "jsonl"   // JSON Lines (one captured request per line)
+	FormatCurl    Format = "curl"    // shell script of cURL commands
+	FormatPostman Format = "postman" // Postman collection v2.1
+)
+
+// Formats returns all supported formats.
+func Formats() []Format { return []Format{FormatHAR, FormatJSONL, FormatCurl, FormatPostman} }
+
+var (
+	// ErrUnsupportedFormat is returned when the archive format is not supported.
+	ErrUnsupportedFormat = errors.New("unsupported format")
+
+	// ErrInvalidArchive is returned when the archive cannot be read (e.g., it's malformed).
+	ErrInvalidArchive = errors.New("invalid archive")
+)
+
+// ContentType returns the MIME type of the archive.
+func (f Format) ContentType() string {
+	switch f {
+	case FormatJSONL:
+		return "application/jsonl; charset=utf-8"
+	case FormatCurl:
+		return "text/x-shellscript; charset=utf-8"
+	default:
+		return "application/json; charset=utf-8"
+	}
+}
+
+// FileExt returns the file extension (with the leading dot) for the archive.
+func (f Format) FileExt() string {
+	switch f {
+	case FormatHAR:
+		return ".har"
+	case FormatJSONL:
+		return ".jsonl"
+	case FormatCurl:
+		return ".sh"
+	case FormatPostman:
+		return ".postman_collection.json"
+	}
+
+	return ""
+}
+
+// Writer writes captured requests to the archive.
+type Writer interface {
+	// Write appends the request with the specified ID to the archive.
+	Write(rID string, _ storage.Request) error
+
+	// Close finalizes the archive and flushes the buffered data. It does not close the underlying writer.
+	Close() error
+}
+
+// NewWriter creates a new archive writer of the specified format. The title is used as the archive name (where it
+// is supported by the format).
+func NewWriter(out io.Writer, f Format, title string) (Writer, error) {
+	var buf = bufio.NewWriter(out)
+
+	switch f {
+	case FormatHAR:
+		return newHARWriter(buf, title), nil
+	case FormatJSONL:
+		return newJSONLWriter(buf), nil
+	case FormatCurl:
+		return newCurlWriter(buf, title), nil
+	case FormatPostman:
+		return newPostmanWriter(buf, title), nil
+	}
+
+	return nil, fmt.Errorf("%w: %s", ErrUnsupportedFormat, f)
+}
+
+// Reader reads captured requests from the archive.
+type Reader interface {
+	// Next returns the next request from the archive. The request ID is not preserved (a new one should be
+	// generated), but the capture time and client address are. If there are no more requests, io.EOF is returned.
+	Next() (*storage.Request, error)
+}
+
+// NewReader creates a new archive reader of the specified format. Only the HAR and JSONL formats can be read. The
+// requests are read one by one, so the whole archive is never kept in memory.
+func NewReader(in io.Reader, f Format) (Reader, error) {
+	switch f {
+	case FormatHAR:
+		return newHARReader(in), nil
+	case FormatJSONL:
+		return newJSONLReader(in), nil
+	}
+
+	return nil, fmt.Errorf("%w: %s", ErrUnsupportedFormat, f)
+}
+
+// isText checks if the body can be written as is (without the base64 encoding).
+func isText(body []byte) bool { return utf8.Valid(body) && !slices.Contains(body, 0) }
+
+// headerValue returns the value of the first header with the specified name (case-insensitive).
+func headerValue(headers []storage.HttpHeader, name string) string {
+	for _, h := range headers {
+		if strings.EqualFold(h.Name, name) {
+			return h.Value
+		}
+	}
+
+	return ""
+}
+
+// capturedAt returns the request capture time in UTC.
+func capturedAt(r storage.Request) time.Time { return time.UnixMilli(r.CreatedAtUnixMilli).UTC() }
+
+// jsonArrayWriter writes a JSON document with an array of items (the items are written one by one).
+type jsonArrayWriter struct {
+	out        *bufio.Writer
+	head, tail string // the document parts before and after the array items
+	started    bool
+}
+
+// item writes the (already encoded) array item.
+func (w *jsonArrayWriter) item(data []byte) error {
+	var sep = ","
+
+	if !w.started {
+		w.started, sep = true, w.head
+	}
+
+	if _, err := w.out.WriteString(sep); err != nil {
+		return err
+	}
+
+	_, err := w.out.Write(data)
+
+	return err
+}
+
+func (w *jsonArrayWriter) close() error {
+	if !w.started {
+		if _, err := w.out.WriteString(w.head); err != nil {
+			return err
+		}
+	}
+
+	if _, err := w.out.WriteString(w.tail); err != nil {
+		return err
+	}
+
+	return w.out.Flush()
+}
@@ -0,0 +1,347 @@
+package archive_test
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gh.tarampamp.am/webhook-tester/v2/internal/archive"
+	"gh.tarampamp.am/webhook-tester/v2/internal/storage"
+)
+
+var (
+	textRequest = storage.Request{ //nolint:gochecknoglobals
+		ClientAddr: "10.0.0.1",
+		Method:     "POST",
+		Body:       []byte(`{"it's":"json"}`),
+		Headers: []storage.HttpHeader{
+			{Name: "Content-Type", Value: "application/json"},
+			{Name: "Content-Length", Value: "15"},
+			{Name: "X-Foo", Value: "bar"},
+		},
+		URL:                "https://example.com/foo?a=1&a=2",
+		CreatedAtUnixMilli: time.Date(2024, 1, 2, 3, 4, 5, 6_000_000, time.UTC).UnixMilli(),
+	}
+
+	binaryRequest = storage.Request{ //nolint:gochecknoglobals
+		ClientAddr:         "10.0.0.2",
+		Method:             "PUT",
+		Body:               []byte{0x00, 0xff, 0xfe, 'a'},
+		URL:                "https://example.com/bin",
+		CreatedAtUnixMilli: time.Date(2024, 1, 2, 3, 4, 6, 0, time.UTC).UnixMilli(),
+	}
+)
+
+func write(t *testing.T, f archive.Format, requests ...storage.Request) string {
+	t.Helper()
+
+	var buf bytes.Buffer
+
+	w, err := archive.NewWriter(&buf, f, "session foo")
+	require.NoError(t, err)
+
+	for i, r := range requests {
+		require.NoError(t, w.Write("id-"+string(rune('1'+i)), r))
+	}
+
+	require.NoError(t, w.Close())
+
+	return buf.String()
+}
+
+func TestNewWriter_Unsupported(t *testing.T) {
+	t.Parallel()
+
+	_, err := archive.NewWriter(&bytes.Buffer{}, "foo", "")
+	require.ErrorIs(t, err, archive.ErrUnsupportedFormat)
+}
+
+func TestFormat(t *testing.T) {
+	t.Parallel()
+
+	for _, f := range archive.Formats() {
+		assert.NotEmpty(t, f.ContentType())
+		assert.NotEmpty(t, f.FileExt())
+	}
+}
+
+func TestHAR(t *testing.T) {
+	t.Parallel()
+
+	t.Run("empty", func(t *testing.T) {
+		t.Parallel()
+
+		var har archive.HAR
+
+		require.NoError(t, json.Unmarshal([]byte(write(t, archive.FormatHAR)), &har))
+		assert.Equal(t, "1.2", har.Log.Version)
+		assert.Equal(t, "session foo", har.Log.Comment)
+		assert.Equal(t, "webhook-tester", har.Log.Creator.Name)
+		assert.NotNil(t, har.Log.Entries)
+		assert.Empty(t, har.Log.Entries)
+	})
+
+	t.Run("requests", func(t *testing.T) {
+		t.Parallel()
+
+		var har archive.HAR
+
+		require.NoError(t, json.Unmarshal([]byte(write(t, archive.FormatHAR, textRequest, binaryRequest)), &har))
+		require.Len(t, har.Log.Entries, 2)
+
+		var text, bin = har.Log.Entries[0], har.Log.Entries[1]
+
+		assert.Equal(t, "id-1", text.ID)
+		assert.Equal(t, "10.0.0.1", text.ClientAddress)
+		assert.Equal(t, textRequest.CreatedAtUnixMilli, text.StartedDateTime.UnixMilli())
+		assert.Equal(t, "POST", text.Request.Method)
+		assert.Equal(t, textRequest.URL, text.Request.URL)
+		assert.Len(t, text.Request.Headers, 3)
+		assert.ElementsMatch(t, []archive.HARNameValue{{"a", "1"}, {"a", "2"}}, text.Request.QueryString)
+		require.NotNil(t, text.Request.PostData)
+		assert.Equal(t, "application/json", text.Request.PostData.MimeType)
+		assert.Equal(t, string(textRequest.Body), text.Request.PostData.Text)
+		assert.Empty(t, text.Request.PostData.Encoding)
+		assert.EqualValues(t, len(textRequest.Body), text.Request.BodySize)
+
+		assert.Equal(t, "id-2", bin.ID)
+		require.NotNil(t, bin.Request.PostData)
+		assert.Equal(t, "base64", bin.Request.PostData.Encoding)
+		assert.Equal(t, base64.StdEncoding.EncodeToString(binaryRequest.Body), bin.Request.PostData.Text)
+		assert.NotNil(t, bin.Request.Headers)
+	})
+}
+
+func TestJSONL(t *testing.T) {
+	t.Parallel()
+
+	assert.Empty(t, write(t, archive.FormatJSONL))
+
+	var (
+		out     = write(t, archive.FormatJSONL, textRequest, binaryRequest)
+		scanner = bufio.NewScanner(strings.NewReader(out))
+		records []archive.JSONLRecord
+	)
+
+	for scanner.Scan() {
+		var rec archive.JSONLRecord
+
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &rec))
+
+		records = append(records, rec)
+	}
+
+	require.Len(t, records, 2)
+
+	assert.Equal(t, archive.JSONLRecord{
+		UUID:                 "id-1",
+		ClientAddress:        "10.0.0.1",
+		Method:               "POST",
+		RequestPayloadBase64: base64.StdEncoding.EncodeToString(textRequest.Body),
+		Headers:              textRequest.Headers,
+		URL:                  textRequest.URL,
+		CapturedAtUnixMilli:  textRequest.CreatedAtUnixMilli,
+	}, records[0])
+
+	assert.Equal(t, base64.StdEncoding.EncodeToString(binaryRequest.Body), records[1].RequestPayloadBase64)
+	assert.Equal(t, []storage.HttpHeader{}, records[1].Headers)
+}
+
+func TestCurl(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "#!/usr/bin/env sh\n\n# session foo\n# Exported by webhook-tester 0.0.0@undefined\n",
+		write(t, archive.FormatCurl),
+	)
+
+	var out = write(t, archive.FormatCurl, textRequest, binaryRequest)
+
+	assert.Contains(t, out, "\n# id-1 (captured at 2024-01-02T03:04:05Z from 10.0.0.1)\n"+
+		`curl -X 'POST' 'https://example.com/foo?a=1&a=2' \`+"\n"+
+		`  -H 'Content-Type: application/json' \`+"\n"+
+		`  -H 'X-Foo: bar' \`+"\n"+
+		`  --data-binary '{"it'"'"'s":"json"}'`+"\n",
+	)
+
+	assert.Contains(t, out, "\n# id-2 (captured at 2024-01-02T03:04:06Z from 10.0.0.2)\n"+
+		`printf '%s' 'AP/+YQ==' | base64 -d | curl -X 'PUT' 'https://example.com/bin' \`+"\n"+
+		`  --data-binary @-`+"\n",
+	)
+}
+
+func TestPostman(t *testing.T) {
+	t.Parallel()
+
+	t.Run("empty", func(t *testing.T) {
+		t.Parallel()
+
+		var c archive.PostmanCollection
+
+		require.NoError(t, json.Unmarshal([]byte(write(t, archive.FormatPostman)), &c))
+		assert.Equal(t, "session foo", c.Info.Name)
+		assert.NotEmpty(t, c.Info.PostmanID)
+		assert.Contains(t, c.Info.Schema, "v2.1.0")
+		assert.NotNil(t, c.Item)
+		assert.Empty(t, c.Item)
+	})
+
+	t.Run("requests", func(t *testing.T) {
+		t.Parallel()
+
+		var c archive.PostmanCollection
+
+		require.NoError(t, json.Unmarshal([]byte(write(t, archive.FormatPostman, textRequest, binaryRequest)), &c))
+		require.Len(t, c.Item, 2)
+
+		assert.Equal(t, archive.PostmanItem{
+			ID:   "id-1",
+			Name: "POST /foo",
+			Request: archive.PostmanRequest{
+				Method: "POST",
+				Header: []archive.PostmanHeader{
+					{Key: "Content-Type", Value: "application/json"},
+					{Key: "Content-Length", Value: "15"},
+					{Key: "X-Foo", Value: "bar"},
+				},
+				URL:  textRequest.URL,
+				Body: &archive.PostmanBody{Mode: "raw", Raw: string(textRequest.Body)},
+			},
+		}, c.Item[0])
+
+		require.NotNil(t, c.Item[1].Request.Body)
+		assert.Equal(t, base64.StdEncoding.EncodeToString(binaryRequest.Body), c.Item[1].Request.Body.Raw)
+		assert.NotEmpty(t, c.Item[1].Request.Description)
+	})
+}
+
+func readAll(t *testing.T, in string, f archive.Format) ([]storage.Request, error) {
+	t.Helper()
+
+	r, err := archive.NewReader(strings.NewReader(in), f)
+	require.NoError(t, err)
+
+	var list []storage.Request
+
+	for {
+		req, rErr := r.Next()
+		if errors.Is(rErr, io.EOF) {
+			return list, nil
+		} else if rErr != nil {
+			return list, rErr
+		}
+
+		list = append(list, *req)
+	}
+}
+
+func TestNewReader(t *testing.T) {
+	t.Parallel()
+
+	t.Run("unsupported", func(t *testing.T) {
+		t.Parallel()
+
+		for _, f := range []archive.Format{archive.FormatCurl, archive.FormatPostman, "foo"} {
+			_, err := archive.NewReader(strings.NewReader(""), f)
+			require.ErrorIs(t, err, archive.ErrUnsupportedFormat)
+		}
+	})
+
+	t.Run("round trip", func(t *testing.T) {
+		t.Parallel()
+
+		var wantBinary = binaryRequest
+
+		wantBinary.Headers = []storage.HttpHeader{} // nil headers are read as empty
+
+		for _, f := range []archive.Format{archive.FormatHAR, archive.FormatJSONL} {
+			list, err := readAll(t, write(t, f, textRequest, binaryRequest), f)
+			require.NoError(t, err, f)
+			require.Equal(t, []storage.Request{textRequest, wantBinary}, list, f)
+
+			list, err = readAll(t, write(t, f), f)
+			require.NoError(t, err, f)
+			require.Empty(t, list, f)
+		}
+	})
+
+	t.Run("har from the browser", func(t *testing.T) {
+		t.Parallel()
+
+		list, err := readAll(t, `{"log": {
+			"version": "1.2",
+			"creator": {"name": "WebInspector", "version": "537.36"},
+			"pages": [{"id": "page_1", "title": "https://example.com/", "pageTimings": {}}],
+			"entries": [{
+				"_initiator": {"type": "other"},
+				"startedDateTime": "2024-01-02T04:04:05.006+01:00",
+				"request": {
+					"method": "post",
+					"url": "https://example.com/api",
+					"headers": [{"name": ":authority", "value": "example.com"}, {"name": "accept", "value": "*/*"}],
+					"postData": {"mimeType": "text/plain", "text": "hello"}
+				},
+				"response": {"status": 200}
+			}, {
+				"startedDateTime": "2024-01-02T03:04:06Z",
+				"request": {"method": "GET", "url": "https://example.com/"}
+			}]
+		}}`, archive.FormatHAR)
+		require.NoError(t, err)
+		require.Equal(t, []storage.Request{
+			{
+				Method:             "POST",
+				URL:                "https://example.com/api",
+				Headers:            []storage.HttpHeader{{Name: "accept", Value: "*/*"}},
+				Body:               []byte("hello"),
+				CreatedAtUnixMilli: textRequest.CreatedAtUnixMilli,
+			},
+			{
+				Method:             "GET",
+				URL:                "https://example.com/",
+				Headers:            []storage.HttpHeader{},
+				CreatedAtUnixMilli: binaryRequest.CreatedAtUnixMilli,
+			},
+		}, list)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		t.Parallel()
+
+		for name, tc := range map[string]struct {
+			giveFormat  archive.Format
+			giveArchive string
+			wantCount   int
+		}{
+			"har: empty":           {archive.FormatHAR, "", 0},
+			"har: not a json":      {archive.FormatHAR, "foo", 0},
+			"har: truncated":       {archive.FormatHAR, `{"log": {"version": "1.2"`, 0},
+			"har: no log":          {archive.FormatHAR, `{"foo": {}}`, 0},
+			"har: no entries":      {archive.FormatHAR, `{"log": {"version": "1.2"}}`, 0},
+			"har: wrong entries":   {archive.FormatHAR, `{"log": {"entries": {}}}`, 0},
+			"har: no method":       {archive.FormatHAR, `{"log": {"entries": [{"request": {"url": "/"}}]}}`, 0},
+			"har: wrong base64":    {archive.FormatHAR, `{"log": {"entries": [{"request": {"method": "POST", "url": "/", "postData": {"text": "!", "encoding": "base64"}}}]}}`, 0}, //nolint:lll
+			"har: broken entry":    {archive.FormatHAR, `{"log": {"entries": [{"request": {"method": "GET", "url": "/"}}, {"request": 1}]}}`, 1},                                   //nolint:lll
+			"jsonl: not a json":    {archive.FormatJSONL, "foo", 0},
+			"jsonl: no url":        {archive.FormatJSONL, `{"method": "GET"}`, 0},
+			"jsonl: wrong base64":  {archive.FormatJSONL, `{"method": "GET", "url": "/", "request_payload_base64": "!"}`, 0},
+			"jsonl: broken record": {archive.FormatJSONL, `{"method": "GET", "url": "/"}` + "\n{\n", 1},
+		} {
+			t.Run(name, func(t *testing.T) {
+				t.Parallel()
+
+				list, err := readAll(t, tc.giveArchive, tc.giveFormat)
+				require.ErrorIs(t, err, archive.ErrInvalidArchive)
+				require.Len(t, list, tc.wantCount)
+			})
+		}
+	})
+}
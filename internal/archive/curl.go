@@ -0,0 +1,94 @@
+package archive
+
+import (
+	"bufio"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"time"
+
+	"gh.tarampamp.am/webhook-tester/v2/internal/storage"
+	"gh.tarampamp.am/webhook-tester/v2/internal/version"
+)
+
+type curlWriter struct {
+	out         *bufio.Writer
+	title       string
+	headWritten bool
+}
+
+func newCurlWriter(out *bufio.Writer, title string) *curlWriter {
+	return &curlWriter{out: out, title: title}
+}
+
+// curlSkipHeaders are the headers that are set by the curl itself (depending on the URL and body).
+var curlSkipHeaders = []string{"Host", "Content-Length"} //nolint:gochecknoglobals
+
+func (w *curlWriter) head() error {
+	if w.headWritten {
+		return nil
+	}
+
+	w.headWritten = true
+
+	_, err := fmt.Fprintf(w.out, "#!/usr/bin/env sh\n\n# %s\n# Exported by webhook-tester %s\n",
+		strings.ReplaceAll(w.title, "\n", " "), version.Version(),
+	)
+
+	return err
+}
+
+func (w *curlWriter) Write(rID string, r storage.Request) error {
+	if err := w.head(); err != nil {
+		return err
+	}
+
+	var b strings.Builder
+
+	_, _ = fmt.Fprintf(&b, "\n# %s (captured at %s from %s)\n",
+		rID, capturedAt(r).Format(time.RFC3339), strings.ReplaceAll(r.ClientAddr, "\n", " "),
+	)
+
+	var binary = len(r.Body) > 0 && !isText(r.Body)
+
+	if binary { // the body is piped to the curl stdin
+		_, _ = fmt.Fprintf(&b, "printf '%%s' %s | base64 -d | ", shellQuote(base64.StdEncoding.EncodeToString(r.Body)))
+	}
+
+	_, _ = fmt.Fprintf(&b, "curl -X %s %s", shellQuote(r.Method), shellQuote(r.URL))
+
+headers:
+	for _, h := range r.Headers {
+		for _, skip := range curlSkipHeaders {
+			if strings.EqualFold(h.Name, skip) {
+				continue headers
+			}
+		}
+
+		_, _ = fmt.Fprintf(&b, " \\\n  -H %s", shellQuote(h.Name+": "+h.Value))
+	}
+
+	switch {
+	case binary:
+		b.WriteString(" \\\n  --data-binary @-")
+	case len(r.Body) > 0:
+		_, _ = fmt.Fprintf(&b, " \\\n  --data-binary %s", shellQuote(string(r.Body)))
+	}
+
+	b.WriteString("\n")
+
+	_, err := w.out.WriteString(b.String())
+
+	return err
+}
+
+func (w *curlWriter) Close() error {
+	if err := w.head(); err != nil {
+		return err
+	}
+
+	return w.out.Flush()
+}
+
+// shellQuote quotes the string for the POSIX shell (single quotes, the single quote itself is escaped).
+func shellQuote(s string) string { return "'" + strings.ReplaceAll(s, "'", `'"'"'`) + "'" }
@@ -0,0 +1,304 @@
+package archive
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"time"
+
+	"gh.tarampamp.am/webhook-tester/v2/internal/storage"
+	"gh.tarampamp.am/webhook-tester/v2/internal/version"
+)
+
+type (
+	// HAR is an HTTP Archive 1.2 document (only the fields used by the app are described).
+	HAR struct {
+		Log HARLog `json:"log"`
+	}
+
+	HARLog struct {
+		Version string     `json:"version"`
+		Creator HARCreator `json:"creator"`
+		Comment string     `json:"comment,omitempty"`
+		Entries []HAREntry `json:"entries"`
+	}
+
+	HARCreator struct {
+		Name    string `json:"name"`
+		Version string `json:"version"`
+	}
+
+	HAREntry struct {
+		StartedDateTime time.Time   `json:"startedDateTime"`
+		Time            float64     `json:"time"`
+		Request         HARRequest  `json:"request"`
+		Response        HARResponse `json:"response"`
+		Cache           struct{}    `json:"cache"`
+		Timings         HARTimings  `json:"timings"`
+		ID              string      `json:"_id,omitempty"`            // custom field - the captured request ID
+		ClientAddress   string      `json:"_clientAddress,omitempty"` // custom field - the client address
+	}
+
+	HARRequest struct {
+		Method      string         `json:"method"`
+		URL         string         `json:"url"`
+		HTTPVersion string         `json:"httpVersion"`
+		Cookies     []HARNameValue `json:"cookies"`
+		Headers     []HARNameValue `json:"headers"`
+		QueryString []HARNameValue `json:"queryString"`
+		PostData    *HARPostData   `json:"postData,omitempty"`
+		HeadersSize int64          `json:"headersSize"`
+		BodySize    int64          `json:"bodySize"`
+	}
+
+	// HARPostData is the request body. The Encoding field is not a part of the HAR 1.2 spec for the request, but it's
+	// widely used (the same as for the response content) to store binary bodies.
+	HARPostData struct {
+		MimeType string `json:"mimeType"`
+		Text     string `json:"text"`
+		Encoding string `json:"encoding,omitempty"` // "base64" or empty
+	}
+
+	HARResponse struct {
+		Status      int            `json:"status"`
+		StatusText  string         `json:"statusText"`
+		HTTPVersion string         `json:"httpVersion"`
+		Cookies     []HARNameValue `json:"cookies"`
+		Headers     []HARNameValue `json:"headers"`
+		Content     HARContent     `json:"content"`
+		RedirectURL string         `json:"redirectURL"`
+		HeadersSize int64          `json:"headersSize"`
+		BodySize    int64          `json:"bodySize"`
+	}
+
+	HARContent struct {
+		Size     int64  `json:"size"`
+		MimeType string `json:"mimeType"`
+		Text     string `json:"text,omitempty"`
+		Encoding string `json:"encoding,omitempty"`
+	}
+
+	HARNameValue struct {
+		Name  string `json:"name"`
+		Value string `json:"value"`
+	}
+
+	HARTimings struct {
+		Send    float64 `json:"send"`
+		Wait    float64 `json:"wait"`
+		Receive float64 `json:"receive"`
+	}
+)
+
+// harVersion is the supported HTTP Archive version.
+const harVersion = "1.2"
+
+// harEncodingBase64 is the body encoding for binary payloads.
+const harEncodingBase64 = "base64"
+
+type harWriter struct{ arr jsonArrayWriter }
+
+func newHARWriter(out *bufio.Writer, title string) *harWriter {
+	var (
+		creator, _ = json.Marshal(HARCreator{Name: "webhook-tester", Version: version.Version()})
+		comment, _ = json.Marshal(title)
+	)
+
+	return &harWriter{arr: jsonArrayWriter{
+		out:  out,
+		head: `{"log":{"version":"` + harVersion + `","creator":` + string(creator) + `,"comment":` + string(comment) + `,"entries":[`, //nolint:lll
+		tail: "]}}\n",
+	}}
+}
+
+func (w *harWriter) Write(rID string, r storage.Request) error {
+	data, err := json.Marshal(toHAREntry(rID, r))
+	if err != nil {
+		return err
+	}
+
+	return w.arr.item(data)
+}
+
+func (w *harWriter) Close() error { return w.arr.close() }
+
+// toHAREntry converts the captured request into the HAR entry. The webhook response is not stored, so the entry
+// response is empty (the status is 0, like for the requests without a response).
+func toHAREntry(rID string, r storage.Request) HAREntry {
+	var entry = HAREntry{
+		StartedDateTime: capturedAt(r),
+		ID:              rID,
+		ClientAddress:   r.ClientAddr,
+		Request: HARRequest{
+			Method:      r.Method,
+			URL:         r.URL,
+			HTTPVersion: "HTTP/1.1",
+			Cookies:     []HARNameValue{},
+			Headers:     make([]HARNameValue, len(r.Headers)),
+			QueryString: []HARNameValue{},
+			HeadersSize: -1,
+			BodySize:    int64(len(r.Body)),
+		},
+		Response: HARResponse{
+			Cookies:     []HARNameValue{},
+			Headers:     []HARNameValue{},
+			HeadersSize: -1,
+			BodySize:    -1,
+		},
+	}
+
+	for i, h := range r.Headers {
+		entry.Request.Headers[i] = HARNameValue{Name: h.Name, Value: h.Value}
+	}
+
+	if u, err := url.Parse(r.URL); err == nil {
+		for name, values := range u.Query() {
+			for _, value := range values {
+				entry.Request.QueryString = append(entry.Request.QueryString, HARNameValue{Name: name, Value: value})
+			}
+		}
+	}
+
+	if len(r.Body) > 0 {
+		entry.Request.PostData = &HARPostData{MimeType: headerValue(r.Headers, "Content-Type")}
+
+		if isText(r.Body) {
+			entry.Request.PostData.Text = string(r.Body)
+		} else {
+			entry.Request.PostData.Text = base64.StdEncoding.EncodeToString(r.Body)
+			entry.Request.PostData.Encoding = harEncodingBase64
+		}
+	}
+
+	return entry
+}
+
+type harReader struct {
+	dec   *json.Decoder
+	ready bool // the decoder is positioned inside the "log.entries" array
+}
+
+func newHARReader(in io.Reader) *harReader { return &harReader{dec: json.NewDecoder(in)} }
+
+func (r *harReader) Next() (*storage.Request, error) {
+	if !r.ready {
+		if err := r.seekEntries(); err != nil {
+			return nil, err
+		}
+
+		r.ready = true
+	}
+
+	if !r.dec.More() {
+		return nil, io.EOF // the rest of the document is not interesting
+	}
+
+	var entry HAREntry
+
+	if err := r.dec.Decode(&entry); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidArchive, err)
+	}
+
+	return fromHAREntry(entry)
+}
+
+// seekEntries moves the decoder to the first item of the "log.entries" array (the entries are decoded one by one,
+// without reading the whole document).
+func (r *harReader) seekEntries() error {
+	for _, key := range []string{"log", "entries"} {
+		if err := seekJSONKey(r.dec, key); err != nil {
+			return err
+		}
+	}
+
+	if tok, err := r.dec.Token(); err != nil {
+		return harInvalid(err)
+	} else if d, ok := tok.(json.Delim); !ok || d != '[' {
+		return fmt.Errorf("%w: entries should be an array", ErrInvalidArchive)
+	}
+
+	return nil
+}
+
+// seekJSONKey reads the object start and skips the object properties until the specified key is found.
+func seekJSONKey(dec *json.Decoder, key string) error {
+	if tok, err := dec.Token(); err != nil {
+		return harInvalid(err)
+	} else if d, ok := tok.(json.Delim); !ok || d != '{' {
+		return fmt.Errorf("%w: an object with the %q property expected", ErrInvalidArchive, key)
+	}
+
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return harInvalid(err)
+		}
+
+		if name, _ := tok.(string); name == key {
+			return nil
+		}
+
+		var skip json.RawMessage
+
+		if err = dec.Decode(&skip); err != nil {
+			return harInvalid(err)
+		}
+	}
+
+	return fmt.Errorf("%w: the %q property is missing", ErrInvalidArchive, key)
+}
+
+// fromHAREntry converts the HAR entry into the captured request.
+func fromHAREntry(entry HAREntry) (*storage.Request, error) {
+	if entry.Request.Method == "" || entry.Request.URL == "" {
+		return nil, fmt.Errorf("%w: the entry request method and URL are required", ErrInvalidArchive)
+	}
+
+	var r = storage.Request{
+		ClientAddr: entry.ClientAddress,
+		Method:     strings.ToUpper(entry.Request.Method),
+		Headers:    make([]storage.HttpHeader, 0, len(entry.Request.Headers)),
+		URL:        entry.Request.URL,
+	}
+
+	if !entry.StartedDateTime.IsZero() {
+		r.CreatedAtUnixMilli = entry.StartedDateTime.UnixMilli()
+	}
+
+	for _, h := range entry.Request.Headers {
+		if strings.HasPrefix(h.Name, ":") {
+			continue // skip HTTP/2 pseudo-headers (e.g. ":authority"), exported by browsers
+		}
+
+		r.Headers = append(r.Headers, storage.HttpHeader{Name: h.Name, Value: h.Value})
+	}
+
+	if pd := entry.Request.PostData; pd != nil && pd.Text != "" {
+		if pd.Encoding == harEncodingBase64 {
+			body, err := base64.StdEncoding.DecodeString(pd.Text)
+			if err != nil {
+				return nil, fmt.Errorf("%w: wrong base64 body: %w", ErrInvalidArchive, err)
+			}
+
+			r.Body = body
+		} else {
+			r.Body = []byte(pd.Text)
+		}
+	}
+
+	return &r, nil
+}
+
+// harInvalid wraps the HAR reading error (the unexpected end of the document is not the end of the entries).
+func harInvalid(err error) error {
+	if errors.Is(err, io.EOF) {
+		err = io.ErrUnexpectedEOF
+	}
+
+	return fmt.Errorf("%w: %w", ErrInvalidArchive, err)
+}
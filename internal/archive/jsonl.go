@@ -0,0 +1,90 @@
+package archive
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"gh.tarampamp.am/webhook-tester/v2/internal/storage"
+)
+
+// JSONLRecord is a single line of the JSON Lines archive. The fields are the same as in the captured request API
+// response, so the lines can be processed by the same tools.
+type JSONLRecord struct {
+	UUID                 string               `json:"uuid"`
+	ClientAddress        string               `json:"client_address"`
+	Method               string               `json:"method"`
+	RequestPayloadBase64 string               `json:"request_payload_base64"`
+	Headers              []storage.HttpHeader `json:"headers"`
+	URL                  string               `json:"url"`
+	CapturedAtUnixMilli  int64                `json:"captured_at_unix_milli"`
+}
+
+type jsonlWriter struct {
+	out *bufio.Writer
+	enc *json.Encoder
+}
+
+func newJSONLWriter(out *bufio.Writer) *jsonlWriter {
+	return &jsonlWriter{out: out, enc: json.NewEncoder(out)}
+}
+
+func (w *jsonlWriter) Write(rID string, r storage.Request) error {
+	var headers = r.Headers
+	if headers == nil {
+		headers = []storage.HttpHeader{}
+	}
+
+	return w.enc.Encode(JSONLRecord{ // the encoder appends a newline
+		UUID:                 rID,
+		ClientAddress:        r.ClientAddr,
+		Method:               r.Method,
+		RequestPayloadBase64: base64.StdEncoding.EncodeToString(r.Body),
+		Headers:              headers,
+		URL:                  r.URL,
+		CapturedAtUnixMilli:  r.CreatedAtUnixMilli,
+	})
+}
+
+func (w *jsonlWriter) Close() error { return w.out.Flush() }
+
+type jsonlReader struct{ dec *json.Decoder }
+
+func newJSONLReader(in io.Reader) *jsonlReader { return &jsonlReader{dec: json.NewDecoder(in)} }
+
+func (r *jsonlReader) Next() (*storage.Request, error) {
+	var rec JSONLRecord
+
+	if err := r.dec.Decode(&rec); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, io.EOF
+		}
+
+		return nil, fmt.Errorf("%w: %w", ErrInvalidArchive, err)
+	}
+
+	if rec.Method == "" || rec.URL == "" {
+		return nil, fmt.Errorf("%w: the method and URL are required", ErrInvalidArchive)
+	}
+
+	body, err := base64.StdEncoding.DecodeString(rec.RequestPayloadBase64)
+	if err != nil {
+		return nil, fmt.Errorf("%w: wrong base64 body: %w", ErrInvalidArchive, err)
+	}
+
+	if len(body) == 0 {
+		body = nil
+	}
+
+	return &storage.Request{
+		ClientAddr:         rec.ClientAddress,
+		Method:             rec.Method,
+		Body:               body,
+		Headers:            rec.Headers,
+		URL:                rec.URL,
+		CreatedAtUnixMilli: rec.CapturedAtUnixMilli,
+	}, nil
+}
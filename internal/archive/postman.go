@@ -0,0 +1,109 @@
+package archive
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/json"
+	"net/url"
+
+	"github.com/google/uuid"
+
+	"gh.tarampamp.am/webhook-tester/v2/internal/storage"
+)
+
+type (
+	// PostmanCollection is a Postman collection v2.1 (only the fields used by the app are described).
+	PostmanCollection struct {
+		Info PostmanInfo   `json:"info"`
+		Item []PostmanItem `json:"item"`
+	}
+
+	PostmanInfo struct {
+		PostmanID string `json:"_postman_id"`
+		Name      string `json:"name"`
+		Schema    string `json:"schema"`
+	}
+
+	PostmanItem struct {
+		ID      string         `json:"id"`
+		Name    string         `json:"name"`
+		Request PostmanRequest `json:"request"`
+	}
+
+	PostmanRequest struct {
+		Method      string          `json:"method"`
+		Header      []PostmanHeader `json:"header"`
+		URL         string          `json:"url"`
+		Body        *PostmanBody    `json:"body,omitempty"`
+		Description string          `json:"description,omitempty"`
+	}
+
+	PostmanHeader struct {
+		Key   string `json:"key"`
+		Value string `json:"value"`
+	}
+
+	PostmanBody struct {
+		Mode string `json:"mode"` // always "raw"
+		Raw  string `json:"raw"`
+	}
+)
+
+// postmanSchema is the supported Postman collection schema.
+const postmanSchema = "https://schema.getpostman.com/json/collection/v2.1.0/collection.json"
+
+type postmanWriter struct{ arr jsonArrayWriter }
+
+func newPostmanWriter(out *bufio.Writer, title string) *postmanWriter {
+	var info, _ = json.Marshal(PostmanInfo{PostmanID: uuid.New().String(), Name: title, Schema: postmanSchema})
+
+	return &postmanWriter{arr: jsonArrayWriter{
+		out:  out,
+		head: `{"info":` + string(info) + `,"item":[`,
+		tail: "]}\n",
+	}}
+}
+
+func (w *postmanWriter) Write(rID string, r storage.Request) error {
+	data, err := json.Marshal(toPostmanItem(rID, r))
+	if err != nil {
+		return err
+	}
+
+	return w.arr.item(data)
+}
+
+func (w *postmanWriter) Close() error { return w.arr.close() }
+
+// toPostmanItem converts the captured request into the Postman collection item. Postman has no way to store binary
+// bodies inline, so they are base64-encoded (and this is noted in the request description).
+func toPostmanItem(rID string, r storage.Request) PostmanItem {
+	var item = PostmanItem{
+		ID:   rID,
+		Name: r.Method + " " + r.URL,
+		Request: PostmanRequest{
+			Method: r.Method,
+			Header: make([]PostmanHeader, len(r.Headers)),
+			URL:    r.URL,
+		},
+	}
+
+	if u, err := url.Parse(r.URL); err == nil && u.Path != "" {
+		item.Name = r.Method + " " + u.Path
+	}
+
+	for i, h := range r.Headers {
+		item.Request.Header[i] = PostmanHeader{Key: h.Name, Value: h.Value}
+	}
+
+	if len(r.Body) > 0 {
+		if isText(r.Body) {
+			item.Request.Body = &PostmanBody{Mode: "raw", Raw: string(r.Body)}
+		} else {
+			item.Request.Body = &PostmanBody{Mode: "raw", Raw: base64.StdEncoding.EncodeToString(r.Body)}
+			item.Request.Description = "The request body is binary, so it is base64-encoded"
+		}
+	}
+
+	return item
+}
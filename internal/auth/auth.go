@@ -0,0 +1,124 @@
+// Package auth authenticates the API requests. The authenticators are pluggable - any type that implements the
+// Authenticator interface can be used, and several authenticators can be combined using the Chain.
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+var (
+	// ErrNoCredentials is returned when the request has no credentials, supported by the authenticator.
+	ErrNoCredentials = errors.New("no credentials")
+
+	// ErrInvalidCredentials is returned when the request credentials are wrong.
+	ErrInvalidCredentials = errors.New("invalid credentials")
+)
+
+// User is an authenticated user.
+type User struct {
+	Name string // unique user name (used as the sessions owner)
+}
+
+// Authenticator authenticates the HTTP requests.
+type Authenticator interface {
+	// Authenticate returns the user the request is made by. If the request has no credentials this authenticator
+	// supports, ErrNoCredentials will be returned. If the credentials are wrong, ErrInvalidCredentials will be
+	// returned.
+	Authenticate(*http.Request) (*User, error)
+}
+
+// Challenger is an optional Authenticator capability - it returns the WWW-Authenticate header value, which is sent
+// to the client when the request is not authenticated.
+type Challenger interface {
+	Challenge() string
+}
+
+// Chain combines several authenticators. The first authenticator that finds the supported credentials in the
+// request decides the result.
+type Chain []Authenticator
+
+var (
+	_ Authenticator = Chain(nil) // verify interface implementation
+	_ Challenger    = Chain(nil)
+)
+
+// Authenticate implements the Authenticator interface.
+func (c Chain) Authenticate(r *http.Request) (*User, error) {
+	for _, a := range c {
+		if u, err := a.Authenticate(r); !errors.Is(err, ErrNoCredentials) {
+			return u, err
+		}
+	}
+
+	return nil, ErrNoCredentials
+}
+
+// Challenge implements the Challenger interface.
+func (c Chain) Challenge() string {
+	var challenges = make([]string, 0, len(c))
+
+	for _, a := range c {
+		if ch, ok := a.(Challenger); ok && !slices.Contains(challenges, ch.Challenge()) { // skip the duplicates
+			challenges = append(challenges, ch.Challenge())
+		}
+	}
+
+	return strings.Join(challenges, ", ")
+}
+
+// Credential is a user secret (password or token).
+type Credential struct {
+	User, Secret string
+}
+
+// ParseCredentials parses the credentials in the "user:secret" format (the secret may contain colons).
+func ParseCredentials(list []string) ([]Credential, error) {
+	var out = make([]Credential, 0, len(list))
+
+	for _, item := range list {
+		user, secret, found := strings.Cut(item, ":")
+		if !found || user == "" || secret == "" {
+			return nil, fmt.Errorf("wrong credentials format (user:secret is expected)")
+		}
+
+		out = append(out, Credential{User: user, Secret: secret})
+	}
+
+	return out, nil
+}
+
+type userCtxKey struct{}
+
+// WithUser returns a copy of the context with the authenticated user.
+func WithUser(ctx context.Context, u *User) context.Context {
+	return context.WithValue(ctx, userCtxKey{}, u)
+}
+
+// UserFromContext returns the authenticated user from the context (nil if the authentication is disabled).
+func UserFromContext(ctx context.Context) *User {
+	if u, ok := ctx.Value(userCtxKey{}).(*User); ok {
+		return u
+	}
+
+	return nil
+}
+
+// OwnerFromContext returns the name of the authenticated user from the context, which should be used as the owner
+// of the created sessions (empty if the authentication is disabled).
+func OwnerFromContext(ctx context.Context) string {
+	if u := UserFromContext(ctx); u != nil {
+		return u.Name
+	}
+
+	return ""
+}
+
+// CanAccess checks if the user can access the session with the specified owner. The sessions without an owner
+// (e.g. created automatically by the incoming webhooks, or before the authentication was enabled) are accessible
+// by any user, and any session is accessible when the authentication is disabled (the user is nil).
+func CanAccess(u *User, owner string) bool { return u == nil || owner == "" || owner == u.Name }
@@ -0,0 +1,193 @@
+package auth_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gh.tarampamp.am/webhook-tester/v2/internal/auth"
+)
+
+func TestParseCredentials(t *testing.T) {
+	t.Parallel()
+
+	got, err := auth.ParseCredentials([]string{"alice:secret", "bob:pass:with:colons"})
+	require.NoError(t, err)
+	assert.Equal(t, []auth.Credential{{"alice", "secret"}, {"bob", "pass:with:colons"}}, got)
+
+	for _, wrong := range []string{"", "alice", "alice:", ":secret"} {
+		_, err = auth.ParseCredentials([]string{wrong})
+		assert.Error(t, err, wrong)
+	}
+}
+
+func TestStaticTokens(t *testing.T) {
+	t.Parallel()
+
+	var a = auth.NewStaticTokens([]auth.Credential{{"alice", "token1"}, {"alice", "token2"}, {"bob", "token3"}})
+
+	for name, tc := range map[string]struct {
+		giveRequest func() *http.Request
+		wantUser    string
+		wantErr     error
+	}{
+		"bearer": {
+			giveRequest: func() *http.Request {
+				var r = httptest.NewRequest(http.MethodGet, "/", http.NoBody)
+
+				r.Header.Set("Authorization", "bearer token2")
+
+				return r
+			},
+			wantUser: "alice",
+		},
+		"api key header": {
+			giveRequest: func() *http.Request {
+				var r = httptest.NewRequest(http.MethodGet, "/", http.NoBody)
+
+				r.Header.Set("X-Api-Key", "token3")
+
+				return r
+			},
+			wantUser: "bob",
+		},
+		"query parameter": {
+			giveRequest: func() *http.Request {
+				return httptest.NewRequest(http.MethodGet, "/?access_token=token1", http.NoBody)
+			},
+			wantUser: "alice",
+		},
+		"wrong token": {
+			giveRequest: func() *http.Request {
+				var r = httptest.NewRequest(http.MethodGet, "/", http.NoBody)
+
+				r.Header.Set("Authorization", "Bearer foo")
+
+				return r
+			},
+			wantErr: auth.ErrInvalidCredentials,
+		},
+		"basic auth": {
+			giveRequest: func() *http.Request {
+				var r = httptest.NewRequest(http.MethodGet, "/?access_token=token1", http.NoBody)
+
+				r.SetBasicAuth("alice", "token1")
+
+				return r
+			},
+			wantErr: auth.ErrNoCredentials,
+		},
+		"no credentials": {
+			giveRequest: func() *http.Request { return httptest.NewRequest(http.MethodGet, "/", http.NoBody) },
+			wantErr:     auth.ErrNoCredentials,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			u, err := a.Authenticate(tc.giveRequest())
+
+			if tc.wantErr != nil {
+				require.ErrorIs(t, err, tc.wantErr)
+				require.Nil(t, u)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.wantUser, u.Name)
+		})
+	}
+}
+
+func TestBasic(t *testing.T) {
+	t.Parallel()
+
+	var a = auth.NewBasic([]auth.Credential{{"alice", "secret"}})
+
+	var newRequest = func(user, password string) *http.Request {
+		var r = httptest.NewRequest(http.MethodGet, "/", http.NoBody)
+
+		r.SetBasicAuth(user, password)
+
+		return r
+	}
+
+	u, err := a.Authenticate(newRequest("alice", "secret"))
+	require.NoError(t, err)
+	assert.Equal(t, "alice", u.Name)
+
+	_, err = a.Authenticate(newRequest("alice", "wrong"))
+	require.ErrorIs(t, err, auth.ErrInvalidCredentials)
+
+	_, err = a.Authenticate(newRequest("bob", "secret"))
+	require.ErrorIs(t, err, auth.ErrInvalidCredentials)
+
+	_, err = a.Authenticate(httptest.NewRequest(http.MethodGet, "/", http.NoBody))
+	require.ErrorIs(t, err, auth.ErrNoCredentials)
+}
+
+func TestChain(t *testing.T) {
+	t.Parallel()
+
+	var a = auth.Chain{
+		auth.NewStaticTokens([]auth.Credential{{"bob", "token"}}),
+		auth.NewBasic([]auth.Credential{{"alice", "secret"}}),
+	}
+
+	var r = httptest.NewRequest(http.MethodGet, "/", http.NoBody)
+
+	r.SetBasicAuth("alice", "secret")
+
+	u, err := a.Authenticate(r)
+	require.NoError(t, err)
+	assert.Equal(t, "alice", u.Name)
+
+	r = httptest.NewRequest(http.MethodGet, "/", http.NoBody)
+	r.Header.Set("Authorization", "Bearer token")
+
+	u, err = a.Authenticate(r)
+	require.NoError(t, err)
+	assert.Equal(t, "bob", u.Name)
+
+	r = httptest.NewRequest(http.MethodGet, "/", http.NoBody)
+	r.Header.Set("Authorization", "Bearer wrong")
+
+	_, err = a.Authenticate(r)
+	require.ErrorIs(t, err, auth.ErrInvalidCredentials)
+
+	_, err = a.Authenticate(httptest.NewRequest(http.MethodGet, "/", http.NoBody))
+	require.ErrorIs(t, err, auth.ErrNoCredentials)
+
+	assert.Equal(t, `Bearer realm="webhook-tester", Basic realm="webhook-tester", charset="UTF-8"`, a.Challenge())
+
+	a = append(a, auth.NewStaticTokens(nil)) // the same challenge is not repeated
+	assert.Equal(t, `Bearer realm="webhook-tester", Basic realm="webhook-tester", charset="UTF-8"`, a.Challenge())
+}
+
+func TestContext(t *testing.T) {
+	t.Parallel()
+
+	var ctx = context.Background()
+
+	assert.Nil(t, auth.UserFromContext(ctx))
+	assert.Empty(t, auth.OwnerFromContext(ctx))
+
+	ctx = auth.WithUser(ctx, &auth.User{Name: "alice"})
+
+	assert.Equal(t, &auth.User{Name: "alice"}, auth.UserFromContext(ctx))
+	assert.Equal(t, "alice", auth.OwnerFromContext(ctx))
+}
+
+func TestCanAccess(t *testing.T) {
+	t.Parallel()
+
+	assert.True(t, auth.CanAccess(nil, "alice"))                       // authentication is disabled
+	assert.True(t, auth.CanAccess(&auth.User{Name: "bob"}, ""))        // no owner
+	assert.True(t, auth.CanAccess(&auth.User{Name: "alice"}, "alice")) // owner
+	assert.False(t, auth.CanAccess(&auth.User{Name: "bob"}, "alice"))  // another user
+}
@@ -0,0 +1,125 @@
+package oidc
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+)
+
+const (
+	sessionCookieName = "wh_session"   // the user session (set after the successful login)
+	flowCookieName    = "wh_oidc_flow" // the login flow state (set before the redirect to the provider)
+
+	flowTTL = 10 * time.Minute // how long the login flow state is valid
+)
+
+type (
+	// session is the user session, stored in the encrypted cookie.
+	session struct {
+		User    string `json:"u"`           // the user name
+		Expiry  int64  `json:"e"`           // unix time (seconds), when the session expires
+		Refresh string `json:"r,omitempty"` // the refresh token (used to extend the expired session)
+	}
+
+	// flow is the state of the login flow, stored in the encrypted cookie.
+	flow struct {
+		State    string `json:"s"` // the OAuth 2.0 state (CSRF protection)
+		Nonce    string `json:"n"` // the OpenID Connect nonce (replay protection)
+		Verifier string `json:"v"` // the PKCE code verifier
+		Return   string `json:"r"` // the local path to redirect to after the login
+		Expiry   int64  `json:"e"` // unix time (seconds), when the flow expires
+	}
+)
+
+// valid checks if the session is not expired.
+func (s *session) valid(now time.Time) bool { return s.User != "" && now.Unix() < s.Expiry }
+
+// sealer encrypts and authenticates the cookie values, so they cannot be read or modified by the client.
+type sealer struct{ aead cipher.AEAD }
+
+func newSealer(secret []byte) (*sealer, error) {
+	var key = sha256.Sum256(secret) // the key is derived from the secret of any length
+
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	return &sealer{aead: aead}, nil
+}
+
+// seal encodes the value into the JSON, encrypts it and returns as a base64 (URL-safe) string. The cookie name is
+// used as the additional data, so the sealed value of one cookie cannot be used as another.
+func (s *sealer) seal(name string, v any) (string, error) {
+	plain, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+
+	var nonce = make([]byte, s.aead.NonceSize())
+
+	if _, err = rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(s.aead.Seal(nonce, nonce, plain, []byte(name))), nil
+}
+
+// open is the opposite of seal.
+func (s *sealer) open(name, sealed string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(sealed)
+	if err != nil {
+		return err
+	}
+
+	if len(data) < s.aead.NonceSize() {
+		return errors.New("sealed value is too short")
+	}
+
+	plain, err := s.aead.Open(nil, data[:s.aead.NonceSize()], data[s.aead.NonceSize():], []byte(name))
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(plain, v)
+}
+
+// sessionFromRequest reads the session from the request cookie. The session may be expired.
+func (p *Provider) sessionFromRequest(r *http.Request) (*session, bool) {
+	c, err := r.Cookie(sessionCookieName)
+	if err != nil {
+		return nil, false
+	}
+
+	var s session
+
+	if err = p.cookies.open(sessionCookieName, c.Value, &s); err != nil || s.User == "" {
+		return nil, false
+	}
+
+	return &s, true
+}
+
+// newCookie creates a new cookie with the common attributes. The negative maxAge removes the cookie.
+func (p *Provider) newCookie(name, value, path string, maxAge int) *http.Cookie {
+	return &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     path,
+		MaxAge:   maxAge,
+		Secure:   p.secureCookies,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode, // the cookies must be sent on the redirect from the provider
+	}
+}
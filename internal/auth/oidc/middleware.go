@@ -0,0 +1,279 @@
+package oidc
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+const (
+	LoginPath  = "/auth/login"  // starts the login flow (the "return" query parameter sets the path to return to)
+	LogoutPath = "/auth/logout" // removes the session and logs out from the provider (if supported)
+)
+
+// defaultSessionTTL is used when the provider does not report the token expiration time.
+const defaultSessionTTL = time.Hour
+
+// Middleware handles the login, callback and logout routes, and refreshes the expired sessions (if the refresh token
+// is available). The requests without a valid session are redirected to the login page (or rejected with the 401
+// status code, if the request is not a page navigation), except the ones the skip function returns true for (e.g.
+// the API routes, which are authenticated separately, or the probes).
+func (p *Provider) Middleware(skip func(*http.Request) bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case LoginPath:
+				p.handleLogin(w, r)
+
+				return
+			case p.callbackPath:
+				p.handleCallback(w, r)
+
+				return
+			case LogoutPath:
+				p.handleLogout(w, r)
+
+				return
+			}
+
+			r = p.refreshSession(w, r)
+
+			if skip != nil && skip(r) {
+				next.ServeHTTP(w, r)
+
+				return
+			}
+
+			if s, ok := p.sessionFromRequest(r); !ok || !s.valid(p.now()) {
+				if r.Method == http.MethodGet || r.Method == http.MethodHead {
+					http.Redirect(w, r, LoginPath+"?"+url.Values{"return": {r.URL.RequestURI()}}.Encode(), http.StatusFound)
+				} else {
+					http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+				}
+
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// handleLogin starts the authorization code flow: the flow state is stored in the cookie, and the user is
+// redirected to the provider.
+func (p *Provider) handleLogin(w http.ResponseWriter, r *http.Request) {
+	var f = flow{
+		State:    rand.Text(),
+		Nonce:    rand.Text(),
+		Verifier: oauth2.GenerateVerifier(),
+		Return:   safeReturnPath(r.URL.Query().Get("return")),
+		Expiry:   p.now().Add(flowTTL).Unix(),
+	}
+
+	sealed, err := p.cookies.seal(flowCookieName, f)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+
+		return
+	}
+
+	http.SetCookie(w, p.newCookie(flowCookieName, sealed, p.callbackPath, int(flowTTL.Seconds())))
+	http.Redirect(w, r, p.oauth.AuthCodeURL(f.State,
+		oauth2.S256ChallengeOption(f.Verifier),
+		oauth2.SetAuthURLParam("nonce", f.Nonce),
+	), http.StatusFound)
+}
+
+// handleCallback completes the authorization code flow: the code is exchanged for the tokens, the ID token is
+// verified, and the session cookie is set.
+func (p *Provider) handleCallback(w http.ResponseWriter, r *http.Request) {
+	var q = r.URL.Query()
+
+	// the flow state is single-use
+	http.SetCookie(w, p.newCookie(flowCookieName, "", p.callbackPath, -1))
+
+	if e := q.Get("error"); e != "" {
+		http.Error(w, fmt.Sprintf("Login failed: %s %s", e, q.Get("error_description")), http.StatusUnauthorized)
+
+		return
+	}
+
+	var f flow
+
+	if c, err := r.Cookie(flowCookieName); err != nil || p.cookies.open(flowCookieName, c.Value, &f) != nil {
+		http.Error(w, "Login failed: the login flow is not started or expired", http.StatusBadRequest)
+
+		return
+	}
+
+	if p.now().Unix() >= f.Expiry || subtle.ConstantTimeCompare([]byte(f.State), []byte(q.Get("state"))) != 1 {
+		http.Error(w, "Login failed: the login flow state mismatch", http.StatusBadRequest)
+
+		return
+	}
+
+	var ctx = p.clientCtx(r.Context())
+
+	token, err := p.oauth.Exchange(ctx, q.Get("code"), oauth2.VerifierOption(f.Verifier))
+	if err != nil {
+		http.Error(w, "Login failed: "+err.Error(), http.StatusUnauthorized)
+
+		return
+	}
+
+	user, err := p.verifyIDToken(r, token, f.Nonce)
+	if err != nil {
+		http.Error(w, "Login failed: "+err.Error(), http.StatusUnauthorized)
+
+		return
+	}
+
+	if _, err = p.setSession(w, session{User: user, Expiry: p.expiry(token), Refresh: token.RefreshToken}); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+
+		return
+	}
+
+	http.Redirect(w, r, f.Return, http.StatusFound)
+}
+
+// handleLogout removes the session and redirects the user to the provider logout endpoint (if supported).
+func (p *Provider) handleLogout(w http.ResponseWriter, r *http.Request) {
+	http.SetCookie(w, p.newCookie(sessionCookieName, "", "/", -1))
+
+	if p.endSessionURL == "" {
+		http.Redirect(w, r, "/", http.StatusFound)
+
+		return
+	}
+
+	u, err := url.Parse(p.endSessionURL)
+	if err != nil {
+		http.Redirect(w, r, "/", http.StatusFound)
+
+		return
+	}
+
+	var q = u.Query()
+
+	q.Set("client_id", p.oauth.ClientID)
+	q.Set("post_logout_redirect_uri", p.logoutURL)
+	u.RawQuery = q.Encode()
+
+	http.Redirect(w, r, u.String(), http.StatusFound)
+}
+
+// refreshSession refreshes the expired session, if it has the refresh token. The new session cookie is set both to
+// the response and the request (so the next handlers see the refreshed session). The session that cannot be
+// refreshed is removed.
+func (p *Provider) refreshSession(w http.ResponseWriter, r *http.Request) *http.Request {
+	s, ok := p.sessionFromRequest(r)
+	if !ok || s.valid(p.now()) || s.Refresh == "" {
+		return r
+	}
+
+	var ctx = p.clientCtx(r.Context())
+
+	token, err := p.oauth.TokenSource(ctx, &oauth2.Token{RefreshToken: s.Refresh}).Token()
+	if err != nil {
+		http.SetCookie(w, p.newCookie(sessionCookieName, "", "/", -1))
+
+		return r
+	}
+
+	var refreshed = session{User: s.User, Expiry: p.expiry(token), Refresh: token.RefreshToken}
+
+	if refreshed.Refresh == "" { // the provider does not rotate the refresh tokens
+		refreshed.Refresh = s.Refresh
+	}
+
+	if _, hasIDToken := token.Extra("id_token").(string); hasIDToken { // the user may be changed (e.g. renamed)
+		if refreshed.User, err = p.verifyIDToken(r, token, ""); err != nil {
+			http.SetCookie(w, p.newCookie(sessionCookieName, "", "/", -1))
+
+			return r
+		}
+	}
+
+	sealed, err := p.setSession(w, refreshed)
+	if err != nil {
+		return r
+	}
+
+	// replace the session cookie in the request
+	var cookies = r.Cookies()
+
+	r = r.Clone(r.Context())
+	r.Header.Del("Cookie")
+
+	for _, c := range cookies {
+		if c.Name != sessionCookieName {
+			r.AddCookie(c)
+		}
+	}
+
+	r.AddCookie(&http.Cookie{Name: sessionCookieName, Value: sealed})
+
+	return r
+}
+
+// verifyIDToken verifies the ID token from the token response, and returns the user name. The nonce is checked only
+// if it is not empty (the refreshed ID tokens have no nonce).
+func (p *Provider) verifyIDToken(r *http.Request, token *oauth2.Token, nonce string) (string, error) {
+	raw, ok := token.Extra("id_token").(string)
+	if !ok || raw == "" {
+		return "", errors.New("no ID token in the token response")
+	}
+
+	idToken, err := p.idVerifier.Verify(p.clientCtx(r.Context()), raw)
+	if err != nil {
+		return "", err
+	}
+
+	if nonce != "" && subtle.ConstantTimeCompare([]byte(idToken.Nonce), []byte(nonce)) != 1 {
+		return "", errors.New("ID token nonce mismatch")
+	}
+
+	return p.userName(idToken)
+}
+
+// setSession sets the session cookie and returns its value. The cookie lives until the browser is closed, since the
+// session may be refreshed after the expiration.
+func (p *Provider) setSession(w http.ResponseWriter, s session) (string, error) {
+	sealed, err := p.cookies.seal(sessionCookieName, s)
+	if err != nil {
+		return "", err
+	}
+
+	http.SetCookie(w, p.newCookie(sessionCookieName, sealed, "/", 0))
+
+	return sealed, nil
+}
+
+// expiry returns the session expiration time for the token.
+func (p *Provider) expiry(t *oauth2.Token) int64 {
+	switch {
+	case t.ExpiresIn > 0:
+		return p.now().Add(time.Duration(t.ExpiresIn) * time.Second).Unix()
+	case !t.Expiry.IsZero():
+		return t.Expiry.Unix()
+	}
+
+	return p.now().Add(defaultSessionTTL).Unix()
+}
+
+// safeReturnPath returns the path if it is local (so the login cannot be used as an open redirect), or "/".
+func safeReturnPath(path string) string {
+	if !strings.HasPrefix(path, "/") || strings.HasPrefix(path, "//") || strings.HasPrefix(path, "/\\") {
+		return "/"
+	}
+
+	return path
+}
@@ -0,0 +1,207 @@
+// Package oidc implements the OpenID Connect authentication: the authorization code flow (with PKCE) for the
+// browser users, and the bearer JWT validation (against the issuer's JWKS) for the API clients.
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	goidc "github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/oauth2"
+
+	"gh.tarampamp.am/webhook-tester/v2/internal/auth"
+)
+
+type (
+	// Config is the OpenID Connect client configuration.
+	Config struct {
+		IssuerURL    string   // the issuer URL (used for the discovery)
+		ClientID     string   // the OAuth 2.0 client ID
+		ClientSecret string   // the OAuth 2.0 client secret (optional for the public clients)
+		RedirectURL  string   // the callback URL (e.g. https://example.com/auth/callback)
+		Scopes       []string // the requested scopes ("openid" is always included)
+		Audience     string   // the expected audience of the API bearer tokens (the client ID, if empty)
+		UserClaim    string   // the claim used as the user name ("sub", if empty)
+		CookieSecret []byte   // the key for the cookies encryption (random, if empty)
+	}
+
+	// Provider authenticates the users using the OpenID Connect provider.
+	Provider struct {
+		oauth         oauth2.Config
+		idVerifier    *goidc.IDTokenVerifier // verifies the ID tokens, issued for the browser users
+		apiVerifier   *goidc.IDTokenVerifier // verifies the bearer tokens, sent by the API clients
+		endSessionURL string                 // the RP-initiated logout endpoint (optional)
+		userClaim     string
+		callbackPath  string
+		logoutURL     string // where to redirect the user after the logout
+		cookies       *sealer
+		secureCookies bool
+		httpClient    *http.Client
+		now           func() time.Time
+	}
+)
+
+var (
+	_ auth.Authenticator = (*Provider)(nil) // verify interface implementation
+	_ auth.Challenger    = (*Provider)(nil)
+)
+
+// DefaultScopes are the scopes requested when no scopes are configured.
+var DefaultScopes = []string{goidc.ScopeOpenID, "profile", "email"} //nolint:gochecknoglobals
+
+// DefaultUserClaim is the claim used as the user name when no claim is configured.
+const DefaultUserClaim = "sub"
+
+// Option allows you to configure the Provider during creation.
+type Option func(*Provider)
+
+// WithHTTPClient sets the HTTP client used for the requests to the OpenID Connect provider.
+func WithHTTPClient(c *http.Client) Option { return func(p *Provider) { p.httpClient = c } }
+
+// WithTimeNow sets the function that returns the current time (useful for testing).
+func WithTimeNow(fn func() time.Time) Option { return func(p *Provider) { p.now = fn } }
+
+// New creates a new Provider. The provider configuration is discovered using the issuer URL, so the OpenID Connect
+// provider must be reachable.
+func New(ctx context.Context, cfg Config, opts ...Option) (*Provider, error) {
+	if cfg.IssuerURL == "" || cfg.ClientID == "" || cfg.RedirectURL == "" {
+		return nil, errors.New("the issuer URL, client ID and redirect URL are required")
+	}
+
+	redirect, err := url.Parse(cfg.RedirectURL)
+	if err != nil || !redirect.IsAbs() || redirect.Path == "" || !strings.HasPrefix(redirect.Path, "/") {
+		return nil, fmt.Errorf("wrong redirect URL: %s", cfg.RedirectURL)
+	}
+
+	var p = Provider{
+		userClaim:     cfg.UserClaim,
+		callbackPath:  redirect.Path,
+		logoutURL:     (&url.URL{Scheme: redirect.Scheme, Host: redirect.Host, Path: "/"}).String(),
+		secureCookies: redirect.Scheme == "https",
+		httpClient:    http.DefaultClient,
+		now:           time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(&p)
+	}
+
+	if p.userClaim == "" {
+		p.userClaim = DefaultUserClaim
+	}
+
+	var secret = cfg.CookieSecret
+
+	if len(secret) == 0 { // the sessions will not survive the application restart
+		secret = make([]byte, 32) //nolint:mnd
+
+		if _, err = rand.Read(secret); err != nil {
+			return nil, err
+		}
+	}
+
+	if p.cookies, err = newSealer(secret); err != nil {
+		return nil, err
+	}
+
+	provider, err := goidc.NewProvider(p.clientCtx(ctx), cfg.IssuerURL)
+	if err != nil {
+		return nil, fmt.Errorf("OpenID Connect discovery failed: %w", err)
+	}
+
+	var scopes = cfg.Scopes
+
+	if len(scopes) == 0 {
+		scopes = DefaultScopes
+	} else if !slices.Contains(scopes, goidc.ScopeOpenID) {
+		scopes = append([]string{goidc.ScopeOpenID}, scopes...)
+	}
+
+	p.oauth = oauth2.Config{
+		ClientID:     cfg.ClientID,
+		ClientSecret: cfg.ClientSecret,
+		Endpoint:     provider.Endpoint(),
+		RedirectURL:  cfg.RedirectURL,
+		Scopes:       scopes,
+	}
+
+	var audience = cfg.Audience
+
+	if audience == "" {
+		audience = cfg.ClientID
+	}
+
+	p.idVerifier = provider.Verifier(&goidc.Config{ClientID: cfg.ClientID, Now: p.now})
+	p.apiVerifier = provider.Verifier(&goidc.Config{ClientID: audience, Now: p.now})
+
+	var claims struct {
+		EndSessionURL string `json:"end_session_endpoint"`
+	}
+
+	if err = provider.Claims(&claims); err == nil {
+		p.endSessionURL = claims.EndSessionURL
+	}
+
+	return &p, nil
+}
+
+// Authenticate implements the auth.Authenticator interface. The user is authenticated by the bearer JWT (issued by
+// the OpenID Connect provider), or by the session cookie (set after the successful login).
+func (p *Provider) Authenticate(r *http.Request) (*auth.User, error) {
+	if scheme, token, found := strings.Cut(r.Header.Get("Authorization"), " "); found {
+		if token = strings.TrimSpace(token); strings.EqualFold(scheme, "Bearer") && isJWT(token) {
+			idToken, err := p.apiVerifier.Verify(p.clientCtx(r.Context()), token)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %w", auth.ErrInvalidCredentials, err)
+			}
+
+			name, err := p.userName(idToken)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %w", auth.ErrInvalidCredentials, err)
+			}
+
+			return &auth.User{Name: name}, nil
+		}
+	}
+
+	if s, ok := p.sessionFromRequest(r); ok && s.valid(p.now()) {
+		return &auth.User{Name: s.User}, nil
+	}
+
+	// the expired or broken session cookie is not an error - other authenticators should have a chance
+	return nil, auth.ErrNoCredentials
+}
+
+// Challenge implements the auth.Challenger interface.
+func (p *Provider) Challenge() string { return `Bearer realm="webhook-tester"` }
+
+// userName extracts the user name from the token claims.
+func (p *Provider) userName(t *goidc.IDToken) (string, error) {
+	var claims map[string]any
+
+	if err := t.Claims(&claims); err != nil {
+		return "", err
+	}
+
+	if name, ok := claims[p.userClaim].(string); ok && name != "" {
+		return name, nil
+	}
+
+	return "", fmt.Errorf("the token has no %q claim", p.userClaim)
+}
+
+// clientCtx returns the context with the HTTP client, used by the oauth2 and go-oidc packages.
+func (p *Provider) clientCtx(ctx context.Context) context.Context {
+	return goidc.ClientContext(ctx, p.httpClient)
+}
+
+// isJWT checks if the token looks like a JWT (header.payload.signature), so the opaque tokens (e.g. the static API
+// tokens) are left to other authenticators.
+func isJWT(token string) bool { return strings.Count(token, ".") == 2 } //nolint:mnd
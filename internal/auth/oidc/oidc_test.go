@@ -0,0 +1,262 @@
+package oidc_test
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gh.tarampamp.am/webhook-tester/v2/internal/auth"
+	"gh.tarampamp.am/webhook-tester/v2/internal/auth/oidc"
+	"gh.tarampamp.am/webhook-tester/v2/internal/auth/oidc/oidctest"
+)
+
+// clock is a shared clock for the fake provider and the relying party, which can be moved forward.
+type clock struct{ offset atomic.Int64 }
+
+func (c *clock) Now() time.Time          { return time.Now().Add(time.Duration(c.offset.Load())) }
+func (c *clock) Advance(d time.Duration) { c.offset.Add(int64(d)) }
+
+// newClock creates a new clock and sets it to the fake provider.
+func newClock(idp *oidctest.Server) *clock {
+	var c = new(clock)
+
+	idp.Now = c.Now
+
+	return c
+}
+
+// newApp starts the application server, protected by the provider middleware. The "/api/" routes are skipped by the
+// middleware and authenticated using the provider as the authenticator. The handlers respond with the user name.
+func newApp(t *testing.T, idp *oidctest.Server, clk *clock) (*httptest.Server, *oidc.Provider) {
+	t.Helper()
+
+	var handler http.Handler
+
+	app := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { handler.ServeHTTP(w, r) }))
+	t.Cleanup(app.Close)
+
+	p, err := oidc.New(t.Context(), oidc.Config{
+		IssuerURL:    idp.Issuer(),
+		ClientID:     idp.ClientID,
+		ClientSecret: idp.ClientSecret,
+		RedirectURL:  app.URL + "/auth/callback",
+	}, oidc.WithTimeNow(clk.Now))
+	require.NoError(t, err)
+
+	handler = p.Middleware(func(r *http.Request) bool {
+		return strings.HasPrefix(r.URL.Path, "/api/")
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		u, aErr := p.Authenticate(r)
+		if aErr != nil {
+			http.Error(w, aErr.Error(), http.StatusUnauthorized)
+
+			return
+		}
+
+		_, _ = fmt.Fprintf(w, "%s %s", u.Name, r.URL.RequestURI())
+	}))
+
+	return app, p
+}
+
+func newClient(t *testing.T) *http.Client {
+	t.Helper()
+
+	jar, err := cookiejar.New(nil)
+	require.NoError(t, err)
+
+	return &http.Client{Jar: jar}
+}
+
+func get(t *testing.T, c *http.Client, url string) (int, string) {
+	t.Helper()
+
+	req, err := http.NewRequestWithContext(t.Context(), http.MethodGet, url, http.NoBody)
+	require.NoError(t, err)
+
+	resp, err := c.Do(req)
+	require.NoError(t, err)
+
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	return resp.StatusCode, string(body)
+}
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	var idp = oidctest.NewServer()
+	defer idp.Close()
+
+	for name, cfg := range map[string]oidc.Config{
+		"no issuer":         {ClientID: "id", RedirectURL: "http://localhost/auth/callback"},
+		"no client ID":      {IssuerURL: idp.Issuer(), RedirectURL: "http://localhost/auth/callback"},
+		"relative redirect": {IssuerURL: idp.Issuer(), ClientID: "id", RedirectURL: "/auth/callback"},
+		"wrong issuer":      {IssuerURL: idp.Issuer() + "/foo", ClientID: "id", RedirectURL: "http://localhost/cb"},
+	} {
+		_, err := oidc.New(t.Context(), cfg)
+		assert.Error(t, err, name)
+	}
+}
+
+func TestProvider_LoginFlow(t *testing.T) {
+	t.Parallel()
+
+	var idp = oidctest.NewServer()
+	defer idp.Close()
+
+	var (
+		clk    = newClock(idp)
+		app, _ = newApp(t, idp, clk)
+		client = newClient(t)
+	)
+
+	// API requests without a session are not redirected
+	code, _ := get(t, client, app.URL+"/api/foo")
+	assert.Equal(t, http.StatusUnauthorized, code)
+
+	// page navigation starts the login flow, and returns to the requested page
+	code, body := get(t, client, app.URL+"/some/page?foo=bar")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "alice /some/page?foo=bar", body)
+
+	// the session cookie authenticates the API requests
+	code, body = get(t, client, app.URL+"/api/foo")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "alice /api/foo", body)
+
+	// the expired session is refreshed using the refresh token
+	var tokenRequests = idp.Requests("/token")
+
+	clk.Advance(2 * time.Hour)
+
+	code, body = get(t, client, app.URL+"/api/foo")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "alice /api/foo", body)
+	assert.Equal(t, tokenRequests+1, idp.Requests("/token"))
+
+	code, _ = get(t, client, app.URL+"/")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, tokenRequests+1, idp.Requests("/token")) // the refreshed session is still valid
+}
+
+func TestProvider_Logout(t *testing.T) {
+	t.Parallel()
+
+	var idp = oidctest.NewServer()
+	defer idp.Close()
+
+	var (
+		app, _ = newApp(t, idp, newClock(idp))
+		client = newClient(t)
+	)
+
+	code, _ := get(t, client, app.URL+"/")
+	require.Equal(t, http.StatusOK, code)
+
+	// do not follow the redirect to the provider
+	client.CheckRedirect = func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }
+
+	req, err := http.NewRequestWithContext(t.Context(), http.MethodGet, app.URL+oidc.LogoutPath, http.NoBody)
+	require.NoError(t, err)
+
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+
+	_ = resp.Body.Close()
+
+	assert.Equal(t, http.StatusFound, resp.StatusCode)
+	assert.True(t, strings.HasPrefix(resp.Header.Get("Location"), idp.Issuer()+"/logout?"))
+	assert.Contains(t, resp.Header.Get("Location"), "post_logout_redirect_uri=")
+
+	// the session is removed, so the next page request is redirected to the login
+	code, _ = get(t, client, app.URL+"/")
+	assert.Equal(t, http.StatusFound, code)
+}
+
+func TestProvider_Callback(t *testing.T) {
+	t.Parallel()
+
+	var idp = oidctest.NewServer()
+	defer idp.Close()
+
+	var app, _ = newApp(t, idp, newClock(idp))
+
+	// no flow cookie
+	code, _ := get(t, newClient(t), app.URL+"/auth/callback?code=foo&state=bar")
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	// error from the provider
+	code, _ = get(t, newClient(t), app.URL+"/auth/callback?error=access_denied")
+	assert.Equal(t, http.StatusUnauthorized, code)
+
+	// the return path must be local (no open redirects)
+	code, body := get(t, newClient(t), app.URL+"/auth/login?return="+"//evil.example.com/foo")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "alice /", body)
+
+	// non-navigation requests without a session are rejected
+	resp, err := newClient(t).Post(app.URL+"/some/page", "text/plain", http.NoBody) //nolint:noctx
+	require.NoError(t, err)
+
+	_ = resp.Body.Close()
+
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+}
+
+func TestProvider_Authenticate(t *testing.T) {
+	t.Parallel()
+
+	var idp = oidctest.NewServer()
+	defer idp.Close()
+
+	var _, p = newApp(t, idp, newClock(idp))
+
+	var withBearer = func(token string) *http.Request {
+		var r = httptest.NewRequest(http.MethodGet, "/api/foo", http.NoBody)
+
+		r.Header.Set("Authorization", "Bearer "+token)
+
+		return r
+	}
+
+	u, err := p.Authenticate(withBearer(idp.AccessToken("bob")))
+	require.NoError(t, err)
+	assert.Equal(t, "bob", u.Name)
+
+	for name, token := range map[string]string{
+		"wrong audience": idp.Sign(map[string]any{"sub": "bob", "aud": "another-client"}),
+		"expired":        idp.Sign(map[string]any{"sub": "bob", "aud": idp.ClientID, "exp": time.Now().Unix() - 60}),
+		"no user claim":  idp.Sign(map[string]any{"aud": idp.ClientID}),
+		"wrong issuer":   idp.Sign(map[string]any{"sub": "bob", "aud": idp.ClientID, "iss": "https://example.com"}),
+	} {
+		_, err = p.Authenticate(withBearer(token))
+		assert.ErrorIs(t, err, auth.ErrInvalidCredentials, name)
+	}
+
+	// the opaque tokens are left to other authenticators
+	_, err = p.Authenticate(withBearer("static-token"))
+	assert.ErrorIs(t, err, auth.ErrNoCredentials)
+
+	var withCookie = httptest.NewRequest(http.MethodGet, "/api/foo", http.NoBody)
+
+	withCookie.AddCookie(&http.Cookie{Name: "wh_session", Value: "forged"})
+
+	_, err = p.Authenticate(withCookie)
+	assert.ErrorIs(t, err, auth.ErrNoCredentials)
+
+	_, err = p.Authenticate(httptest.NewRequest(http.MethodGet, "/api/foo", http.NoBody))
+	assert.ErrorIs(t, err, auth.ErrNoCredentials)
+}
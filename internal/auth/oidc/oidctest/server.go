@@ -0,0 +1,305 @@
+// Package oidctest provides a fake OpenID Connect provider for testing. It supports the discovery, JWKS, the
+// authorization code flow (with PKCE), the token refreshing and the logout. The authorization endpoint approves the
+// request immediately (without any user interaction) for the configured user.
+package oidctest
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/go-jose/go-jose/v4"
+)
+
+// Server is a fake OpenID Connect provider.
+type Server struct {
+	ClientID, ClientSecret string        // the only known client
+	Audience               string        // the audience of the issued access tokens (the client ID, if empty)
+	TokenTTL               time.Duration // the lifetime of the issued tokens
+	Now                    func() time.Time
+
+	srv    *httptest.Server
+	signer jose.Signer
+	jwks   jose.JSONWebKeySet
+
+	mu      sync.Mutex
+	user    string            // the user the tokens are issued for
+	codes   map[string]grant  // authorization code -> grant
+	refresh map[string]string // refresh token -> user
+	stats   map[string]int    // endpoint -> number of requests
+}
+
+type grant struct {
+	user, nonce, challenge, redirectURI string
+}
+
+const keyID = "test-key"
+
+// NewServer starts a new fake provider. The caller should call Close when finished, to shut it down.
+func NewServer() *Server {
+	key, err := rsa.GenerateKey(rand.Reader, 2048) //nolint:mnd
+	if err != nil {
+		panic(err)
+	}
+
+	signer, err := jose.NewSigner(
+		jose.SigningKey{Algorithm: jose.RS256, Key: key},
+		(&jose.SignerOptions{}).WithType("JWT").WithHeader("kid", keyID),
+	)
+	if err != nil {
+		panic(err)
+	}
+
+	var s = Server{
+		ClientID:     "webhook-tester",
+		ClientSecret: "secret",
+		TokenTTL:     time.Hour,
+		Now:          time.Now,
+		signer:       signer,
+		jwks: jose.JSONWebKeySet{Keys: []jose.JSONWebKey{
+			{Key: &key.PublicKey, KeyID: keyID, Algorithm: string(jose.RS256), Use: "sig"},
+		}},
+		user:    "alice",
+		codes:   make(map[string]grant),
+		refresh: make(map[string]string),
+		stats:   make(map[string]int),
+	}
+
+	var mux = http.NewServeMux()
+
+	mux.HandleFunc("GET /.well-known/openid-configuration", s.handleDiscovery)
+	mux.HandleFunc("GET /jwks", s.handleJWKS)
+	mux.HandleFunc("GET /authorize", s.handleAuthorize)
+	mux.HandleFunc("POST /token", s.handleToken)
+	mux.HandleFunc("GET /logout", func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusOK) })
+
+	s.srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.stats[r.URL.Path]++
+		s.mu.Unlock()
+
+		mux.ServeHTTP(w, r)
+	}))
+
+	return &s
+}
+
+// Issuer returns the issuer URL.
+func (s *Server) Issuer() string { return s.srv.URL }
+
+// Close shuts down the server.
+func (s *Server) Close() { s.srv.Close() }
+
+// SetUser sets the user (the "sub" claim) the next tokens will be issued for.
+func (s *Server) SetUser(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.user = name
+}
+
+// Requests returns the number of requests to the endpoint (e.g. "/token").
+func (s *Server) Requests(path string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.stats[path]
+}
+
+// AccessToken issues the signed access token (JWT) for the user.
+func (s *Server) AccessToken(user string) string {
+	var aud = s.Audience
+
+	if aud == "" {
+		aud = s.ClientID
+	}
+
+	return s.Sign(map[string]any{"sub": user, "aud": aud})
+}
+
+// Sign signs the claims. The "iss", "iat" and "exp" claims are set, if missing.
+func (s *Server) Sign(claims map[string]any) string {
+	var now = s.Now()
+
+	if _, ok := claims["iss"]; !ok {
+		claims["iss"] = s.Issuer()
+	}
+
+	if _, ok := claims["iat"]; !ok {
+		claims["iat"] = now.Unix()
+	}
+
+	if _, ok := claims["exp"]; !ok {
+		claims["exp"] = now.Add(s.TokenTTL).Unix()
+	}
+
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		panic(err)
+	}
+
+	jws, err := s.signer.Sign(payload)
+	if err != nil {
+		panic(err)
+	}
+
+	token, err := jws.CompactSerialize()
+	if err != nil {
+		panic(err)
+	}
+
+	return token
+}
+
+func (s *Server) handleDiscovery(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{
+		"issuer":                                s.Issuer(),
+		"authorization_endpoint":                s.Issuer() + "/authorize",
+		"token_endpoint":                        s.Issuer() + "/token",
+		"jwks_uri":                              s.Issuer() + "/jwks",
+		"end_session_endpoint":                  s.Issuer() + "/logout",
+		"response_types_supported":              []string{"code"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{string(jose.RS256)},
+		"code_challenge_methods_supported":      []string{"S256"},
+	})
+}
+
+func (s *Server) handleJWKS(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, s.jwks)
+}
+
+func (s *Server) handleAuthorize(w http.ResponseWriter, r *http.Request) {
+	var q = r.URL.Query()
+
+	switch {
+	case q.Get("response_type") != "code", q.Get("client_id") != s.ClientID:
+		http.Error(w, "unsupported response type or unknown client", http.StatusBadRequest)
+
+		return
+	case q.Get("code_challenge_method") != "S256" || q.Get("code_challenge") == "":
+		http.Error(w, "PKCE is required", http.StatusBadRequest)
+
+		return
+	}
+
+	redirect, err := url.Parse(q.Get("redirect_uri"))
+	if err != nil || !redirect.IsAbs() {
+		http.Error(w, "wrong redirect URI", http.StatusBadRequest)
+
+		return
+	}
+
+	var code = rand.Text()
+
+	s.mu.Lock()
+	s.codes[code] = grant{
+		user:        s.user,
+		nonce:       q.Get("nonce"),
+		challenge:   q.Get("code_challenge"),
+		redirectURI: redirect.String(),
+	}
+	s.mu.Unlock()
+
+	var rq = redirect.Query()
+
+	rq.Set("code", code)
+	rq.Set("state", q.Get("state"))
+	redirect.RawQuery = rq.Encode()
+
+	http.Redirect(w, r, redirect.String(), http.StatusFound)
+}
+
+func (s *Server) handleToken(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_request"})
+
+		return
+	}
+
+	clientID, clientSecret, ok := r.BasicAuth()
+	if !ok {
+		clientID, clientSecret = r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+	}
+
+	if clientID != s.ClientID || clientSecret != s.ClientSecret {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_client"})
+
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var user, nonce string
+
+	switch r.PostForm.Get("grant_type") {
+	case "authorization_code":
+		var code = r.PostForm.Get("code")
+
+		g, found := s.codes[code]
+		if !found || g.redirectURI != r.PostForm.Get("redirect_uri") || g.challenge != s256(r.PostForm.Get("code_verifier")) {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+
+			return
+		}
+
+		delete(s.codes, code) // the code is single-use
+
+		user, nonce = g.user, g.nonce
+	case "refresh_token":
+		u, found := s.refresh[r.PostForm.Get("refresh_token")]
+		if !found {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+
+			return
+		}
+
+		delete(s.refresh, r.PostForm.Get("refresh_token")) // the refresh tokens are rotated
+
+		user = u
+	default:
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "unsupported_grant_type"})
+
+		return
+	}
+
+	var idClaims = map[string]any{"sub": user, "aud": s.ClientID}
+
+	if nonce != "" {
+		idClaims["nonce"] = nonce
+	}
+
+	var refreshToken = rand.Text()
+
+	s.refresh[refreshToken] = user
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"access_token":  s.AccessToken(user),
+		"token_type":    "Bearer",
+		"expires_in":    int64(s.TokenTTL.Seconds()),
+		"refresh_token": refreshToken,
+		"id_token":      s.Sign(idClaims),
+	})
+}
+
+// s256 returns the PKCE S256 code challenge for the verifier.
+func s256(verifier string) string {
+	var sum = sha256.Sum256([]byte(verifier))
+
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+
+	_ = json.NewEncoder(w).Encode(v)
+}
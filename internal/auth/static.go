@@ -0,0 +1,106 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"net/http"
+	"strings"
+)
+
+// StaticTokens authenticates the requests using the static API tokens. The token is read from the
+// "Authorization: Bearer <token>" header, the "X-Api-Key" header or the "access_token" query parameter (since the
+// browsers cannot set headers for the WebSocket connections).
+type StaticTokens struct {
+	users map[[sha256.Size]byte]string // token hash -> user name
+}
+
+var (
+	_ Authenticator = (*StaticTokens)(nil) // verify interface implementation
+	_ Challenger    = (*StaticTokens)(nil)
+)
+
+// NewStaticTokens creates a new StaticTokens authenticator. The same user may have several tokens.
+func NewStaticTokens(tokens []Credential) *StaticTokens {
+	var a = StaticTokens{users: make(map[[sha256.Size]byte]string, len(tokens))}
+
+	for _, t := range tokens {
+		a.users[sha256.Sum256([]byte(t.Secret))] = t.User
+	}
+
+	return &a
+}
+
+// Authenticate implements the Authenticator interface.
+func (a *StaticTokens) Authenticate(r *http.Request) (*User, error) {
+	var token string
+
+	if scheme, value, found := strings.Cut(r.Header.Get("Authorization"), " "); found {
+		if !strings.EqualFold(scheme, "Bearer") {
+			return nil, ErrNoCredentials // e.g. basic auth
+		}
+
+		token = strings.TrimSpace(value)
+	} else if value = r.Header.Get("X-Api-Key"); value != "" {
+		token = value
+	} else if r.URL != nil {
+		token = r.URL.Query().Get("access_token")
+	}
+
+	if token == "" {
+		return nil, ErrNoCredentials
+	}
+
+	// the token is hashed before the lookup, so the lookup time does not depend on the token value
+	if user, ok := a.users[sha256.Sum256([]byte(token))]; ok {
+		return &User{Name: user}, nil
+	}
+
+	return nil, ErrInvalidCredentials
+}
+
+// Challenge implements the Challenger interface.
+func (a *StaticTokens) Challenge() string { return `Bearer realm="webhook-tester"` }
+
+// Basic authenticates the requests using the HTTP Basic authentication (RFC 7617).
+type Basic struct {
+	passwords map[string][sha256.Size]byte // user name -> password hash
+}
+
+var (
+	_ Authenticator = (*Basic)(nil) // verify interface implementation
+	_ Challenger    = (*Basic)(nil)
+)
+
+// NewBasic creates a new Basic authenticator.
+func NewBasic(users []Credential) *Basic {
+	var a = Basic{passwords: make(map[string][sha256.Size]byte, len(users))}
+
+	for _, u := range users {
+		a.passwords[u.User] = sha256.Sum256([]byte(u.Secret))
+	}
+
+	return &a
+}
+
+// Authenticate implements the Authenticator interface.
+func (a *Basic) Authenticate(r *http.Request) (*User, error) {
+	user, password, ok := r.BasicAuth()
+	if !ok {
+		return nil, ErrNoCredentials
+	}
+
+	var (
+		got             = sha256.Sum256([]byte(password))
+		expected, known = a.passwords[user]
+	)
+
+	// the hashes are compared in constant time, even if the user is unknown
+	if subtle.ConstantTimeCompare(got[:], expected[:]) == 1 && known {
+		return &User{Name: user}, nil
+	}
+
+	return nil, ErrInvalidCredentials
+}
+
+// Challenge implements the Challenger interface.
+func (a *Basic) Challenge() string { return `Basic realm="webhook-tester", charset="UTF-8"` }
@@ -0,0 +1,115 @@
+// Package certs provides the TLS certificates for the HTTPS server - loaded from the PEM files (and reloaded when the
+// files are changed, so the renewed certificates are picked up without the restart), or self-signed ones, generated
+// on the fly for the local testing.
+package certs
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// Reloader keeps the certificate, loaded from the PEM-encoded certificate and key files, and reloads it when the
+// files are changed. Use the GetCertificate method as the [tls.Config.GetCertificate] callback.
+type Reloader struct {
+	certFile, keyFile string
+
+	mu    sync.RWMutex
+	cert  *tls.Certificate
+	state filesState // the files state of the loaded certificate (to detect the changes)
+}
+
+// filesState is used to detect the certificate and key files changes.
+type filesState struct {
+	certMod, keyMod   time.Time
+	certSize, keySize int64
+}
+
+// NewReloader loads the certificate from the given files and returns a new Reloader.
+func NewReloader(certFile, keyFile string) (*Reloader, error) {
+	var r = Reloader{certFile: certFile, keyFile: keyFile}
+
+	if _, err := r.Reload(); err != nil {
+		return nil, err
+	}
+
+	return &r, nil
+}
+
+// GetCertificate returns the loaded certificate. It matches the [tls.Config.GetCertificate] signature.
+func (r *Reloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.cert, nil
+}
+
+// Reload reloads the certificate, if the files were changed since the last load (true is returned in this case).
+// On error, the previously loaded certificate is kept.
+func (r *Reloader) Reload() (bool, error) {
+	state, err := r.stat()
+	if err != nil {
+		return false, err
+	}
+
+	r.mu.RLock()
+	var unchanged = r.cert != nil && r.state == state
+	r.mu.RUnlock()
+
+	if unchanged {
+		return false, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return false, fmt.Errorf("failed to load the TLS certificate: %w", err)
+	}
+
+	r.mu.Lock()
+	r.cert, r.state = &cert, state
+	r.mu.Unlock()
+
+	return true, nil
+}
+
+// Watch checks the files for changes with the given interval and reloads the certificate, until the context is
+// canceled. The callback (if not nil) is called after each reloading attempt, which was caused by the files change
+// (or failed).
+func (r *Reloader) Watch(ctx context.Context, interval time.Duration, callback func(error)) {
+	var ticker = time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if reloaded, err := r.Reload(); (reloaded || err != nil) && callback != nil {
+				callback(err)
+			}
+		}
+	}
+}
+
+// stat returns the current state of the certificate and key files.
+func (r *Reloader) stat() (filesState, error) {
+	certStat, err := os.Stat(r.certFile)
+	if err != nil {
+		return filesState{}, fmt.Errorf("failed to stat the TLS certificate file: %w", err)
+	}
+
+	keyStat, err := os.Stat(r.keyFile)
+	if err != nil {
+		return filesState{}, fmt.Errorf("failed to stat the TLS key file: %w", err)
+	}
+
+	return filesState{
+		certMod:  certStat.ModTime(),
+		keyMod:   keyStat.ModTime(),
+		certSize: certStat.Size(),
+		keySize:  keyStat.Size(),
+	}, nil
+}
@@ -0,0 +1,155 @@
+package certs_test
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gh.tarampamp.am/webhook-tester/v2/internal/certs"
+)
+
+func TestSelfSigned(t *testing.T) {
+	t.Parallel()
+
+	cert, err := certs.SelfSigned("webhook.local", "10.0.0.1", "localhost", "")
+	require.NoError(t, err)
+	require.NotNil(t, cert.Leaf)
+
+	assert.Equal(t, []string{"localhost", "webhook.local"}, cert.Leaf.DNSNames)
+	assert.Len(t, cert.Leaf.IPAddresses, 3)
+	assert.WithinDuration(t, time.Now().Add(certs.SelfSignedValidity), cert.Leaf.NotAfter, time.Minute)
+
+	var roots = x509.NewCertPool()
+
+	roots.AddCert(cert.Leaf)
+
+	for _, name := range []string{"webhook.local", "localhost", "10.0.0.1", "::1"} {
+		_, vErr := cert.Leaf.Verify(x509.VerifyOptions{DNSName: name, Roots: roots})
+		assert.NoError(t, vErr, name)
+	}
+
+	_, err = cert.Leaf.Verify(x509.VerifyOptions{DNSName: "example.com", Roots: roots})
+	assert.Error(t, err)
+
+	// it works for the TLS handshake
+	var ln = tls.NewListener(newLocalListener(t), &tls.Config{Certificates: []tls.Certificate{*cert}}) //nolint:gosec
+
+	go func() {
+		if conn, aErr := ln.Accept(); aErr == nil {
+			_ = conn.(*tls.Conn).Handshake()
+			_ = conn.Close()
+		}
+	}()
+
+	conn, err := tls.Dial("tcp", ln.Addr().String(), &tls.Config{RootCAs: roots, ServerName: "localhost"}) //nolint:gosec
+	require.NoError(t, err)
+
+	require.NoError(t, conn.Close())
+}
+
+func TestReloader(t *testing.T) {
+	t.Parallel()
+
+	var (
+		dir      = t.TempDir()
+		certFile = filepath.Join(dir, "tls.crt")
+		keyFile  = filepath.Join(dir, "tls.key")
+	)
+
+	_, err := certs.NewReloader(certFile, keyFile)
+	require.Error(t, err) // the files do not exist
+
+	var first = writeSelfSigned(t, certFile, keyFile, "first.local")
+
+	r, err := certs.NewReloader(certFile, keyFile)
+	require.NoError(t, err)
+
+	assert.Equal(t, first, loadedSerial(t, r))
+
+	reloaded, err := r.Reload()
+	require.NoError(t, err)
+	assert.False(t, reloaded) // nothing changed
+
+	// the broken files are not loaded, the previous certificate is kept
+	require.NoError(t, os.WriteFile(keyFile, []byte("broken"), 0o600))
+
+	reloaded, err = r.Reload()
+	require.Error(t, err)
+	assert.False(t, reloaded)
+	assert.Equal(t, first, loadedSerial(t, r))
+
+	// the renewed certificate is picked up by the watcher
+	var (
+		ctx, cancel = context.WithCancel(context.Background())
+		events      = make(chan error, 8)
+	)
+
+	defer cancel()
+
+	go r.Watch(ctx, 10*time.Millisecond, func(err error) { events <- err })
+
+	var second = writeSelfSigned(t, certFile, keyFile, "second.local")
+
+	require.Eventually(t, func() bool { return loadedSerial(t, r) == second }, 5*time.Second, 10*time.Millisecond)
+
+	for { // the errors may be reported while the files are being written
+		if err = <-events; err == nil {
+			break
+		}
+	}
+}
+
+// writeSelfSigned writes a new self-signed certificate and its key in the PEM format to the given files and returns
+// the certificate serial number.
+func writeSelfSigned(t *testing.T, certFile, keyFile string, hosts ...string) string {
+	t.Helper()
+
+	cert, err := certs.SelfSigned(hosts...)
+	require.NoError(t, err)
+
+	keyDer, err := x509.MarshalPKCS8PrivateKey(cert.PrivateKey)
+	require.NoError(t, err)
+
+	var (
+		certPem = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Certificate[0]})
+		keyPem  = pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDer})
+	)
+
+	require.NoError(t, os.WriteFile(certFile, certPem, 0o600))
+	require.NoError(t, os.WriteFile(keyFile, keyPem, 0o600))
+
+	return cert.Leaf.SerialNumber.String()
+}
+
+// loadedSerial returns the serial number of the certificate, served by the reloader.
+func loadedSerial(t *testing.T, r *certs.Reloader) string {
+	t.Helper()
+
+	cert, err := r.GetCertificate(nil)
+	require.NoError(t, err)
+
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	require.NoError(t, err)
+
+	return leaf.SerialNumber.String()
+}
+
+func newLocalListener(t *testing.T) net.Listener {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	t.Cleanup(func() { _ = ln.Close() })
+
+	return ln
+}
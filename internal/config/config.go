@@ -1,16 +1,44 @@
 package config
 
 import (
+	"crypto/x509"
 	"net/url"
 	"time"
+
+	"go.opentelemetry.io/otel/trace"
+
+	"gh.tarampamp.am/webhook-tester/v2/internal/auth"
+	"gh.tarampamp.am/webhook-tester/v2/internal/auth/oidc"
+	"gh.tarampamp.am/webhook-tester/v2/internal/forward"
+	"gh.tarampamp.am/webhook-tester/v2/internal/http/realip"
+	"gh.tarampamp.am/webhook-tester/v2/internal/ipfilter"
+	"gh.tarampamp.am/webhook-tester/v2/internal/metrics"
+	"gh.tarampamp.am/webhook-tester/v2/internal/notify"
+	"gh.tarampamp.am/webhook-tester/v2/internal/ratelimit"
+	"gh.tarampamp.am/webhook-tester/v2/internal/slug"
 )
 
 type AppSettings struct {
-	MaxRequests        uint16        // how many requests can be stored in the storage
-	MaxRequestBodySize uint32        // max size of the request body
-	SessionTTL         time.Duration // session time to live
-	AutoCreateSessions bool          // feature: auto create sessions
-	TunnelEnabled      bool          // feature: tunnel (public url to local server) enabled
-	TunnelURL          *url.URL      // tunnel public url
-	PublicURLRoot      *url.URL      // public URL root override for webhook URLs
+	MaxRequests        uint16               // how many requests can be stored in the storage
+	MaxRequestBodySize uint32               // max size of the request body
+	SessionTTL         time.Duration        // session time to live
+	AutoCreateSessions bool                 // feature: auto create sessions
+	TunnelEnabled      bool                 // feature: tunnel (public url to local server) enabled
+	TunnelURL          *url.URL             // tunnel public url
+	PublicURLRoot      *url.URL             // public URL root override for webhook URLs
+	OutboundHostPolicy *forward.HostPolicy  // allowed/denied hosts for forwarding, replaying and notifications
+	Authenticator      auth.Authenticator   // API requests authenticator (nil = authentication is disabled)
+	OIDC               *oidc.Provider       // OpenID Connect login for the frontend (nil = login is disabled)
+	SlugPolicy         *slug.Policy         // allowed session slugs (webhook path aliases; nil = slugs are disabled)
+	Metrics            *metrics.Metrics     // metrics collector (nil = metrics are disabled)
+	ServeMetrics       bool                 // serve the metrics at /metrics on the main HTTP server (not a separate port)
+	TracerProvider     trace.TracerProvider // OpenTelemetry tracer provider (nil = tracing is disabled)
+	HTTPSRedirectPort  uint16               // redirect the plain HTTP requests to HTTPS on this port (zero = no redirect)
+	TLSClientAuth      string               // default TLS client certificates mode (empty = they are not requested)
+	TLSClientCAs       *x509.CertPool       // CA bundle for the client certificates verification (nil = system roots)
+	RateLimits         *ratelimit.Limits    // webhooks and sessions creation rate limits (nil = no limits)
+	IPPolicy           *ipfilter.Policy     // allowed/denied webhook client IP addresses (nil = no restrictions)
+	RecordBlocked      bool                 // record the webhooks, blocked by the IP filters, on the session
+	RealIP             *realip.Resolver     // client IP, scheme and host resolver (nil = proxy headers are never trusted)
+	Notifier           *notify.Dispatcher   // notifications dispatcher (nil = the new targets are picked up later)
 }
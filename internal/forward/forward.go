@@ -0,0 +1,190 @@
+// Package forward sends (forwards) captured requests to the upstream targets.
+package forward
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+type (
+	// Client sends requests to the upstream targets. It does not follow redirects.
+	Client struct {
+		http        *http.Client
+		maxBodySize int64
+		policy      *HostPolicy
+	}
+
+	// Request is a request to send.
+	Request struct {
+		Method  string
+		URL     string
+		Headers http.Header
+		Body    []byte
+	}
+
+	// Response is an upstream response.
+	Response struct {
+		StatusCode int
+		Headers    http.Header
+		Body       []byte        // may be truncated, if the upstream response is too large
+		Latency    time.Duration // the time between the request sending and the response body reading
+	}
+)
+
+// ErrRequestFailed is returned when the upstream request fails (e.g., the connection cannot be established, or the
+// timeout is exceeded).
+var ErrRequestFailed = errors.New("upstream request failed")
+
+// DefaultMaxBodySize is the default maximum size of the upstream response body to read.
+const DefaultMaxBodySize = 10 << 20 // 10 MiB
+
+// Option allows you to configure the Client during creation.
+type Option func(*Client)
+
+// WithHTTPClient sets the HTTP client to use. Note that the client's CheckRedirect function will be overridden.
+func WithHTTPClient(c *http.Client) Option { return func(f *Client) { f.http = c } }
+
+// WithMaxBodySize sets the maximum size of the upstream response body to read (the rest is discarded).
+func WithMaxBodySize(n int64) Option { return func(f *Client) { f.maxBodySize = n } }
+
+// WithHostPolicy sets the policy for the target hosts (nil keeps the [DefaultHostPolicy]; use an empty policy to
+// allow any host). Note that the policy is applied only when the HTTP client uses the [http.Transport], and proxies
+// are not used in this case.
+func WithHostPolicy(p *HostPolicy) Option {
+	return func(f *Client) {
+		if p != nil {
+			f.policy = p
+		}
+	}
+}
+
+// New creates a new Client. Unless another policy is set, the [DefaultHostPolicy] is used, so the loopback,
+// link-local and private networks cannot be reached.
+func New(opts ...Option) *Client {
+	var c = Client{
+		http:        &http.Client{Transport: http.DefaultTransport.(*http.Transport).Clone()}, //nolint:forcetypeassert
+		maxBodySize: DefaultMaxBodySize,
+		policy:      DefaultHostPolicy(),
+	}
+
+	for _, opt := range opts {
+		opt(&c)
+	}
+
+	// do not follow redirects, the upstream response should be returned as is
+	c.http.CheckRedirect = func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }
+
+	if t, ok := c.http.Transport.(*http.Transport); ok && c.policy != nil {
+		const dialTimeout, keepAlive = 30 * time.Second, 30 * time.Second
+
+		t = t.Clone()
+		t.Proxy = nil // the policy cannot be applied to the requests sent through a proxy
+		t.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+			host, _, err := net.SplitHostPort(addr)
+			if err != nil {
+				return nil, err
+			}
+
+			var dialer = net.Dialer{Timeout: dialTimeout, KeepAlive: keepAlive, Control: c.policy.dialControl(host)}
+
+			return dialer.DialContext(ctx, network, addr)
+		}
+
+		c.http.Transport = t
+	}
+
+	return &c
+}
+
+// Do sends the request and reads the upstream response. Use the context to limit the request time.
+func (c *Client) Do(ctx context.Context, r Request) (*Response, error) {
+	req, rErr := http.NewRequestWithContext(ctx, r.Method, r.URL, bytes.NewReader(r.Body))
+	if rErr != nil {
+		return nil, fmt.Errorf("failed to create a request: %w", rErr)
+	}
+
+	for name, values := range r.Headers {
+		if IsHopByHopHeader(name) {
+			continue
+		}
+
+		for _, value := range values {
+			req.Header.Add(name, value)
+		}
+	}
+
+	var start = time.Now()
+
+	resp, dErr := c.http.Do(req)
+	if dErr != nil {
+		return nil, fmt.Errorf("%w: %w", ErrRequestFailed, dErr)
+	}
+
+	defer func() { _ = resp.Body.Close() }()
+
+	body, bErr := io.ReadAll(io.LimitReader(resp.Body, c.maxBodySize))
+	if bErr != nil {
+		return nil, fmt.Errorf("%w: failed to read the response body: %w", ErrRequestFailed, bErr)
+	}
+
+	return &Response{
+		StatusCode: resp.StatusCode,
+		Headers:    resp.Header,
+		Body:       body,
+		Latency:    time.Since(start),
+	}, nil
+}
+
+// TargetURL builds the URL to forward the request to by appending the path (if not empty or "/") and the query
+// string to the target URL.
+func TargetURL(target, path, rawQuery string) (string, error) {
+	u, err := url.Parse(target)
+	if err != nil {
+		return "", err
+	}
+
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "", errors.New("unsupported URL scheme (only http and https are allowed)")
+	}
+
+	if path != "" && path != "/" {
+		u = u.JoinPath(path)
+	}
+
+	if rawQuery != "" {
+		if u.RawQuery != "" {
+			u.RawQuery += "&" + rawQuery
+		} else {
+			u.RawQuery = rawQuery
+		}
+	}
+
+	return u.String(), nil
+}
+
+// hopByHopHeaders is a list of headers that should not be forwarded (RFC 7230, section 6.1), plus the headers that
+// are managed by the HTTP client itself.
+var hopByHopHeaders = [...]string{ //nolint:gochecknoglobals
+	"Connection", "Keep-Alive", "Proxy-Authenticate", "Proxy-Authorization", "Proxy-Connection", "Te", "Trailer",
+	"Transfer-Encoding", "Upgrade", "Content-Length", "Host", "Accept-Encoding",
+}
+
+// IsHopByHopHeader checks if the header should not be passed through (it is connection-specific or managed by
+// the HTTP client/server itself).
+func IsHopByHopHeader(name string) bool {
+	for _, h := range hopByHopHeaders {
+		if strings.EqualFold(h, name) {
+			return true
+		}
+	}
+
+	return false
+}
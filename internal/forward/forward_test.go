@@ -0,0 +1,128 @@
+package forward_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gh.tarampamp.am/webhook-tester/v2/internal/forward"
+)
+
+func TestClient_Do(t *testing.T) {
+	t.Parallel()
+
+	var srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/redirect":
+			http.Redirect(w, r, "/elsewhere", http.StatusFound)
+		case "/slow":
+			time.Sleep(200 * time.Millisecond)
+		default:
+			body, _ := io.ReadAll(r.Body)
+
+			assert.Equal(t, http.MethodPut, r.Method)
+			assert.Equal(t, "bar", r.Header.Get("X-Foo"))
+			assert.Empty(t, r.Header.Get("Connection"))
+			assert.Equal(t, "a=b", r.URL.RawQuery)
+
+			w.Header().Set("X-Upstream", "1")
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write(append([]byte("echo: "), body...))
+		}
+	}))
+
+	t.Cleanup(srv.Close)
+
+	anyHost, err := forward.NewHostPolicy(nil, nil) // the test server listens on the loopback interface
+	require.NoError(t, err)
+
+	var client = forward.New(forward.WithHostPolicy(anyHost))
+
+	t.Run("success", func(t *testing.T) {
+		t.Parallel()
+
+		resp, err := client.Do(t.Context(), forward.Request{
+			Method:  http.MethodPut,
+			URL:     srv.URL + "/echo?a=b",
+			Headers: http.Header{"X-Foo": {"bar"}, "Connection": {"close"}},
+			Body:    []byte("payload"),
+		})
+		require.NoError(t, err)
+
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+		assert.Equal(t, "1", resp.Headers.Get("X-Upstream"))
+		assert.Equal(t, "echo: payload", string(resp.Body))
+		assert.Positive(t, resp.Latency)
+	})
+
+	t.Run("body is truncated", func(t *testing.T) {
+		t.Parallel()
+
+		resp, err := forward.New(forward.WithHostPolicy(anyHost), forward.WithMaxBodySize(4)).Do(t.Context(), forward.Request{
+			Method:  http.MethodPut,
+			URL:     srv.URL + "/echo?a=b",
+			Headers: http.Header{"X-Foo": {"bar"}},
+		})
+		require.NoError(t, err)
+
+		assert.Equal(t, "echo", string(resp.Body))
+	})
+
+	t.Run("redirects are not followed", func(t *testing.T) {
+		t.Parallel()
+
+		resp, err := client.Do(t.Context(), forward.Request{Method: http.MethodGet, URL: srv.URL + "/redirect"})
+		require.NoError(t, err)
+
+		assert.Equal(t, http.StatusFound, resp.StatusCode)
+		assert.Equal(t, "/elsewhere", resp.Headers.Get("Location"))
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		t.Parallel()
+
+		var ctx, cancel = context.WithTimeout(t.Context(), 10*time.Millisecond)
+		defer cancel()
+
+		_, err := client.Do(ctx, forward.Request{Method: http.MethodGet, URL: srv.URL + "/slow"})
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+}
+
+func TestTargetURL(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		giveTarget, givePath, giveQuery string
+		want                            string
+		wantErr                         bool
+	}{
+		"target only":        {giveTarget: "http://localhost:8080/hooks", givePath: "/", want: "http://localhost:8080/hooks"},
+		"with path":          {giveTarget: "http://localhost:8080/hooks", givePath: "/github/push", want: "http://localhost:8080/hooks/github/push"},
+		"with query":         {giveTarget: "https://example.com", giveQuery: "a=b", want: "https://example.com?a=b"},
+		"both queries":       {giveTarget: "https://example.com/?x=1", giveQuery: "a=b", want: "https://example.com/?x=1&a=b"},
+		"unsupported scheme": {giveTarget: "ftp://example.com", wantErr: true},
+		"invalid URL":        {giveTarget: "http://[::1", wantErr: true},
+		"relative URL":       {giveTarget: "/foo", wantErr: true},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := forward.TargetURL(tc.giveTarget, tc.givePath, tc.giveQuery)
+			if tc.wantErr {
+				require.Error(t, err)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
@@ -0,0 +1,172 @@
+package forward
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"strings"
+	"syscall"
+)
+
+// ErrHostNotAllowed is returned when the target host is not allowed by the HostPolicy.
+var ErrHostNotAllowed = errors.New("target host is not allowed")
+
+type (
+	// HostPolicy decides which target hosts are allowed to connect to. Each rule is an IP address, a CIDR
+	// (e.g. 10.0.0.0/8), a host name (e.g. example.com) or a wildcard host name (e.g. *.example.com, matches
+	// subdomains only). IP addresses and CIDRs are checked against the resolved address right before the connection
+	// is established (so DNS tricks do not help to bypass the policy).
+	//
+	// The host is allowed when the allow list is empty or any of the allow rules matches, and none of the deny rules
+	// matches (deny rules take precedence). An empty policy allows everything.
+	HostPolicy struct {
+		allow, deny hostRules
+	}
+
+	hostRules struct {
+		names    []string       // lower-cased host names, wildcards start with "*."
+		prefixes []netip.Prefix // IP networks
+	}
+)
+
+// DefaultDeniedHosts are the unspecified, loopback, link-local (including the cloud metadata endpoints), private
+// and shared (CGNAT) networks, which are denied by the [DefaultHostPolicy].
+var DefaultDeniedHosts = []string{ //nolint:gochecknoglobals
+	"0.0.0.0/8", "127.0.0.0/8", "169.254.0.0/16", "10.0.0.0/8", "172.16.0.0/12", "192.168.0.0/16", "100.64.0.0/10",
+	"::/128", "::1/128", "fe80::/10", "fc00::/7",
+}
+
+// DefaultHostPolicy returns the policy, which denies the [DefaultDeniedHosts], so the outbound requests cannot reach
+// the services of the app host network (and the cloud metadata).
+func DefaultHostPolicy() *HostPolicy {
+	p, err := NewHostPolicy(nil, DefaultDeniedHosts)
+	if err != nil {
+		panic(err) // the default rules are valid
+	}
+
+	return p
+}
+
+// NewHostPolicy creates a new HostPolicy with the given allow and deny rules.
+func NewHostPolicy(allow, deny []string) (*HostPolicy, error) {
+	var p HostPolicy
+
+	for _, list := range []struct {
+		rules []string
+		to    *hostRules
+	}{{allow, &p.allow}, {deny, &p.deny}} {
+		for _, rule := range list.rules {
+			if err := list.to.add(rule); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return &p, nil
+}
+
+// ValidateHostRule checks the host rule syntax.
+func ValidateHostRule(rule string) error { return new(hostRules).add(rule) }
+
+func (r *hostRules) add(rule string) error {
+	rule = strings.ToLower(strings.TrimSpace(rule))
+
+	if rule == "" {
+		return errors.New("empty host rule")
+	}
+
+	if strings.Contains(rule, "/") {
+		prefix, err := netip.ParsePrefix(rule)
+		if err != nil {
+			return fmt.Errorf("wrong CIDR [%s]: %w", rule, err)
+		}
+
+		r.prefixes = append(r.prefixes, prefix.Masked())
+
+		return nil
+	}
+
+	if addr, err := netip.ParseAddr(strings.Trim(rule, "[]")); err == nil {
+		r.prefixes = append(r.prefixes, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+
+		return nil
+	}
+
+	var name = strings.TrimPrefix(rule, "*.")
+
+	if name == "" || strings.ContainsAny(name, "*:?#@ \t") {
+		return fmt.Errorf("wrong host name [%s]", rule)
+	}
+
+	r.names = append(r.names, rule)
+
+	return nil
+}
+
+func (r *hostRules) empty() bool { return len(r.names) == 0 && len(r.prefixes) == 0 }
+
+// matchName checks if the host name matches any of the name rules.
+func (r *hostRules) matchName(host string) bool {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+
+	for _, name := range r.names {
+		if wildcard, ok := strings.CutPrefix(name, "*."); ok {
+			if strings.HasSuffix(host, "."+wildcard) {
+				return true
+			}
+		} else if host == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// matchAddr checks if the IP address matches any of the network rules.
+func (r *hostRules) matchAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+
+	for _, prefix := range r.prefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// dialControl returns a function for the [net.Dialer.Control], that checks the host (the one from the request URL)
+// and the resolved address (the one the dialer is going to connect to) against the policy.
+func (p *HostPolicy) dialControl(host string) func(_, address string, _ syscall.RawConn) error {
+	return func(_, address string, _ syscall.RawConn) error {
+		ipStr, _, err := net.SplitHostPort(address)
+		if err != nil {
+			return err
+		}
+
+		addr, err := netip.ParseAddr(ipStr)
+		if err != nil {
+			return err
+		}
+
+		return p.check(host, addr)
+	}
+}
+
+// check checks the host name and the resolved IP address against the policy.
+func (p *HostPolicy) check(host string, addr netip.Addr) error {
+	if p == nil {
+		return nil
+	}
+
+	if p.deny.matchName(host) || p.deny.matchAddr(addr) {
+		return fmt.Errorf("%w: %s (%s) is denied", ErrHostNotAllowed, host, addr)
+	}
+
+	if !p.allow.empty() && !p.allow.matchName(host) && !p.allow.matchAddr(addr) {
+		return fmt.Errorf("%w: %s (%s) is not in the allow list", ErrHostNotAllowed, host, addr)
+	}
+
+	return nil
+}
@@ -0,0 +1,89 @@
+package forward_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gh.tarampamp.am/webhook-tester/v2/internal/forward"
+)
+
+func TestNewHostPolicy(t *testing.T) {
+	t.Parallel()
+
+	for _, rule := range []string{"10.0.0.0/8", "::1", "[::1]", "fd00::/8", "127.0.0.1", "example.com", "*.example.com"} {
+		_, err := forward.NewHostPolicy([]string{rule}, []string{rule})
+		require.NoError(t, err, rule)
+		require.NoError(t, forward.ValidateHostRule(rule), rule)
+	}
+
+	for _, rule := range []string{"", " ", "10.0.0.0/33", "foo/bar", "*.", "*", "exa mple.com", "example.com:8080"} {
+		_, err := forward.NewHostPolicy(nil, []string{rule})
+		require.Error(t, err, rule)
+		require.Error(t, forward.ValidateHostRule(rule), rule)
+	}
+}
+
+func TestClient_Do_HostPolicy(t *testing.T) {
+	t.Parallel()
+
+	var srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	t.Cleanup(srv.Close)
+
+	var localhostURL = strings.Replace(srv.URL, "127.0.0.1", "localhost", 1)
+
+	for name, tc := range map[string]struct {
+		giveAllow, giveDeny []string
+		giveURL             string
+		wantAllowed         bool
+	}{
+		"no rules":                      {giveURL: srv.URL, wantAllowed: true},
+		"denied by CIDR":                {giveDeny: []string{"127.0.0.0/8"}, giveURL: srv.URL},
+		"denied by resolved address":    {giveDeny: []string{"127.0.0.0/8", "::1"}, giveURL: localhostURL},
+		"denied by name":                {giveDeny: []string{"localhost"}, giveURL: localhostURL},
+		"not in the allow list":         {giveAllow: []string{"example.com", "*.example.com"}, giveURL: srv.URL},
+		"allowed by CIDR":               {giveAllow: []string{"127.0.0.0/8"}, giveURL: srv.URL, wantAllowed: true},
+		"allowed by name":               {giveAllow: []string{"localhost"}, giveURL: localhostURL, wantAllowed: true},
+		"allowed, but denied":           {giveAllow: []string{"localhost"}, giveDeny: []string{"127.0.0.1", "::1"}, giveURL: localhostURL},
+		"allowed, other address denied": {giveAllow: []string{"127.0.0.1"}, giveDeny: []string{"10.0.0.0/8"}, giveURL: srv.URL, wantAllowed: true},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			policy, err := forward.NewHostPolicy(tc.giveAllow, tc.giveDeny)
+			require.NoError(t, err)
+
+			resp, err := forward.New(forward.WithHostPolicy(policy)).Do(t.Context(), forward.Request{
+				Method: http.MethodGet,
+				URL:    tc.giveURL,
+			})
+
+			if tc.wantAllowed {
+				require.NoError(t, err)
+				assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+				return
+			}
+
+			require.ErrorIs(t, err, forward.ErrHostNotAllowed)
+		})
+	}
+
+	t.Run("default policy", func(t *testing.T) {
+		t.Parallel()
+
+		for _, client := range []*forward.Client{forward.New(), forward.New(forward.WithHostPolicy(nil))} {
+			for _, u := range []string{srv.URL, localhostURL, "http://169.254.169.254/latest/meta-data/"} {
+				_, err := client.Do(t.Context(), forward.Request{Method: http.MethodGet, URL: u})
+				require.ErrorIs(t, err, forward.ErrHostNotAllowed, u)
+			}
+		}
+	})
+}
@@ -31,10 +31,16 @@ func (h *Handler) Handle(ctx context.Context, sID sID, rID rID) (*openapi.Captur
 
 	return &openapi.CapturedRequestsResponse{
 		CapturedAtUnixMilli:  r.CreatedAtUnixMilli,
+		Forwarded:            openapi.NewRequestForwardResult(r.Forwarded),
+		Signature:            openapi.NewRequestSignatureResult(r.Signature),
 		ClientAddress:        r.ClientAddr,
+		Dropped:              openapi.NewDroppedReason(r.Dropped),
 		Headers:              rHeaders,
 		Method:               strings.ToUpper(r.Method),
+		PeerAddress:          openapi.NewPeerAddress(r.PeerAddr),
 		RequestPayloadBase64: base64.StdEncoding.EncodeToString(r.Body),
+		TraceId:              openapi.NewTraceID(r.TraceID),
+		Tls:                  openapi.NewRequestTLS(r.TLS),
 		Url:                  r.URL,
 		Uuid:                 rID,
 	}, nil
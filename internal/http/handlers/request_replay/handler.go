@@ -0,0 +1,93 @@
+package request_replay
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"gh.tarampamp.am/webhook-tester/v2/internal/forward"
+	"gh.tarampamp.am/webhook-tester/v2/internal/http/openapi"
+	"gh.tarampamp.am/webhook-tester/v2/internal/storage"
+)
+
+type (
+	sID = openapi.SessionUUIDInPath
+	rID = openapi.RequestUUIDInPath
+
+	Handler struct {
+		db     storage.Storage
+		client *forward.Client
+	}
+)
+
+// replayTimeout limits the replay request time (should be less than the HTTP server write timeout).
+const replayTimeout = 30 * time.Second
+
+// New creates a new handler. The policy limits the target hosts (nil means no restrictions).
+func New(db storage.Storage, policy *forward.HostPolicy) *Handler {
+	return &Handler{db: db, client: forward.New(forward.WithHostPolicy(policy))}
+}
+
+func (h *Handler) Handle(ctx context.Context, sID sID, rID rID, p openapi.ReplayRequest) (*openapi.ReplayResponse, error) {
+	r, rErr := h.db.GetRequest(ctx, sID.String(), rID.String())
+	if rErr != nil {
+		return nil, rErr
+	}
+
+	var req = forward.Request{Method: r.Method, URL: r.URL, Headers: make(http.Header, len(r.Headers)), Body: r.Body}
+
+	for _, header := range r.Headers {
+		req.Headers.Set(header.Name, header.Value)
+	}
+
+	// apply the overrides
+	if p.Url != nil && *p.Url != "" {
+		req.URL = *p.Url
+	}
+
+	if p.Headers != nil {
+		for _, header := range *p.Headers {
+			if header.Value == "" {
+				req.Headers.Del(header.Name)
+			} else {
+				req.Headers.Set(header.Name, header.Value)
+			}
+		}
+	}
+
+	if p.RequestPayloadBase64 != nil {
+		body, decErr := base64.StdEncoding.DecodeString(*p.RequestPayloadBase64)
+		if decErr != nil {
+			return nil, fmt.Errorf("cannot decode request payload (wrong base64): %w", decErr)
+		}
+
+		req.Body = body
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, replayTimeout)
+	defer cancel()
+
+	resp, doErr := h.client.Do(ctx, req)
+	if doErr != nil {
+		return nil, doErr
+	}
+
+	var headers = make([]openapi.HttpHeader, 0, len(resp.Headers))
+	for name, value := range resp.Headers {
+		headers = append(headers, openapi.HttpHeader{Name: name, Value: strings.Join(value, "; ")})
+	}
+
+	slices.SortFunc(headers, func(a, b openapi.HttpHeader) int { return strings.Compare(a.Name, b.Name) })
+
+	return &openapi.ReplayResponse{
+		Headers:            headers,
+		LatencyMillis:      resp.Latency.Milliseconds(),
+		ResponseBodyBase64: base64.StdEncoding.EncodeToString(resp.Body),
+		StatusCode:         resp.StatusCode,
+		Url:                req.URL,
+	}, nil
+}
@@ -0,0 +1,271 @@
+package requests_events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"gh.tarampamp.am/webhook-tester/v2/internal/http/openapi"
+	"gh.tarampamp.am/webhook-tester/v2/internal/metrics"
+	"gh.tarampamp.am/webhook-tester/v2/internal/pubsub"
+	"gh.tarampamp.am/webhook-tester/v2/internal/storage"
+)
+
+type (
+	sID = openapi.SessionUUIDInPath
+
+	Handler struct {
+		db      storage.Storage
+		sub     pubsub.Subscriber[pubsub.RequestEvent]
+		metrics *metrics.Metrics // nil = metrics are disabled
+	}
+)
+
+const (
+	heartbeatInterval = 15 * time.Second // how often the heartbeat comments are sent (keeps the proxies from timing out)
+	reconnectDelay    = 3 * time.Second  // the client reconnection delay (the "retry" field)
+)
+
+func New(db storage.Storage, sub pubsub.Subscriber[pubsub.RequestEvent], m *metrics.Metrics) *Handler {
+	return &Handler{db: db, sub: sub, metrics: m}
+}
+
+// Handle streams the session request events to the client using the Server-Sent Events. When the last event ID is
+// set (the client reconnects), the requests captured after that one are replayed from the storage first.
+func (h *Handler) Handle(
+	ctx context.Context,
+	w http.ResponseWriter,
+	sID sID,
+	params openapi.ApiSessionRequestsEventsParams,
+) error {
+	if _, err := h.db.GetSession(ctx, sID.String()); err != nil {
+		return fmt.Errorf("failed to get the session: %w", err)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// subscribe before reading the missed requests, so no events are lost in between
+	sub, unsubscribe, err := h.sub.Subscribe(ctx, sID.String())
+	if err != nil {
+		return fmt.Errorf("failed to subscribe to the captured requests for the session %s: %w", sID.String(), err)
+	}
+
+	defer unsubscribe()
+
+	var missed []storage.QueriedRequest
+
+	if params.LastEventID != nil && *params.LastEventID != "" {
+		if missed, err = h.missed(ctx, sID.String(), *params.LastEventID); err != nil {
+			return err
+		}
+	}
+
+	var rc = http.NewResponseController(w)
+
+	// the stream is long-lived, so the server write timeout must not be applied (it's fine if it's not supported)
+	_ = rc.SetWriteDeadline(time.Time{})
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no") // disable the response buffering by nginx
+	w.WriteHeader(http.StatusOK)
+
+	defer h.metrics.SSESubscribed()()
+
+	// from this point, the response is started, so the errors mean that the client has gone away
+	if _, err = fmt.Fprintf(w, "retry: %d\n\n", reconnectDelay.Milliseconds()); err != nil {
+		return nil
+	}
+
+	var replayed = make(map[string]struct{}, len(missed))
+
+	for _, r := range missed {
+		replayed[r.ID] = struct{}{}
+
+		if err = writeEvent(w, pubsub.RequestEvent{
+			Action:  pubsub.RequestActionCreate,
+			Request: fromStorage(r.ID, r.Request),
+		}); err != nil {
+			return nil
+		}
+	}
+
+	if err = rc.Flush(); err != nil {
+		return nil
+	}
+
+	var heartbeat = time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-ctx.Done(): // the client has disconnected or the server is shutting down
+			return nil
+
+		case event, isOpened := <-sub:
+			if !isOpened {
+				return nil // this should never happen, but just in case
+			}
+
+			if event.Action == pubsub.RequestActionCreate && event.Request != nil {
+				if _, ok := replayed[event.Request.ID]; ok { // already sent while replaying
+					delete(replayed, event.Request.ID)
+
+					continue
+				}
+			}
+
+			if err = writeEvent(w, event); err != nil {
+				return nil
+			}
+
+		case <-heartbeat.C:
+			if _, err = io.WriteString(w, ": heartbeat\n\n"); err != nil {
+				return nil
+			}
+		}
+
+		if err = rc.Flush(); err != nil {
+			return nil
+		}
+	}
+}
+
+// missed returns the requests captured after the one with the last event ID (oldest first). The requests, captured
+// at the same millisecond, are returned too (so they may be repeated, but not lost). If the request is unknown (e.g.
+// it's already deleted), all the stored requests are returned.
+func (h *Handler) missed(ctx context.Context, sID, lastID string) ([]storage.QueriedRequest, error) {
+	var query = storage.RequestsQuery{Order: storage.RequestsOldestFirst}
+
+	if uuid.Validate(lastID) == nil { // the malformed IDs are unknown too
+		last, err := h.db.GetRequest(ctx, sID, lastID)
+		if err != nil && !errors.Is(err, storage.ErrNotFound) {
+			return nil, fmt.Errorf("failed to get the last received request: %w", err)
+		}
+
+		if last != nil {
+			query.Since = time.UnixMilli(last.CreatedAtUnixMilli)
+		}
+	}
+
+	page, err := storage.QueryRequests(ctx, h.db, sID, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the missed requests: %w", err)
+	}
+
+	var res = make([]storage.QueriedRequest, 0, len(page.Requests))
+
+	for _, r := range page.Requests {
+		if r.ID != lastID {
+			res = append(res, r)
+		}
+	}
+
+	return res, nil
+}
+
+// writeEvent writes the request event using the same encoder as the WebSocket subscription does. The events about the
+// captured requests have the request ID as the event ID (used by the client to resume the stream). The unknown
+// events are skipped.
+func writeEvent(w io.Writer, e pubsub.RequestEvent) error {
+	event, ok := openapi.NewRequestEvent(e)
+	if !ok {
+		return nil // skip the unknown event
+	}
+
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode the event: %w", err)
+	}
+
+	var b strings.Builder
+
+	if event.Action == openapi.RequestEventActionCreate && event.Request != nil {
+		b.WriteString("id: " + event.Request.Uuid.String() + "\n")
+	}
+
+	b.WriteString("data: ")
+	b.Write(data) // the JSON has no newlines, so a single data line is enough
+	b.WriteString("\n\n")
+
+	_, err = io.WriteString(w, b.String())
+
+	return err
+}
+
+// fromStorage converts the stored request into the event format (the body is not included, like in the published
+// events).
+func fromStorage(id string, r storage.Request) *pubsub.Request {
+	var headers = make([]pubsub.HttpHeader, len(r.Headers))
+	for i, h := range r.Headers {
+		headers[i] = pubsub.HttpHeader{Name: h.Name, Value: h.Value}
+	}
+
+	var res = pubsub.Request{
+		ID:                 id,
+		ClientAddr:         r.ClientAddr,
+		PeerAddr:           r.PeerAddr,
+		Method:             r.Method,
+		Headers:            headers,
+		URL:                r.URL,
+		CreatedAtUnixMilli: r.CreatedAtUnixMilli,
+		TraceID:            r.TraceID,
+		Dropped:            r.Dropped,
+	}
+
+	if f := r.Forwarded; f != nil {
+		var fHeaders = make([]pubsub.HttpHeader, len(f.Headers))
+		for i, h := range f.Headers {
+			fHeaders[i] = pubsub.HttpHeader{Name: h.Name, Value: h.Value}
+		}
+
+		res.Forwarded = &pubsub.ForwardResult{
+			URL:        f.URL,
+			StatusCode: f.StatusCode,
+			Headers:    fHeaders,
+			Body:       f.Body,
+			Latency:    f.Latency,
+			Error:      f.Error,
+		}
+	}
+
+	if s := r.Signature; s != nil {
+		res.Signature = &pubsub.SignatureResult{Provider: s.Provider, Valid: s.Valid, Error: s.Error}
+	}
+
+	if t := r.TLS; t != nil {
+		res.TLS = &pubsub.RequestTLS{Version: t.Version, CipherSuite: t.CipherSuite, ServerName: t.ServerName}
+
+		for _, c := range t.ClientCerts {
+			res.TLS.ClientCerts = append(res.TLS.ClientCerts, pubsub.TLSCertificate{
+				Subject:            c.Subject,
+				Issuer:             c.Issuer,
+				SerialNumber:       c.SerialNumber,
+				SANs:               c.SANs,
+				NotBeforeUnixMilli: c.NotBeforeUnixMilli,
+				NotAfterUnixMilli:  c.NotAfterUnixMilli,
+				SHA1Fingerprint:    c.SHA1Fingerprint,
+				SHA256Fingerprint:  c.SHA256Fingerprint,
+			})
+		}
+
+		if a := t.ClientAuth; a != nil {
+			res.TLS.ClientAuth = &pubsub.ClientAuthResult{
+				Mode:     a.Mode,
+				Verified: a.Verified,
+				Rejected: a.Rejected,
+				Error:    a.Error,
+			}
+		}
+	}
+
+	return &res
+}
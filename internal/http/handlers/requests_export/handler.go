@@ -0,0 +1,83 @@
+package requests_export
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"gh.tarampamp.am/webhook-tester/v2/internal/archive"
+	"gh.tarampamp.am/webhook-tester/v2/internal/http/openapi"
+	"gh.tarampamp.am/webhook-tester/v2/internal/storage"
+)
+
+type (
+	sID = openapi.SessionUUIDInPath
+
+	Handler struct{ db storage.Storage }
+)
+
+// ErrInterrupted is returned when the export was started (the response status and headers are already sent), but
+// cannot be completed.
+var ErrInterrupted = errors.New("export interrupted")
+
+// pageSize is the number of requests read from the storage at once.
+const pageSize = 100
+
+func New(db storage.Storage) *Handler { return &Handler{db: db} }
+
+func (h *Handler) Handle(
+	ctx context.Context,
+	w http.ResponseWriter,
+	sID sID,
+	params openapi.ApiSessionExportRequestsParams,
+) error {
+	var format = archive.FormatHAR
+
+	if params.Format != nil {
+		format = archive.Format(*params.Format)
+	}
+
+	// the requests are read page by page (oldest first), so the whole session is not kept in memory (if the
+	// storage supports queries)
+	var query = storage.RequestsQuery{Order: storage.RequestsOldestFirst, Limit: pageSize}
+
+	// the first page is read before the response headers are sent, to respond with a proper error status
+	page, err := storage.QueryRequests(ctx, h.db, sID.String(), query)
+	if err != nil {
+		return err
+	}
+
+	aw, err := archive.NewWriter(w, format, "webhook-tester session "+sID.String())
+	if err != nil {
+		return err
+	}
+
+	w.Header().Set("Content-Type", format.ContentType())
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s%s"`, sID.String(), format.FileExt()))
+	w.WriteHeader(http.StatusOK)
+
+	for {
+		for _, r := range page.Requests {
+			if wErr := aw.Write(r.ID, r.Request); wErr != nil {
+				return fmt.Errorf("%w: %w", ErrInterrupted, wErr)
+			}
+		}
+
+		if page.NextCursor == "" {
+			break
+		}
+
+		query.Cursor = page.NextCursor
+
+		if page, err = storage.QueryRequests(ctx, h.db, sID.String(), query); err != nil {
+			return fmt.Errorf("%w: %w", ErrInterrupted, err)
+		}
+	}
+
+	if err = aw.Close(); err != nil {
+		return fmt.Errorf("%w: %w", ErrInterrupted, err)
+	}
+
+	return nil
+}
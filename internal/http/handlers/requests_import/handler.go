@@ -0,0 +1,110 @@
+package requests_import
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"gh.tarampamp.am/webhook-tester/v2/internal/archive"
+	"gh.tarampamp.am/webhook-tester/v2/internal/http/openapi"
+	"gh.tarampamp.am/webhook-tester/v2/internal/pubsub"
+	"gh.tarampamp.am/webhook-tester/v2/internal/storage"
+)
+
+type (
+	sID = openapi.SessionUUIDInPath
+
+	Handler struct {
+		appCtx      context.Context
+		db          storage.Storage
+		pub         pubsub.Publisher[pubsub.RequestEvent]
+		maxBodySize uint32
+	}
+)
+
+func New(
+	appCtx context.Context,
+	db storage.Storage,
+	pub pubsub.Publisher[pubsub.RequestEvent],
+	maxBodySize uint32,
+) *Handler {
+	return &Handler{appCtx: appCtx, db: db, pub: pub, maxBodySize: maxBodySize}
+}
+
+func (h *Handler) Handle(
+	ctx context.Context,
+	sID sID,
+	params openapi.ApiSessionImportRequestsParams,
+	body io.Reader,
+) (*openapi.ImportRequestsResponse, error) {
+	var format = archive.FormatHAR
+
+	if params.Format != nil {
+		format = archive.Format(*params.Format)
+	}
+
+	reader, rErr := archive.NewReader(body, format)
+	if rErr != nil {
+		return nil, rErr
+	}
+
+	// check the session existence before reading the archive
+	if _, err := h.db.GetSession(ctx, sID.String()); err != nil {
+		return nil, err
+	}
+
+	var resp openapi.ImportRequestsResponse
+
+	for {
+		r, err := reader.Next()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+
+			return nil, fmt.Errorf("%w (%d requests imported)", err, resp.Imported)
+		}
+
+		if h.maxBodySize > 0 && uint32(len(r.Body)) > h.maxBodySize { //nolint:gosec
+			resp.Skipped++
+
+			continue
+		}
+
+		if r.CreatedAtUnixMilli == 0 {
+			r.CreatedAtUnixMilli = time.Now().UnixMilli() // the same time is stored and published
+		}
+
+		rID, err := h.db.NewRequest(ctx, sID.String(), *r)
+		if err != nil {
+			return nil, err
+		}
+
+		resp.Imported++
+
+		// convert headers to the pubsub format
+		var headers = make([]pubsub.HttpHeader, len(r.Headers))
+		for i, rh := range r.Headers {
+			headers[i] = pubsub.HttpHeader{Name: rh.Name, Value: rh.Value}
+		}
+
+		// notify the subscribers
+		if err = h.pub.Publish(h.appCtx, sID.String(), pubsub.RequestEvent{ //nolint:contextcheck
+			Action: pubsub.RequestActionCreate,
+			Request: &pubsub.Request{
+				ID:                 rID,
+				ClientAddr:         r.ClientAddr,
+				Method:             r.Method,
+				Headers:            headers,
+				URL:                r.URL,
+				CreatedAtUnixMilli: r.CreatedAtUnixMilli,
+			},
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	return &resp, nil
+}
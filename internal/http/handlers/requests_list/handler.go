@@ -4,8 +4,9 @@ import (
 	"context"
 	"encoding/base64"
 	"fmt"
-	"slices"
+	"regexp"
 	"strings"
+	"time"
 
 	"github.com/google/uuid"
 
@@ -21,18 +22,29 @@ type (
 
 func New(db storage.Storage) *Handler { return &Handler{db: db} }
 
-func (h *Handler) Handle(ctx context.Context, sID sID) (*openapi.CapturedRequestsListResponse, error) {
-	rList, lErr := h.db.GetAllRequests(ctx, sID.String())
-	if lErr != nil {
-		return nil, lErr
+// Handle returns the session requests that match the params, and the cursor for the next page (empty if there are
+// no more requests).
+func (h *Handler) Handle(
+	ctx context.Context,
+	sID sID,
+	params openapi.ApiSessionListRequestsParams,
+) (*openapi.CapturedRequestsListResponse, string, error) {
+	query, qErr := toQuery(params)
+	if qErr != nil {
+		return nil, "", qErr
 	}
 
-	var list = make([]openapi.CapturedRequest, 0, len(rList))
+	page, pErr := storage.QueryRequests(ctx, h.db, sID.String(), *query)
+	if pErr != nil {
+		return nil, "", pErr
+	}
+
+	var list = make([]openapi.CapturedRequest, 0, len(page.Requests))
 
-	for rID, r := range rList {
-		rUUID, pErr := uuid.Parse(rID)
+	for _, r := range page.Requests { // already sorted
+		rUUID, pErr := uuid.Parse(r.ID)
 		if pErr != nil {
-			return nil, fmt.Errorf("failed to parse request UUID: %w", pErr)
+			return nil, "", fmt.Errorf("failed to parse request UUID: %w", pErr)
 		}
 
 		var rHeaders = make([]openapi.HttpHeader, len(r.Headers))
@@ -42,19 +54,89 @@ func (h *Handler) Handle(ctx context.Context, sID sID) (*openapi.CapturedRequest
 
 		list = append(list, openapi.CapturedRequest{
 			CapturedAtUnixMilli:  r.CreatedAtUnixMilli,
+			Forwarded:            openapi.NewRequestForwardResult(r.Forwarded),
+			Signature:            openapi.NewRequestSignatureResult(r.Signature),
 			ClientAddress:        r.ClientAddr,
+			Dropped:              openapi.NewDroppedReason(r.Dropped),
 			Headers:              rHeaders,
 			Method:               strings.ToUpper(r.Method),
+			PeerAddress:          openapi.NewPeerAddress(r.PeerAddr),
 			RequestPayloadBase64: base64.StdEncoding.EncodeToString(r.Body),
+			TraceId:              openapi.NewTraceID(r.TraceID),
+			Tls:                  openapi.NewRequestTLS(r.TLS),
 			Url:                  r.URL,
 			Uuid:                 rUUID,
 		})
+	}
 
-		// sort the list by the captured time from newest to oldest
-		slices.SortFunc(list, func(a, b openapi.CapturedRequest) int {
-			return int(b.CapturedAtUnixMilli - a.CapturedAtUnixMilli)
-		})
+	return &list, page.NextCursor, nil
+}
+
+// toQuery converts the (validated) request params into the storage query.
+func toQuery(p openapi.ApiSessionListRequestsParams) (*storage.RequestsQuery, error) {
+	var q storage.RequestsQuery
+
+	if p.Method != nil {
+		q.Method = *p.Method
+	}
+
+	if p.Header != nil {
+		q.HeaderName = *p.Header
+	}
+
+	if p.HeaderValue != nil {
+		q.HeaderValue = *p.HeaderValue
+	}
+
+	if p.Url != nil {
+		q.URL = *p.Url
+	}
+
+	if p.UrlRegex != nil && *p.UrlRegex != "" {
+		re, err := regexp.Compile(*p.UrlRegex)
+		if err != nil {
+			return nil, err
+		}
+
+		q.URLRegexp = re
+	}
+
+	if p.Body != nil {
+		q.Body = []byte(*p.Body)
+	}
+
+	if p.BodyRegex != nil && *p.BodyRegex != "" {
+		re, err := regexp.Compile(*p.BodyRegex)
+		if err != nil {
+			return nil, err
+		}
+
+		q.BodyRegexp = re
+	}
+
+	if p.ClientAddress != nil {
+		q.ClientAddr = *p.ClientAddress
+	}
+
+	if p.Since != nil {
+		q.Since = time.UnixMilli(*p.Since)
+	}
+
+	if p.Until != nil {
+		q.Until = time.UnixMilli(*p.Until)
+	}
+
+	if p.Order != nil && *p.Order == openapi.ApiSessionListRequestsParamsOrderOldest {
+		q.Order = storage.RequestsOldestFirst
+	}
+
+	if p.Cursor != nil {
+		q.Cursor = *p.Cursor
+	}
+
+	if p.Limit != nil && *p.Limit > 0 {
+		q.Limit = uint32(*p.Limit) //nolint:gosec // validated
 	}
 
-	return &list, nil
+	return &q, nil
 }
@@ -5,13 +5,12 @@ import (
 	"fmt"
 	"io"
 	"net/http"
-	"strings"
 	"time"
 
-	"github.com/google/uuid"
 	"github.com/gorilla/websocket"
 
 	"gh.tarampamp.am/webhook-tester/v2/internal/http/openapi"
+	"gh.tarampamp.am/webhook-tester/v2/internal/metrics"
 	"gh.tarampamp.am/webhook-tester/v2/internal/pubsub"
 	"gh.tarampamp.am/webhook-tester/v2/internal/storage"
 )
@@ -22,12 +21,13 @@ type (
 	Handler struct {
 		db       storage.Storage
 		sub      pubsub.Subscriber[pubsub.RequestEvent]
+		metrics  *metrics.Metrics // nil = metrics are disabled
 		upgrader websocket.Upgrader
 	}
 )
 
-func New(db storage.Storage, sub pubsub.Subscriber[pubsub.RequestEvent]) *Handler {
-	return &Handler{db: db, sub: sub}
+func New(db storage.Storage, sub pubsub.Subscriber[pubsub.RequestEvent], m *metrics.Metrics) *Handler {
+	return &Handler{db: db, sub: sub, metrics: m}
 }
 
 func (h *Handler) Handle(ctx context.Context, w http.ResponseWriter, r *http.Request, sID sID) error {
@@ -56,6 +56,7 @@ func (h *Handler) Handle(ctx context.Context, w http.ResponseWriter, r *http.Req
 	}
 
 	defer unsubscribe()
+	defer h.metrics.WebSocketSubscribed()()
 
 	// read messages from the client in a separate goroutine and cancel the context when the connection is closed or
 	// an error occurs
@@ -110,45 +111,13 @@ func (h *Handler) writer(ctx context.Context, ws *websocket.Conn, sub <-chan pub
 				return nil // this should never happen, but just in case
 			}
 
-			var (
-				action  openapi.RequestEventAction
-				request *openapi.RequestEventRequest
-			)
-
-			switch r.Action {
-			case pubsub.RequestActionCreate:
-				action = openapi.RequestEventActionCreate
-			case pubsub.RequestActionDelete:
-				action = openapi.RequestEventActionDelete
-			case pubsub.RequestActionClear:
-				action = openapi.RequestEventActionClear
-			default:
-				continue // skip the unknown action
-			}
-
-			if r.Request != nil {
-				rID, pErr := uuid.Parse(r.Request.ID)
-				if pErr != nil {
-					continue
-				}
-
-				var rHeaders = make([]openapi.HttpHeader, len(r.Request.Headers))
-				for i, header := range r.Request.Headers {
-					rHeaders[i].Name, rHeaders[i].Value = header.Name, header.Value
-				}
-
-				request = &openapi.RequestEventRequest{
-					Uuid:                rID,
-					CapturedAtUnixMilli: r.Request.CreatedAtUnixMilli,
-					ClientAddress:       r.Request.ClientAddr,
-					Headers:             rHeaders,
-					Method:              strings.ToUpper(r.Request.Method),
-					Url:                 r.Request.URL,
-				}
+			event, ok := openapi.NewRequestEvent(r)
+			if !ok {
+				continue // skip the unknown event
 			}
 
 			// write the response to the client
-			if err := ws.WriteJSON(openapi.RequestEvent{Action: action, Request: request}); err != nil {
+			if err := ws.WriteJSON(event); err != nil {
 				return fmt.Errorf("failed to write the message: %w", err)
 			}
 
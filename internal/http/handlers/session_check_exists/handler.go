@@ -7,6 +7,7 @@ import (
 
 	"golang.org/x/sync/errgroup"
 
+	"gh.tarampamp.am/webhook-tester/v2/internal/auth"
 	"gh.tarampamp.am/webhook-tester/v2/internal/http/openapi"
 	"gh.tarampamp.am/webhook-tester/v2/internal/storage"
 )
@@ -18,6 +19,7 @@ func New(db storage.Storage) *Handler { return &Handler{db: db} }
 func (h *Handler) Handle(ctx context.Context, ids []openapi.UUID) (*openapi.CheckSessionExistsResponse, error) {
 	var (
 		eg, egCtx = errgroup.WithContext(ctx)
+		user      = auth.UserFromContext(ctx)
 
 		mu  sync.Mutex
 		res = make(openapi.CheckSessionExistsResponse, len(ids)) // map[sID]bool
@@ -26,7 +28,8 @@ func (h *Handler) Handle(ctx context.Context, ids []openapi.UUID) (*openapi.Chec
 	for _, id := range ids {
 		eg.Go(func(sID string) func() error {
 			return func() error {
-				if _, err := h.db.GetSession(egCtx, sID); err != nil {
+				sess, err := h.db.GetSession(egCtx, sID)
+				if err != nil {
 					if errors.Is(err, storage.ErrNotFound) {
 						mu.Lock()
 						res[sID] = false
@@ -39,7 +42,7 @@ func (h *Handler) Handle(ctx context.Context, ids []openapi.UUID) (*openapi.Chec
 				}
 
 				mu.Lock()
-				res[sID] = true
+				res[sID] = auth.CanAccess(user, sess.Owner) // the sessions of other users are reported as missing
 				mu.Unlock()
 
 				return nil
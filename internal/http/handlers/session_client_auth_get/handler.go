@@ -0,0 +1,26 @@
+package session_client_auth_get
+
+import (
+	"context"
+	"fmt"
+
+	"gh.tarampamp.am/webhook-tester/v2/internal/http/openapi"
+	"gh.tarampamp.am/webhook-tester/v2/internal/storage"
+)
+
+type (
+	sID = openapi.SessionUUIDInPath
+
+	Handler struct{ db storage.Storage }
+)
+
+func New(db storage.Storage) *Handler { return &Handler{db: db} }
+
+func (h *Handler) Handle(ctx context.Context, sID sID) (*openapi.SessionClientAuthResponse, error) {
+	sess, sErr := h.db.GetSession(ctx, sID.String())
+	if sErr != nil {
+		return nil, fmt.Errorf("failed to get session: %w", sErr)
+	}
+
+	return &openapi.SessionClientAuthResponse{Mode: sess.ClientAuth}, nil
+}
@@ -0,0 +1,48 @@
+package session_client_auth_set
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"gh.tarampamp.am/webhook-tester/v2/internal/http/openapi"
+	"gh.tarampamp.am/webhook-tester/v2/internal/storage"
+)
+
+type (
+	sID = openapi.SessionUUIDInPath
+
+	Handler struct {
+		db          storage.Storage
+		defaultMode string // the app default mode (empty = the client certificates are not requested)
+	}
+)
+
+// ErrNotRequested is returned when the session mode requires the client certificates, but the HTTPS server does not
+// request them (the app default mode is none).
+var ErrNotRequested = errors.New("the client certificates are not requested by the server (the default mode is none)")
+
+func New(db storage.Storage, defaultMode string) *Handler {
+	return &Handler{db: db, defaultMode: defaultMode}
+}
+
+func (h *Handler) Handle(
+	ctx context.Context,
+	sID sID,
+	p openapi.SetSessionClientAuthRequest,
+) (*openapi.SessionClientAuthResponse, error) {
+	// the client certificates are never presented to the app, so the session mode would be silently ignored
+	if h.defaultMode == "" && (p.Mode == storage.ClientAuthRequest || p.Mode == storage.ClientAuthRequire) {
+		return nil, ErrNotRequested
+	}
+
+	if err := h.db.UpdateSession(ctx, sID.String(), func(s *storage.Session) error {
+		s.ClientAuth = p.Mode // empty mode means the default one is used
+
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("failed to set session client auth mode: %w", err)
+	}
+
+	return &openapi.SessionClientAuthResponse{Mode: p.Mode}, nil
+}
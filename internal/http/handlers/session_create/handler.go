@@ -8,6 +8,7 @@ import (
 
 	"github.com/google/uuid"
 
+	"gh.tarampamp.am/webhook-tester/v2/internal/auth"
 	"gh.tarampamp.am/webhook-tester/v2/internal/http/openapi"
 	"gh.tarampamp.am/webhook-tester/v2/internal/storage"
 )
@@ -32,6 +33,8 @@ func (h *Handler) Handle(ctx context.Context, p openapi.CreateSessionRequest) (*
 		Headers:      sHeaders,
 		ResponseBody: responseBody,
 		Delay:        time.Second * time.Duration(p.Delay),
+		Templated:    p.Templated != nil && *p.Templated,
+		Owner:        auth.OwnerFromContext(ctx),
 	})
 	if sErr != nil {
 		return nil, fmt.Errorf("failed to create a new session: %w", sErr)
@@ -54,11 +57,15 @@ func (h *Handler) Handle(ctx context.Context, p openapi.CreateSessionRequest) (*
 
 	return &openapi.SessionOptionsResponse{
 		CreatedAtUnixMilli: sess.CreatedAtUnixMilli,
+		Labels:             append(make([]string, 0, len(sess.Labels)), sess.Labels...),
+		Name:               sess.Name,
+		Note:               sess.Note,
 		Response: openapi.SessionResponseOptions{
 			Delay:              uint16(sess.Delay.Seconds()),
 			Headers:            rHeaders,
 			ResponseBodyBase64: base64.StdEncoding.EncodeToString(sess.ResponseBody),
 			StatusCode:         openapi.StatusCode(sess.Code),
+			Templated:          &sess.Templated,
 		},
 		Uuid: sUUID,
 	}, nil
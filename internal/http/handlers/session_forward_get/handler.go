@@ -0,0 +1,35 @@
+package session_forward_get
+
+import (
+	"context"
+	"fmt"
+
+	"gh.tarampamp.am/webhook-tester/v2/internal/http/openapi"
+	"gh.tarampamp.am/webhook-tester/v2/internal/storage"
+)
+
+type (
+	sID = openapi.SessionUUIDInPath
+
+	Handler struct{ db storage.Storage }
+)
+
+func New(db storage.Storage) *Handler { return &Handler{db: db} }
+
+func (h *Handler) Handle(ctx context.Context, sID sID) (*openapi.SessionForwardResponse, error) {
+	sess, sErr := h.db.GetSession(ctx, sID.String())
+	if sErr != nil {
+		return nil, fmt.Errorf("failed to get session: %w", sErr)
+	}
+
+	if sess.Forward == nil {
+		return &openapi.SessionForwardResponse{}, nil // forwarding is disabled
+	}
+
+	return &openapi.SessionForwardResponse{
+		PassHeaders:    sess.Forward.PassHeaders,
+		ReturnResponse: sess.Forward.ReturnResponse,
+		Timeout:        uint16(sess.Forward.Timeout.Seconds()),
+		Url:            sess.Forward.URL,
+	}, nil
+}
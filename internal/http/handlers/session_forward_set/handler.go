@@ -0,0 +1,54 @@
+package session_forward_set
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"gh.tarampamp.am/webhook-tester/v2/internal/http/openapi"
+	"gh.tarampamp.am/webhook-tester/v2/internal/storage"
+)
+
+type (
+	sID = openapi.SessionUUIDInPath
+
+	Handler struct{ db storage.Storage }
+)
+
+func New(db storage.Storage) *Handler { return &Handler{db: db} }
+
+func (h *Handler) Handle(
+	ctx context.Context,
+	sID sID,
+	p openapi.SetSessionForwardRequest,
+) (*openapi.SessionForwardResponse, error) {
+	var opts *storage.ForwardOptions
+
+	if p.Url != "" { // empty URL disables the forwarding
+		opts = &storage.ForwardOptions{
+			URL:            p.Url,
+			PassHeaders:    p.PassHeaders,
+			Timeout:        time.Second * time.Duration(p.Timeout),
+			ReturnResponse: p.ReturnResponse,
+		}
+	}
+
+	if err := h.db.UpdateSession(ctx, sID.String(), func(s *storage.Session) error {
+		s.Forward = opts
+
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("failed to set session forwarding: %w", err)
+	}
+
+	if opts == nil {
+		return &openapi.SessionForwardResponse{}, nil
+	}
+
+	return &openapi.SessionForwardResponse{
+		PassHeaders:    opts.PassHeaders,
+		ReturnResponse: opts.ReturnResponse,
+		Timeout:        uint16(opts.Timeout.Seconds()),
+		Url:            opts.URL,
+	}, nil
+}
@@ -30,11 +30,15 @@ func (h *Handler) Handle(ctx context.Context, sID sID) (*openapi.SessionOptionsR
 
 	return &openapi.SessionOptionsResponse{
 		CreatedAtUnixMilli: sess.CreatedAtUnixMilli,
+		Labels:             append(make([]string, 0, len(sess.Labels)), sess.Labels...),
+		Name:               sess.Name,
+		Note:               sess.Note,
 		Response: openapi.SessionResponseOptions{
 			Delay:              uint16(sess.Delay.Seconds()),
 			Headers:            sHeaders,
 			ResponseBodyBase64: base64.StdEncoding.EncodeToString(sess.ResponseBody),
 			StatusCode:         openapi.StatusCode(sess.Code),
+			Templated:          &sess.Templated,
 		},
 		Uuid: sID,
 	}, nil
@@ -0,0 +1,32 @@
+package session_ip_filter_get
+
+import (
+	"context"
+	"fmt"
+
+	"gh.tarampamp.am/webhook-tester/v2/internal/http/openapi"
+	"gh.tarampamp.am/webhook-tester/v2/internal/storage"
+)
+
+type (
+	sID = openapi.SessionUUIDInPath
+
+	Handler struct{ db storage.Storage }
+)
+
+func New(db storage.Storage) *Handler { return &Handler{db: db} }
+
+func (h *Handler) Handle(ctx context.Context, sID sID) (*openapi.SessionIPFilterResponse, error) {
+	sess, sErr := h.db.GetSession(ctx, sID.String())
+	if sErr != nil {
+		return nil, fmt.Errorf("failed to get session: %w", sErr)
+	}
+
+	var resp = openapi.SessionIPFilterResponse{Allow: []string{}, Deny: []string{}}
+
+	if f := sess.IPFilter; f != nil {
+		resp.Allow, resp.Deny = append(resp.Allow, f.Allow...), append(resp.Deny, f.Deny...)
+	}
+
+	return &resp, nil
+}
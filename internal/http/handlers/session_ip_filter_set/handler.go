@@ -0,0 +1,45 @@
+package session_ip_filter_set
+
+import (
+	"context"
+	"fmt"
+
+	"gh.tarampamp.am/webhook-tester/v2/internal/http/openapi"
+	"gh.tarampamp.am/webhook-tester/v2/internal/storage"
+)
+
+type (
+	sID = openapi.SessionUUIDInPath
+
+	Handler struct{ db storage.Storage }
+)
+
+func New(db storage.Storage) *Handler { return &Handler{db: db} }
+
+func (h *Handler) Handle(
+	ctx context.Context,
+	sID sID,
+	p openapi.SetSessionIPFilterRequest,
+) (*openapi.SessionIPFilterResponse, error) {
+	var filter *storage.IPFilterOptions // nil = no restrictions
+
+	if len(p.Allow) > 0 || len(p.Deny) > 0 {
+		filter = &storage.IPFilterOptions{Allow: p.Allow, Deny: p.Deny}
+	}
+
+	if err := h.db.UpdateSession(ctx, sID.String(), func(s *storage.Session) error {
+		s.IPFilter = filter
+
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("failed to set session IP filter: %w", err)
+	}
+
+	var resp = openapi.SessionIPFilterResponse{Allow: []string{}, Deny: []string{}}
+
+	if filter != nil {
+		resp.Allow, resp.Deny = append(resp.Allow, filter.Allow...), append(resp.Deny, filter.Deny...)
+	}
+
+	return &resp, nil
+}
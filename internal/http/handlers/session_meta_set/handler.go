@@ -0,0 +1,85 @@
+package session_meta_set
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"slices"
+
+	"gh.tarampamp.am/webhook-tester/v2/internal/http/openapi"
+	"gh.tarampamp.am/webhook-tester/v2/internal/pubsub"
+	"gh.tarampamp.am/webhook-tester/v2/internal/storage"
+)
+
+type (
+	sID = openapi.SessionUUIDInPath
+
+	Handler struct {
+		appCtx context.Context
+		db     storage.Storage
+		pub    pubsub.Publisher[pubsub.RequestEvent]
+	}
+)
+
+func New(appCtx context.Context, db storage.Storage, pub pubsub.Publisher[pubsub.RequestEvent]) *Handler {
+	return &Handler{appCtx: appCtx, db: db, pub: pub}
+}
+
+// Handle updates the passed fields of the session description (the missing ones are preserved) and notifies the
+// session subscribers.
+func (h *Handler) Handle(
+	ctx context.Context,
+	sID sID,
+	p openapi.UpdateSessionMetaRequest,
+) (*openapi.SessionOptionsResponse, error) {
+	var sess storage.Session
+
+	if err := h.db.UpdateSession(ctx, sID.String(), func(s *storage.Session) error {
+		if p.Name != nil {
+			s.Name = *p.Name
+		}
+
+		if p.Labels != nil {
+			s.Labels = nil
+
+			if len(*p.Labels) > 0 {
+				s.Labels = slices.Clone(*p.Labels)
+			}
+		}
+
+		if p.Note != nil {
+			s.Note = *p.Note
+		}
+
+		sess = *s
+
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("failed to update session: %w", err)
+	}
+
+	// notify the subscribers
+	if err := h.pub.Publish(h.appCtx, sID.String(), pubsub.RequestEvent{Action: pubsub.RequestActionSessionUpdate}); err != nil { //nolint:contextcheck,lll
+		return nil, err
+	}
+
+	var sHeaders = make([]openapi.HttpHeader, len(sess.Headers))
+	for i, header := range sess.Headers {
+		sHeaders[i].Name, sHeaders[i].Value = header.Name, header.Value
+	}
+
+	return &openapi.SessionOptionsResponse{
+		CreatedAtUnixMilli: sess.CreatedAtUnixMilli,
+		Labels:             append(make([]string, 0, len(sess.Labels)), sess.Labels...),
+		Name:               sess.Name,
+		Note:               sess.Note,
+		Response: openapi.SessionResponseOptions{
+			Delay:              uint16(sess.Delay.Seconds()),
+			Headers:            sHeaders,
+			ResponseBodyBase64: base64.StdEncoding.EncodeToString(sess.ResponseBody),
+			StatusCode:         openapi.StatusCode(sess.Code),
+			Templated:          &sess.Templated,
+		},
+		Uuid: sID,
+	}, nil
+}
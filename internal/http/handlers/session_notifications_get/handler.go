@@ -0,0 +1,92 @@
+package session_notifications_get
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"gh.tarampamp.am/webhook-tester/v2/internal/http/openapi"
+	"gh.tarampamp.am/webhook-tester/v2/internal/notify"
+	"gh.tarampamp.am/webhook-tester/v2/internal/storage"
+)
+
+type (
+	sID = openapi.SessionUUIDInPath
+
+	Handler struct {
+		db       storage.Storage
+		notifier *notify.Dispatcher
+	}
+)
+
+func New(db storage.Storage, notifier *notify.Dispatcher) *Handler {
+	return &Handler{db: db, notifier: notifier}
+}
+
+func (h *Handler) Handle(ctx context.Context, sID sID) (*openapi.SessionNotificationsResponse, error) {
+	sess, sErr := h.db.GetSession(ctx, sID.String())
+	if sErr != nil {
+		return nil, fmt.Errorf("failed to get session: %w", sErr)
+	}
+
+	states, stErr := h.notifier.States(ctx, sID.String())
+	if stErr != nil {
+		return nil, fmt.Errorf("failed to get session notifications state: %w", stErr)
+	}
+
+	var resp = make(openapi.SessionNotificationsResponse, len(sess.Notifications))
+
+	for i, t := range sess.Notifications {
+		target, err := toOpenAPITarget(t, states[t.ID])
+		if err != nil {
+			return nil, err
+		}
+
+		resp[i] = *target
+	}
+
+	return &resp, nil
+}
+
+func toOpenAPITarget(t storage.NotificationTarget, state notify.TargetState) (*openapi.NotificationTarget, error) {
+	tUUID, pErr := uuid.Parse(t.ID)
+	if pErr != nil {
+		return nil, fmt.Errorf("failed to parse notification target UUID: %w", pErr)
+	}
+
+	var out = openapi.NotificationTarget{
+		Uuid:                      tUUID,
+		Kind:                      t.Kind,
+		Url:                       t.URL,
+		HasSecret:                 t.Secret != "", // the secret is write-only
+		Template:                  t.Template,
+		ConsecutiveFailures:       state.Failures,
+		CircuitOpenUntilUnixMilli: state.OpenUntilUnixMilli,
+		Deliveries:                make([]openapi.NotificationDelivery, len(state.Deliveries)),
+	}
+
+	for i, d := range state.Deliveries {
+		dUUID, dErr := uuid.Parse(d.ID)
+		if dErr != nil {
+			return nil, fmt.Errorf("failed to parse notification delivery UUID: %w", dErr)
+		}
+
+		rUUID, rErr := uuid.Parse(d.RequestID)
+		if rErr != nil {
+			return nil, fmt.Errorf("failed to parse request UUID: %w", rErr)
+		}
+
+		out.Deliveries[i] = openapi.NotificationDelivery{
+			Uuid:               dUUID,
+			RequestUuid:        rUUID,
+			Attempts:           d.Attempts,
+			StatusCode:         d.StatusCode,
+			Error:              d.Error,
+			LatencyMillis:      int(d.Latency.Milliseconds()),
+			CreatedAtUnixMilli: d.CreatedAtUnixMilli,
+		}
+	}
+
+	return &out, nil
+}
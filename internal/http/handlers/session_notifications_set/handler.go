@@ -0,0 +1,156 @@
+package session_notifications_set
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"gh.tarampamp.am/webhook-tester/v2/internal/http/openapi"
+	"gh.tarampamp.am/webhook-tester/v2/internal/notify"
+	"gh.tarampamp.am/webhook-tester/v2/internal/storage"
+)
+
+type (
+	sID = openapi.SessionUUIDInPath
+
+	Handler struct {
+		db       storage.Storage
+		notifier *notify.Dispatcher
+	}
+)
+
+func New(db storage.Storage, notifier *notify.Dispatcher) *Handler {
+	return &Handler{db: db, notifier: notifier}
+}
+
+func (h *Handler) Handle(
+	ctx context.Context,
+	sID sID,
+	p openapi.SetSessionNotificationsRequest,
+) (*openapi.SessionNotificationsResponse, error) {
+	var targets []storage.NotificationTarget
+
+	if err := h.db.UpdateSession(ctx, sID.String(), func(s *storage.Session) error {
+		targets = make([]storage.NotificationTarget, len(p))
+
+		for i, opts := range p {
+			var t = storage.NotificationTarget{Kind: string(opts.Kind), URL: opts.Url}
+
+			if opts.Secret != nil {
+				t.Secret = *opts.Secret
+			} else { // the secret is write-only, so the clients cannot send it back
+				t.Secret = existingSecret(t, s.Notifications)
+			}
+
+			if opts.Template != nil && t.Kind == storage.NotificationKindTemplate {
+				t.Template = *opts.Template
+			}
+
+			targets[i] = withID(t, s.Notifications)
+		}
+
+		s.Notifications = targets
+
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("failed to set session notifications: %w", err)
+	}
+
+	var ids = make([]string, len(targets))
+
+	for i, t := range targets {
+		ids[i] = t.ID
+	}
+
+	if err := h.notifier.SetTargets(ctx, sID.String(), ids); err != nil {
+		return nil, fmt.Errorf("failed to index session notifications: %w", err)
+	}
+
+	states, stErr := h.notifier.States(ctx, sID.String())
+	if stErr != nil {
+		return nil, fmt.Errorf("failed to get session notifications state: %w", stErr)
+	}
+
+	var resp = make(openapi.SessionNotificationsResponse, len(targets))
+
+	for i, t := range targets {
+		target, err := toOpenAPITarget(t, states[t.ID])
+		if err != nil {
+			return nil, err
+		}
+
+		resp[i] = *target
+	}
+
+	return &resp, nil
+}
+
+// existingSecret returns the secret of the existing target with the same kind and URL (empty if there is none).
+func existingSecret(t storage.NotificationTarget, existing []storage.NotificationTarget) string {
+	for _, e := range existing {
+		if e.Kind == t.Kind && e.URL == t.URL {
+			return e.Secret
+		}
+	}
+
+	return ""
+}
+
+// withID returns the target with the ID of the same existing target (so re-saving the targets doesn't reset their
+// delivery state), or with a new ID.
+func withID(t storage.NotificationTarget, existing []storage.NotificationTarget) storage.NotificationTarget {
+	for _, e := range existing {
+		if e.Kind == t.Kind && e.URL == t.URL && e.Secret == t.Secret && e.Template == t.Template {
+			t.ID = e.ID
+
+			return t
+		}
+	}
+
+	t.ID = uuid.NewString()
+
+	return t
+}
+
+func toOpenAPITarget(t storage.NotificationTarget, state notify.TargetState) (*openapi.NotificationTarget, error) {
+	tUUID, pErr := uuid.Parse(t.ID)
+	if pErr != nil {
+		return nil, fmt.Errorf("failed to parse notification target UUID: %w", pErr)
+	}
+
+	var out = openapi.NotificationTarget{
+		Uuid:                      tUUID,
+		Kind:                      t.Kind,
+		Url:                       t.URL,
+		HasSecret:                 t.Secret != "", // the secret is write-only
+		Template:                  t.Template,
+		ConsecutiveFailures:       state.Failures,
+		CircuitOpenUntilUnixMilli: state.OpenUntilUnixMilli,
+		Deliveries:                make([]openapi.NotificationDelivery, len(state.Deliveries)),
+	}
+
+	for i, d := range state.Deliveries {
+		dUUID, dErr := uuid.Parse(d.ID)
+		if dErr != nil {
+			return nil, fmt.Errorf("failed to parse notification delivery UUID: %w", dErr)
+		}
+
+		rUUID, rErr := uuid.Parse(d.RequestID)
+		if rErr != nil {
+			return nil, fmt.Errorf("failed to parse request UUID: %w", rErr)
+		}
+
+		out.Deliveries[i] = openapi.NotificationDelivery{
+			Uuid:               dUUID,
+			RequestUuid:        rUUID,
+			Attempts:           d.Attempts,
+			StatusCode:         d.StatusCode,
+			Error:              d.Error,
+			LatencyMillis:      int(d.Latency.Milliseconds()),
+			CreatedAtUnixMilli: d.CreatedAtUnixMilli,
+		}
+	}
+
+	return &out, nil
+}
@@ -0,0 +1,61 @@
+package session_rules_get
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+
+	"gh.tarampamp.am/webhook-tester/v2/internal/http/openapi"
+	"gh.tarampamp.am/webhook-tester/v2/internal/storage"
+)
+
+type (
+	sID = openapi.SessionUUIDInPath
+
+	Handler struct{ db storage.Storage }
+)
+
+func New(db storage.Storage) *Handler { return &Handler{db: db} }
+
+func (h *Handler) Handle(ctx context.Context, sID sID) (*openapi.ResponseRulesResponse, error) {
+	sess, sErr := h.db.GetSession(ctx, sID.String())
+	if sErr != nil {
+		return nil, fmt.Errorf("failed to get session: %w", sErr)
+	}
+
+	var rules = make(openapi.ResponseRulesResponse, len(sess.Rules))
+
+	for i, rule := range sess.Rules {
+		var rHeaders = make([]openapi.HttpHeader, len(rule.Response.Headers))
+		for j, header := range rule.Response.Headers {
+			rHeaders[j].Name, rHeaders[j].Value = header.Name, header.Value
+		}
+
+		rules[i] = openapi.ResponseRule{
+			Methods:  append(make([]openapi.HttpMethod, 0, len(rule.Methods)), rule.Methods...),
+			Path:     rule.Path,
+			Headers:  toOpenAPIConditions(rule.Headers),
+			Query:    toOpenAPIConditions(rule.Query),
+			JsonBody: toOpenAPIConditions(rule.JSONBody),
+			Response: openapi.SessionResponseOptions{
+				Delay:              uint16(rule.Response.Delay.Seconds()),
+				Headers:            rHeaders,
+				ResponseBodyBase64: base64.StdEncoding.EncodeToString(rule.Response.ResponseBody),
+				StatusCode:         openapi.StatusCode(rule.Response.Code),
+				Templated:          &rule.Response.Templated,
+			},
+		}
+	}
+
+	return &rules, nil
+}
+
+func toOpenAPIConditions(in []storage.RuleCondition) []openapi.ResponseRuleCondition {
+	var out = make([]openapi.ResponseRuleCondition, len(in))
+
+	for i, cond := range in {
+		out[i].Name, out[i].Value = cond.Name, cond.Value
+	}
+
+	return out
+}
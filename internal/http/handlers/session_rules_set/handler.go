@@ -0,0 +1,114 @@
+package session_rules_set
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"time"
+
+	"gh.tarampamp.am/webhook-tester/v2/internal/http/openapi"
+	"gh.tarampamp.am/webhook-tester/v2/internal/storage"
+)
+
+type (
+	sID = openapi.SessionUUIDInPath
+
+	Handler struct{ db storage.Storage }
+)
+
+func New(db storage.Storage) *Handler { return &Handler{db: db} }
+
+func (h *Handler) Handle(
+	ctx context.Context,
+	sID sID,
+	p openapi.SetResponseRulesRequest,
+) (*openapi.ResponseRulesResponse, error) {
+	var rules = make([]storage.ResponseRule, len(p))
+
+	for i, rule := range p {
+		var rHeaders = make([]storage.HttpHeader, len(rule.Response.Headers))
+		for j, header := range rule.Response.Headers {
+			rHeaders[j] = storage.HttpHeader{Name: header.Name, Value: header.Value}
+		}
+
+		var responseBody, decErr = base64.StdEncoding.DecodeString(rule.Response.ResponseBodyBase64)
+		if decErr != nil {
+			return nil, fmt.Errorf("cannot decode response body (wrong base64): %w", decErr)
+		}
+
+		var methods = make([]string, len(rule.Methods))
+		for j, method := range rule.Methods {
+			methods[j] = strings.ToUpper(method)
+		}
+
+		rules[i] = storage.ResponseRule{
+			Methods:  methods,
+			Path:     rule.Path,
+			Headers:  toStorageConditions(rule.Headers),
+			Query:    toStorageConditions(rule.Query),
+			JSONBody: toStorageConditions(rule.JsonBody),
+			Response: storage.RuleResponse{
+				Code:         uint16(rule.Response.StatusCode), //nolint:gosec
+				Headers:      rHeaders,
+				ResponseBody: responseBody,
+				Delay:        time.Second * time.Duration(rule.Response.Delay),
+				Templated:    rule.Response.Templated != nil && *rule.Response.Templated,
+			},
+		}
+	}
+
+	if err := h.db.UpdateSession(ctx, sID.String(), func(s *storage.Session) error {
+		s.Rules = rules
+
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("failed to set session rules: %w", err)
+	}
+
+	var resp = make(openapi.ResponseRulesResponse, len(rules))
+
+	for i, rule := range rules {
+		var rHeaders = make([]openapi.HttpHeader, len(rule.Response.Headers))
+		for j, header := range rule.Response.Headers {
+			rHeaders[j].Name, rHeaders[j].Value = header.Name, header.Value
+		}
+
+		resp[i] = openapi.ResponseRule{
+			Methods:  rule.Methods,
+			Path:     rule.Path,
+			Headers:  toOpenAPIConditions(rule.Headers),
+			Query:    toOpenAPIConditions(rule.Query),
+			JsonBody: toOpenAPIConditions(rule.JSONBody),
+			Response: openapi.SessionResponseOptions{
+				Delay:              uint16(rule.Response.Delay.Seconds()),
+				Headers:            rHeaders,
+				ResponseBodyBase64: base64.StdEncoding.EncodeToString(rule.Response.ResponseBody),
+				StatusCode:         openapi.StatusCode(rule.Response.Code),
+				Templated:          &rule.Response.Templated,
+			},
+		}
+	}
+
+	return &resp, nil
+}
+
+func toStorageConditions(in []openapi.ResponseRuleCondition) []storage.RuleCondition {
+	var out = make([]storage.RuleCondition, len(in))
+
+	for i, cond := range in {
+		out[i] = storage.RuleCondition{Name: cond.Name, Value: cond.Value}
+	}
+
+	return out
+}
+
+func toOpenAPIConditions(in []storage.RuleCondition) []openapi.ResponseRuleCondition {
+	var out = make([]openapi.ResponseRuleCondition, len(in))
+
+	for i, cond := range in {
+		out[i].Name, out[i].Value = cond.Name, cond.Value
+	}
+
+	return out
+}
@@ -0,0 +1,35 @@
+package session_signature_get
+
+import (
+	"context"
+	"fmt"
+
+	"gh.tarampamp.am/webhook-tester/v2/internal/http/openapi"
+	"gh.tarampamp.am/webhook-tester/v2/internal/storage"
+)
+
+type (
+	sID = openapi.SessionUUIDInPath
+
+	Handler struct{ db storage.Storage }
+)
+
+func New(db storage.Storage) *Handler { return &Handler{db: db} }
+
+func (h *Handler) Handle(ctx context.Context, sID sID) (*openapi.SessionSignatureResponse, error) {
+	sess, sErr := h.db.GetSession(ctx, sID.String())
+	if sErr != nil {
+		return nil, fmt.Errorf("failed to get session: %w", sErr)
+	}
+
+	if sess.Signature == nil {
+		return &openapi.SessionSignatureResponse{}, nil // verification is disabled
+	}
+
+	return &openapi.SessionSignatureResponse{
+		Provider:   sess.Signature.Provider,
+		RejectCode: sess.Signature.RejectCode,
+		Secret:     sess.Signature.Secret,
+		Tolerance:  uint32(sess.Signature.Tolerance.Seconds()),
+	}, nil
+}
@@ -0,0 +1,54 @@
+package session_signature_set
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"gh.tarampamp.am/webhook-tester/v2/internal/http/openapi"
+	"gh.tarampamp.am/webhook-tester/v2/internal/storage"
+)
+
+type (
+	sID = openapi.SessionUUIDInPath
+
+	Handler struct{ db storage.Storage }
+)
+
+func New(db storage.Storage) *Handler { return &Handler{db: db} }
+
+func (h *Handler) Handle(
+	ctx context.Context,
+	sID sID,
+	p openapi.SetSessionSignatureRequest,
+) (*openapi.SessionSignatureResponse, error) {
+	var opts *storage.SignatureOptions
+
+	if p.Secret != "" { // empty secret disables the verification
+		opts = &storage.SignatureOptions{
+			Provider:   p.Provider,
+			Secret:     p.Secret,
+			Tolerance:  time.Second * time.Duration(p.Tolerance),
+			RejectCode: p.RejectCode,
+		}
+	}
+
+	if err := h.db.UpdateSession(ctx, sID.String(), func(s *storage.Session) error {
+		s.Signature = opts
+
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("failed to set session signature verification: %w", err)
+	}
+
+	if opts == nil {
+		return &openapi.SessionSignatureResponse{}, nil
+	}
+
+	return &openapi.SessionSignatureResponse{
+		Provider:   opts.Provider,
+		RejectCode: opts.RejectCode,
+		Secret:     opts.Secret,
+		Tolerance:  uint32(opts.Tolerance.Seconds()),
+	}, nil
+}
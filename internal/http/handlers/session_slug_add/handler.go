@@ -0,0 +1,60 @@
+package session_slug_add
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"gh.tarampamp.am/webhook-tester/v2/internal/http/openapi"
+	"gh.tarampamp.am/webhook-tester/v2/internal/slug"
+	"gh.tarampamp.am/webhook-tester/v2/internal/storage"
+)
+
+type (
+	sID = openapi.SessionUUIDInPath
+
+	Handler struct {
+		db     storage.Storage
+		policy *slug.Policy // nil = slugs are disabled
+	}
+)
+
+func New(db storage.Storage, policy *slug.Policy) *Handler { return &Handler{db: db, policy: policy} }
+
+// Handle reserves the slug for the session and returns all the session slugs. Re-adding the slug, which is already
+// owned by the session, is a no-op.
+func (h *Handler) Handle(
+	ctx context.Context,
+	sID sID,
+	p openapi.AddSessionSlugRequest,
+) (*openapi.SessionSlugsResponse, error) {
+	if h.policy == nil {
+		return nil, slug.ErrDisabled
+	}
+
+	if err := h.policy.Validate(p.Slug); err != nil {
+		return nil, err
+	}
+
+	current, err := h.db.GetSessionSlugs(ctx, sID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session slugs: %w", err)
+	}
+
+	if len(current) >= slug.MaxPerSession && !slices.Contains(current, p.Slug) {
+		return nil, slug.ErrTooMany
+	}
+
+	if err = h.db.AddSessionSlug(ctx, sID.String(), p.Slug); err != nil {
+		return nil, fmt.Errorf("failed to add session slug: %w", err)
+	}
+
+	slugs, err := h.db.GetSessionSlugs(ctx, sID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session slugs: %w", err)
+	}
+
+	var resp = append(make(openapi.SessionSlugsResponse, 0, len(slugs)), slugs...)
+
+	return &resp, nil
+}
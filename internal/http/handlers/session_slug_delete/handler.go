@@ -0,0 +1,37 @@
+package session_slug_delete
+
+import (
+	"context"
+	"fmt"
+
+	"gh.tarampamp.am/webhook-tester/v2/internal/http/openapi"
+	"gh.tarampamp.am/webhook-tester/v2/internal/storage"
+)
+
+type (
+	sID = openapi.SessionUUIDInPath
+
+	Handler struct{ db storage.Storage }
+)
+
+func New(db storage.Storage) *Handler { return &Handler{db: db} }
+
+// Handle releases the session slug and returns the remaining session slugs.
+func (h *Handler) Handle(
+	ctx context.Context,
+	sID sID,
+	slug openapi.SlugInQuery,
+) (*openapi.SessionSlugsResponse, error) {
+	if err := h.db.DeleteSessionSlug(ctx, sID.String(), slug); err != nil {
+		return nil, fmt.Errorf("failed to delete session slug: %w", err)
+	}
+
+	slugs, err := h.db.GetSessionSlugs(ctx, sID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session slugs: %w", err)
+	}
+
+	var resp = append(make(openapi.SessionSlugsResponse, 0, len(slugs)), slugs...)
+
+	return &resp, nil
+}
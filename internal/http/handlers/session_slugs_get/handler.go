@@ -0,0 +1,28 @@
+package session_slugs_get
+
+import (
+	"context"
+	"fmt"
+
+	"gh.tarampamp.am/webhook-tester/v2/internal/http/openapi"
+	"gh.tarampamp.am/webhook-tester/v2/internal/storage"
+)
+
+type (
+	sID = openapi.SessionUUIDInPath
+
+	Handler struct{ db storage.Storage }
+)
+
+func New(db storage.Storage) *Handler { return &Handler{db: db} }
+
+func (h *Handler) Handle(ctx context.Context, sID sID) (*openapi.SessionSlugsResponse, error) {
+	slugs, err := h.db.GetSessionSlugs(ctx, sID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session slugs: %w", err)
+	}
+
+	var resp = append(make(openapi.SessionSlugsResponse, 0, len(slugs)), slugs...)
+
+	return &resp, nil
+}
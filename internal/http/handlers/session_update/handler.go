@@ -0,0 +1,86 @@
+package session_update
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"time"
+
+	"gh.tarampamp.am/webhook-tester/v2/internal/http/openapi"
+	"gh.tarampamp.am/webhook-tester/v2/internal/pubsub"
+	"gh.tarampamp.am/webhook-tester/v2/internal/storage"
+)
+
+type (
+	sID = openapi.SessionUUIDInPath
+
+	Handler struct {
+		appCtx context.Context
+		db     storage.Storage
+		pub    pubsub.Publisher[pubsub.RequestEvent]
+	}
+)
+
+func New(appCtx context.Context, db storage.Storage, pub pubsub.Publisher[pubsub.RequestEvent]) *Handler {
+	return &Handler{appCtx: appCtx, db: db, pub: pub}
+}
+
+// Handle replaces the session response options (the session UUID, TTL and requests are preserved) and notifies the
+// session subscribers.
+func (h *Handler) Handle(
+	ctx context.Context,
+	sID sID,
+	p openapi.UpdateSessionRequest,
+) (*openapi.SessionOptionsResponse, error) {
+	var sHeaders = make([]storage.HttpHeader, len(p.Headers))
+	for i, header := range p.Headers {
+		sHeaders[i] = storage.HttpHeader{Name: header.Name, Value: header.Value}
+	}
+
+	var responseBody, decErr = base64.StdEncoding.DecodeString(p.ResponseBodyBase64)
+	if decErr != nil {
+		return nil, fmt.Errorf("cannot decode response body (wrong base64): %w", decErr)
+	}
+
+	if err := h.db.UpdateSession(ctx, sID.String(), func(s *storage.Session) error {
+		s.Code = uint16(p.StatusCode) //nolint:gosec
+		s.Headers = sHeaders
+		s.ResponseBody = responseBody
+		s.Delay = time.Second * time.Duration(p.Delay)
+		s.Templated = p.Templated != nil && *p.Templated
+
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("failed to update session: %w", err)
+	}
+
+	sess, sErr := h.db.GetSession(ctx, sID.String())
+	if sErr != nil {
+		return nil, fmt.Errorf("failed to get session: %w", sErr)
+	}
+
+	// notify the subscribers
+	if err := h.pub.Publish(h.appCtx, sID.String(), pubsub.RequestEvent{Action: pubsub.RequestActionSessionUpdate}); err != nil { //nolint:contextcheck,lll
+		return nil, err
+	}
+
+	var rHeaders = make([]openapi.HttpHeader, len(sess.Headers))
+	for i, header := range sess.Headers {
+		rHeaders[i].Name, rHeaders[i].Value = header.Name, header.Value
+	}
+
+	return &openapi.SessionOptionsResponse{
+		CreatedAtUnixMilli: sess.CreatedAtUnixMilli,
+		Labels:             append(make([]string, 0, len(sess.Labels)), sess.Labels...),
+		Name:               sess.Name,
+		Note:               sess.Note,
+		Response: openapi.SessionResponseOptions{
+			Delay:              uint16(sess.Delay.Seconds()),
+			Headers:            rHeaders,
+			ResponseBodyBase64: base64.StdEncoding.EncodeToString(sess.ResponseBody),
+			StatusCode:         openapi.StatusCode(sess.Code),
+			Templated:          &sess.Templated,
+		},
+		Uuid: sID,
+	}, nil
+}
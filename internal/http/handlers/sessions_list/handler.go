@@ -0,0 +1,64 @@
+package sessions_list
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"gh.tarampamp.am/webhook-tester/v2/internal/auth"
+	"gh.tarampamp.am/webhook-tester/v2/internal/http/openapi"
+	"gh.tarampamp.am/webhook-tester/v2/internal/storage"
+)
+
+type Handler struct{ db storage.Storage }
+
+func New(db storage.Storage) *Handler { return &Handler{db: db} }
+
+// Handle returns the sessions, available for the current user, and the cursor for the next page (empty if there are
+// no more sessions).
+func (h *Handler) Handle(
+	ctx context.Context,
+	params openapi.ApiSessionListParams,
+) (*openapi.SessionsListResponse, string, error) {
+	var query = storage.SessionsQuery{Owner: auth.OwnerFromContext(ctx)}
+
+	if params.Cursor != nil {
+		query.Cursor = *params.Cursor
+	}
+
+	if params.Limit != nil && *params.Limit > 0 {
+		query.Limit = uint32(*params.Limit) //nolint:gosec // validated
+	}
+
+	page, err := h.db.ListSessions(ctx, query)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var list = make([]openapi.ListedSession, 0, len(page.Sessions))
+
+	for _, s := range page.Sessions { // already sorted
+		sUUID, pErr := uuid.Parse(s.ID)
+		if pErr != nil {
+			return nil, "", fmt.Errorf("failed to parse session UUID: %w", pErr)
+		}
+
+		var item = openapi.ListedSession{
+			Uuid:               sUUID,
+			Name:               s.Name,
+			Labels:             append(make([]string, 0, len(s.Labels)), s.Labels...),
+			CreatedAtUnixMilli: s.CreatedAtUnixMilli,
+			ExpiresAtUnixMilli: s.ExpiresAt.UnixMilli(),
+			RequestsCount:      s.RequestsCount,
+		}
+
+		if s.LastRequestAtUnixMilli > 0 {
+			item.LastRequestAtUnixMilli = &s.LastRequestAtUnixMilli
+		}
+
+		list = append(list, item)
+	}
+
+	return &list, page.NextCursor, nil
+}
@@ -1,6 +1,8 @@
 package settings_get
 
 import (
+	"net/http"
+
 	"gh.tarampamp.am/webhook-tester/v2/internal/config"
 	"gh.tarampamp.am/webhook-tester/v2/internal/http/openapi"
 )
@@ -9,7 +11,7 @@ type Handler struct{ cfg *config.AppSettings }
 
 func New(s *config.AppSettings) *Handler { return &Handler{cfg: s} }
 
-func (h *Handler) Handle() (resp openapi.SettingsResponse) {
+func (h *Handler) Handle(r *http.Request) (resp openapi.SettingsResponse) {
 	resp.Limits.MaxRequestBodySize = h.cfg.MaxRequestBodySize
 	resp.Limits.MaxRequests = h.cfg.MaxRequests
 	resp.Limits.SessionTtl = uint32(h.cfg.SessionTTL.Seconds())
@@ -24,7 +26,30 @@ func (h *Handler) Handle() (resp openapi.SettingsResponse) {
 		var publicUrlRoot = h.cfg.PublicURLRoot.String()
 
 		resp.PublicUrlRoot = &publicUrlRoot
+	} else if publicUrlRoot := h.cfg.RealIP.Origin(r); publicUrlRoot != directOrigin(r) {
+		resp.PublicUrlRoot = &publicUrlRoot // the app is behind the (trusted) proxy, which changes the scheme or host
+	}
+
+	if h.cfg.SlugPolicy != nil {
+		var slugPattern = h.cfg.SlugPolicy.Pattern()
+
+		resp.SlugPattern = &slugPattern
+	}
+
+	if h.cfg.TLSClientAuth != "" {
+		var clientAuth = h.cfg.TLSClientAuth
+
+		resp.TlsClientAuth = &clientAuth
 	}
 
 	return
 }
+
+// directOrigin returns the scheme and the host of the request, as it was received by the app.
+func directOrigin(r *http.Request) string {
+	if r.TLS != nil {
+		return "https://" + r.Host
+	}
+
+	return "http://" + r.Host
+}
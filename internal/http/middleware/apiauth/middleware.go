@@ -0,0 +1,80 @@
+// Package apiauth contains the middleware that authenticates the API requests, and restricts access to the sessions
+// owned by other users.
+package apiauth
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"gh.tarampamp.am/webhook-tester/v2/internal/auth"
+	"gh.tarampamp.am/webhook-tester/v2/internal/http/openapi"
+	"gh.tarampamp.am/webhook-tester/v2/internal/storage"
+)
+
+// sessionPathValue is the name of the route path parameter with the session UUID.
+const sessionPathValue = "session_uuid"
+
+// New creates the middleware. It must be applied to the API routes (not to the base router), since the session UUID
+// is read from the route path parameters. The requests to the non-API routes (like probes) are passed as is.
+func New(log *zap.Logger, a auth.Authenticator, db storage.Storage) func(http.Handler) http.Handler {
+	var challenge string
+
+	if c, ok := a.(auth.Challenger); ok {
+		challenge = c.Challenge()
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !strings.HasPrefix(r.URL.Path, "/api/") {
+				next.ServeHTTP(w, r)
+
+				return
+			}
+
+			user, err := a.Authenticate(r)
+			if err != nil {
+				if challenge != "" {
+					w.Header().Set("WWW-Authenticate", challenge)
+				}
+
+				respondWithError(w, log, http.StatusUnauthorized, err.Error())
+
+				return
+			}
+
+			var ctx = auth.WithUser(r.Context(), user)
+
+			// the sessions owned by other users are reported as not found (so their existence is not revealed)
+			if sID := r.PathValue(sessionPathValue); sID != "" {
+				sess, sErr := db.GetSession(ctx, sID)
+
+				switch {
+				case sErr == nil && !auth.CanAccess(user, sess.Owner):
+					respondWithError(w, log, http.StatusNotFound, storage.ErrSessionNotFound.Error())
+
+					return
+				case sErr != nil && !errors.Is(sErr, storage.ErrNotFound):
+					respondWithError(w, log, http.StatusInternalServerError, sErr.Error())
+
+					return
+				}
+			}
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// respondWithError writes the error in the API format.
+func respondWithError(w http.ResponseWriter, log *zap.Logger, code int, msg string) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(code)
+
+	if err := json.NewEncoder(w).Encode(openapi.ErrorResponse{Error: msg}); err != nil {
+		log.Error("failed to respond with an error", zap.Error(err), zap.Int("code", code))
+	}
+}
@@ -0,0 +1,41 @@
+package httpsredirect
+
+import (
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// New creates a middleware for [http.ServeMux] that redirects the plain HTTP requests to HTTPS on the given port.
+// The 308 (Permanent Redirect) status code is used, so the clients repeat the request with the same method and body
+// (this is important for the webhooks).
+//
+// The skipper function should return true if the request should not be redirected. It's ok to pass nil.
+func New(httpsPort uint16, skipper func(*http.Request) bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.TLS != nil || (skipper != nil && skipper(r)) {
+				next.ServeHTTP(w, r)
+
+				return
+			}
+
+			var host = r.Host
+
+			if h, _, err := net.SplitHostPort(host); err == nil {
+				host = h
+			} else if len(host) > 1 && host[0] == '[' && host[len(host)-1] == ']' { // IPv6 without the port
+				host = host[1 : len(host)-1]
+			}
+
+			if httpsPort != 443 { //nolint:mnd
+				host = net.JoinHostPort(host, strconv.Itoa(int(httpsPort)))
+			} else if strings.Contains(host, ":") { // IPv6
+				host = "[" + host + "]"
+			}
+
+			http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusPermanentRedirect)
+		})
+	}
+}
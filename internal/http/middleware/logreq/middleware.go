@@ -2,12 +2,28 @@ package logreq
 
 import (
 	"net/http"
+	"net/url"
 	"time"
 
 	"go.uber.org/zap"
 )
 
-// New creates a middleware for [http.ServeMux] that logs every incoming request.
+// redacted replaces the credential values in the logs.
+const redacted = "xxxxx"
+
+// credentialQueryParams are the query parameters, which may contain the credentials (e.g. the API token is accepted
+// from the "access_token" query parameter, since the browsers cannot set the headers for WebSockets).
+var credentialQueryParams = [...]string{ //nolint:gochecknoglobals
+	"access_token", "id_token", "refresh_token", "api_key", "token",
+}
+
+// credentialHeaders are the headers, which may contain the credentials.
+var credentialHeaders = [...]string{ //nolint:gochecknoglobals
+	"Authorization", "Proxy-Authorization", "X-Api-Key", "Cookie", "Set-Cookie",
+}
+
+// New creates a middleware for [http.ServeMux] that logs every incoming request. The credentials (in the query
+// parameters and headers) are redacted.
 //
 // The skipper function should return true if the request should be skipped. It's ok to pass nil.
 func New(log *zap.Logger, skipper func(*http.Request) bool) func(http.Handler) http.Handler {
@@ -28,7 +44,7 @@ func New(log *zap.Logger, skipper func(*http.Request) bool) func(http.Handler) h
 					zap.String("useragent", r.UserAgent()),
 					zap.String("host", r.Host),
 					zap.String("method", r.Method),
-					zap.String("url", r.URL.String()),
+					zap.String("url", redactURL(r.URL)),
 					zap.String("content type", w.Header().Get("Content-Type")),
 					zap.String("remote addr", r.RemoteAddr),
 					zap.String("method", r.Method),
@@ -45,8 +61,8 @@ func New(log *zap.Logger, skipper func(*http.Request) bool) func(http.Handler) h
 
 				if log.Level() <= zap.DebugLevel {
 					fields = append(fields,
-						zap.Any("request headers", r.Header.Clone()),
-						zap.Any("response headers", w.Header().Clone()),
+						zap.Any("request headers", redactHeaders(r.Header)),
+						zap.Any("response headers", redactHeaders(w.Header())),
 					)
 				}
 
@@ -57,3 +73,54 @@ func New(log *zap.Logger, skipper func(*http.Request) bool) func(http.Handler) h
 		})
 	}
 }
+
+// redactURL returns the URL string with the credential query parameter values (and the password) redacted.
+func redactURL(u *url.URL) string {
+	if u == nil {
+		return ""
+	}
+
+	if u.RawQuery == "" {
+		return u.Redacted()
+	}
+
+	var (
+		query = u.Query()
+		found bool
+	)
+
+	for _, name := range credentialQueryParams {
+		if values, ok := query[name]; ok {
+			for i := range values {
+				values[i] = redacted
+			}
+
+			found = true
+		}
+	}
+
+	if !found {
+		return u.Redacted()
+	}
+
+	var clone = *u
+
+	clone.RawQuery = query.Encode()
+
+	return clone.Redacted()
+}
+
+// redactHeaders returns a copy of the headers with the credential values redacted.
+func redactHeaders(h http.Header) http.Header {
+	var clone = h.Clone()
+
+	for _, name := range credentialHeaders {
+		if values, ok := clone[name]; ok {
+			for i := range values {
+				values[i] = redacted
+			}
+		}
+	}
+
+	return clone
+}
@@ -0,0 +1,102 @@
+package tracereq
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"gh.tarampamp.am/webhook-tester/v2/internal/tracing"
+)
+
+// TraceIDHeader is the response header with the trace ID of the request.
+const TraceIDHeader = "X-Trace-Id"
+
+// New creates a middleware for [http.ServeMux] that starts a span for every incoming request. The W3C trace context
+// of the request (the "traceparent" header) is respected, so the span continues the trace of the caller. The trace
+// ID is returned in the X-Trace-Id response header.
+//
+// The skipper function should return true if the request should be skipped. It's ok to pass nil.
+func New(tp trace.TracerProvider, skipper func(*http.Request) bool) func(http.Handler) http.Handler {
+	var (
+		tracer     = tracing.Tracer(tp)
+		propagator = tracing.Propagator()
+	)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if skipper != nil && skipper(r) {
+				next.ServeHTTP(w, r)
+
+				return
+			}
+
+			var scheme = "http"
+			if r.TLS != nil {
+				scheme = "https"
+			}
+
+			ctx, span := tracer.Start(
+				propagator.Extract(r.Context(), propagation.HeaderCarrier(r.Header)),
+				"HTTP "+r.Method,
+				trace.WithSpanKind(trace.SpanKindServer),
+				trace.WithAttributes(
+					semconv.HTTPRequestMethodOriginal(r.Method),
+					semconv.URLPath(r.URL.Path),
+					semconv.URLScheme(scheme),
+					semconv.ServerAddress(r.Host),
+					semconv.ClientAddress(r.RemoteAddr),
+					semconv.UserAgentOriginal(r.UserAgent()),
+				),
+			)
+			defer span.End()
+
+			if id := tracing.TraceID(ctx); id != "" {
+				w.Header().Set(TraceIDHeader, id)
+			}
+
+			var sw = &statusWriter{ResponseWriter: w, status: http.StatusOK}
+
+			next.ServeHTTP(sw, r.WithContext(ctx))
+
+			span.SetAttributes(semconv.HTTPResponseStatusCode(sw.status))
+
+			if sw.status >= http.StatusInternalServerError {
+				span.SetStatus(codes.Error, http.StatusText(sw.status))
+			}
+		})
+	}
+}
+
+// statusWriter remembers the response status code.
+type statusWriter struct {
+	http.ResponseWriter
+
+	status      int
+	wroteHeader bool
+}
+
+func (w *statusWriter) WriteHeader(code int) {
+	if !w.wroteHeader {
+		w.status, w.wroteHeader = code, true
+	}
+
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// Unwrap returns the original writer (it's used by the [http.ResponseController]).
+func (w *statusWriter) Unwrap() http.ResponseWriter { return w.ResponseWriter }
+
+// Hijack implements the [http.Hijacker] (it's required for the WebSocket connections).
+func (w *statusWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	w.status, w.wroteHeader = http.StatusSwitchingProtocols, true
+
+	return http.NewResponseController(w.ResponseWriter).Hijack()
+}
+
+// Flush implements the [http.Flusher] (it's required for the streaming responses).
+func (w *statusWriter) Flush() { _ = http.NewResponseController(w.ResponseWriter).Flush() }
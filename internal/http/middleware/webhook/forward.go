@@ -0,0 +1,73 @@
+package webhook
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"gh.tarampamp.am/webhook-tester/v2/internal/forward"
+	"gh.tarampamp.am/webhook-tester/v2/internal/storage"
+)
+
+// defaultForwardTimeout is used when the session forwarding timeout is not set.
+const defaultForwardTimeout = 10 * time.Second
+
+// newForwardRequest builds the request to forward using the session forwarding options. The path after the session
+// ID (or slug) and the query string are appended to the target URL. Without the headers passing, only the content
+// type is forwarded.
+func newForwardRequest(opts *storage.ForwardOptions, r *http.Request, prefix string, body []byte) (forward.Request, error) { //nolint:lll
+	target, err := forward.TargetURL(opts.URL, subPath(r, prefix), r.URL.RawQuery)
+	if err != nil {
+		return forward.Request{URL: opts.URL}, err
+	}
+
+	var headers = make(http.Header)
+
+	if opts.PassHeaders {
+		headers = r.Header.Clone()
+	} else if ct := r.Header.Get("Content-Type"); ct != "" {
+		headers.Set("Content-Type", ct)
+	}
+
+	return forward.Request{Method: r.Method, URL: target, Headers: headers, Body: body}, nil
+}
+
+// doForward sends the request to the upstream and returns the forwarding result. The upstream response is returned
+// only if the forwarding succeeded.
+func doForward(
+	ctx context.Context,
+	client *forward.Client,
+	timeout time.Duration,
+	req forward.Request,
+) (storage.ForwardResult, *forward.Response) {
+	if timeout <= 0 {
+		timeout = defaultForwardTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var start = time.Now()
+
+	resp, err := client.Do(ctx, req)
+	if err != nil {
+		return storage.ForwardResult{URL: req.URL, Latency: time.Since(start), Error: err.Error()}, nil
+	}
+
+	var headers = make([]storage.HttpHeader, 0, len(resp.Headers))
+	for name, value := range resp.Headers {
+		headers = append(headers, storage.HttpHeader{Name: name, Value: strings.Join(value, "; ")})
+	}
+
+	slices.SortFunc(headers, func(i, j storage.HttpHeader) int { return strings.Compare(i.Name, j.Name) })
+
+	return storage.ForwardResult{
+		URL:        req.URL,
+		StatusCode: uint16(resp.StatusCode), //nolint:gosec
+		Headers:    headers,
+		Body:       resp.Body,
+		Latency:    resp.Latency,
+	}, resp
+}
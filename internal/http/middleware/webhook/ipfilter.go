@@ -0,0 +1,26 @@
+package webhook
+
+import (
+	"gh.tarampamp.am/webhook-tester/v2/internal/ipfilter"
+	"gh.tarampamp.am/webhook-tester/v2/internal/storage"
+)
+
+// The scopes of the blocked requests metric.
+const (
+	blockedScopeApp     = "app"     // blocked by the app-wide IP filter
+	blockedScopeSession = "session" // blocked by the session IP filter
+)
+
+// checkSessionIPFilter checks the client IP address against the session IP filter (nil filter allows everything).
+func checkSessionIPFilter(opts *storage.IPFilterOptions, clientAddr string) error {
+	if opts == nil {
+		return nil
+	}
+
+	policy, err := ipfilter.NewPolicy(opts.Allow, opts.Deny) // the rules are validated when the filter is set
+	if err != nil {
+		return err
+	}
+
+	return policy.Check(clientAddr)
+}
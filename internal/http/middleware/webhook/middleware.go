@@ -4,20 +4,28 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"html"
 	"io"
-	"net"
 	"net/http"
 	"slices"
 	"strconv"
 	"strings"
 	"time"
 
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 
 	"gh.tarampamp.am/webhook-tester/v2/internal/config"
+	"gh.tarampamp.am/webhook-tester/v2/internal/forward"
 	"gh.tarampamp.am/webhook-tester/v2/internal/http/openapi"
+	"gh.tarampamp.am/webhook-tester/v2/internal/http/realip"
 	"gh.tarampamp.am/webhook-tester/v2/internal/pubsub"
+	"gh.tarampamp.am/webhook-tester/v2/internal/ratelimit"
+	"gh.tarampamp.am/webhook-tester/v2/internal/slug"
 	"gh.tarampamp.am/webhook-tester/v2/internal/storage"
+	"gh.tarampamp.am/webhook-tester/v2/internal/template"
+	"gh.tarampamp.am/webhook-tester/v2/internal/tracing"
 )
 
 func New( //nolint:funlen,gocognit,gocyclo
@@ -27,16 +35,78 @@ func New( //nolint:funlen,gocognit,gocyclo
 	pub pubsub.Publisher[pubsub.RequestEvent],
 	cfg *config.AppSettings,
 ) func(http.Handler) http.Handler {
+	var (
+		fwdClient = forward.New(forward.WithHostPolicy(cfg.OutboundHostPolicy))
+		dropped   = newDroppedRecorder(appCtx, log, db, pub, cfg.RealIP)
+		tracer    = tracing.Tracer(cfg.TracerProvider)
+	)
+
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			var sID, doIt = shouldCaptureRequest(r)
+			var (
+				sID, doIt = shouldCaptureRequest(r)
+				prefix    = sID // the path prefix, which identifies the session (the ID or slug)
+			)
+
+			if !doIt && cfg.SlugPolicy != nil { // try to resolve the session by the slug
+				sID, prefix, doIt = resolveSlug(r, log, db, cfg.SlugPolicy)
+			}
+
 			if !doIt {
 				next.ServeHTTP(w, r)
 
 				return
 			}
 
-			var reqCtx = r.Context()
+			// trace the capturing (the storage and pub/sub spans will be the children of this one)
+			ctx, span := tracer.Start(r.Context(), "webhook.capture", trace.WithAttributes(
+				attribute.String("webhook_tester.session.id", sID),
+				attribute.String("webhook_tester.path_prefix", prefix),
+			))
+			defer span.End()
+
+			r = r.WithContext(ctx)
+
+			if cfg.Metrics != nil { // count the captured webhooks by the response status code
+				var sw = &statusWriter{ResponseWriter: w, status: http.StatusOK}
+
+				defer func() { cfg.Metrics.ObserveWebhook(r.Method, sw.status) }()
+
+				w = sw
+			}
+
+			var (
+				reqCtx     = r.Context()
+				clientAddr = cfg.RealIP.ClientIP(r)
+			)
+
+			// throttle the requests per client IP address (it's checked first, since no storage access is needed)
+			if ok, retryAfter := allowRequest(reqCtx, log, cfg, ratelimit.ScopeIP, clientAddr); !ok {
+				if cfg.RateLimits.ShouldRecordDropped() {
+					if _, err := db.GetSession(reqCtx, sID); err == nil { //nolint:contextcheck
+						dropped.record(reqCtx, r, sID, "rate limited (per client IP)")
+					}
+				}
+
+				respondRateLimited(w, log, retryAfter)
+
+				return
+			}
+
+			// reject the requests from the not allowed client IP addresses (before the session may be auto-created)
+			if err := cfg.IPPolicy.Check(clientAddr); err != nil {
+				cfg.Metrics.ObserveBlocked(blockedScopeApp)
+
+				if cfg.RecordBlocked {
+					if _, gErr := db.GetSession(reqCtx, sID); gErr == nil { //nolint:contextcheck
+						dropped.record(reqCtx, r, sID, "blocked by the app IP filter: "+err.Error())
+					}
+				}
+
+				respondWithError(w, log, http.StatusForbidden, html.EscapeString("Access denied: "+err.Error()))
+
+				return
+			}
 
 			// get the session from the storage
 			sess, sErr := db.GetSession(reqCtx, sID) //nolint:contextcheck
@@ -45,6 +115,13 @@ func New( //nolint:funlen,gocognit,gocyclo
 				if errors.Is(sErr, storage.ErrNotFound) {
 					// but the auto-creation is enabled
 					if cfg.AutoCreateSessions {
+						// throttle the sessions creation per client IP address
+						if ok, retryAfter := allowRequest(reqCtx, log, cfg, ratelimit.ScopeSessionCreate, clientAddr); !ok {
+							respondRateLimited(w, log, retryAfter)
+
+							return
+						}
+
 						// create a new session with some default values
 						if _, err := db.NewSession(reqCtx, storage.Session{ //nolint:contextcheck
 							Code: http.StatusOK,
@@ -75,6 +152,30 @@ func New( //nolint:funlen,gocognit,gocyclo
 				}
 			}
 
+			// throttle the requests per session (before the session lifetime is extended)
+			if ok, retryAfter := allowRequest(reqCtx, log, cfg, ratelimit.ScopeSession, sID); !ok {
+				if cfg.RateLimits.ShouldRecordDropped() {
+					dropped.record(reqCtx, r, sID, "rate limited (per session)")
+				}
+
+				respondRateLimited(w, log, retryAfter)
+
+				return
+			}
+
+			// reject the requests from the client IP addresses, which are not allowed by the session
+			if err := checkSessionIPFilter(sess.IPFilter, clientAddr); err != nil {
+				cfg.Metrics.ObserveBlocked(blockedScopeSession)
+
+				if cfg.RecordBlocked {
+					dropped.record(reqCtx, r, sID, "blocked by the session IP filter: "+err.Error())
+				}
+
+				respondWithError(w, log, http.StatusForbidden, html.EscapeString("Access denied: "+err.Error()))
+
+				return
+			}
+
 			{ // increase the session lifetime
 				var delta = time.Now().Add(cfg.SessionTTL).Sub(time.Unix(0, sess.CreatedAtUnixMilli*int64(time.Millisecond)))
 
@@ -94,6 +195,8 @@ func New( //nolint:funlen,gocognit,gocyclo
 				}
 			}
 
+			cfg.Metrics.ObserveWebhookBody(len(body))
+
 			// check the request body size and respond with an error if it's too large
 			if cfg.MaxRequestBodySize > 0 && uint32(len(body)) > cfg.MaxRequestBodySize { //nolint:gosec
 				respondWithError(w, log,
@@ -104,22 +207,35 @@ func New( //nolint:funlen,gocognit,gocyclo
 				return
 			}
 
-			// convert request headers into the storage format
-			var rHeaders = make([]storage.HttpHeader, 0, len(r.Header))
-			for name, value := range r.Header {
-				rHeaders = append(rHeaders, storage.HttpHeader{Name: name, Value: strings.Join(value, "; ")})
+			var (
+				rHeaders   = storageHeaders(r.Header)
+				sigResult  *storage.SignatureResult // the signature verification result (nil if not verified)
+				clientAuth = cfg.TLSClientAuth      // the client certificates mode (the session one overrides it)
+			)
+
+			// the session mode takes effect only if the certificates are requested by the HTTPS server (the default
+			// mode is not none), and it cannot be set otherwise, but it may be left from the previous app run
+			if sess.ClientAuth != "" && clientAuth != "" {
+				clientAuth = sess.ClientAuth
 			}
 
-			// sort headers by name
-			slices.SortFunc(rHeaders, func(i, j storage.HttpHeader) int { return strings.Compare(i.Name, j.Name) })
+			var reqTLS = requestTLS(r.TLS, clientAuth, cfg.TLSClientCAs)
+
+			if opts := sess.Signature; opts != nil {
+				sigResult = verifySignature(opts, r.Header, body)
+			}
 
 			// and save the request to the storage
 			rID, rErr := db.NewRequest(reqCtx, sID, storage.Request{ //nolint:contextcheck
-				ClientAddr: extractRealIP(r),
+				ClientAddr: clientAddr,
+				PeerAddr:   realip.PeerIP(r),
 				Method:     r.Method,
 				Body:       body,
 				Headers:    rHeaders,
-				URL:        extractFullUrl(r),
+				URL:        cfg.RealIP.URL(r),
+				Signature:  sigResult,
+				TraceID:    tracing.TraceID(reqCtx),
+				TLS:        reqTLS,
 			})
 			if rErr != nil {
 				respondWithError(w, log, http.StatusInternalServerError, rErr.Error())
@@ -128,66 +244,169 @@ func New( //nolint:funlen,gocognit,gocyclo
 			}
 
 			w.Header().Set("X-Wh-Request-Id", rID)
+			span.SetAttributes(attribute.String("webhook_tester.request.id", rID))
+
+			// the app context for the asynchronous operations (see the note below), which continues the capturing trace
+			var asyncCtx = trace.ContextWithSpanContext(appCtx, span.SpanContext())
+
+			// reject the request without a valid client certificate (it's captured, but not forwarded), if the
+			// client certificates are required
+			if reqTLS != nil && reqTLS.ClientAuth != nil && reqTLS.ClientAuth.Rejected {
+				go publishRequest(asyncCtx, log, db, pub, sID, rID, pubsub.RequestActionCreate)
+
+				respondWithError(w, log,
+					http.StatusForbidden,
+					html.EscapeString("Client certificate verification failed: "+reqTLS.ClientAuth.Error),
+				)
+
+				return
+			}
+
+			// reject the request with an invalid signature (it's captured, but not forwarded), if the session is
+			// configured to do so
+			if sigResult != nil && !sigResult.Valid && sess.Signature.RejectCode > 0 {
+				go publishRequest(asyncCtx, log, db, pub, sID, rID, pubsub.RequestActionCreate)
+
+				respondWithError(w, log,
+					int(sess.Signature.RejectCode),
+					html.EscapeString("Invalid signature: "+sigResult.Error),
+				)
+
+				return
+			}
+
+			var (
+				fwdReq   *forward.Request  // the request to forward asynchronously (nil if not needed)
+				upstream *forward.Response // the upstream response (for the synchronous forwarding only)
+				fwdErr   string            // the synchronous forwarding error
+			)
+
+			if opts := sess.Forward; opts != nil {
+				if req, err := newForwardRequest(opts, r, prefix, body); err != nil {
+					fwdErr = err.Error()
+
+					// store the error, so the user can see why the request was not forwarded
+					if err = db.SetRequestForwardResult(reqCtx, sID, rID, storage.ForwardResult{ //nolint:contextcheck
+						URL:   opts.URL,
+						Error: fwdErr,
+					}); err != nil {
+						log.Error("failed to store the forwarding result", zap.Error(err))
+					}
+				} else if opts.ReturnResponse { // forward synchronously, since the upstream response is needed
+					var result storage.ForwardResult
+
+					result, upstream = doForward(reqCtx, fwdClient, opts.Timeout, req) //nolint:contextcheck
+					fwdErr = result.Error
+
+					if err = db.SetRequestForwardResult(reqCtx, sID, rID, result); err != nil { //nolint:contextcheck
+						log.Error("failed to store the forwarding result", zap.Error(err))
+					}
+				} else {
+					fwdReq = &req // will be forwarded asynchronously
+				}
+			}
 
 			// publish the captured request to the pub/sub. important note - we should use the app ctx instead of the req ctx
 			// because the request context can be canceled before the goroutine finishes (and moreover - before the
 			// subscribers will receive the event - in this case the event will be lost)
 			go func() {
-				// read the actual data from the storage (the main point is the time of creation)
-				captured, dbErr := db.GetRequest(appCtx, sID, rID)
-				if dbErr != nil {
-					log.Error("failed to get a captured request", zap.Error(dbErr))
+				publishRequest(asyncCtx, log, db, pub, sID, rID, pubsub.RequestActionCreate)
 
+				if fwdReq == nil {
 					return
 				}
 
-				var headers = make([]pubsub.HttpHeader, len(captured.Headers))
-				for i, h := range captured.Headers {
-					headers[i] = pubsub.HttpHeader{Name: h.Name, Value: h.Value}
-				}
+				// forward the request asynchronously, and notify the subscribers about the result
+				var result, _ = doForward(asyncCtx, fwdClient, sess.Forward.Timeout, *fwdReq)
+
+				if err := db.SetRequestForwardResult(asyncCtx, sID, rID, result); err != nil {
+					log.Error("failed to store the forwarding result", zap.Error(err))
 
-				if err := pub.Publish(appCtx, sID, pubsub.RequestEvent{
-					Action: pubsub.RequestActionCreate,
-					Request: &pubsub.Request{
-						ID:                 rID,
-						ClientAddr:         captured.ClientAddr,
-						Method:             captured.Method,
-						Headers:            headers,
-						URL:                captured.URL,
-						CreatedAtUnixMilli: captured.CreatedAtUnixMilli,
-					},
-				}); err != nil {
-					log.Error("failed to publish a captured request", zap.Error(err))
+					return
 				}
-			}()
 
-			// wait for the delay if it's set
-			if sess.Delay > 0 {
-				sleep(reqCtx, sess.Delay) //nolint:contextcheck
-			}
+				publishRequest(asyncCtx, log, db, pub, sID, rID, pubsub.RequestActionUpdate)
+			}()
 
 			// set the header to allow CORS requests from any origin and method
 			w.Header().Set("Access-Control-Allow-Origin", "*")
 			w.Header().Set("Access-Control-Allow-Methods", "*")
 			w.Header().Set("Access-Control-Allow-Headers", "*")
 
-			// set the session headers
-			for _, h := range sess.Headers {
+			// respond with the upstream response, if the session is configured to do so
+			if sess.Forward != nil && sess.Forward.ReturnResponse {
+				if upstream == nil {
+					respondWithError(w, log, http.StatusBadGateway, html.EscapeString("Forwarding failed: "+fwdErr))
+
+					return
+				}
+
+				respondWithUpstream(w, log, upstream)
+
+				return
+			}
+
+			// by default, use the response from the session
+			var resp = storage.RuleResponse{
+				Code:         sess.Code,
+				Headers:      sess.Headers,
+				ResponseBody: sess.ResponseBody,
+				Delay:        sess.Delay,
+				Templated:    sess.Templated,
+			}
+
+			// but if any of the session rules matches the request, use the rule response instead
+			if idx := matchRule(sess.Rules, r, subPath(r, prefix), body); idx >= 0 {
+				resp = sess.Rules[idx].Response
+
+				w.Header().Set("X-Wh-Matched-Rule", strconv.Itoa(idx+1))
+			}
+
+			// render the response templates (if enabled) using the captured request as the data source
+			if resp.Templated {
+				rendered, err := renderResponse(reqCtx, resp, template.Request{ //nolint:contextcheck
+					Method:     r.Method,
+					URL:        r.URL,
+					Headers:    r.Header,
+					Body:       body,
+					ClientAddr: clientAddr,
+					SessionID:  sID,
+					RequestID:  rID,
+				})
+				if err != nil {
+					respondWithError(w, log,
+						http.StatusInternalServerError,
+						html.EscapeString("Cannot render the response template: "+err.Error()),
+					)
+
+					return
+				}
+
+				resp = rendered
+			}
+
+			// wait for the delay if it's set
+			if resp.Delay > 0 {
+				sleep(reqCtx, resp.Delay) //nolint:contextcheck
+			}
+
+			// set the response headers
+			for _, h := range resp.Headers {
 				w.Header().Set(h.Name, h.Value)
 			}
 
-			// by default, use the status code from the session
-			var statusCode = int(sess.Code)
+			// by default, use the status code from the response
+			var statusCode = int(resp.Code)
 
-			// extract requested status code from the request URL (it should be the last part)
-			if parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/"); len(parts) > 1 {
-				// loop over parts slice from the end to the beginning
-				for i := len(parts) - 1; i >= 0; i-- {
-					if code, err := strconv.Atoi(parts[i]); err == nil && code >= 100 && code <= 599 {
-						statusCode = code
+			// extract requested status code from the request URL (it should be the last part after the session ID/slug)
+			var parts = strings.Split(strings.Trim(subPath(r, prefix), "/"), "/")
 
-						break
-					}
+			// loop over parts slice from the end to the beginning
+			for i := len(parts) - 1; i >= 0; i-- {
+				if code, err := strconv.Atoi(parts[i]); err == nil && code >= 100 && code <= 599 {
+					statusCode = code
+
+					break
 				}
 			}
 
@@ -195,13 +414,158 @@ func New( //nolint:funlen,gocognit,gocyclo
 			w.WriteHeader(statusCode)
 
 			// write the response body
-			if _, err := w.Write(sess.ResponseBody); err != nil { //nolint:gosec
+			if _, err := w.Write(resp.ResponseBody); err != nil { //nolint:gosec
 				log.Error("failed to write the response body", zap.Error(err))
 			}
 		})
 	}
 }
 
+// statusWriter remembers the response status code.
+type statusWriter struct {
+	http.ResponseWriter
+
+	status      int
+	wroteHeader bool
+}
+
+func (w *statusWriter) WriteHeader(code int) {
+	if !w.wroteHeader {
+		w.status, w.wroteHeader = code, true
+	}
+
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// Unwrap returns the original writer (it's used by the [http.ResponseController]).
+func (w *statusWriter) Unwrap() http.ResponseWriter { return w.ResponseWriter }
+
+// publishRequest reads the captured request from the storage and publishes it to the pub/sub.
+func publishRequest(
+	ctx context.Context,
+	log *zap.Logger,
+	db storage.Storage,
+	pub pubsub.Publisher[pubsub.RequestEvent],
+	sID, rID string,
+	action pubsub.RequestAction,
+) {
+	// read the actual data from the storage (the main point is the time of creation)
+	captured, dbErr := db.GetRequest(ctx, sID, rID)
+	if dbErr != nil {
+		log.Error("failed to get a captured request", zap.Error(dbErr))
+
+		return
+	}
+
+	var headers = make([]pubsub.HttpHeader, len(captured.Headers))
+	for i, h := range captured.Headers {
+		headers[i] = pubsub.HttpHeader{Name: h.Name, Value: h.Value}
+	}
+
+	var forwarded *pubsub.ForwardResult
+
+	if f := captured.Forwarded; f != nil {
+		var fHeaders = make([]pubsub.HttpHeader, len(f.Headers))
+		for i, h := range f.Headers {
+			fHeaders[i] = pubsub.HttpHeader{Name: h.Name, Value: h.Value}
+		}
+
+		forwarded = &pubsub.ForwardResult{
+			URL:        f.URL,
+			StatusCode: f.StatusCode,
+			Headers:    fHeaders,
+			Body:       f.Body,
+			Latency:    f.Latency,
+			Error:      f.Error,
+		}
+	}
+
+	var sig *pubsub.SignatureResult
+
+	if s := captured.Signature; s != nil {
+		sig = &pubsub.SignatureResult{Provider: s.Provider, Valid: s.Valid, Error: s.Error}
+	}
+
+	var reqTLS *pubsub.RequestTLS
+
+	if t := captured.TLS; t != nil {
+		reqTLS = &pubsub.RequestTLS{Version: t.Version, CipherSuite: t.CipherSuite, ServerName: t.ServerName}
+
+		for _, c := range t.ClientCerts {
+			reqTLS.ClientCerts = append(reqTLS.ClientCerts, pubsub.TLSCertificate{
+				Subject:            c.Subject,
+				Issuer:             c.Issuer,
+				SerialNumber:       c.SerialNumber,
+				SANs:               c.SANs,
+				NotBeforeUnixMilli: c.NotBeforeUnixMilli,
+				NotAfterUnixMilli:  c.NotAfterUnixMilli,
+				SHA1Fingerprint:    c.SHA1Fingerprint,
+				SHA256Fingerprint:  c.SHA256Fingerprint,
+			})
+		}
+
+		if a := t.ClientAuth; a != nil {
+			reqTLS.ClientAuth = &pubsub.ClientAuthResult{
+				Mode:     a.Mode,
+				Verified: a.Verified,
+				Rejected: a.Rejected,
+				Error:    a.Error,
+			}
+		}
+	}
+
+	if err := pub.Publish(ctx, sID, pubsub.RequestEvent{
+		Action: action,
+		Request: &pubsub.Request{
+			ID:                 rID,
+			ClientAddr:         captured.ClientAddr,
+			PeerAddr:           captured.PeerAddr,
+			Method:             captured.Method,
+			Headers:            headers,
+			URL:                captured.URL,
+			CreatedAtUnixMilli: captured.CreatedAtUnixMilli,
+			Forwarded:          forwarded,
+			Signature:          sig,
+			TraceID:            captured.TraceID,
+			TLS:                reqTLS,
+			Dropped:            captured.Dropped,
+		},
+	}); err != nil {
+		log.Error("failed to publish a captured request", zap.Error(err))
+	}
+}
+
+// storageHeaders converts the request headers into the storage format (sorted by name).
+func storageHeaders(h http.Header) []storage.HttpHeader {
+	var headers = make([]storage.HttpHeader, 0, len(h))
+	for name, value := range h {
+		headers = append(headers, storage.HttpHeader{Name: name, Value: strings.Join(value, "; ")})
+	}
+
+	slices.SortFunc(headers, func(i, j storage.HttpHeader) int { return strings.Compare(i.Name, j.Name) })
+
+	return headers
+}
+
+// respondWithUpstream writes the upstream response (except the hop-by-hop headers) to the client.
+func respondWithUpstream(w http.ResponseWriter, log *zap.Logger, resp *forward.Response) {
+	for name, values := range resp.Headers {
+		if forward.IsHopByHopHeader(name) {
+			continue
+		}
+
+		for _, value := range values {
+			w.Header().Add(name, value)
+		}
+	}
+
+	w.WriteHeader(resp.StatusCode)
+
+	if _, err := w.Write(resp.Body); err != nil { //nolint:gosec
+		log.Error("failed to write the upstream response body", zap.Error(err))
+	}
+}
+
 // shouldCaptureRequest checks if the request should be captured (the path starts with a valid UUID).
 func shouldCaptureRequest(r *http.Request) (string, bool) {
 	if r.URL == nil {
@@ -217,6 +581,40 @@ func shouldCaptureRequest(r *http.Request) (string, bool) {
 	return "", false
 }
 
+// resolveSlug resolves the session ID by the slug the request path starts with (the longest slug wins). The matched
+// slug is returned alongside the session ID.
+func resolveSlug(
+	r *http.Request,
+	log *zap.Logger,
+	db storage.Storage,
+	policy *slug.Policy,
+) (sID, matched string, _ bool) {
+	if r.URL == nil {
+		return "", "", false
+	}
+
+	for _, candidate := range policy.Candidates(r.URL.Path) {
+		id, err := db.ResolveSlug(r.Context(), candidate)
+		if err == nil {
+			return id, candidate, true
+		}
+
+		if !errors.Is(err, storage.ErrNotFound) {
+			log.Error("failed to resolve the session slug", zap.String("slug", candidate), zap.Error(err))
+
+			break
+		}
+	}
+
+	return "", "", false
+}
+
+// subPath returns the request path after the session ID or slug (e.g. "/foo/bar" for "/{session-uuid}/foo/bar" or
+// "/{slug}/foo/bar").
+func subPath(r *http.Request, prefix string) string {
+	return "/" + strings.TrimLeft(strings.TrimPrefix(strings.TrimLeft(r.URL.Path, "/"), prefix), "/")
+}
+
 // TODO: add supporting of format requested by the user (json, html, plain text, etc).
 func respondWithError(w http.ResponseWriter, log *zap.Logger, code int, msg string) {
 	var s strings.Builder
@@ -259,44 +657,6 @@ func respondWithError(w http.ResponseWriter, log *zap.Logger, code int, msg stri
 	}
 }
 
-// extractFullUrl returns the full URL from the request.
-func extractFullUrl(r *http.Request) string {
-	var scheme = "http"
-	if r.TLS != nil {
-		scheme = "https"
-	}
-
-	return fmt.Sprintf("%s://%s%s", scheme, r.Host, r.RequestURI)
-}
-
-// we will trust following HTTP headers for the real ip extracting (priority low -> high).
-var trustHeaders = [...]string{"X-Forwarded-For", "X-Real-IP", "CF-Connecting-IP"} //nolint:gochecknoglobals
-
-func extractRealIP(r *http.Request) string {
-	var ip string
-
-	for _, name := range trustHeaders {
-		if value := r.Header.Get(name); value != "" {
-			// `X-Forwarded-For` can be `10.0.0.1, 10.0.0.2, 10.0.0.3`
-			if strings.Contains(value, ",") {
-				parts := strings.Split(value, ",")
-
-				if len(parts) > 0 {
-					ip = strings.TrimSpace(parts[0])
-				}
-			} else {
-				ip = strings.TrimSpace(value)
-			}
-		}
-	}
-
-	if net.ParseIP(ip) != nil {
-		return ip
-	}
-
-	return strings.Split(r.RemoteAddr, ":")[0]
-}
-
 func sleep(ctx context.Context, d time.Duration) {
 	var timer = time.NewTimer(d)
 	defer timer.Stop()
@@ -0,0 +1,1078 @@
+package webhook_test
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.uber.org/zap"
+
+	"gh.tarampamp.am/webhook-tester/v2/internal/config"
+	"gh.tarampamp.am/webhook-tester/v2/internal/forward"
+	"gh.tarampamp.am/webhook-tester/v2/internal/http/middleware/webhook"
+	"gh.tarampamp.am/webhook-tester/v2/internal/http/realip"
+	"gh.tarampamp.am/webhook-tester/v2/internal/ipfilter"
+	"gh.tarampamp.am/webhook-tester/v2/internal/pubsub"
+	"gh.tarampamp.am/webhook-tester/v2/internal/ratelimit"
+	"gh.tarampamp.am/webhook-tester/v2/internal/slug"
+	"gh.tarampamp.am/webhook-tester/v2/internal/storage"
+	"gh.tarampamp.am/webhook-tester/v2/internal/tracing"
+)
+
+func TestNew_NotCaptured(t *testing.T) {
+	t.Parallel()
+
+	var (
+		db = storage.NewInMemory(time.Minute, 8)
+		mw = webhook.New(t.Context(), zap.NewNop(), db, pubsub.NewInMemory[pubsub.RequestEvent](), &config.AppSettings{})
+	)
+
+	t.Cleanup(func() { _ = db.Close() })
+
+	var handler = mw(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusTeapot) }))
+
+	for _, url := range []string{"/", "/api/settings", "/foo/bar", "/9b6bbab9-c197-4dd3-bc3f"} {
+		var rr = httptest.NewRecorder()
+
+		handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, url, http.NoBody))
+
+		assert.Equal(t, http.StatusTeapot, rr.Code, url) // passed to the next handler
+	}
+}
+
+// setForward sets the session forwarding options.
+func setForward(t *testing.T, db storage.Storage, sID string, opts *storage.ForwardOptions) {
+	t.Helper()
+
+	require.NoError(t, db.UpdateSession(context.Background(), sID, func(s *storage.Session) error {
+		s.Forward = opts
+
+		return nil
+	}))
+}
+
+// setSignature sets the session signature verification options.
+func setSignature(t *testing.T, db storage.Storage, sID string, opts *storage.SignatureOptions) {
+	t.Helper()
+
+	require.NoError(t, db.UpdateSession(context.Background(), sID, func(s *storage.Session) error {
+		s.Signature = opts
+
+		return nil
+	}))
+}
+
+func TestNew_Slugs(t *testing.T) {
+	t.Parallel()
+
+	var ctx = context.Background()
+
+	policy, err := slug.NewPolicy(slug.DefaultPattern, "api", "s")
+	require.NoError(t, err)
+
+	var (
+		db = storage.NewInMemory(time.Minute, 8)
+		mw = webhook.New(ctx, zap.NewNop(), db, pubsub.NewInMemory[pubsub.RequestEvent](), &config.AppSettings{
+			SlugPolicy: policy,
+		})
+	)
+
+	t.Cleanup(func() { _ = db.Close() })
+
+	sID, err := db.NewSession(ctx, storage.Session{
+		Code:         http.StatusOK,
+		ResponseBody: []byte("short"),
+		Rules: []storage.ResponseRule{{
+			Path:     "/events",
+			Response: storage.RuleResponse{Code: http.StatusAccepted, ResponseBody: []byte("events")},
+		}},
+	})
+	require.NoError(t, err)
+
+	other, err := db.NewSession(ctx, storage.Session{Code: http.StatusOK, ResponseBody: []byte("long")})
+	require.NoError(t, err)
+
+	require.NoError(t, db.AddSessionSlug(ctx, sID, "hooks"))
+	require.NoError(t, db.AddSessionSlug(ctx, other, "hooks/stripe-staging"))
+	require.NoError(t, db.AddSessionSlug(ctx, sID, "api")) // reserved by the policy, so never resolved
+
+	var (
+		next    = http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusTeapot) })
+		handler = mw(next)
+	)
+
+	for path, tc := range map[string]struct {
+		wantCode int
+		wantBody string
+	}{
+		"/hooks":                        {http.StatusOK, "short"},
+		"/hooks/events":                 {http.StatusAccepted, "events"}, // the rules path is relative to the slug
+		"/hooks/foo/404":                {http.StatusNotFound, "short"},
+		"/hooks/stripe-staging":         {http.StatusOK, "long"}, // the longest slug wins
+		"/hooks/stripe-staging/":        {http.StatusOK, "long"},
+		"/hooks/stripe-staging/foo/201": {http.StatusCreated, "long"},
+		"/hooks/stripe-staging-foo/bar": {http.StatusOK, "short"},
+		"/api/settings":                 {http.StatusTeapot, ""},
+		"/s/" + sID:                     {http.StatusTeapot, ""},
+		"/foo/hooks":                    {http.StatusTeapot, ""},
+		"/Hooks":                        {http.StatusTeapot, ""},
+	} {
+		var rr = httptest.NewRecorder()
+
+		handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, path, http.NoBody))
+
+		assert.Equal(t, tc.wantCode, rr.Code, path)
+		assert.Equal(t, tc.wantBody, rr.Body.String(), path)
+	}
+
+	all, err := db.GetAllRequests(ctx, other)
+	require.NoError(t, err)
+	assert.Len(t, all, 3)
+
+	// the slugs are not resolved if disabled
+	mw = webhook.New(ctx, zap.NewNop(), db, pubsub.NewInMemory[pubsub.RequestEvent](), &config.AppSettings{})
+
+	var rr = httptest.NewRecorder()
+
+	mw(next).ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/hooks", http.NoBody))
+	assert.Equal(t, http.StatusTeapot, rr.Code)
+}
+
+func TestNew_ResponseRules(t *testing.T) {
+	t.Parallel()
+
+	var (
+		ctx = context.Background()
+		db  = storage.NewInMemory(time.Minute, 8)
+		mw  = webhook.New(ctx, zap.NewNop(), db, pubsub.NewInMemory[pubsub.RequestEvent](), &config.AppSettings{})
+	)
+
+	t.Cleanup(func() { _ = db.Close() })
+
+	sID, err := db.NewSession(ctx, storage.Session{
+		Code:         http.StatusOK,
+		ResponseBody: []byte("default"),
+		Rules: []storage.ResponseRule{
+			{ // #1
+				Methods:  []string{"post"},
+				Path:     "/events/*",
+				JSONBody: []storage.RuleCondition{{Name: "type", Value: "url_verification"}, {Name: "challenge"}},
+				Response: storage.RuleResponse{Code: http.StatusAccepted, ResponseBody: []byte("verification")},
+			},
+			{ // #2
+				Headers: []storage.RuleCondition{{Name: "x-github-event", Value: "ping"}},
+				Response: storage.RuleResponse{
+					Code:         http.StatusCreated,
+					Headers:      []storage.HttpHeader{{Name: "X-Foo", Value: "bar"}},
+					ResponseBody: []byte("pong"),
+				},
+			},
+			{ // #3
+				Query:    []storage.RuleCondition{{Name: "mode", Value: "subscribe"}},
+				JSONBody: []storage.RuleCondition{{Name: "items.1.id", Value: "42"}},
+				Response: storage.RuleResponse{Code: http.StatusNoContent},
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	var handler = mw(http.HandlerFunc(func(http.ResponseWriter, *http.Request) { t.Error("should not be called") }))
+
+	for name, tc := range map[string]struct {
+		giveMethod, givePath, giveBody string
+		giveHeaders                    map[string]string
+		wantCode                       int
+		wantBody, wantRule             string
+	}{
+		"no rules matched": {
+			giveMethod: http.MethodGet, givePath: "/", wantCode: http.StatusOK, wantBody: "default",
+		},
+		"rule #1": {
+			giveMethod: http.MethodPost,
+			givePath:   "/events/slack",
+			giveBody:   `{"type": "url_verification", "challenge": "foo"}`,
+			wantCode:   http.StatusAccepted,
+			wantBody:   "verification",
+			wantRule:   "1",
+		},
+		"rule #1, but the method differs": {
+			giveMethod: http.MethodPut,
+			givePath:   "/events/slack",
+			giveBody:   `{"type": "url_verification", "challenge": "foo"}`,
+			wantCode:   http.StatusOK,
+			wantBody:   "default",
+		},
+		"rule #1, but the path differs": {
+			giveMethod: http.MethodPost,
+			givePath:   "/events/slack/foo",
+			giveBody:   `{"type": "url_verification", "challenge": "foo"}`,
+			wantCode:   http.StatusOK,
+			wantBody:   "default",
+		},
+		"rule #1, but the body field is missing": {
+			giveMethod: http.MethodPost,
+			givePath:   "/events/slack",
+			giveBody:   `{"type": "url_verification"}`,
+			wantCode:   http.StatusOK,
+			wantBody:   "default",
+		},
+		"rule #2": {
+			giveMethod:  http.MethodPost,
+			givePath:    "/anything",
+			giveHeaders: map[string]string{"X-GitHub-Event": "ping"},
+			wantCode:    http.StatusCreated,
+			wantBody:    "pong",
+			wantRule:    "2",
+		},
+		"rule #2, but the header value differs": {
+			giveMethod:  http.MethodPost,
+			giveHeaders: map[string]string{"X-GitHub-Event": "push"},
+			wantCode:    http.StatusOK,
+			wantBody:    "default",
+		},
+		"rule #3": {
+			giveMethod: http.MethodPost,
+			givePath:   "/?mode=subscribe",
+			giveBody:   `{"items": [{"id": 1}, {"id": 42}]}`,
+			wantCode:   http.StatusNoContent,
+			wantRule:   "3",
+		},
+		"rule #3, but the body is not a JSON": {
+			giveMethod: http.MethodPost,
+			givePath:   "/?mode=subscribe",
+			giveBody:   `foo bar`,
+			wantCode:   http.StatusOK,
+			wantBody:   "default",
+		},
+		"status code from the path takes precedence": {
+			giveMethod:  http.MethodPost,
+			givePath:    "/foo/418",
+			giveHeaders: map[string]string{"X-GitHub-Event": "ping"},
+			wantCode:    http.StatusTeapot,
+			wantBody:    "pong",
+			wantRule:    "2",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var (
+				rr  = httptest.NewRecorder()
+				req = httptest.NewRequest(tc.giveMethod, "/"+sID+tc.givePath, strings.NewReader(tc.giveBody))
+			)
+
+			for k, v := range tc.giveHeaders {
+				req.Header.Set(k, v)
+			}
+
+			handler.ServeHTTP(rr, req)
+
+			assert.Equal(t, tc.wantCode, rr.Code)
+			assert.Equal(t, tc.wantBody, rr.Body.String())
+			assert.Equal(t, tc.wantRule, rr.Header().Get("X-Wh-Matched-Rule"))
+			assert.NotEmpty(t, rr.Header().Get("X-Wh-Request-Id"))
+		})
+	}
+}
+
+func TestNew_Templates(t *testing.T) {
+	t.Parallel()
+
+	var (
+		ctx = context.Background()
+		db  = storage.NewInMemory(time.Minute, 8)
+		mw  = webhook.New(ctx, zap.NewNop(), db, pubsub.NewInMemory[pubsub.RequestEvent](), &config.AppSettings{})
+	)
+
+	t.Cleanup(func() { _ = db.Close() })
+
+	sID, err := db.NewSession(ctx, storage.Session{
+		Code:         http.StatusOK,
+		Headers:      []storage.HttpHeader{{Name: "X-Request-Id", Value: "{{ .RequestID }}"}},
+		ResponseBody: []byte(`{{ method }} {{ query "hub.challenge" }}`),
+		Templated:    true,
+		Rules: []storage.ResponseRule{
+			{
+				JSONBody: []storage.RuleCondition{{Name: "type", Value: "url_verification"}},
+				Response: storage.RuleResponse{
+					Code:         http.StatusOK,
+					ResponseBody: []byte(`{"challenge":{{ json "challenge" | toJSON }}}`),
+					Templated:    true,
+				},
+			},
+			{
+				Headers:  []storage.RuleCondition{{Name: "X-Broken"}},
+				Response: storage.RuleResponse{Code: http.StatusOK, ResponseBody: []byte(`{{ b64dec "!" }}`), Templated: true},
+			},
+			{
+				Headers:  []storage.RuleCondition{{Name: "X-Static"}},
+				Response: storage.RuleResponse{Code: http.StatusOK, ResponseBody: []byte(`{{ method }}`)},
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	var handler = mw(http.HandlerFunc(func(http.ResponseWriter, *http.Request) { t.Error("should not be called") }))
+
+	for name, tc := range map[string]struct {
+		giveMethod, givePath, giveBody string
+		giveHeaders                    map[string]string
+		wantCode                       int
+		wantBody                       string
+		wantRenderedHeader             bool
+	}{
+		"session response": {
+			giveMethod:         http.MethodGet,
+			givePath:           "/?hub.mode=subscribe&hub.challenge=1158201444",
+			wantCode:           http.StatusOK,
+			wantBody:           "GET 1158201444",
+			wantRenderedHeader: true,
+		},
+		"rule response": {
+			giveMethod: http.MethodPost,
+			giveBody:   `{"type": "url_verification", "challenge": "3eZbrw1aB\""}`,
+			wantCode:   http.StatusOK,
+			wantBody:   `{"challenge":"3eZbrw1aB\""}`,
+		},
+		"rendering error": {
+			giveMethod:  http.MethodPost,
+			giveHeaders: map[string]string{"X-Broken": "1"},
+			wantCode:    http.StatusInternalServerError,
+		},
+		"not templated rule response": {
+			giveMethod:  http.MethodPost,
+			giveHeaders: map[string]string{"X-Static": "1"},
+			wantCode:    http.StatusOK,
+			wantBody:    "{{ method }}",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var (
+				rr  = httptest.NewRecorder()
+				req = httptest.NewRequest(tc.giveMethod, "/"+sID+tc.givePath, strings.NewReader(tc.giveBody))
+			)
+
+			for k, v := range tc.giveHeaders {
+				req.Header.Set(k, v)
+			}
+
+			handler.ServeHTTP(rr, req)
+
+			assert.Equal(t, tc.wantCode, rr.Code)
+
+			if tc.wantBody != "" {
+				assert.Equal(t, tc.wantBody, rr.Body.String())
+			}
+
+			if tc.wantRenderedHeader {
+				assert.Equal(t, rr.Header().Get("X-Wh-Request-Id"), rr.Header().Get("X-Request-Id"))
+			}
+		})
+	}
+}
+
+func TestNew_Forward(t *testing.T) {
+	t.Parallel()
+
+	var upstream = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		w.Header().Set("X-Upstream-Path", r.URL.Path)
+		w.Header().Set("X-Upstream-Query", r.URL.RawQuery)
+		w.Header().Set("X-Upstream-Foo", r.Header.Get("X-Foo"))
+		w.WriteHeader(http.StatusAccepted)
+		_, _ = w.Write(append([]byte("upstream: "), body...))
+	}))
+
+	t.Cleanup(upstream.Close)
+
+	anyHost, err := forward.NewHostPolicy(nil, nil) // the upstream listens on the loopback interface
+	require.NoError(t, err)
+
+	var (
+		ctx = context.Background()
+		db  = storage.NewInMemory(time.Minute, 8)
+		ps  = pubsub.NewInMemory[pubsub.RequestEvent]()
+		mw  = webhook.New(ctx, zap.NewNop(), db, ps, &config.AppSettings{OutboundHostPolicy: anyHost})
+	)
+
+	t.Cleanup(func() { _ = db.Close() })
+
+	var handler = mw(http.HandlerFunc(func(http.ResponseWriter, *http.Request) { t.Error("should not be called") }))
+
+	var newRequest = func(sID string) *http.Request {
+		var req = httptest.NewRequest(http.MethodPost, "/"+sID+"/github?a=b", strings.NewReader("payload"))
+
+		req.Header.Set("X-Foo", "bar")
+
+		return req
+	}
+
+	t.Run("return the upstream response", func(t *testing.T) {
+		t.Parallel()
+
+		sID, err := db.NewSession(ctx, storage.Session{Code: http.StatusOK, ResponseBody: []byte("canned")})
+		require.NoError(t, err)
+		setForward(t, db, sID, &storage.ForwardOptions{
+			URL:            upstream.URL + "/hooks",
+			PassHeaders:    true,
+			Timeout:        time.Second,
+			ReturnResponse: true,
+		})
+
+		var rr = httptest.NewRecorder()
+
+		handler.ServeHTTP(rr, newRequest(sID))
+
+		assert.Equal(t, http.StatusAccepted, rr.Code)
+		assert.Equal(t, "upstream: payload", rr.Body.String())
+		assert.Equal(t, "/hooks/github", rr.Header().Get("X-Upstream-Path"))
+		assert.Equal(t, "a=b", rr.Header().Get("X-Upstream-Query"))
+		assert.Equal(t, "bar", rr.Header().Get("X-Upstream-Foo"))
+
+		req, err := db.GetRequest(ctx, sID, rr.Header().Get("X-Wh-Request-Id"))
+		require.NoError(t, err)
+		require.NotNil(t, req.Forwarded)
+		assert.Equal(t, upstream.URL+"/hooks/github?a=b", req.Forwarded.URL)
+		assert.EqualValues(t, http.StatusAccepted, req.Forwarded.StatusCode)
+		assert.Equal(t, "upstream: payload", string(req.Forwarded.Body))
+		assert.Empty(t, req.Forwarded.Error)
+	})
+
+	t.Run("upstream is unavailable", func(t *testing.T) {
+		t.Parallel()
+
+		sID, err := db.NewSession(ctx, storage.Session{Code: http.StatusOK})
+		require.NoError(t, err)
+		setForward(t, db, sID, &storage.ForwardOptions{
+			URL:            "http://127.0.0.1:1", // nobody listens here
+			Timeout:        time.Second,
+			ReturnResponse: true,
+		})
+
+		var rr = httptest.NewRecorder()
+
+		handler.ServeHTTP(rr, newRequest(sID))
+
+		assert.Equal(t, http.StatusBadGateway, rr.Code)
+
+		req, err := db.GetRequest(ctx, sID, rr.Header().Get("X-Wh-Request-Id"))
+		require.NoError(t, err)
+		require.NotNil(t, req.Forwarded)
+		assert.Zero(t, req.Forwarded.StatusCode)
+		assert.NotEmpty(t, req.Forwarded.Error)
+	})
+
+	t.Run("target host is not allowed", func(t *testing.T) {
+		t.Parallel()
+
+		policy, err := forward.NewHostPolicy(nil, []string{"127.0.0.0/8", "::1"})
+		require.NoError(t, err)
+
+		var restricted = webhook.New(ctx, zap.NewNop(), db, ps, &config.AppSettings{OutboundHostPolicy: policy})(
+			http.HandlerFunc(func(http.ResponseWriter, *http.Request) { t.Error("should not be called") }),
+		)
+
+		sID, err := db.NewSession(ctx, storage.Session{Code: http.StatusOK})
+		require.NoError(t, err)
+		setForward(t, db, sID, &storage.ForwardOptions{
+			URL:            upstream.URL, // the loopback address
+			Timeout:        time.Second,
+			ReturnResponse: true,
+		})
+
+		var rr = httptest.NewRecorder()
+
+		restricted.ServeHTTP(rr, newRequest(sID))
+
+		assert.Equal(t, http.StatusBadGateway, rr.Code)
+		assert.NotContains(t, rr.Body.String(), "upstream: payload")
+
+		req, err := db.GetRequest(ctx, sID, rr.Header().Get("X-Wh-Request-Id"))
+		require.NoError(t, err)
+		require.NotNil(t, req.Forwarded)
+		assert.Zero(t, req.Forwarded.StatusCode)
+		assert.Contains(t, req.Forwarded.Error, forward.ErrHostNotAllowed.Error())
+	})
+
+	t.Run("asynchronous forwarding", func(t *testing.T) {
+		t.Parallel()
+
+		sID, err := db.NewSession(ctx, storage.Session{Code: http.StatusOK, ResponseBody: []byte("canned")})
+		require.NoError(t, err)
+		setForward(t, db, sID, &storage.ForwardOptions{URL: upstream.URL, Timeout: time.Second})
+
+		sub, unsubscribe, err := ps.Subscribe(ctx, sID)
+		require.NoError(t, err)
+
+		t.Cleanup(unsubscribe)
+
+		var rr = httptest.NewRecorder()
+
+		handler.ServeHTTP(rr, newRequest(sID))
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "canned", rr.Body.String())
+
+		for _, wantAction := range []pubsub.RequestAction{pubsub.RequestActionCreate, pubsub.RequestActionUpdate} {
+			select {
+			case event := <-sub:
+				require.Equal(t, wantAction, event.Action)
+
+				if wantAction == pubsub.RequestActionUpdate {
+					require.NotNil(t, event.Request.Forwarded)
+					assert.EqualValues(t, http.StatusAccepted, event.Request.Forwarded.StatusCode)
+					assert.Contains(t, event.Request.Forwarded.Headers, // the request headers were not passed
+						pubsub.HttpHeader{Name: "X-Upstream-Foo", Value: ""},
+					)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatalf("event %s was not received", wantAction)
+			}
+		}
+
+		req, err := db.GetRequest(ctx, sID, rr.Header().Get("X-Wh-Request-Id"))
+		require.NoError(t, err)
+		require.NotNil(t, req.Forwarded)
+		assert.Equal(t, "upstream: payload", string(req.Forwarded.Body))
+	})
+}
+
+func TestNew_Signature(t *testing.T) {
+	t.Parallel()
+
+	const secret = "It's a Secret to Everybody"
+
+	var (
+		ctx = context.Background()
+		db  = storage.NewInMemory(time.Minute, 8)
+		ps  = pubsub.NewInMemory[pubsub.RequestEvent]()
+		mw  = webhook.New(ctx, zap.NewNop(), db, ps, &config.AppSettings{})
+	)
+
+	t.Cleanup(func() { _ = db.Close() })
+
+	var handler = mw(http.HandlerFunc(func(http.ResponseWriter, *http.Request) { t.Error("should not be called") }))
+
+	var newRequest = func(sID, body, sigSecret string) *http.Request {
+		var (
+			req = httptest.NewRequest(http.MethodPost, "/"+sID, strings.NewReader(body))
+			mac = hmac.New(sha256.New, []byte(sigSecret))
+		)
+
+		_, _ = mac.Write([]byte(body))
+
+		req.Header.Set("X-Hub-Signature-256", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+
+		return req
+	}
+
+	t.Run("not verified", func(t *testing.T) {
+		t.Parallel()
+
+		sID, err := db.NewSession(ctx, storage.Session{Code: http.StatusOK})
+		require.NoError(t, err)
+
+		var rr = httptest.NewRecorder()
+
+		handler.ServeHTTP(rr, newRequest(sID, "payload", "foo"))
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+
+		req, err := db.GetRequest(ctx, sID, rr.Header().Get("X-Wh-Request-Id"))
+		require.NoError(t, err)
+		assert.Nil(t, req.Signature)
+	})
+
+	t.Run("valid and invalid, without rejection", func(t *testing.T) {
+		t.Parallel()
+
+		sID, err := db.NewSession(ctx, storage.Session{Code: http.StatusOK, ResponseBody: []byte("canned")})
+		require.NoError(t, err)
+		setSignature(t, db, sID, &storage.SignatureOptions{Provider: "github", Secret: secret})
+
+		var rr = httptest.NewRecorder()
+
+		handler.ServeHTTP(rr, newRequest(sID, "payload", secret))
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+
+		req, err := db.GetRequest(ctx, sID, rr.Header().Get("X-Wh-Request-Id"))
+		require.NoError(t, err)
+		assert.Equal(t, &storage.SignatureResult{Provider: "github", Valid: true}, req.Signature)
+
+		rr = httptest.NewRecorder()
+
+		handler.ServeHTTP(rr, newRequest(sID, "payload", "wrong secret"))
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "canned", rr.Body.String())
+
+		req, err = db.GetRequest(ctx, sID, rr.Header().Get("X-Wh-Request-Id"))
+		require.NoError(t, err)
+		require.NotNil(t, req.Signature)
+		assert.False(t, req.Signature.Valid)
+		assert.Equal(t, "signature mismatch", req.Signature.Error)
+	})
+
+	t.Run("invalid, with rejection", func(t *testing.T) {
+		t.Parallel()
+
+		sID, err := db.NewSession(ctx, storage.Session{Code: http.StatusOK})
+		require.NoError(t, err)
+		setSignature(t, db, sID, &storage.SignatureOptions{
+			Provider:   "github",
+			Secret:     secret,
+			RejectCode: http.StatusUnauthorized,
+		})
+
+		sub, unsubscribe, err := ps.Subscribe(ctx, sID)
+		require.NoError(t, err)
+
+		t.Cleanup(unsubscribe)
+
+		var rr = httptest.NewRecorder()
+
+		handler.ServeHTTP(rr, newRequest(sID, "payload", "wrong secret"))
+
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+		assert.Contains(t, rr.Body.String(), "signature mismatch")
+
+		select { // the request is captured anyway
+		case event := <-sub:
+			require.Equal(t, pubsub.RequestActionCreate, event.Action)
+			require.NotNil(t, event.Request.Signature)
+			assert.Equal(t, pubsub.SignatureResult{Provider: "github", Error: "signature mismatch"}, *event.Request.Signature)
+		case <-time.After(5 * time.Second):
+			t.Fatal("event was not received")
+		}
+
+		rr = httptest.NewRecorder()
+
+		handler.ServeHTTP(rr, newRequest(sID, "payload", secret))
+
+		assert.Equal(t, http.StatusOK, rr.Code) // valid signature
+	})
+}
+
+func TestNew_Tracing(t *testing.T) {
+	t.Parallel()
+
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+
+	var (
+		ctx = context.Background()
+		sr  = tracetest.NewSpanRecorder()
+		tp  = sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr))
+		db  = storage.NewInMemory(time.Minute, 8)
+		ps  = pubsub.NewInMemory[pubsub.RequestEvent]()
+		mw  = webhook.New(ctx, zap.NewNop(), tracing.NewStorage(db, "memory", tp), ps, &config.AppSettings{
+			TracerProvider: tp,
+		})
+	)
+
+	t.Cleanup(func() { _ = db.Close(); _ = tp.Shutdown(ctx) })
+
+	sID, err := db.NewSession(ctx, storage.Session{Code: http.StatusOK})
+	require.NoError(t, err)
+
+	var req = httptest.NewRequest(http.MethodPost, "/"+sID, http.NoBody)
+
+	// the incoming W3C trace context (it's extracted by the HTTP tracing middleware)
+	req = req.WithContext(tracing.Propagator().Extract(req.Context(), propagation.MapCarrier{
+		"traceparent": "00-" + traceID + "-00f067aa0ba902b7-01",
+	}))
+
+	var rr = httptest.NewRecorder()
+
+	mw(http.NotFoundHandler()).ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	// the trace ID is stored alongside the captured request
+	captured, err := db.GetRequest(ctx, sID, rr.Header().Get("X-Wh-Request-Id"))
+	require.NoError(t, err)
+	assert.Equal(t, traceID, captured.TraceID)
+
+	var capture sdktrace.ReadOnlySpan
+
+	for _, span := range sr.Ended() {
+		assert.Equal(t, traceID, span.SpanContext().TraceID().String()) // all the spans belong to the incoming trace
+
+		if span.Name() == "webhook.capture" {
+			capture = span
+		}
+	}
+
+	require.NotNil(t, capture)
+	assert.Contains(t, capture.Attributes(), attribute.String("webhook_tester.session.id", sID))
+
+	var hasStorageChild bool
+
+	for _, span := range sr.Ended() {
+		if span.Name() == "storage.new_request" && span.Parent().SpanID() == capture.SpanContext().SpanID() {
+			hasStorageChild = true
+		}
+	}
+
+	assert.True(t, hasStorageChild)
+}
+
+func TestNew_RateLimits(t *testing.T) {
+	t.Parallel()
+
+	var (
+		ctx  = context.Background()
+		rate = ratelimit.Rate{Limit: 2, Period: time.Minute}
+		ps   = pubsub.NewInMemory[pubsub.RequestEvent]()
+	)
+
+	var newHandler = func(t *testing.T, db *storage.InMemory, limits *ratelimit.Limits) http.Handler {
+		t.Helper()
+
+		t.Cleanup(func() { _ = db.Close() })
+
+		return webhook.New(ctx, zap.NewNop(), db, ps, &config.AppSettings{
+			AutoCreateSessions: true,
+			RateLimits:         limits,
+		})(http.HandlerFunc(func(http.ResponseWriter, *http.Request) { t.Error("should not be called") }))
+	}
+
+	var send = func(handler http.Handler, sID, ip string) *httptest.ResponseRecorder {
+		var (
+			rr  = httptest.NewRecorder()
+			req = httptest.NewRequest(http.MethodPost, "/"+sID, strings.NewReader("payload"))
+		)
+
+		req.RemoteAddr = net.JoinHostPort(ip, "1234")
+
+		handler.ServeHTTP(rr, req)
+
+		return rr
+	}
+
+	t.Run("per session, recording dropped", func(t *testing.T) {
+		t.Parallel()
+
+		var (
+			db      = storage.NewInMemory(time.Minute, 8)
+			handler = newHandler(t, db, &ratelimit.Limits{PerSession: ratelimit.NewInMemory(rate), RecordDropped: true})
+		)
+
+		sID, err := db.NewSession(ctx, storage.Session{Code: http.StatusOK})
+		require.NoError(t, err)
+
+		for _, ip := range []string{"10.0.0.1", "10.0.0.2"} {
+			assert.Equal(t, http.StatusOK, send(handler, sID, ip).Code)
+		}
+
+		sub, unsubscribe, err := ps.Subscribe(ctx, sID)
+		require.NoError(t, err)
+
+		t.Cleanup(unsubscribe)
+
+		var rr = send(handler, sID, "10.0.0.3") // any client IP
+
+		assert.Equal(t, http.StatusTooManyRequests, rr.Code)
+		assert.Equal(t, "30", rr.Header().Get("Retry-After"))
+
+		for received := false; !received; { // the dropped request is recorded (without the body) and published
+			select {
+			case event := <-sub:
+				require.Equal(t, pubsub.RequestActionCreate, event.Action)
+
+				if event.Request.Dropped == "" {
+					continue // the events of the previous (allowed) requests are published asynchronously
+				}
+
+				assert.Equal(t, "rate limited (per session)", event.Request.Dropped)
+				assert.Equal(t, "10.0.0.3", event.Request.ClientAddr)
+
+				received = true
+			case <-time.After(5 * time.Second):
+				t.Fatal("event was not received")
+			}
+		}
+
+		for range 10 { // the flood is not recorded (at most one dropped request is recorded per the window)
+			assert.Equal(t, http.StatusTooManyRequests, send(handler, sID, "10.0.0.3").Code)
+		}
+
+		requests, err := db.GetAllRequests(ctx, sID)
+		require.NoError(t, err)
+		require.Len(t, requests, 3)
+
+		var dropped int
+
+		for _, req := range requests {
+			if req.Dropped != "" {
+				dropped++
+
+				assert.Empty(t, req.Body)
+			}
+		}
+
+		assert.Equal(t, 1, dropped)
+
+		// other sessions are not affected
+		otherID, err := db.NewSession(ctx, storage.Session{Code: http.StatusOK})
+		require.NoError(t, err)
+
+		assert.Equal(t, http.StatusOK, send(handler, otherID, "10.0.0.3").Code)
+	})
+
+	t.Run("per client IP, without recording", func(t *testing.T) {
+		t.Parallel()
+
+		var (
+			db      = storage.NewInMemory(time.Minute, 8)
+			handler = newHandler(t, db, &ratelimit.Limits{PerIP: ratelimit.NewInMemory(rate)})
+		)
+
+		sID, err := db.NewSession(ctx, storage.Session{Code: http.StatusOK})
+		require.NoError(t, err)
+
+		for range 2 {
+			assert.Equal(t, http.StatusOK, send(handler, sID, "10.0.0.1").Code)
+		}
+
+		assert.Equal(t, http.StatusTooManyRequests, send(handler, sID, "10.0.0.1").Code)
+		assert.Equal(t, http.StatusOK, send(handler, sID, "10.0.0.2").Code) // another client
+
+		requests, err := db.GetAllRequests(ctx, sID)
+		require.NoError(t, err)
+		assert.Len(t, requests, 3)
+	})
+
+	t.Run("sessions auto-creation", func(t *testing.T) {
+		t.Parallel()
+
+		var (
+			db      = storage.NewInMemory(time.Minute, 8)
+			handler = newHandler(t, db, &ratelimit.Limits{SessionCreate: ratelimit.NewInMemory(rate)})
+			ids     = []string{
+				"11111111-1111-1111-1111-111111111111",
+				"22222222-2222-2222-2222-222222222222",
+				"33333333-3333-3333-3333-333333333333",
+			}
+		)
+
+		assert.Equal(t, http.StatusOK, send(handler, ids[0], "10.0.0.1").Code)
+		assert.Equal(t, http.StatusOK, send(handler, ids[0], "10.0.0.1").Code) // the existing session
+		assert.Equal(t, http.StatusOK, send(handler, ids[1], "10.0.0.1").Code)
+
+		var rr = send(handler, ids[2], "10.0.0.1")
+
+		assert.Equal(t, http.StatusTooManyRequests, rr.Code)
+		assert.NotEmpty(t, rr.Header().Get("Retry-After"))
+
+		_, err := db.GetSession(ctx, ids[2])
+		require.ErrorIs(t, err, storage.ErrSessionNotFound)
+
+		assert.Equal(t, http.StatusOK, send(handler, ids[2], "10.0.0.2").Code) // another client
+	})
+}
+
+func TestNew_IPFilter(t *testing.T) {
+	t.Parallel()
+
+	var (
+		ctx = context.Background()
+		db  = storage.NewInMemory(time.Minute, 8)
+		ps  = pubsub.NewInMemory[pubsub.RequestEvent]()
+	)
+
+	t.Cleanup(func() { _ = db.Close() })
+
+	appPolicy, err := ipfilter.NewPolicy(nil, []string{"203.0.113.0/24"})
+	require.NoError(t, err)
+
+	trusted, err := ipfilter.ParsePrefixes([]string{"10.0.0.0/8"})
+	require.NoError(t, err)
+
+	var newHandler = func(recordBlocked bool) http.Handler {
+		return webhook.New(ctx, zap.NewNop(), db, ps, &config.AppSettings{
+			IPPolicy:      appPolicy,
+			RealIP:        realip.NewResolver(trusted),
+			RecordBlocked: recordBlocked,
+		})(http.HandlerFunc(func(http.ResponseWriter, *http.Request) { t.Error("should not be called") }))
+	}
+
+	var handler = newHandler(true)
+
+	var send = func(sID, remoteAddr, forwardedFor string) *httptest.ResponseRecorder {
+		var (
+			rr  = httptest.NewRecorder()
+			req = httptest.NewRequest(http.MethodPost, "/"+sID, strings.NewReader("payload"))
+		)
+
+		req.RemoteAddr = remoteAddr
+
+		if forwardedFor != "" {
+			req.Header.Set("X-Forwarded-For", forwardedFor)
+		}
+
+		handler.ServeHTTP(rr, req)
+
+		return rr
+	}
+
+	sID, err := db.NewSession(ctx, storage.Session{
+		Code:     http.StatusOK,
+		IPFilter: &storage.IPFilterOptions{Allow: []string{"192.0.2.0/24", "203.0.113.0/24"}},
+	})
+	require.NoError(t, err)
+
+	// allowed by the session
+	assert.Equal(t, http.StatusOK, send(sID, "192.0.2.1:1234", "").Code)
+
+	// allowed, since the header is set by the trusted proxy
+	assert.Equal(t, http.StatusOK, send(sID, "10.0.0.1:1234", "192.0.2.7").Code)
+
+	// not allowed by the session (the spoofed header is ignored)
+	var rr = send(sID, "198.51.100.1:1234", "192.0.2.7")
+
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	assert.Contains(t, rr.Body.String(), "198.51.100.1 is not in the allow list")
+
+	// denied by the app filter (even if the session allows it)
+	rr = send(sID, "10.0.0.1:1234", "203.0.113.5")
+
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	assert.Contains(t, rr.Body.String(), "203.0.113.5 is denied")
+
+	// the blocked requests are recorded with the reason (at most one per the window, so the second is skipped)
+	requests, err := db.GetAllRequests(ctx, sID)
+	require.NoError(t, err)
+	require.Len(t, requests, 3)
+
+	var reasons []string
+
+	for _, req := range requests {
+		if req.Dropped != "" {
+			reasons = append(reasons, req.Dropped)
+
+			assert.Empty(t, req.Body)
+		}
+	}
+
+	assert.Equal(t, []string{
+		"blocked by the session IP filter: client IP address is not allowed: 198.51.100.1 is not in the allow list",
+	}, reasons)
+
+	// unknown session
+	assert.Equal(t, http.StatusForbidden, send("00000000-0000-0000-0000-000000000000", "203.0.113.5:1234", "").Code)
+
+	// the blocked requests are not recorded, unless it's enabled
+	handler = newHandler(false)
+
+	assert.Equal(t, http.StatusForbidden, send(sID, "198.51.100.1:1234", "").Code)
+
+	requests, err = db.GetAllRequests(ctx, sID)
+	require.NoError(t, err)
+	require.Len(t, requests, 3)
+}
+
+func TestNew_TrustedProxies(t *testing.T) {
+	t.Parallel()
+
+	var (
+		ctx = context.Background()
+		db  = storage.NewInMemory(time.Minute, 8)
+		ps  = pubsub.NewInMemory[pubsub.RequestEvent]()
+	)
+
+	t.Cleanup(func() { _ = db.Close() })
+
+	trusted, err := ipfilter.ParsePrefixes([]string{"10.0.0.0/8"})
+	require.NoError(t, err)
+
+	var handler = webhook.New(ctx, zap.NewNop(), db, ps, &config.AppSettings{
+		RealIP: realip.NewResolver(trusted),
+	})(http.HandlerFunc(func(http.ResponseWriter, *http.Request) { t.Error("should not be called") }))
+
+	sID, err := db.NewSession(ctx, storage.Session{Code: http.StatusOK})
+	require.NoError(t, err)
+
+	for name, tt := range map[string]struct {
+		giveRemoteAddr string
+		giveHeaders    map[string]string
+		wantClientAddr string
+		wantPeerAddr   string
+		wantURL        string
+	}{
+		"direct": {
+			giveRemoteAddr: "192.0.2.1:1234",
+			giveHeaders:    map[string]string{"Forwarded": "for=198.51.100.1;proto=https;host=evil.com"},
+			wantClientAddr: "192.0.2.1",
+			wantPeerAddr:   "192.0.2.1",
+			wantURL:        "http://example.com/" + sID + "/direct?foo=bar",
+		},
+		"forwarded": {
+			giveRemoteAddr: "10.0.0.1:1234",
+			giveHeaders:    map[string]string{"Forwarded": `for="[2001:db8::1]:4711";proto=https;host=hooks.example.org`},
+			wantClientAddr: "2001:db8::1",
+			wantPeerAddr:   "10.0.0.1",
+			wantURL:        "https://hooks.example.org/" + sID + "/forwarded?foo=bar",
+		},
+		"x-forwarded": {
+			giveRemoteAddr: "10.0.0.1:1234",
+			giveHeaders: map[string]string{
+				"X-Forwarded-For":   "198.51.100.1",
+				"X-Forwarded-Proto": "https",
+				"X-Forwarded-Host":  "hooks.example.org",
+				"X-Forwarded-Port":  "8443",
+			},
+			wantClientAddr: "198.51.100.1",
+			wantPeerAddr:   "10.0.0.1",
+			wantURL:        "https://hooks.example.org:8443/" + sID + "/x-forwarded?foo=bar",
+		},
+	} {
+		var (
+			rr  = httptest.NewRecorder()
+			req = httptest.NewRequest(http.MethodPost, "http://example.com/"+sID+"/"+name+"?foo=bar", http.NoBody)
+		)
+
+		req.RemoteAddr, req.RequestURI = tt.giveRemoteAddr, "/"+sID+"/"+name+"?foo=bar"
+
+		for k, v := range tt.giveHeaders {
+			req.Header.Set(k, v)
+		}
+
+		handler.ServeHTTP(rr, req)
+
+		require.Equal(t, http.StatusOK, rr.Code, name)
+
+		requests, rErr := db.GetAllRequests(ctx, sID)
+		require.NoError(t, rErr)
+
+		var found bool
+
+		for _, r := range requests {
+			if r.URL == tt.wantURL {
+				found = true
+
+				assert.Equal(t, tt.wantClientAddr, r.ClientAddr, name)
+				assert.Equal(t, tt.wantPeerAddr, r.PeerAddr, name)
+			}
+		}
+
+		assert.True(t, found, name)
+	}
+}
@@ -0,0 +1,160 @@
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+
+	"gh.tarampamp.am/webhook-tester/v2/internal/config"
+	"gh.tarampamp.am/webhook-tester/v2/internal/http/realip"
+	"gh.tarampamp.am/webhook-tester/v2/internal/pubsub"
+	"gh.tarampamp.am/webhook-tester/v2/internal/ratelimit"
+	"gh.tarampamp.am/webhook-tester/v2/internal/storage"
+	"gh.tarampamp.am/webhook-tester/v2/internal/tracing"
+)
+
+// allowRequest takes a token for the key from the rate limiter of the scope. The rejected request is counted. If
+// the limiter fails (e.g. Redis is unavailable), the request is allowed - the webhooks capturing is more important.
+func allowRequest(
+	ctx context.Context,
+	log *zap.Logger,
+	cfg *config.AppSettings,
+	scope, key string,
+) (bool, time.Duration) {
+	ok, retryAfter, err := cfg.RateLimits.Allow(ctx, scope, key)
+	if err != nil {
+		log.Error("rate limiter failed", zap.String("scope", scope), zap.Error(err))
+
+		return true, 0
+	}
+
+	if !ok {
+		cfg.Metrics.ObserveRateLimited(scope)
+	}
+
+	return ok, retryAfter
+}
+
+// respondRateLimited responds with the 429 status code and the Retry-After header.
+func respondRateLimited(w http.ResponseWriter, log *zap.Logger, retryAfter time.Duration) {
+	var secs = ratelimit.RetryAfterSeconds(retryAfter)
+
+	w.Header().Set("Retry-After", secs)
+
+	respondWithError(w, log, http.StatusTooManyRequests, "Too many requests, retry in "+secs+" second(s)")
+}
+
+// droppedRecordWindow is the time window, during which at most one dropped request is recorded on the session (the
+// rest are counted and reported by the next record). So a flood of the rejected requests does not cause a storage
+// write (and an event) per request, and does not push the captured requests out of the session.
+const droppedRecordWindow = time.Minute
+
+// droppedRecorder records the rejected requests on the sessions as dropped, at most one per the window.
+type droppedRecorder struct {
+	appCtx   context.Context
+	log      *zap.Logger
+	db       storage.Storage
+	pub      pubsub.Publisher[pubsub.RequestEvent]
+	resolver *realip.Resolver
+
+	mu      sync.Mutex
+	windows map[ /* session ID */ string]*droppedWindow
+	pruned  time.Time // the last time the stale windows were removed
+}
+
+type droppedWindow struct {
+	start   time.Time // the time of the last record
+	skipped uint64    // the dropped requests, which were not recorded since then
+}
+
+func newDroppedRecorder(
+	appCtx context.Context,
+	log *zap.Logger,
+	db storage.Storage,
+	pub pubsub.Publisher[pubsub.RequestEvent],
+	resolver *realip.Resolver,
+) *droppedRecorder {
+	return &droppedRecorder{
+		appCtx:   appCtx,
+		log:      log,
+		db:       db,
+		pub:      pub,
+		resolver: resolver,
+		windows:  make(map[string]*droppedWindow),
+	}
+}
+
+// take reports whether the dropped request can be recorded on the session now, and the number of the dropped
+// requests, which were not recorded since the previous record.
+func (d *droppedRecorder) take(sID string, now time.Time) (bool, uint64) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if now.Sub(d.pruned) >= droppedRecordWindow {
+		for id, w := range d.windows {
+			if now.Sub(w.start) >= droppedRecordWindow {
+				delete(d.windows, id)
+			}
+		}
+
+		d.pruned = now
+	}
+
+	if w, ok := d.windows[sID]; ok {
+		if now.Sub(w.start) < droppedRecordWindow {
+			w.skipped++
+
+			return false, 0
+		}
+
+		var skipped = w.skipped
+
+		w.start, w.skipped = now, 0
+
+		return true, skipped
+	}
+
+	d.windows[sID] = &droppedWindow{start: now}
+
+	return true, 0
+}
+
+// record stores the rejected request (without the body) on the session, marking it as dropped with the given
+// reason, and publishes it to the pub/sub - so the user can see that the requests were rejected. It's a no-op if
+// another request has been recorded on the session within the window.
+func (d *droppedRecorder) record(reqCtx context.Context, r *http.Request, sID, reason string) {
+	ok, skipped := d.take(sID, time.Now())
+	if !ok {
+		return
+	}
+
+	if skipped > 0 {
+		reason += fmt.Sprintf(" (and %d more request(s) were dropped since the previous record)", skipped)
+	}
+
+	rID, err := d.db.NewRequest(reqCtx, sID, storage.Request{
+		ClientAddr: d.resolver.ClientIP(r),
+		PeerAddr:   realip.PeerIP(r),
+		Method:     r.Method,
+		Headers:    storageHeaders(r.Header),
+		URL:        d.resolver.URL(r),
+		TraceID:    tracing.TraceID(reqCtx),
+		Dropped:    reason,
+	})
+	if err != nil {
+		d.log.Error("failed to record the dropped request", zap.Error(err))
+
+		return
+	}
+
+	// use the app context, since the request context will be canceled before the event is published
+	go publishRequest(
+		trace.ContextWithSpanContext(d.appCtx, trace.SpanContextFromContext(reqCtx)),
+		d.log, d.db, d.pub, sID, rID, pubsub.RequestActionCreate,
+	)
+}
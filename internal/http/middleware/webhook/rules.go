@@ -0,0 +1,81 @@
+package webhook
+
+import (
+	"net/http"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+
+	"gh.tarampamp.am/webhook-tester/v2/internal/jsonpath"
+	"gh.tarampamp.am/webhook-tester/v2/internal/storage"
+)
+
+// matchRule returns the index of the first rule that matches the request, or -1 if there is no matching rule. The
+// subPath is the request path after the session ID (e.g. "/foo/bar" for "/{session-uuid}/foo/bar").
+func matchRule(rules []storage.ResponseRule, r *http.Request, subPath string, body []byte) int {
+	if len(rules) == 0 {
+		return -1
+	}
+
+	var (
+		query    = r.URL.Query()
+		jsonBody = sync.OnceValue(func() any { return jsonpath.Decode(body) }) // decode the body only when needed
+	)
+
+	for i := range rules {
+		var rule = &rules[i]
+
+		if len(rule.Methods) > 0 && !slices.ContainsFunc(rule.Methods, func(m string) bool {
+			return strings.EqualFold(m, r.Method)
+		}) {
+			continue
+		}
+
+		if rule.Path != "" {
+			if ok, _ := path.Match(rule.Path, subPath); !ok {
+				continue
+			}
+		}
+
+		if !matchConditions(rule.Headers, func(name string) []string { return r.Header.Values(name) }) {
+			continue
+		}
+
+		if !matchConditions(rule.Query, func(name string) []string { return query[name] }) {
+			continue
+		}
+
+		if !matchConditions(rule.JSONBody, func(name string) []string {
+			if v, found := jsonpath.Lookup(jsonBody(), name); found {
+				return []string{v}
+			}
+
+			return nil
+		}) {
+			continue
+		}
+
+		return i
+	}
+
+	return -1
+}
+
+// matchConditions checks if all the conditions are satisfied. The values function should return all the values
+// for the given name (nil or an empty slice if the name is not present).
+func matchConditions(conditions []storage.RuleCondition, values func(name string) []string) bool {
+	for _, cond := range conditions {
+		var got = values(cond.Name)
+
+		if len(got) == 0 {
+			return false // not present
+		}
+
+		if cond.Value != "" && !slices.Contains(got, cond.Value) {
+			return false // present, but the value is different
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,22 @@
+package webhook
+
+import (
+	"net/http"
+	"time"
+
+	"gh.tarampamp.am/webhook-tester/v2/internal/signature"
+	"gh.tarampamp.am/webhook-tester/v2/internal/storage"
+)
+
+// verifySignature verifies the request signature using the session options.
+func verifySignature(opts *storage.SignatureOptions, h http.Header, body []byte) *storage.SignatureResult {
+	var result = storage.SignatureResult{Provider: opts.Provider, Valid: true}
+
+	if err := signature.Verify(
+		signature.Provider(opts.Provider), opts.Secret, h, body, time.Now(), opts.Tolerance,
+	); err != nil {
+		result.Valid, result.Error = false, err.Error()
+	}
+
+	return &result
+}
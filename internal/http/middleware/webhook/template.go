@@ -0,0 +1,37 @@
+package webhook
+
+import (
+	"context"
+	"fmt"
+
+	"gh.tarampamp.am/webhook-tester/v2/internal/storage"
+	"gh.tarampamp.am/webhook-tester/v2/internal/template"
+)
+
+// renderResponse renders the response body and header values as templates using the captured request as the data
+// source. The original response is not modified.
+func renderResponse(
+	ctx context.Context,
+	resp storage.RuleResponse,
+	data template.Request,
+) (storage.RuleResponse, error) {
+	var headers = make([]storage.HttpHeader, len(resp.Headers))
+
+	for i, h := range resp.Headers {
+		value, err := template.Render(ctx, h.Value, data, template.DefaultTimeout)
+		if err != nil {
+			return resp, fmt.Errorf("header %s: %w", h.Name, err)
+		}
+
+		headers[i] = storage.HttpHeader{Name: h.Name, Value: string(value)}
+	}
+
+	body, err := template.Render(ctx, string(resp.ResponseBody), data, template.DefaultTimeout)
+	if err != nil {
+		return resp, fmt.Errorf("response body: %w", err)
+	}
+
+	resp.Headers, resp.ResponseBody = headers, body
+
+	return resp, nil
+}
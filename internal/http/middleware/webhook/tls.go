@@ -0,0 +1,106 @@
+package webhook
+
+import (
+	"crypto/sha1" //nolint:gosec // used for the fingerprints only
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+
+	"gh.tarampamp.am/webhook-tester/v2/internal/storage"
+)
+
+// requestTLS extracts the TLS connection details of the request (nil if the request wasn't received over TLS). The
+// client certificate chain is verified against the roots (the system ones, if nil), if the client auth mode is
+// "request" or "require".
+func requestTLS(cs *tls.ConnectionState, clientAuth string, roots *x509.CertPool) *storage.RequestTLS {
+	if cs == nil {
+		return nil
+	}
+
+	var res = storage.RequestTLS{
+		Version:     tls.VersionName(cs.Version),
+		CipherSuite: tls.CipherSuiteName(cs.CipherSuite),
+		ServerName:  cs.ServerName,
+	}
+
+	if len(cs.PeerCertificates) > 0 {
+		res.ClientCerts = make([]storage.TLSCertificate, len(cs.PeerCertificates))
+
+		for i, cert := range cs.PeerCertificates {
+			res.ClientCerts[i] = certificateInfo(cert)
+		}
+	}
+
+	if clientAuth == storage.ClientAuthRequest || clientAuth == storage.ClientAuthRequire {
+		res.ClientAuth = verifyClientCert(cs.PeerCertificates, clientAuth, roots)
+	}
+
+	return &res
+}
+
+// verifyClientCert verifies the client certificate chain (the first one is the leaf certificate, the rest are the
+// intermediates). In the "require" mode, the request with a missing or invalid certificate is marked as rejected.
+func verifyClientCert(chain []*x509.Certificate, mode string, roots *x509.CertPool) *storage.ClientAuthResult {
+	var res = storage.ClientAuthResult{Mode: mode}
+
+	if len(chain) == 0 {
+		res.Error = "no client certificate presented"
+	} else {
+		var intermediates = x509.NewCertPool()
+
+		for _, cert := range chain[1:] {
+			intermediates.AddCert(cert)
+		}
+
+		if _, err := chain[0].Verify(x509.VerifyOptions{
+			Roots:         roots,
+			Intermediates: intermediates,
+			KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		}); err != nil {
+			res.Error = err.Error()
+		} else {
+			res.Verified = true
+		}
+	}
+
+	res.Rejected = mode == storage.ClientAuthRequire && !res.Verified
+
+	return &res
+}
+
+// certificateInfo converts the X.509 certificate into the storage format.
+func certificateInfo(c *x509.Certificate) storage.TLSCertificate {
+	var (
+		sha1Sum   = sha1.Sum(c.Raw) //nolint:gosec
+		sha256Sum = sha256.Sum256(c.Raw)
+		sans      = make([]string, 0, len(c.DNSNames)+len(c.IPAddresses)+len(c.EmailAddresses)+len(c.URIs))
+	)
+
+	for _, name := range c.DNSNames {
+		sans = append(sans, "DNS:"+name)
+	}
+
+	for _, ip := range c.IPAddresses {
+		sans = append(sans, "IP:"+ip.String())
+	}
+
+	for _, email := range c.EmailAddresses {
+		sans = append(sans, "email:"+email)
+	}
+
+	for _, uri := range c.URIs {
+		sans = append(sans, "URI:"+uri.String())
+	}
+
+	return storage.TLSCertificate{
+		Subject:            c.Subject.String(),
+		Issuer:             c.Issuer.String(),
+		SerialNumber:       c.SerialNumber.Text(16), //nolint:mnd
+		SANs:               sans,
+		NotBeforeUnixMilli: c.NotBefore.UnixMilli(),
+		NotAfterUnixMilli:  c.NotAfter.UnixMilli(),
+		SHA1Fingerprint:    hex.EncodeToString(sha1Sum[:]),
+		SHA256Fingerprint:  hex.EncodeToString(sha256Sum[:]),
+	}
+}
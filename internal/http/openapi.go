@@ -5,28 +5,57 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"strings"
 
 	"go.uber.org/zap"
 
+	"gh.tarampamp.am/webhook-tester/v2/internal/archive"
 	"gh.tarampamp.am/webhook-tester/v2/internal/config"
+	"gh.tarampamp.am/webhook-tester/v2/internal/forward"
 	"gh.tarampamp.am/webhook-tester/v2/internal/http/handlers/live"
 	"gh.tarampamp.am/webhook-tester/v2/internal/http/handlers/ready"
 	"gh.tarampamp.am/webhook-tester/v2/internal/http/handlers/request_delete"
 	"gh.tarampamp.am/webhook-tester/v2/internal/http/handlers/request_get"
+	"gh.tarampamp.am/webhook-tester/v2/internal/http/handlers/request_replay"
 	"gh.tarampamp.am/webhook-tester/v2/internal/http/handlers/requests_delete_all"
+	"gh.tarampamp.am/webhook-tester/v2/internal/http/handlers/requests_events"
+	"gh.tarampamp.am/webhook-tester/v2/internal/http/handlers/requests_export"
+	"gh.tarampamp.am/webhook-tester/v2/internal/http/handlers/requests_import"
 	"gh.tarampamp.am/webhook-tester/v2/internal/http/handlers/requests_list"
 	"gh.tarampamp.am/webhook-tester/v2/internal/http/handlers/requests_subscribe"
 	"gh.tarampamp.am/webhook-tester/v2/internal/http/handlers/session_check_exists"
+	"gh.tarampamp.am/webhook-tester/v2/internal/http/handlers/session_client_auth_get"
+	"gh.tarampamp.am/webhook-tester/v2/internal/http/handlers/session_client_auth_set"
 	"gh.tarampamp.am/webhook-tester/v2/internal/http/handlers/session_create"
 	"gh.tarampamp.am/webhook-tester/v2/internal/http/handlers/session_delete"
+	"gh.tarampamp.am/webhook-tester/v2/internal/http/handlers/session_forward_get"
+	"gh.tarampamp.am/webhook-tester/v2/internal/http/handlers/session_forward_set"
 	"gh.tarampamp.am/webhook-tester/v2/internal/http/handlers/session_get"
+	"gh.tarampamp.am/webhook-tester/v2/internal/http/handlers/session_ip_filter_get"
+	"gh.tarampamp.am/webhook-tester/v2/internal/http/handlers/session_ip_filter_set"
+	"gh.tarampamp.am/webhook-tester/v2/internal/http/handlers/session_meta_set"
+	"gh.tarampamp.am/webhook-tester/v2/internal/http/handlers/session_notifications_get"
+	"gh.tarampamp.am/webhook-tester/v2/internal/http/handlers/session_notifications_set"
+	"gh.tarampamp.am/webhook-tester/v2/internal/http/handlers/session_rules_get"
+	"gh.tarampamp.am/webhook-tester/v2/internal/http/handlers/session_rules_set"
+	"gh.tarampamp.am/webhook-tester/v2/internal/http/handlers/session_signature_get"
+	"gh.tarampamp.am/webhook-tester/v2/internal/http/handlers/session_signature_set"
+	"gh.tarampamp.am/webhook-tester/v2/internal/http/handlers/session_slug_add"
+	"gh.tarampamp.am/webhook-tester/v2/internal/http/handlers/session_slug_delete"
+	"gh.tarampamp.am/webhook-tester/v2/internal/http/handlers/session_slugs_get"
+	"gh.tarampamp.am/webhook-tester/v2/internal/http/handlers/session_update"
+	"gh.tarampamp.am/webhook-tester/v2/internal/http/handlers/sessions_list"
 	"gh.tarampamp.am/webhook-tester/v2/internal/http/handlers/settings_get"
 	"gh.tarampamp.am/webhook-tester/v2/internal/http/handlers/version"
 	"gh.tarampamp.am/webhook-tester/v2/internal/http/handlers/version_latest"
 	"gh.tarampamp.am/webhook-tester/v2/internal/http/openapi"
+	"gh.tarampamp.am/webhook-tester/v2/internal/http/realip"
+	"gh.tarampamp.am/webhook-tester/v2/internal/metrics"
 	"gh.tarampamp.am/webhook-tester/v2/internal/pubsub"
+	"gh.tarampamp.am/webhook-tester/v2/internal/ratelimit"
+	"gh.tarampamp.am/webhook-tester/v2/internal/slug"
 	"gh.tarampamp.am/webhook-tester/v2/internal/storage"
 	appVersion "gh.tarampamp.am/webhook-tester/v2/internal/version"
 )
@@ -38,23 +67,48 @@ type ( // type aliases for better readability
 )
 
 type OpenAPI struct {
-	log *zap.Logger
+	log        *zap.Logger
+	rateLimits *ratelimit.Limits
+	metrics    *metrics.Metrics
+	realIP     *realip.Resolver
 
 	handlers struct {
-		settingsGet        func() openapi.SettingsResponse
-		sessionCreate      func(context.Context, openapi.CreateSessionRequest) (*openapi.SessionOptionsResponse, error)
-		sessionCheckExists func(ctx context.Context, ids []openapi.UUID) (*openapi.CheckSessionExistsResponse, error)
-		sessionGet         func(context.Context, sID) (*openapi.SessionOptionsResponse, error)
-		sessionDelete      func(context.Context, sID) (*openapi.SuccessfulOperationResponse, error)
-		requestsList       func(context.Context, sID) (*openapi.CapturedRequestsListResponse, error)
-		requestsDelete     func(context.Context, sID) (*openapi.SuccessfulOperationResponse, error)
-		requestsSubscribe  func(context.Context, http.ResponseWriter, *http.Request, sID) error
-		requestGet         func(context.Context, sID, rID) (*openapi.CapturedRequestsResponse, error)
-		requestDelete      func(context.Context, sID, rID) (*openapi.SuccessfulOperationResponse, error)
-		appVersion         func() openapi.VersionResponse
-		appVersionLatest   func(context.Context, http.ResponseWriter) (*openapi.VersionResponse, error)
-		readinessProbe     func(context.Context, http.ResponseWriter, string)
-		livenessProbe      func(http.ResponseWriter, string)
+		settingsGet          func(*http.Request) openapi.SettingsResponse
+		sessionCreate        func(context.Context, openapi.CreateSessionRequest) (*openapi.SessionOptionsResponse, error)
+		sessionCheckExists   func(ctx context.Context, ids []openapi.UUID) (*openapi.CheckSessionExistsResponse, error)
+		sessionsList         func(context.Context, openapi.ApiSessionListParams) (*openapi.SessionsListResponse, string, error) //nolint:lll
+		sessionGet           func(context.Context, sID) (*openapi.SessionOptionsResponse, error)
+		sessionUpdate        func(context.Context, sID, openapi.UpdateSessionRequest) (*openapi.SessionOptionsResponse, error)     //nolint:lll
+		sessionMetaSet       func(context.Context, sID, openapi.UpdateSessionMetaRequest) (*openapi.SessionOptionsResponse, error) //nolint:lll
+		sessionDelete        func(context.Context, sID) (*openapi.SuccessfulOperationResponse, error)
+		sessionRulesGet      func(context.Context, sID) (*openapi.ResponseRulesResponse, error)
+		sessionRulesSet      func(context.Context, sID, openapi.SetResponseRulesRequest) (*openapi.ResponseRulesResponse, error) //nolint:lll
+		sessionForwardGet    func(context.Context, sID) (*openapi.SessionForwardResponse, error)
+		sessionForwardSet    func(context.Context, sID, openapi.SetSessionForwardRequest) (*openapi.SessionForwardResponse, error) //nolint:lll
+		sessionSignatureGet  func(context.Context, sID) (*openapi.SessionSignatureResponse, error)
+		sessionSignatureSet  func(context.Context, sID, openapi.SetSessionSignatureRequest) (*openapi.SessionSignatureResponse, error) //nolint:lll
+		sessionClientAuthGet func(context.Context, sID) (*openapi.SessionClientAuthResponse, error)
+		sessionClientAuthSet func(context.Context, sID, openapi.SetSessionClientAuthRequest) (*openapi.SessionClientAuthResponse, error) //nolint:lll
+		sessionIPFilterGet   func(context.Context, sID) (*openapi.SessionIPFilterResponse, error)
+		sessionIPFilterSet   func(context.Context, sID, openapi.SetSessionIPFilterRequest) (*openapi.SessionIPFilterResponse, error) //nolint:lll
+		sessionNotifyGet     func(context.Context, sID) (*openapi.SessionNotificationsResponse, error)
+		sessionNotifySet     func(context.Context, sID, openapi.SetSessionNotificationsRequest) (*openapi.SessionNotificationsResponse, error) //nolint:lll
+		sessionSlugsGet      func(context.Context, sID) (*openapi.SessionSlugsResponse, error)
+		sessionSlugAdd       func(context.Context, sID, openapi.AddSessionSlugRequest) (*openapi.SessionSlugsResponse, error)
+		sessionSlugDelete    func(context.Context, sID, openapi.SlugInQuery) (*openapi.SessionSlugsResponse, error)
+		requestsList         func(context.Context, sID, openapi.ApiSessionListRequestsParams) (*openapi.CapturedRequestsListResponse, string, error) //nolint:lll
+		requestsDelete       func(context.Context, sID) (*openapi.SuccessfulOperationResponse, error)
+		requestsSubscribe    func(context.Context, http.ResponseWriter, *http.Request, sID) error
+		requestsEvents       func(context.Context, http.ResponseWriter, sID, openapi.ApiSessionRequestsEventsParams) error
+		requestsExport       func(context.Context, http.ResponseWriter, sID, openapi.ApiSessionExportRequestsParams) error
+		requestsImport       func(context.Context, sID, openapi.ApiSessionImportRequestsParams, io.Reader) (*openapi.ImportRequestsResponse, error) //nolint:lll
+		requestGet           func(context.Context, sID, rID) (*openapi.CapturedRequestsResponse, error)
+		requestDelete        func(context.Context, sID, rID) (*openapi.SuccessfulOperationResponse, error)
+		requestReplay        func(context.Context, sID, rID, openapi.ReplayRequest) (*openapi.ReplayResponse, error)
+		appVersion           func() openapi.VersionResponse
+		appVersionLatest     func(context.Context, http.ResponseWriter) (*openapi.VersionResponse, error)
+		readinessProbe       func(context.Context, http.ResponseWriter, string)
+		livenessProbe        func(http.ResponseWriter, string)
 	}
 }
 
@@ -69,18 +123,40 @@ func NewOpenAPI(
 	db storage.Storage,
 	pubSub pubsub.PubSub[pubsub.RequestEvent],
 ) *OpenAPI {
-	var si = &OpenAPI{log: log}
+	var si = &OpenAPI{log: log, rateLimits: cfg.RateLimits, metrics: cfg.Metrics, realIP: cfg.RealIP}
 
 	si.handlers.settingsGet = settings_get.New(cfg).Handle
 	si.handlers.sessionCreate = session_create.New(db).Handle
 	si.handlers.sessionCheckExists = session_check_exists.New(db).Handle
+	si.handlers.sessionsList = sessions_list.New(db).Handle
 	si.handlers.sessionGet = session_get.New(db).Handle
+	si.handlers.sessionUpdate = session_update.New(appCtx, db, pubSub).Handle
+	si.handlers.sessionMetaSet = session_meta_set.New(appCtx, db, pubSub).Handle
 	si.handlers.sessionDelete = session_delete.New(db).Handle
+	si.handlers.sessionRulesGet = session_rules_get.New(db).Handle
+	si.handlers.sessionRulesSet = session_rules_set.New(db).Handle
+	si.handlers.sessionForwardGet = session_forward_get.New(db).Handle
+	si.handlers.sessionForwardSet = session_forward_set.New(db).Handle
+	si.handlers.sessionSignatureGet = session_signature_get.New(db).Handle
+	si.handlers.sessionSignatureSet = session_signature_set.New(db).Handle
+	si.handlers.sessionClientAuthGet = session_client_auth_get.New(db).Handle
+	si.handlers.sessionClientAuthSet = session_client_auth_set.New(db, cfg.TLSClientAuth).Handle
+	si.handlers.sessionIPFilterGet = session_ip_filter_get.New(db).Handle
+	si.handlers.sessionIPFilterSet = session_ip_filter_set.New(db).Handle
+	si.handlers.sessionNotifyGet = session_notifications_get.New(db, cfg.Notifier).Handle
+	si.handlers.sessionNotifySet = session_notifications_set.New(db, cfg.Notifier).Handle
+	si.handlers.sessionSlugsGet = session_slugs_get.New(db).Handle
+	si.handlers.sessionSlugAdd = session_slug_add.New(db, cfg.SlugPolicy).Handle
+	si.handlers.sessionSlugDelete = session_slug_delete.New(db).Handle
 	si.handlers.requestsList = requests_list.New(db).Handle
 	si.handlers.requestsDelete = requests_delete_all.New(appCtx, db, pubSub).Handle
-	si.handlers.requestsSubscribe = requests_subscribe.New(db, pubSub).Handle
+	si.handlers.requestsSubscribe = requests_subscribe.New(db, pubSub, cfg.Metrics).Handle
+	si.handlers.requestsEvents = requests_events.New(db, pubSub, cfg.Metrics).Handle
+	si.handlers.requestsExport = requests_export.New(db).Handle
+	si.handlers.requestsImport = requests_import.New(appCtx, db, pubSub, cfg.MaxRequestBodySize).Handle
 	si.handlers.requestGet = request_get.New(db).Handle
 	si.handlers.requestDelete = request_delete.New(appCtx, db, pubSub).Handle
+	si.handlers.requestReplay = request_replay.New(db, cfg.OutboundHostPolicy).Handle
 	si.handlers.appVersion = version.New(appVersion.Version()).Handle
 	si.handlers.appVersionLatest = version_latest.New(lastAppVer).Handle
 	si.handlers.readinessProbe = ready.New(rdyChecker).Handle
@@ -89,8 +165,8 @@ func NewOpenAPI(
 	return si
 }
 
-func (o *OpenAPI) ApiSettings(w http.ResponseWriter, _ *http.Request) {
-	o.respToJson(w, o.handlers.settingsGet())
+func (o *OpenAPI) ApiSettings(w http.ResponseWriter, r *http.Request) {
+	o.respToJson(w, o.handlers.settingsGet(r))
 }
 
 func (o *OpenAPI) ApiSessionCreate(w http.ResponseWriter, r *http.Request) {
@@ -108,6 +184,19 @@ func (o *OpenAPI) ApiSessionCreate(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	var clientAddr = o.realIP.ClientIP(r)
+
+	// throttle the sessions creation per client IP address (the limiter failure is not the reason to reject it)
+	if ok, retryAfter, err := o.rateLimits.Allow(r.Context(), ratelimit.ScopeSessionCreate, clientAddr); err != nil {
+		o.log.Error("rate limiter failed", zap.String("scope", ratelimit.ScopeSessionCreate), zap.Error(err))
+	} else if !ok {
+		o.metrics.ObserveRateLimited(ratelimit.ScopeSessionCreate)
+		w.Header().Set("Retry-After", ratelimit.RetryAfterSeconds(retryAfter))
+		o.errorToJson(w, errors.New("too many requests"), http.StatusTooManyRequests)
+
+		return
+	}
+
 	if resp, err := o.handlers.sessionCreate(r.Context(), payload); err != nil {
 		o.errorToJson(w, err, http.StatusInternalServerError)
 	} else {
@@ -142,6 +231,30 @@ func (o *OpenAPI) ApiSessionCheckExists(w http.ResponseWriter, r *http.Request)
 	}
 }
 
+func (o *OpenAPI) ApiSessionList(w http.ResponseWriter, r *http.Request, params openapi.ApiSessionListParams) {
+	if err := params.Validate(); err != nil {
+		o.errorToJson(w, err, http.StatusBadRequest)
+
+		return
+	}
+
+	if resp, nextCursor, err := o.handlers.sessionsList(r.Context(), params); err != nil {
+		var statusCode = http.StatusInternalServerError
+
+		if errors.Is(err, storage.ErrInvalidCursor) {
+			statusCode = http.StatusBadRequest
+		}
+
+		o.errorToJson(w, err, statusCode)
+	} else {
+		if nextCursor != "" {
+			w.Header().Set("X-Next-Cursor", nextCursor)
+		}
+
+		o.respToJson(w, resp)
+	}
+}
+
 func (o *OpenAPI) ApiSessionGet(w http.ResponseWriter, r *http.Request, sID sID) {
 	if resp, err := o.handlers.sessionGet(r.Context(), sID); err != nil {
 		var statusCode = http.StatusInternalServerError
@@ -156,6 +269,62 @@ func (o *OpenAPI) ApiSessionGet(w http.ResponseWriter, r *http.Request, sID sID)
 	}
 }
 
+func (o *OpenAPI) ApiSessionUpdate(w http.ResponseWriter, r *http.Request, sID sID) {
+	var payload openapi.UpdateSessionRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		o.errorToJson(w, err, http.StatusBadRequest)
+
+		return
+	}
+
+	if err := payload.Validate(); err != nil {
+		o.errorToJson(w, err, http.StatusBadRequest)
+
+		return
+	}
+
+	if resp, err := o.handlers.sessionUpdate(r.Context(), sID, payload); err != nil {
+		var statusCode = http.StatusInternalServerError
+
+		if errors.Is(err, storage.ErrNotFound) {
+			statusCode = http.StatusNotFound
+		}
+
+		o.errorToJson(w, err, statusCode)
+	} else {
+		o.respToJson(w, resp)
+	}
+}
+
+func (o *OpenAPI) ApiSessionUpdateMeta(w http.ResponseWriter, r *http.Request, sID sID) {
+	var payload openapi.UpdateSessionMetaRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		o.errorToJson(w, err, http.StatusBadRequest)
+
+		return
+	}
+
+	if err := payload.Validate(); err != nil {
+		o.errorToJson(w, err, http.StatusBadRequest)
+
+		return
+	}
+
+	if resp, err := o.handlers.sessionMetaSet(r.Context(), sID, payload); err != nil {
+		var statusCode = http.StatusInternalServerError
+
+		if errors.Is(err, storage.ErrNotFound) {
+			statusCode = http.StatusNotFound
+		}
+
+		o.errorToJson(w, err, statusCode)
+	} else {
+		o.respToJson(w, resp)
+	}
+}
+
 func (o *OpenAPI) ApiSessionDelete(w http.ResponseWriter, r *http.Request, sID sID) {
 	if resp, err := o.handlers.sessionDelete(r.Context(), sID); err != nil {
 		var statusCode = http.StatusInternalServerError
@@ -170,8 +339,102 @@ func (o *OpenAPI) ApiSessionDelete(w http.ResponseWriter, r *http.Request, sID s
 	}
 }
 
-func (o *OpenAPI) ApiSessionListRequests(w http.ResponseWriter, r *http.Request, sID sID) {
-	if resp, err := o.handlers.requestsList(r.Context(), sID); err != nil {
+func (o *OpenAPI) ApiSessionGetRules(w http.ResponseWriter, r *http.Request, sID sID) {
+	if resp, err := o.handlers.sessionRulesGet(r.Context(), sID); err != nil {
+		var statusCode = http.StatusInternalServerError
+
+		if errors.Is(err, storage.ErrNotFound) {
+			statusCode = http.StatusNotFound
+		}
+
+		o.errorToJson(w, err, statusCode)
+	} else {
+		o.respToJson(w, resp)
+	}
+}
+
+func (o *OpenAPI) ApiSessionSetRules(w http.ResponseWriter, r *http.Request, sID sID) {
+	var payload openapi.SetResponseRulesRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		o.errorToJson(w, err, http.StatusBadRequest)
+
+		return
+	}
+
+	const maxRulesCount = 32
+
+	if len(payload) > maxRulesCount {
+		o.errorToJson(w, fmt.Errorf("too many rules (max count is %d)", maxRulesCount), http.StatusBadRequest)
+
+		return
+	}
+
+	for i, rule := range payload {
+		if err := rule.Validate(); err != nil {
+			o.errorToJson(w, fmt.Errorf("rule #%d: %w", i+1, err), http.StatusBadRequest)
+
+			return
+		}
+	}
+
+	if resp, err := o.handlers.sessionRulesSet(r.Context(), sID, payload); err != nil {
+		var statusCode = http.StatusInternalServerError
+
+		if errors.Is(err, storage.ErrNotFound) {
+			statusCode = http.StatusNotFound
+		}
+
+		o.errorToJson(w, err, statusCode)
+	} else {
+		o.respToJson(w, resp)
+	}
+}
+
+func (o *OpenAPI) ApiSessionGetForward(w http.ResponseWriter, r *http.Request, sID sID) {
+	if resp, err := o.handlers.sessionForwardGet(r.Context(), sID); err != nil {
+		var statusCode = http.StatusInternalServerError
+
+		if errors.Is(err, storage.ErrNotFound) {
+			statusCode = http.StatusNotFound
+		}
+
+		o.errorToJson(w, err, statusCode)
+	} else {
+		o.respToJson(w, resp)
+	}
+}
+
+func (o *OpenAPI) ApiSessionSetForward(w http.ResponseWriter, r *http.Request, sID sID) {
+	var payload openapi.SetSessionForwardRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		o.errorToJson(w, err, http.StatusBadRequest)
+
+		return
+	}
+
+	if err := payload.Validate(); err != nil {
+		o.errorToJson(w, err, http.StatusBadRequest)
+
+		return
+	}
+
+	if resp, err := o.handlers.sessionForwardSet(r.Context(), sID, payload); err != nil {
+		var statusCode = http.StatusInternalServerError
+
+		if errors.Is(err, storage.ErrNotFound) {
+			statusCode = http.StatusNotFound
+		}
+
+		o.errorToJson(w, err, statusCode)
+	} else {
+		o.respToJson(w, resp)
+	}
+}
+
+func (o *OpenAPI) ApiSessionGetSignature(w http.ResponseWriter, r *http.Request, sID sID) {
+	if resp, err := o.handlers.sessionSignatureGet(r.Context(), sID); err != nil {
 		var statusCode = http.StatusInternalServerError
 
 		if errors.Is(err, storage.ErrNotFound) {
@@ -184,6 +447,266 @@ func (o *OpenAPI) ApiSessionListRequests(w http.ResponseWriter, r *http.Request,
 	}
 }
 
+func (o *OpenAPI) ApiSessionSetSignature(w http.ResponseWriter, r *http.Request, sID sID) {
+	var payload openapi.SetSessionSignatureRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		o.errorToJson(w, err, http.StatusBadRequest)
+
+		return
+	}
+
+	if err := payload.Validate(); err != nil {
+		o.errorToJson(w, err, http.StatusBadRequest)
+
+		return
+	}
+
+	if resp, err := o.handlers.sessionSignatureSet(r.Context(), sID, payload); err != nil {
+		var statusCode = http.StatusInternalServerError
+
+		if errors.Is(err, storage.ErrNotFound) {
+			statusCode = http.StatusNotFound
+		}
+
+		o.errorToJson(w, err, statusCode)
+	} else {
+		o.respToJson(w, resp)
+	}
+}
+
+func (o *OpenAPI) ApiSessionGetClientAuth(w http.ResponseWriter, r *http.Request, sID sID) {
+	if resp, err := o.handlers.sessionClientAuthGet(r.Context(), sID); err != nil {
+		var statusCode = http.StatusInternalServerError
+
+		if errors.Is(err, storage.ErrNotFound) {
+			statusCode = http.StatusNotFound
+		}
+
+		o.errorToJson(w, err, statusCode)
+	} else {
+		o.respToJson(w, resp)
+	}
+}
+
+func (o *OpenAPI) ApiSessionSetClientAuth(w http.ResponseWriter, r *http.Request, sID sID) {
+	var payload openapi.SetSessionClientAuthRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		o.errorToJson(w, err, http.StatusBadRequest)
+
+		return
+	}
+
+	if err := payload.Validate(); err != nil {
+		o.errorToJson(w, err, http.StatusBadRequest)
+
+		return
+	}
+
+	if resp, err := o.handlers.sessionClientAuthSet(r.Context(), sID, payload); err != nil {
+		var statusCode = http.StatusInternalServerError
+
+		switch {
+		case errors.Is(err, storage.ErrNotFound):
+			statusCode = http.StatusNotFound
+		case errors.Is(err, session_client_auth_set.ErrNotRequested):
+			statusCode = http.StatusBadRequest
+		}
+
+		o.errorToJson(w, err, statusCode)
+	} else {
+		o.respToJson(w, resp)
+	}
+}
+
+func (o *OpenAPI) ApiSessionGetIPFilter(w http.ResponseWriter, r *http.Request, sID sID) {
+	if resp, err := o.handlers.sessionIPFilterGet(r.Context(), sID); err != nil {
+		var statusCode = http.StatusInternalServerError
+
+		if errors.Is(err, storage.ErrNotFound) {
+			statusCode = http.StatusNotFound
+		}
+
+		o.errorToJson(w, err, statusCode)
+	} else {
+		o.respToJson(w, resp)
+	}
+}
+
+func (o *OpenAPI) ApiSessionSetIPFilter(w http.ResponseWriter, r *http.Request, sID sID) {
+	var payload openapi.SetSessionIPFilterRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		o.errorToJson(w, err, http.StatusBadRequest)
+
+		return
+	}
+
+	if err := payload.Validate(); err != nil {
+		o.errorToJson(w, err, http.StatusBadRequest)
+
+		return
+	}
+
+	if resp, err := o.handlers.sessionIPFilterSet(r.Context(), sID, payload); err != nil {
+		var statusCode = http.StatusInternalServerError
+
+		if errors.Is(err, storage.ErrNotFound) {
+			statusCode = http.StatusNotFound
+		}
+
+		o.errorToJson(w, err, statusCode)
+	} else {
+		o.respToJson(w, resp)
+	}
+}
+
+func (o *OpenAPI) ApiSessionGetNotifications(w http.ResponseWriter, r *http.Request, sID sID) {
+	if resp, err := o.handlers.sessionNotifyGet(r.Context(), sID); err != nil {
+		var statusCode = http.StatusInternalServerError
+
+		if errors.Is(err, storage.ErrNotFound) {
+			statusCode = http.StatusNotFound
+		}
+
+		o.errorToJson(w, err, statusCode)
+	} else {
+		o.respToJson(w, resp)
+	}
+}
+
+func (o *OpenAPI) ApiSessionSetNotifications(w http.ResponseWriter, r *http.Request, sID sID) {
+	var payload openapi.SetSessionNotificationsRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		o.errorToJson(w, err, http.StatusBadRequest)
+
+		return
+	}
+
+	const maxTargetsCount = 10
+
+	if len(payload) > maxTargetsCount {
+		o.errorToJson(w, fmt.Errorf("too many targets (max count is %d)", maxTargetsCount), http.StatusBadRequest)
+
+		return
+	}
+
+	for i, target := range payload {
+		if err := target.Validate(); err != nil {
+			o.errorToJson(w, fmt.Errorf("target #%d: %w", i+1, err), http.StatusBadRequest)
+
+			return
+		}
+	}
+
+	if resp, err := o.handlers.sessionNotifySet(r.Context(), sID, payload); err != nil {
+		var statusCode = http.StatusInternalServerError
+
+		if errors.Is(err, storage.ErrNotFound) {
+			statusCode = http.StatusNotFound
+		}
+
+		o.errorToJson(w, err, statusCode)
+	} else {
+		o.respToJson(w, resp)
+	}
+}
+
+func (o *OpenAPI) ApiSessionGetSlugs(w http.ResponseWriter, r *http.Request, sID sID) {
+	if resp, err := o.handlers.sessionSlugsGet(r.Context(), sID); err != nil {
+		var statusCode = http.StatusInternalServerError
+
+		if errors.Is(err, storage.ErrNotFound) {
+			statusCode = http.StatusNotFound
+		}
+
+		o.errorToJson(w, err, statusCode)
+	} else {
+		o.respToJson(w, resp)
+	}
+}
+
+func (o *OpenAPI) ApiSessionAddSlug(w http.ResponseWriter, r *http.Request, sID sID) {
+	var payload openapi.AddSessionSlugRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		o.errorToJson(w, err, http.StatusBadRequest)
+
+		return
+	}
+
+	if resp, err := o.handlers.sessionSlugAdd(r.Context(), sID, payload); err != nil {
+		var statusCode = http.StatusInternalServerError
+
+		switch {
+		case errors.Is(err, storage.ErrNotFound):
+			statusCode = http.StatusNotFound
+		case errors.Is(err, storage.ErrSlugTaken):
+			statusCode = http.StatusConflict
+		case errors.Is(err, slug.ErrInvalid), errors.Is(err, slug.ErrReserved),
+			errors.Is(err, slug.ErrTooMany), errors.Is(err, slug.ErrDisabled):
+			statusCode = http.StatusBadRequest
+		}
+
+		o.errorToJson(w, err, statusCode)
+	} else {
+		o.respToJson(w, resp)
+	}
+}
+
+func (o *OpenAPI) ApiSessionDeleteSlug(
+	w http.ResponseWriter,
+	r *http.Request,
+	sID sID,
+	params openapi.ApiSessionDeleteSlugParams,
+) {
+	if resp, err := o.handlers.sessionSlugDelete(r.Context(), sID, params.Slug); err != nil {
+		var statusCode = http.StatusInternalServerError
+
+		if errors.Is(err, storage.ErrNotFound) {
+			statusCode = http.StatusNotFound
+		}
+
+		o.errorToJson(w, err, statusCode)
+	} else {
+		o.respToJson(w, resp)
+	}
+}
+
+func (o *OpenAPI) ApiSessionListRequests(
+	w http.ResponseWriter,
+	r *http.Request,
+	sID sID,
+	params openapi.ApiSessionListRequestsParams,
+) {
+	if err := params.Validate(); err != nil {
+		o.errorToJson(w, err, http.StatusBadRequest)
+
+		return
+	}
+
+	if resp, nextCursor, err := o.handlers.requestsList(r.Context(), sID, params); err != nil {
+		var statusCode = http.StatusInternalServerError
+
+		switch {
+		case errors.Is(err, storage.ErrNotFound):
+			statusCode = http.StatusNotFound
+		case errors.Is(err, storage.ErrInvalidCursor):
+			statusCode = http.StatusBadRequest
+		}
+
+		o.errorToJson(w, err, statusCode)
+	} else {
+		if nextCursor != "" {
+			w.Header().Set("X-Next-Cursor", nextCursor)
+		}
+
+		o.respToJson(w, resp)
+	}
+}
+
 func (o *OpenAPI) ApiSessionDeleteAllRequests(w http.ResponseWriter, r *http.Request, sID sID) {
 	if resp, err := o.handlers.requestsDelete(r.Context(), sID); err != nil {
 		var statusCode = http.StatusInternalServerError
@@ -210,6 +733,81 @@ func (o *OpenAPI) ApiSessionRequestsSubscribe(w http.ResponseWriter, r *http.Req
 	}
 }
 
+func (o *OpenAPI) ApiSessionRequestsEvents(
+	w http.ResponseWriter,
+	r *http.Request,
+	sID sID,
+	params openapi.ApiSessionRequestsEventsParams,
+) {
+	if err := o.handlers.requestsEvents(r.Context(), w, sID, params); err != nil {
+		var statusCode = http.StatusInternalServerError
+
+		if errors.Is(err, storage.ErrNotFound) {
+			statusCode = http.StatusNotFound
+		}
+
+		o.errorToJson(w, err, statusCode)
+	}
+}
+
+func (o *OpenAPI) ApiSessionExportRequests(
+	w http.ResponseWriter,
+	r *http.Request,
+	sID sID,
+	params openapi.ApiSessionExportRequestsParams,
+) {
+	if err := params.Validate(); err != nil {
+		o.errorToJson(w, err, http.StatusBadRequest)
+
+		return
+	}
+
+	if err := o.handlers.requestsExport(r.Context(), w, sID, params); err != nil {
+		var statusCode = http.StatusInternalServerError
+
+		switch {
+		case errors.Is(err, requests_export.ErrInterrupted): // the response is already (partially) sent
+			o.log.Warn("requests export interrupted", zap.Error(err), zap.String("session", sID.String()))
+
+			return
+		case errors.Is(err, storage.ErrNotFound):
+			statusCode = http.StatusNotFound
+		case errors.Is(err, archive.ErrUnsupportedFormat):
+			statusCode = http.StatusBadRequest
+		}
+
+		o.errorToJson(w, err, statusCode)
+	}
+}
+
+func (o *OpenAPI) ApiSessionImportRequests(
+	w http.ResponseWriter,
+	r *http.Request,
+	sID sID,
+	params openapi.ApiSessionImportRequestsParams,
+) {
+	if err := params.Validate(); err != nil {
+		o.errorToJson(w, err, http.StatusBadRequest)
+
+		return
+	}
+
+	if resp, err := o.handlers.requestsImport(r.Context(), sID, params, r.Body); err != nil {
+		var statusCode = http.StatusInternalServerError
+
+		switch {
+		case errors.Is(err, storage.ErrNotFound):
+			statusCode = http.StatusNotFound
+		case errors.Is(err, archive.ErrUnsupportedFormat), errors.Is(err, archive.ErrInvalidArchive):
+			statusCode = http.StatusBadRequest
+		}
+
+		o.errorToJson(w, err, statusCode)
+	} else {
+		o.respToJson(w, resp)
+	}
+}
+
 func (o *OpenAPI) ApiSessionGetRequest(w http.ResponseWriter, r *http.Request, sID sID, rID rID) {
 	if resp, err := o.handlers.requestGet(r.Context(), sID, rID); err != nil {
 		var statusCode = http.StatusInternalServerError
@@ -238,6 +836,40 @@ func (o *OpenAPI) ApiSessionDeleteRequest(w http.ResponseWriter, r *http.Request
 	}
 }
 
+func (o *OpenAPI) ApiSessionReplayRequest(w http.ResponseWriter, r *http.Request, sID sID, rID rID) {
+	var payload openapi.ReplayRequest
+
+	// the request body is optional
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil && !errors.Is(err, io.EOF) {
+		o.errorToJson(w, err, http.StatusBadRequest)
+
+		return
+	}
+
+	if err := payload.Validate(); err != nil {
+		o.errorToJson(w, err, http.StatusBadRequest)
+
+		return
+	}
+
+	if resp, err := o.handlers.requestReplay(r.Context(), sID, rID, payload); err != nil {
+		var statusCode = http.StatusInternalServerError
+
+		switch {
+		case errors.Is(err, storage.ErrNotFound):
+			statusCode = http.StatusNotFound
+		case errors.Is(err, forward.ErrHostNotAllowed):
+			statusCode = http.StatusForbidden
+		case errors.Is(err, forward.ErrRequestFailed):
+			statusCode = http.StatusBadGateway
+		}
+
+		o.errorToJson(w, err, statusCode)
+	} else {
+		o.respToJson(w, resp)
+	}
+}
+
 func (o *OpenAPI) ApiAppVersion(w http.ResponseWriter, _ *http.Request) {
 	o.respToJson(w, o.handlers.appVersion())
 }
@@ -0,0 +1,213 @@
+package openapi
+
+import (
+	"encoding/base64"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"gh.tarampamp.am/webhook-tester/v2/internal/pubsub"
+	"gh.tarampamp.am/webhook-tester/v2/internal/storage"
+)
+
+// NewTraceID converts the trace ID into the OpenAPI format (nil if the request wasn't traced).
+func NewTraceID(id string) *TraceID {
+	if id == "" {
+		return nil
+	}
+
+	return &id
+}
+
+// NewPeerAddress converts the peer address into the OpenAPI format (nil if it wasn't recorded).
+func NewPeerAddress(addr string) *PeerAddress {
+	if addr == "" {
+		return nil
+	}
+
+	return &addr
+}
+
+// NewDroppedReason converts the drop reason into the OpenAPI format (nil if the request wasn't dropped).
+func NewDroppedReason(reason string) *DroppedReason {
+	if reason == "" {
+		return nil
+	}
+
+	return &reason
+}
+
+// NewRequestForwardResult converts the forwarding result into the OpenAPI format (nil if the request wasn't
+// forwarded).
+func NewRequestForwardResult(r *storage.ForwardResult) *RequestForwardResult {
+	if r == nil {
+		return nil
+	}
+
+	var headers = make([]HttpHeader, len(r.Headers))
+	for i, header := range r.Headers {
+		headers[i].Name, headers[i].Value = header.Name, header.Value
+	}
+
+	return &RequestForwardResult{
+		Error:              r.Error,
+		Headers:            headers,
+		LatencyMillis:      r.Latency.Milliseconds(),
+		ResponseBodyBase64: base64.StdEncoding.EncodeToString(r.Body),
+		StatusCode:         int(r.StatusCode),
+		Url:                r.URL,
+	}
+}
+
+// NewRequestSignatureResult converts the signature verification result into the OpenAPI format (nil if the
+// signature wasn't verified).
+func NewRequestSignatureResult(r *storage.SignatureResult) *RequestSignatureResult {
+	if r == nil {
+		return nil
+	}
+
+	return &RequestSignatureResult{Error: r.Error, Provider: r.Provider, Valid: r.Valid}
+}
+
+// NewRequestTLS converts the TLS connection details into the OpenAPI format (nil if the request wasn't received over
+// HTTPS).
+func NewRequestTLS(t *storage.RequestTLS) *RequestTLS {
+	if t == nil {
+		return nil
+	}
+
+	var res = RequestTLS{Version: t.Version, CipherSuite: t.CipherSuite, ServerName: t.ServerName}
+
+	if len(t.ClientCerts) > 0 {
+		var certs = make([]TLSCertificate, len(t.ClientCerts))
+
+		for i, c := range t.ClientCerts {
+			certs[i] = TLSCertificate{
+				Subject:            c.Subject,
+				Issuer:             c.Issuer,
+				SerialNumber:       c.SerialNumber,
+				Sans:               append(make([]string, 0, len(c.SANs)), c.SANs...),
+				NotBeforeUnixMilli: c.NotBeforeUnixMilli,
+				NotAfterUnixMilli:  c.NotAfterUnixMilli,
+				Sha1Fingerprint:    c.SHA1Fingerprint,
+				Sha256Fingerprint:  c.SHA256Fingerprint,
+			}
+		}
+
+		res.ClientCertificates = &certs
+	}
+
+	if a := t.ClientAuth; a != nil {
+		res.ClientAuth = &ClientAuthResult{Mode: a.Mode, Verified: a.Verified, Rejected: a.Rejected, Error: a.Error}
+	}
+
+	return &res
+}
+
+// NewRequestEvent converts the pub/sub request event into the OpenAPI format, which is sent to the subscribers (both
+// WebSocket and Server-Sent Events ones). It returns false for the unknown events (they must be skipped).
+func NewRequestEvent(e pubsub.RequestEvent) (*RequestEvent, bool) {
+	var res RequestEvent
+
+	switch e.Action {
+	case pubsub.RequestActionCreate:
+		res.Action = RequestEventActionCreate
+	case pubsub.RequestActionUpdate:
+		res.Action = RequestEventActionUpdate
+	case pubsub.RequestActionDelete:
+		res.Action = RequestEventActionDelete
+	case pubsub.RequestActionClear:
+		res.Action = RequestEventActionClear
+	case pubsub.RequestActionSessionUpdate:
+		res.Action = RequestEventActionSessionUpdate
+	default:
+		return nil, false // unknown action
+	}
+
+	if r := e.Request; r != nil {
+		rID, pErr := uuid.Parse(r.ID)
+		if pErr != nil {
+			return nil, false
+		}
+
+		var rHeaders = make([]HttpHeader, len(r.Headers))
+		for i, header := range r.Headers {
+			rHeaders[i].Name, rHeaders[i].Value = header.Name, header.Value
+		}
+
+		res.Request = &RequestEventRequest{
+			Uuid:                rID,
+			CapturedAtUnixMilli: r.CreatedAtUnixMilli,
+			Forwarded:           eventForwardResult(r.Forwarded),
+			Signature:           eventSignatureResult(r.Signature),
+			ClientAddress:       r.ClientAddr,
+			Dropped:             NewDroppedReason(r.Dropped),
+			Headers:             rHeaders,
+			Method:              strings.ToUpper(r.Method),
+			PeerAddress:         NewPeerAddress(r.PeerAddr),
+			TraceId:             NewTraceID(r.TraceID),
+			Tls:                 eventRequestTLS(r.TLS),
+			Url:                 r.URL,
+		}
+	}
+
+	return &res, true
+}
+
+// eventForwardResult is the [NewRequestForwardResult] for the pub/sub events.
+func eventForwardResult(r *pubsub.ForwardResult) *RequestForwardResult {
+	if r == nil {
+		return nil
+	}
+
+	var headers = make([]storage.HttpHeader, len(r.Headers))
+	for i, header := range r.Headers {
+		headers[i] = storage.HttpHeader{Name: header.Name, Value: header.Value}
+	}
+
+	return NewRequestForwardResult(&storage.ForwardResult{
+		URL:        r.URL,
+		StatusCode: r.StatusCode,
+		Headers:    headers,
+		Body:       r.Body,
+		Latency:    r.Latency,
+		Error:      r.Error,
+	})
+}
+
+// eventSignatureResult is the [NewRequestSignatureResult] for the pub/sub events.
+func eventSignatureResult(r *pubsub.SignatureResult) *RequestSignatureResult {
+	if r == nil {
+		return nil
+	}
+
+	return NewRequestSignatureResult(&storage.SignatureResult{Provider: r.Provider, Valid: r.Valid, Error: r.Error})
+}
+
+// eventRequestTLS is the [NewRequestTLS] for the pub/sub events.
+func eventRequestTLS(t *pubsub.RequestTLS) *RequestTLS {
+	if t == nil {
+		return nil
+	}
+
+	var tls = storage.RequestTLS{Version: t.Version, CipherSuite: t.CipherSuite, ServerName: t.ServerName}
+
+	for _, c := range t.ClientCerts {
+		tls.ClientCerts = append(tls.ClientCerts, storage.TLSCertificate{
+			Subject:            c.Subject,
+			Issuer:             c.Issuer,
+			SerialNumber:       c.SerialNumber,
+			SANs:               c.SANs,
+			NotBeforeUnixMilli: c.NotBeforeUnixMilli,
+			NotAfterUnixMilli:  c.NotAfterUnixMilli,
+			SHA1Fingerprint:    c.SHA1Fingerprint,
+			SHA256Fingerprint:  c.SHA256Fingerprint,
+		})
+	}
+
+	if a := t.ClientAuth; a != nil {
+		tls.ClientAuth = &storage.ClientAuthResult{Mode: a.Mode, Verified: a.Verified, Rejected: a.Rejected, Error: a.Error}
+	}
+
+	return NewRequestTLS(&tls)
+}
@@ -3,8 +3,17 @@ package openapi
 import (
 	"encoding/base64"
 	"fmt"
+	"net/url"
+	"path"
+	"regexp"
+	"slices"
 	"strings"
 	"unicode/utf8"
+
+	"gh.tarampamp.am/webhook-tester/v2/internal/ipfilter"
+	"gh.tarampamp.am/webhook-tester/v2/internal/signature"
+	"gh.tarampamp.am/webhook-tester/v2/internal/storage"
+	"gh.tarampamp.am/webhook-tester/v2/internal/template"
 )
 
 func (data CreateSessionRequest) Validate() error {
@@ -49,5 +58,403 @@ func (data CreateSessionRequest) Validate() error {
 		return fmt.Errorf("wrong status code (should be between %d and %d)", minStatusCode, maxStatusCode)
 	}
 
+	if data.Templated != nil && *data.Templated {
+		if err := data.validateTemplates(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// validateTemplates checks the response body and header values to be valid templates.
+func (data CreateSessionRequest) validateTemplates() error {
+	for _, header := range data.Headers {
+		if err := template.Validate(header.Value); err != nil {
+			return fmt.Errorf("wrong header %s template: %w", header.Name, err)
+		}
+	}
+
+	if v, err := base64.StdEncoding.DecodeString(data.ResponseBodyBase64); err == nil {
+		if err = template.Validate(string(v)); err != nil {
+			return fmt.Errorf("wrong response body template: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (data UpdateSessionMetaRequest) Validate() error {
+	const (
+		maxNameLen, maxNoteLen   = 128, 65536
+		maxLabelsCount           = 32
+		minLabelLen, maxLabelLen = 1, 64
+	)
+
+	if data.Name != nil && utf8.RuneCountInString(*data.Name) > maxNameLen {
+		return fmt.Errorf("name is too long (max length is %d)", maxNameLen)
+	}
+
+	if data.Note != nil && utf8.RuneCountInString(*data.Note) > maxNoteLen {
+		return fmt.Errorf("note is too long (max length is %d)", maxNoteLen)
+	}
+
+	if data.Labels != nil {
+		if len(*data.Labels) > maxLabelsCount {
+			return fmt.Errorf("too many labels (max count is %d)", maxLabelsCount)
+		}
+
+		for i, label := range *data.Labels {
+			if l := utf8.RuneCountInString(label); l < minLabelLen || l > maxLabelLen || strings.TrimSpace(label) == "" {
+				return fmt.Errorf("label length should be between %d and %d", minLabelLen, maxLabelLen)
+			}
+
+			if slices.Contains((*data.Labels)[:i], label) {
+				return fmt.Errorf("duplicate label: %s", label)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (data ResponseRule) Validate() error {
+	const (
+		maxMethodsCount, maxConditionsCount = 10, 10
+		maxMethodLen, maxPathLen            = 16, 1024
+		minCondNameLen, maxCondNameLen      = 1, 256
+		maxCondValueLen                     = 2048
+	)
+
+	if len(data.Methods) > maxMethodsCount {
+		return fmt.Errorf("too many methods (max count is %d)", maxMethodsCount)
+	}
+
+	for _, method := range data.Methods {
+		if l := len(method); l == 0 || l > maxMethodLen {
+			return fmt.Errorf("method length should be between 1 and %d", maxMethodLen)
+		}
+	}
+
+	if utf8.RuneCountInString(data.Path) > maxPathLen {
+		return fmt.Errorf("path pattern is too long (max length is %d)", maxPathLen)
+	}
+
+	if _, err := path.Match(data.Path, ""); err != nil {
+		return fmt.Errorf("wrong path pattern: %w", err)
+	}
+
+	for _, group := range [...]struct {
+		name       string
+		conditions []ResponseRuleCondition
+	}{
+		{"header", data.Headers},
+		{"query", data.Query},
+		{"JSON body", data.JsonBody},
+	} {
+		var name, conditions = group.name, group.conditions
+
+		if len(conditions) > maxConditionsCount {
+			return fmt.Errorf("too many %s conditions (max count is %d)", name, maxConditionsCount)
+		}
+
+		for _, cond := range conditions {
+			if l := utf8.RuneCountInString(cond.Name); l < minCondNameLen || l > maxCondNameLen {
+				return fmt.Errorf("%s condition name length should be between %d and %d", name, minCondNameLen, maxCondNameLen)
+			}
+
+			if utf8.RuneCountInString(cond.Value) > maxCondValueLen {
+				return fmt.Errorf("%s condition value length should be less than %d", name, maxCondValueLen)
+			}
+		}
+	}
+
+	if err := data.Response.Validate(); err != nil {
+		return fmt.Errorf("wrong rule response: %w", err)
+	}
+
+	return nil
+}
+
+func (data SessionForwardOptions) Validate() error {
+	const (
+		maxURLLen              = 2048
+		minTimeout, maxTimeout = 1, 30 // IMPORTANT! Must be less than http/writeTimeout value!
+	)
+
+	if data.Url == "" {
+		return nil // forwarding is disabled, other options are ignored
+	}
+
+	if utf8.RuneCountInString(data.Url) > maxURLLen {
+		return fmt.Errorf("URL is too long (max length is %d)", maxURLLen)
+	}
+
+	if u, err := url.Parse(data.Url); err != nil {
+		return fmt.Errorf("wrong URL: %w", err)
+	} else if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("wrong URL scheme (only http and https are allowed)")
+	} else if u.Host == "" {
+		return fmt.Errorf("URL host should not be empty")
+	}
+
+	if data.Timeout < minTimeout || data.Timeout > maxTimeout {
+		return fmt.Errorf("wrong timeout (should be between %d and %d seconds)", minTimeout, maxTimeout)
+	}
+
+	return nil
+}
+
+func (data SessionSignatureOptions) Validate() error {
+	const (
+		maxSecretLen                        = 1024
+		maxToleranceSeconds                 = 86400
+		minRejectCode, maxRejectCode uint16 = 400, 599
+	)
+
+	if data.Secret == "" {
+		return nil // verification is disabled, other options are ignored
+	}
+
+	if !slices.Contains(signature.Providers(), signature.Provider(data.Provider)) {
+		return fmt.Errorf("unsupported provider %q", data.Provider)
+	}
+
+	if len(data.Secret) > maxSecretLen {
+		return fmt.Errorf("secret is too long (max length is %d)", maxSecretLen)
+	}
+
+	if err := signature.Provider(data.Provider).ValidateSecret(data.Secret); err != nil {
+		return err
+	}
+
+	if data.Tolerance > maxToleranceSeconds {
+		return fmt.Errorf("tolerance is too much (max is %d seconds)", maxToleranceSeconds)
+	}
+
+	if data.RejectCode != 0 && (data.RejectCode < minRejectCode || data.RejectCode > maxRejectCode) {
+		return fmt.Errorf("wrong reject code (should be zero or between %d and %d)", minRejectCode, maxRejectCode)
+	}
+
+	return nil
+}
+
+func (data SessionClientAuthOptions) Validate() error {
+	var modes = []string{"", storage.ClientAuthNone, storage.ClientAuthRequest, storage.ClientAuthRequire}
+
+	if !slices.Contains(modes, data.Mode) {
+		return fmt.Errorf("unsupported client auth mode %q", data.Mode)
+	}
+
+	return nil
+}
+
+func (data SessionIPFilterOptions) Validate() error {
+	const maxRulesCount = 256
+
+	for _, list := range []struct {
+		name  string
+		rules []string
+	}{{"allow", data.Allow}, {"deny", data.Deny}} {
+		if len(list.rules) > maxRulesCount {
+			return fmt.Errorf("too many %s rules (max is %d)", list.name, maxRulesCount)
+		}
+
+		if _, err := ipfilter.ParsePrefixes(list.rules); err != nil {
+			return fmt.Errorf("wrong %s rule: %w", list.name, err)
+		}
+	}
+
+	return nil
+}
+
+func (data NotificationTargetOptions) Validate() error {
+	const maxURLLen, maxSecretLen, maxTemplateLen = 2048, 1024, 10240
+
+	if data.Kind != NotificationTargetOptionsKindHttp && data.Kind != NotificationTargetOptionsKindTemplate {
+		return fmt.Errorf("unsupported notification kind %q", data.Kind)
+	}
+
+	if utf8.RuneCountInString(data.Url) > maxURLLen {
+		return fmt.Errorf("URL is too long (max length is %d)", maxURLLen)
+	}
+
+	if u, err := url.Parse(data.Url); err != nil {
+		return fmt.Errorf("wrong URL: %w", err)
+	} else if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("wrong URL scheme (only http and https are allowed)")
+	} else if u.Host == "" {
+		return fmt.Errorf("URL host should not be empty")
+	}
+
+	if data.Secret != nil && *data.Secret != "" {
+		if len(*data.Secret) > maxSecretLen {
+			return fmt.Errorf("secret is too long (max length is %d)", maxSecretLen)
+		}
+
+		if err := signature.ProviderStandard.ValidateSecret(*data.Secret); err != nil {
+			return err
+		}
+	}
+
+	if data.Template != nil && *data.Template != "" {
+		if len(*data.Template) > maxTemplateLen {
+			return fmt.Errorf("template is too long (max length is %d)", maxTemplateLen)
+		}
+
+		if err := template.Validate(*data.Template); err != nil {
+			return fmt.Errorf("wrong template: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (data ReplayOptions) Validate() error {
+	const (
+		maxURLLen, maxHeadersCount       = 2048, 32
+		minHeaderKeyLen, maxHeaderKeyLen = 1, 256
+		maxHeaderValueLen                = 8192
+	)
+
+	if data.Url != nil && *data.Url != "" {
+		if utf8.RuneCountInString(*data.Url) > maxURLLen {
+			return fmt.Errorf("URL is too long (max length is %d)", maxURLLen)
+		}
+
+		if u, err := url.Parse(*data.Url); err != nil {
+			return fmt.Errorf("wrong URL: %w", err)
+		} else if u.Scheme != "http" && u.Scheme != "https" {
+			return fmt.Errorf("wrong URL scheme (only http and https are allowed)")
+		} else if u.Host == "" {
+			return fmt.Errorf("URL host should not be empty")
+		}
+	}
+
+	if data.Headers != nil {
+		if len(*data.Headers) > maxHeadersCount {
+			return fmt.Errorf("too many headers (max count is %d)", maxHeadersCount)
+		}
+
+		for _, header := range *data.Headers {
+			if l := utf8.RuneCountInString(strings.TrimSpace(header.Name)); l < minHeaderKeyLen || l > maxHeaderKeyLen {
+				return fmt.Errorf("header key length should be between %d and %d", minHeaderKeyLen, maxHeaderKeyLen)
+			}
+
+			if l := utf8.RuneCountInString(header.Value); l > maxHeaderValueLen {
+				return fmt.Errorf("header value length should be less than %d", maxHeaderValueLen)
+			}
+		}
+	}
+
+	if data.RequestPayloadBase64 != nil {
+		if _, err := base64.StdEncoding.DecodeString(*data.RequestPayloadBase64); err != nil {
+			return fmt.Errorf("cannot decode request payload (wrong base64): %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (data ApiSessionListParams) Validate() error {
+	const (
+		maxCursorLen       = 256
+		minLimit, maxLimit = 1, 1000
+	)
+
+	if data.Cursor != nil && utf8.RuneCountInString(*data.Cursor) > maxCursorLen {
+		return fmt.Errorf("cursor is too long (max length is %d)", maxCursorLen)
+	}
+
+	if data.Limit != nil && (*data.Limit < minLimit || *data.Limit > maxLimit) {
+		return fmt.Errorf("limit should be between %d and %d", minLimit, maxLimit)
+	}
+
+	return nil
+}
+
+func (data ApiSessionListRequestsParams) Validate() error {
+	const (
+		maxMethodLen, maxHeaderNameLen, maxClientAddrLen = 32, 256, 256
+		maxSubstringLen, maxCursorLen                    = 1024, 256
+		minLimit, maxLimit                               = 1, 1000
+	)
+
+	for _, v := range []struct {
+		name   string
+		value  *string
+		maxLen int
+	}{
+		{"method", data.Method, maxMethodLen},
+		{"header", data.Header, maxHeaderNameLen},
+		{"header_value", data.HeaderValue, maxSubstringLen},
+		{"url", data.Url, maxSubstringLen},
+		{"url_regex", data.UrlRegex, maxSubstringLen},
+		{"body", data.Body, maxSubstringLen},
+		{"body_regex", data.BodyRegex, maxSubstringLen},
+		{"client_address", data.ClientAddress, maxClientAddrLen},
+		{"cursor", data.Cursor, maxCursorLen},
+	} {
+		if v.value != nil && utf8.RuneCountInString(*v.value) > v.maxLen {
+			return fmt.Errorf("%s is too long (max length is %d)", v.name, v.maxLen)
+		}
+	}
+
+	if data.HeaderValue != nil && *data.HeaderValue != "" && (data.Header == nil || *data.Header == "") {
+		return fmt.Errorf("header_value requires the header name")
+	}
+
+	if data.UrlRegex != nil {
+		if _, err := regexp.Compile(*data.UrlRegex); err != nil {
+			return fmt.Errorf("wrong URL regular expression: %w", err)
+		}
+	}
+
+	if data.BodyRegex != nil {
+		if _, err := regexp.Compile(*data.BodyRegex); err != nil {
+			return fmt.Errorf("wrong body regular expression: %w", err)
+		}
+	}
+
+	if data.Since != nil && data.Until != nil && *data.Since > *data.Until {
+		return fmt.Errorf("since should be less than or equal to until")
+	}
+
+	if data.Order != nil && *data.Order != ApiSessionListRequestsParamsOrderNewest &&
+		*data.Order != ApiSessionListRequestsParamsOrderOldest {
+		return fmt.Errorf("wrong order (should be %s or %s)",
+			ApiSessionListRequestsParamsOrderNewest, ApiSessionListRequestsParamsOrderOldest,
+		)
+	}
+
+	if data.Limit != nil && (*data.Limit < minLimit || *data.Limit > maxLimit) {
+		return fmt.Errorf("limit should be between %d and %d", minLimit, maxLimit)
+	}
+
+	return nil
+}
+
+func (data ApiSessionExportRequestsParams) Validate() error {
+	if data.Format != nil {
+		switch *data.Format {
+		case ApiSessionExportRequestsParamsFormatHar, ApiSessionExportRequestsParamsFormatJsonl,
+			ApiSessionExportRequestsParamsFormatCurl, ApiSessionExportRequestsParamsFormatPostman:
+		default:
+			return fmt.Errorf("unsupported format: %s", *data.Format)
+		}
+	}
+
+	return nil
+}
+
+func (data ApiSessionImportRequestsParams) Validate() error {
+	if data.Format != nil {
+		switch *data.Format {
+		case ApiSessionImportRequestsParamsFormatHar, ApiSessionImportRequestsParamsFormatJsonl:
+		default:
+			return fmt.Errorf("unsupported format: %s", *data.Format)
+		}
+	}
+
 	return nil
 }
@@ -0,0 +1,175 @@
+package realip
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+	"strings"
+)
+
+// ForwardedElement is the single element (set by one proxy) of the RFC 7239 Forwarded header. The node values
+// (For, By) are kept as is (e.g. "192.0.2.43:47011", "[2001:db8::1]", "unknown" or the obfuscated "_hidden").
+type ForwardedElement struct {
+	For   string // the client (or the previous proxy) node
+	By    string // the proxy interface node
+	Host  string // the Host request header field as received by the proxy
+	Proto string // the protocol used to make the request (e.g. "http" or "https")
+}
+
+// ParseForwarded parses the RFC 7239 Forwarded header values (each value may contain multiple comma-separated
+// elements; the values of the repeated header lines are concatenated). The parameter names are case-insensitive,
+// the values may be tokens or quoted strings. The unknown parameters are ignored.
+func ParseForwarded(values ...string) ([]ForwardedElement, error) {
+	var (
+		res   = make([]ForwardedElement, 0, 1)
+		input = strings.Join(values, ",")
+	)
+
+	for input = strings.TrimLeft(input, " \t"); input != ""; {
+		var (
+			el  ForwardedElement
+			err error
+		)
+
+		if el, input, err = parseForwardedElement(input); err != nil {
+			return nil, err
+		}
+
+		res = append(res, el)
+
+		switch {
+		case input == "":
+		case input[0] == ',':
+			if input = strings.TrimLeft(input[1:], " \t"); input == "" {
+				return nil, errors.New("forwarded: trailing comma")
+			}
+		default:
+			return nil, fmt.Errorf("forwarded: unexpected character %q", input[0])
+		}
+	}
+
+	return res, nil
+}
+
+// parseForwardedElement parses the semicolon-separated pairs of the element at the start of the input, and returns
+// the rest of the input (starting with a comma or empty).
+func parseForwardedElement(input string) (ForwardedElement, string, error) {
+	var (
+		el   ForwardedElement
+		seen = make(map[string]struct{}, 4) //nolint:mnd
+	)
+
+	for {
+		var name, value string
+
+		name, input = cutToken(input)
+		if name == "" || input == "" || input[0] != '=' {
+			return el, "", errors.New("forwarded: malformed pair (expected name=value)")
+		}
+
+		var err error
+
+		if value, input, err = parsePairValue(input[1:]); err != nil {
+			return el, "", err
+		}
+
+		name = strings.ToLower(name)
+
+		if _, dup := seen[name]; dup {
+			return el, "", fmt.Errorf("forwarded: duplicate parameter %q", name)
+		}
+
+		seen[name] = struct{}{}
+
+		switch name {
+		case "for":
+			el.For = value
+		case "by":
+			el.By = value
+		case "host":
+			el.Host = value
+		case "proto":
+			el.Proto = value
+		}
+
+		if input = strings.TrimLeft(input, " \t"); input == "" || input[0] != ';' {
+			return el, input, nil
+		}
+
+		input = strings.TrimLeft(input[1:], " \t")
+	}
+}
+
+// parsePairValue parses the token or quoted-string value at the start of the input.
+func parsePairValue(input string) (string, string, error) {
+	if input == "" || input[0] != '"' {
+		value, rest := cutToken(input)
+		if value == "" {
+			return "", "", errors.New("forwarded: empty value")
+		}
+
+		return value, rest, nil
+	}
+
+	var b strings.Builder
+
+	for i := 1; i < len(input); i++ {
+		switch c := input[i]; c {
+		case '"':
+			return b.String(), input[i+1:], nil
+		case '\\': // quoted-pair
+			if i++; i == len(input) {
+				return "", "", errors.New("forwarded: unterminated quoted string")
+			}
+
+			b.WriteByte(input[i])
+		default:
+			b.WriteByte(c)
+		}
+	}
+
+	return "", "", errors.New("forwarded: unterminated quoted string")
+}
+
+// cutToken cuts the RFC 7230 token from the start of the input.
+func cutToken(input string) (token, rest string) {
+	var i int
+
+	for i < len(input) && isTokenChar(input[i]) {
+		i++
+	}
+
+	return input[:i], input[i:]
+}
+
+func isTokenChar(c byte) bool {
+	switch {
+	case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		return true
+	}
+
+	return strings.IndexByte("!#$%&'*+-.^_`|~", c) >= 0
+}
+
+// NodeIP returns the IP address of the Forwarded node (e.g. "192.0.2.43:47011" or "[2001:db8::1]:4711"). An
+// empty string is returned for the "unknown" and obfuscated nodes.
+func NodeIP(node string) string {
+	var host = node
+
+	if strings.HasPrefix(node, "[") { // IPv6, optionally with the port
+		end := strings.IndexByte(node, ']')
+		if end < 0 {
+			return ""
+		}
+
+		host = node[1:end]
+	} else if h, _, ok := strings.Cut(node, ":"); ok {
+		host = h
+	}
+
+	if addr, err := netip.ParseAddr(host); err == nil {
+		return addr.Unmap().String()
+	}
+
+	return ""
+}
@@ -0,0 +1,79 @@
+package realip_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gh.tarampamp.am/webhook-tester/v2/internal/http/realip"
+)
+
+func TestParseForwarded(t *testing.T) {
+	t.Parallel()
+
+	for name, tt := range map[string]struct {
+		give    []string
+		want    []realip.ForwardedElement
+		wantErr string
+	}{
+		"single": {
+			give: []string{`for="_gazonk"`},
+			want: []realip.ForwardedElement{{For: "_gazonk"}},
+		},
+		"all the parameters": {
+			give: []string{`For="[2001:db8:cafe::17]:4711";proto=https;BY=203.0.113.43;host=example.com;ext=foo`},
+			want: []realip.ForwardedElement{
+				{For: "[2001:db8:cafe::17]:4711", By: "203.0.113.43", Host: "example.com", Proto: "https"},
+			},
+		},
+		"multiple elements": {
+			give: []string{`for=192.0.2.43, for=198.51.100.17 ; proto=http`, "for=unknown"},
+			want: []realip.ForwardedElement{{For: "192.0.2.43"}, {For: "198.51.100.17", Proto: "http"}, {For: "unknown"}},
+		},
+		"quoted pair": {
+			give: []string{`for="a\"b,c;d"`},
+			want: []realip.ForwardedElement{{For: `a"b,c;d`}},
+		},
+		"empty":                {give: []string{""}, want: []realip.ForwardedElement{}},
+		"no value":             {give: []string{"for="}, wantErr: "empty value"},
+		"no pair":              {give: []string{"for"}, wantErr: "malformed pair"},
+		"duplicate":            {give: []string{"for=a;for=b"}, wantErr: "duplicate parameter"},
+		"trailing comma":       {give: []string{"for=a,"}, wantErr: "trailing comma"},
+		"unterminated":         {give: []string{`for="a`}, wantErr: "unterminated quoted string"},
+		"unexpected character": {give: []string{`for=a b`}, wantErr: "unexpected character"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := realip.ParseForwarded(tt.give...)
+
+			if tt.wantErr != "" {
+				require.ErrorContains(t, err, tt.wantErr)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestNodeIP(t *testing.T) {
+	t.Parallel()
+
+	for give, want := range map[string]string{
+		"192.0.2.43":               "192.0.2.43",
+		"192.0.2.43:47011":         "192.0.2.43",
+		"[2001:db8:cafe::17]":      "2001:db8:cafe::17",
+		"[2001:db8:cafe::17]:4711": "2001:db8:cafe::17",
+		"[::ffff:192.0.2.1]":       "192.0.2.1",
+		"[2001:db8:cafe::17":       "",
+		"unknown":                  "",
+		"_hidden":                  "",
+		"":                         "",
+	} {
+		assert.Equal(t, want, realip.NodeIP(give), give)
+	}
+}
@@ -0,0 +1,223 @@
+// Package realip extracts the client IP address, the scheme and the host of the HTTP request, as seen by the first
+// proxy (respecting the RFC 7239 Forwarded header and the common X-Forwarded-* ones).
+package realip
+
+import (
+	"net"
+	"net/http"
+	"net/netip"
+	"slices"
+	"strconv"
+	"strings"
+
+	"gh.tarampamp.am/webhook-tester/v2/internal/ipfilter"
+)
+
+const forwardedHeader = "Forwarded"
+
+type (
+	// Resolver resolves the client IP address of the request. The proxy headers are respected only if the request is
+	// received from the trusted proxy (so they cannot be spoofed by the clients, connected directly).
+	//
+	// A nil Resolver trusts nobody (the connected peer address is used).
+	Resolver struct {
+		trusted  []netip.Prefix
+		ipHeader string // the single-value client IP header (e.g. X-Real-IP), set by the trusted proxies
+	}
+
+	// Option allows you to configure the Resolver during creation.
+	Option func(*Resolver)
+)
+
+// WithClientIPHeader makes the Resolver trust the single-value client IP header (e.g. X-Real-IP or
+// CF-Connecting-IP) of the requests from the trusted proxies. It has priority over the Forwarded and X-Forwarded-For
+// headers, so use it only when the trusted proxy always overwrites the header set by the client.
+func WithClientIPHeader(name string) Option {
+	return func(res *Resolver) { res.ipHeader = http.CanonicalHeaderKey(strings.TrimSpace(name)) }
+}
+
+// WithTrustedLoopback makes the Resolver trust the proxies on the loopback interface (127.0.0.0/8 and ::1), in
+// addition to the given ones. Use it when the app is exposed using a tunnel (e.g. ngrok), which agent connects to the
+// app over the loopback interface and passes the client address in the proxy headers.
+func WithTrustedLoopback() Option {
+	return func(res *Resolver) {
+		res.trusted = append(res.trusted, netip.MustParsePrefix("127.0.0.0/8"), netip.MustParsePrefix("::1/128"))
+	}
+}
+
+// NewResolver creates a new Resolver, which trusts the proxy headers from the given networks only. By default, only
+// the Forwarded and X-Forwarded-For headers are used for the client IP address (the right-most address, which is not
+// a trusted proxy).
+func NewResolver(trustedProxies []netip.Prefix, opts ...Option) *Resolver {
+	var res = Resolver{trusted: slices.Clone(trustedProxies)}
+
+	for _, opt := range opts {
+		opt(&res)
+	}
+
+	return &res
+}
+
+// ClientIP returns the client IP address of the request.
+func (res *Resolver) ClientIP(r *http.Request) string {
+	var peer = PeerIP(r)
+
+	if !res.trustsPeer(r) {
+		return peer // the headers are not trusted
+	}
+
+	if res.ipHeader != "" {
+		if ip := strings.TrimSpace(r.Header.Get(res.ipHeader)); net.ParseIP(ip) != nil {
+			return ip
+		}
+	}
+
+	if el, ok := res.forwarded(r); ok {
+		if ip := NodeIP(el.For); net.ParseIP(ip) != nil {
+			return ip
+		}
+	}
+
+	if value := r.Header.Get("X-Forwarded-For"); value != "" { // can be `10.0.0.1, 10.0.0.2, 10.0.0.3`
+		if ip := res.fromForwardedFor(strings.Split(value, ",")); net.ParseIP(ip) != nil {
+			return ip
+		}
+	}
+
+	return peer
+}
+
+// Scheme returns the scheme ("http" or "https") of the request, as it was made by the client. The Forwarded header
+// "proto" parameter has priority over the X-Forwarded-Proto header.
+func (res *Resolver) Scheme(r *http.Request) string {
+	if res.trustsPeer(r) {
+		if el, ok := res.forwarded(r); ok {
+			if scheme, valid := normalizeScheme(el.Proto); valid {
+				return scheme
+			}
+		}
+
+		if scheme, valid := normalizeScheme(firstValue(r.Header.Get("X-Forwarded-Proto"))); valid {
+			return scheme
+		}
+	}
+
+	if r.TLS != nil {
+		return "https"
+	}
+
+	return "http"
+}
+
+// Host returns the host (with the port, if it's not the default one) of the request, as it was made by the client.
+// The Forwarded header "host" parameter has priority over the X-Forwarded-Host (and X-Forwarded-Port) headers.
+func (res *Resolver) Host(r *http.Request) string {
+	if res.trustsPeer(r) {
+		if el, ok := res.forwarded(r); ok && isValidHost(el.Host) {
+			return el.Host
+		}
+
+		if host := firstValue(r.Header.Get("X-Forwarded-Host")); isValidHost(host) {
+			var port = firstValue(r.Header.Get("X-Forwarded-Port"))
+
+			if _, _, err := net.SplitHostPort(host); err != nil && port != "" && !isDefaultPort(res.Scheme(r), port) {
+				if p, pErr := strconv.ParseUint(port, 10, 16); pErr == nil {
+					host = net.JoinHostPort(strings.Trim(host, "[]"), strconv.FormatUint(p, 10))
+				}
+			}
+
+			return host
+		}
+	}
+
+	return r.Host
+}
+
+// URL returns the full URL of the request, as it was made by the client.
+func (res *Resolver) URL(r *http.Request) string {
+	return res.Scheme(r) + "://" + res.Host(r) + r.RequestURI
+}
+
+// Origin returns the scheme and the host of the request (e.g. "https://example.com"), as it was made by the client.
+func (res *Resolver) Origin(r *http.Request) string { return res.Scheme(r) + "://" + res.Host(r) }
+
+// trustsPeer reports whether the proxy headers of the request can be trusted (the peer is a trusted proxy).
+func (res *Resolver) trustsPeer(r *http.Request) bool { return res != nil && res.isTrusted(PeerIP(r)) }
+
+// forwarded returns the Forwarded header element, set by the first proxy (the one the client connected to) - the
+// right-most element, which "for" node is not a trusted proxy (the left-most ones are set by the client, so they
+// cannot be trusted). The malformed header is ignored.
+func (res *Resolver) forwarded(r *http.Request) (ForwardedElement, bool) {
+	var values = r.Header.Values(forwardedHeader)
+	if len(values) == 0 {
+		return ForwardedElement{}, false
+	}
+
+	elements, err := ParseForwarded(values...)
+	if err != nil || len(elements) == 0 {
+		return ForwardedElement{}, false
+	}
+
+	for i := len(elements) - 1; i >= 0; i-- {
+		if !res.isTrusted(NodeIP(elements[i].For)) {
+			return elements[i], true
+		}
+	}
+
+	return elements[0], true // all of them are the trusted proxies
+}
+
+// fromForwardedFor returns the client address from the X-Forwarded-For header values - the right-most address, which
+// is not a trusted proxy (the left-most ones are set by the client, so they cannot be trusted).
+func (res *Resolver) fromForwardedFor(values []string) string {
+	for i := len(values) - 1; i >= 0; i-- {
+		if value := strings.TrimSpace(values[i]); !res.isTrusted(value) {
+			return value
+		}
+	}
+
+	return strings.TrimSpace(values[0]) // all of them are the trusted proxies
+}
+
+// isTrusted checks if the IP address belongs to the trusted proxies.
+func (res *Resolver) isTrusted(ip string) bool {
+	addr, err := netip.ParseAddr(ip)
+
+	return err == nil && ipfilter.Contains(res.trusted, addr)
+}
+
+// firstValue returns the first (left-most) value of the comma-separated header value.
+func firstValue(value string) string {
+	first, _, _ := strings.Cut(value, ",")
+
+	return strings.TrimSpace(first)
+}
+
+// normalizeScheme returns the lower-cased scheme, if it's "http" or "https".
+func normalizeScheme(scheme string) (string, bool) {
+	switch scheme = strings.ToLower(scheme); scheme {
+	case "http", "https":
+		return scheme, true
+	}
+
+	return "", false
+}
+
+// isDefaultPort reports whether the port is the default one for the scheme.
+func isDefaultPort(scheme, port string) bool {
+	return (scheme == "http" && port == "80") || (scheme == "https" && port == "443")
+}
+
+// isValidHost reports whether the host (with an optional port) can be safely used in the URL.
+func isValidHost(host string) bool {
+	return host != "" && len(host) <= 255 && !strings.ContainsAny(host, " \t/\\?#@\"'<>") //nolint:mnd
+}
+
+// PeerIP returns the IP address of the connected peer (the client itself or the proxy).
+func PeerIP(r *http.Request) string {
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+
+	return r.RemoteAddr
+}
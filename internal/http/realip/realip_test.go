@@ -0,0 +1,382 @@
+package realip_test
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gh.tarampamp.am/webhook-tester/v2/internal/http/realip"
+	"gh.tarampamp.am/webhook-tester/v2/internal/ipfilter"
+)
+
+func TestResolver_ClientIP_Nil(t *testing.T) {
+	t.Parallel()
+
+	var resolver *realip.Resolver // trusts nobody
+
+	for name, tt := range map[string]struct {
+		giveHeaders map[string]string
+		want        string
+	}{
+		"remote addr":      {want: "192.0.2.1"},
+		"x-forwarded-for":  {giveHeaders: map[string]string{"X-Forwarded-For": "10.0.0.1, 10.0.0.2"}, want: "192.0.2.1"},
+		"forwarded":        {giveHeaders: map[string]string{"Forwarded": "for=10.0.0.1"}, want: "192.0.2.1"},
+		"x-real-ip":        {giveHeaders: map[string]string{"X-Real-IP": "10.0.0.3"}, want: "192.0.2.1"},
+		"cf-connecting-ip": {giveHeaders: map[string]string{"CF-Connecting-IP": "10.0.0.4"}, want: "192.0.2.1"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var req = httptest.NewRequest(http.MethodGet, "/", http.NoBody) // RemoteAddr is 192.0.2.1:1234
+
+			for k, v := range tt.giveHeaders {
+				req.Header.Set(k, v)
+			}
+
+			assert.Equal(t, tt.want, resolver.ClientIP(req))
+		})
+	}
+}
+
+func TestResolver_ClientIP(t *testing.T) {
+	t.Parallel()
+
+	trusted, err := ipfilter.ParsePrefixes([]string{"10.0.0.0/8", "::1"})
+	require.NoError(t, err)
+
+	var resolver = realip.NewResolver(trusted)
+
+	for name, tt := range map[string]struct {
+		giveRemoteAddr string
+		giveHeaders    map[string]string
+		want           string
+	}{
+		"direct": {
+			giveRemoteAddr: "192.0.2.1:1234",
+			want:           "192.0.2.1",
+		},
+		"spoofed headers": {
+			giveRemoteAddr: "192.0.2.1:1234",
+			giveHeaders:    map[string]string{"X-Forwarded-For": "198.51.100.1", "X-Real-IP": "198.51.100.1"},
+			want:           "192.0.2.1",
+		},
+		"trusted proxy": {
+			giveRemoteAddr: "10.0.0.1:1234",
+			giveHeaders:    map[string]string{"X-Forwarded-For": "198.51.100.1"},
+			want:           "198.51.100.1",
+		},
+		"single-value headers are not trusted by default": {
+			giveRemoteAddr: "10.0.0.1:1234",
+			giveHeaders: map[string]string{
+				"X-Real-IP":        "203.0.113.7", // passed through by the proxy, as set by the client
+				"CF-Connecting-IP": "203.0.113.7",
+				"X-Forwarded-For":  "198.51.100.1",
+			},
+			want: "198.51.100.1",
+		},
+		"trusted IPv6 proxy": {
+			giveRemoteAddr: "[::1]:1234",
+			giveHeaders:    map[string]string{"X-Forwarded-For": "198.51.100.1"},
+			want:           "198.51.100.1",
+		},
+		"proxies chain": {
+			giveRemoteAddr: "10.0.0.1:1234",
+			giveHeaders:    map[string]string{"X-Forwarded-For": "203.0.113.7, 198.51.100.1, 10.0.0.2"},
+			want:           "198.51.100.1", // the first one is set by the client
+		},
+		"trusted proxies only": {
+			giveRemoteAddr: "10.0.0.1:1234",
+			giveHeaders:    map[string]string{"X-Forwarded-For": "10.0.0.3, 10.0.0.2"},
+			want:           "10.0.0.3",
+		},
+		"trusted proxy without headers": {
+			giveRemoteAddr: "10.0.0.1:1234",
+			want:           "10.0.0.1",
+		},
+		"forwarded": {
+			giveRemoteAddr: "10.0.0.1:1234",
+			giveHeaders:    map[string]string{"Forwarded": `for=203.0.113.7, for="[2001:db8::1]:4711";proto=https, for=10.0.0.2`},
+			want:           "2001:db8::1",
+		},
+		"forwarded over x-forwarded-for": {
+			giveRemoteAddr: "10.0.0.1:1234",
+			giveHeaders:    map[string]string{"Forwarded": "for=198.51.100.2", "X-Forwarded-For": "198.51.100.1"},
+			want:           "198.51.100.2",
+		},
+		"obfuscated forwarded": {
+			giveRemoteAddr: "10.0.0.1:1234",
+			giveHeaders:    map[string]string{"Forwarded": "for=_hidden", "X-Forwarded-For": "198.51.100.1"},
+			want:           "198.51.100.1",
+		},
+		"spoofed forwarded": {
+			giveRemoteAddr: "192.0.2.1:1234",
+			giveHeaders:    map[string]string{"Forwarded": "for=198.51.100.1"},
+			want:           "192.0.2.1",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var req = httptest.NewRequest(http.MethodGet, "/", http.NoBody)
+
+			req.RemoteAddr = tt.giveRemoteAddr
+
+			for k, v := range tt.giveHeaders {
+				req.Header.Set(k, v)
+			}
+
+			assert.Equal(t, tt.want, resolver.ClientIP(req))
+		})
+	}
+}
+
+func TestResolver_ClientIP_TrustedLoopback(t *testing.T) {
+	t.Parallel()
+
+	trusted, err := ipfilter.ParsePrefixes([]string{"10.0.0.0/8"})
+	require.NoError(t, err)
+
+	var resolver = realip.NewResolver(trusted, realip.WithTrustedLoopback())
+
+	for name, tt := range map[string]struct {
+		giveRemoteAddr string
+		giveHeaders    map[string]string
+		want           string
+	}{
+		"tunnel agent (ipv4)": {
+			giveRemoteAddr: "127.0.0.1:1234",
+			giveHeaders:    map[string]string{"X-Forwarded-For": "198.51.100.1"},
+			want:           "198.51.100.1",
+		},
+		"tunnel agent (ipv6)": {
+			giveRemoteAddr: "[::1]:1234",
+			giveHeaders:    map[string]string{"Forwarded": "for=198.51.100.2"},
+			want:           "198.51.100.2",
+		},
+		"the given proxies are still trusted": {
+			giveRemoteAddr: "10.0.0.1:1234",
+			giveHeaders:    map[string]string{"X-Forwarded-For": "198.51.100.3"},
+			want:           "198.51.100.3",
+		},
+		"other peers are not trusted": {
+			giveRemoteAddr: "192.0.2.1:1234",
+			giveHeaders:    map[string]string{"X-Forwarded-For": "198.51.100.4"},
+			want:           "192.0.2.1",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var req = httptest.NewRequest(http.MethodGet, "/", http.NoBody)
+
+			req.RemoteAddr = tt.giveRemoteAddr
+
+			for k, v := range tt.giveHeaders {
+				req.Header.Set(k, v)
+			}
+
+			assert.Equal(t, tt.want, resolver.ClientIP(req))
+		})
+	}
+
+	// without any given proxies
+	var req = httptest.NewRequest(http.MethodGet, "/", http.NoBody)
+
+	req.RemoteAddr = "127.0.0.1:1234"
+	req.Header.Set("X-Forwarded-For", "198.51.100.5")
+
+	assert.Equal(t, "198.51.100.5", realip.NewResolver(nil, realip.WithTrustedLoopback()).ClientIP(req))
+}
+
+func TestResolver_ClientIP_Header(t *testing.T) {
+	t.Parallel()
+
+	trusted, err := ipfilter.ParsePrefixes([]string{"10.0.0.0/8"})
+	require.NoError(t, err)
+
+	var resolver = realip.NewResolver(trusted, realip.WithClientIPHeader("x-real-ip"))
+
+	for name, tt := range map[string]struct {
+		giveRemoteAddr string
+		giveHeaders    map[string]string
+		want           string
+	}{
+		"trusted proxy": {
+			giveRemoteAddr: "10.0.0.1:1234",
+			giveHeaders:    map[string]string{"X-Real-IP": " 198.51.100.1 ", "X-Forwarded-For": "198.51.100.2"},
+			want:           "198.51.100.1",
+		},
+		"spoofed by the client": {
+			giveRemoteAddr: "192.0.2.1:1234",
+			giveHeaders:    map[string]string{"X-Real-IP": "198.51.100.1"},
+			want:           "192.0.2.1",
+		},
+		"other header is not trusted": {
+			giveRemoteAddr: "10.0.0.1:1234",
+			giveHeaders:    map[string]string{"CF-Connecting-IP": "203.0.113.7", "X-Forwarded-For": "198.51.100.2"},
+			want:           "198.51.100.2",
+		},
+		"wrong value": {
+			giveRemoteAddr: "10.0.0.1:1234",
+			giveHeaders:    map[string]string{"X-Real-IP": "foo", "X-Forwarded-For": "198.51.100.2"},
+			want:           "198.51.100.2",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var req = httptest.NewRequest(http.MethodGet, "/", http.NoBody)
+
+			req.RemoteAddr = tt.giveRemoteAddr
+
+			for k, v := range tt.giveHeaders {
+				req.Header.Set(k, v)
+			}
+
+			assert.Equal(t, tt.want, resolver.ClientIP(req))
+		})
+	}
+}
+
+func TestPeerIP(t *testing.T) {
+	t.Parallel()
+
+	var req = httptest.NewRequest(http.MethodGet, "/", http.NoBody)
+
+	req.Header.Set("X-Real-IP", "198.51.100.1")
+
+	for give, want := range map[string]string{
+		"192.0.2.1:1234": "192.0.2.1",
+		"[::1]:1234":     "::1",
+		"192.0.2.1":      "192.0.2.1",
+	} {
+		req.RemoteAddr = give
+
+		assert.Equal(t, want, realip.PeerIP(req), give)
+	}
+}
+
+func TestResolver_URL(t *testing.T) {
+	t.Parallel()
+
+	trusted, err := ipfilter.ParsePrefixes([]string{"10.0.0.0/8"})
+	require.NoError(t, err)
+
+	var resolver = realip.NewResolver(trusted)
+
+	for name, tt := range map[string]struct {
+		giveResolver   *realip.Resolver
+		giveRemoteAddr string
+		giveTLS        bool
+		giveHeaders    map[string]string
+		wantURL        string
+		wantOrigin     string
+	}{
+		"direct": {
+			giveResolver:   resolver,
+			giveRemoteAddr: "192.0.2.1:1234",
+			wantURL:        "http://example.com/foo?bar=baz",
+			wantOrigin:     "http://example.com",
+		},
+		"direct tls": {
+			giveResolver:   resolver,
+			giveRemoteAddr: "192.0.2.1:1234",
+			giveTLS:        true,
+			wantURL:        "https://example.com/foo?bar=baz",
+			wantOrigin:     "https://example.com",
+		},
+		"spoofed headers": {
+			giveResolver:   resolver,
+			giveRemoteAddr: "192.0.2.1:1234",
+			giveHeaders: map[string]string{
+				"Forwarded":         "proto=https;host=evil.com",
+				"X-Forwarded-Proto": "https",
+				"X-Forwarded-Host":  "evil.com",
+			},
+			wantURL:    "http://example.com/foo?bar=baz",
+			wantOrigin: "http://example.com",
+		},
+		"forwarded": {
+			giveResolver:   resolver,
+			giveRemoteAddr: "10.0.0.1:1234",
+			giveHeaders: map[string]string{
+				"Forwarded":         `for=192.0.2.60;proto=HTTPS;host="hooks.example.org:8443", for=10.0.0.2;proto=http`,
+				"X-Forwarded-Proto": "http",
+				"X-Forwarded-Host":  "ignored.example.org",
+			},
+			wantURL:    "https://hooks.example.org:8443/foo?bar=baz",
+			wantOrigin: "https://hooks.example.org:8443",
+		},
+		"x-forwarded": {
+			giveResolver:   resolver,
+			giveRemoteAddr: "10.0.0.1:1234",
+			giveHeaders: map[string]string{
+				"X-Forwarded-Proto": "https, http",
+				"X-Forwarded-Host":  "hooks.example.org",
+				"X-Forwarded-Port":  "8443",
+			},
+			wantURL:    "https://hooks.example.org:8443/foo?bar=baz",
+			wantOrigin: "https://hooks.example.org:8443",
+		},
+		"x-forwarded default port": {
+			giveResolver:   resolver,
+			giveRemoteAddr: "10.0.0.1:1234",
+			giveHeaders: map[string]string{
+				"X-Forwarded-Proto": "https",
+				"X-Forwarded-Host":  "hooks.example.org",
+				"X-Forwarded-Port":  "443",
+			},
+			wantURL:    "https://hooks.example.org/foo?bar=baz",
+			wantOrigin: "https://hooks.example.org",
+		},
+		"wrong values": {
+			giveResolver:   resolver,
+			giveRemoteAddr: "10.0.0.1:1234",
+			giveHeaders: map[string]string{
+				"X-Forwarded-Proto": "javascript",
+				"X-Forwarded-Host":  "evil.com/path",
+			},
+			wantURL:    "http://example.com/foo?bar=baz",
+			wantOrigin: "http://example.com",
+		},
+		"malformed forwarded": {
+			giveResolver:   resolver,
+			giveRemoteAddr: "10.0.0.1:1234",
+			giveHeaders: map[string]string{
+				"Forwarded":         `proto=https;host="unterminated`,
+				"X-Forwarded-Proto": "https",
+			},
+			wantURL:    "https://example.com/foo?bar=baz",
+			wantOrigin: "https://example.com",
+		},
+		"nil resolver": {
+			giveRemoteAddr: "192.0.2.1:1234",
+			giveHeaders:    map[string]string{"Forwarded": "proto=https;host=hooks.example.org, proto=http;host=proxy"},
+			wantURL:        "http://example.com/foo?bar=baz",
+			wantOrigin:     "http://example.com",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var req = httptest.NewRequest(http.MethodGet, "http://example.com/foo?bar=baz", http.NoBody)
+
+			req.RemoteAddr, req.RequestURI = tt.giveRemoteAddr, "/foo?bar=baz" // as the server sets it
+
+			if tt.giveTLS {
+				req.TLS = &tls.ConnectionState{}
+			}
+
+			for k, v := range tt.giveHeaders {
+				req.Header.Set(k, v)
+			}
+
+			assert.Equal(t, tt.wantURL, tt.giveResolver.URL(req))
+			assert.Equal(t, tt.wantOrigin, tt.giveResolver.Origin(req))
+		})
+	}
+}
@@ -2,6 +2,7 @@ package http
 
 import (
 	"context"
+	"crypto/tls"
 	"errors"
 	"net"
 	"net/http"
@@ -12,7 +13,10 @@ import (
 
 	"gh.tarampamp.am/webhook-tester/v2/internal/config"
 	"gh.tarampamp.am/webhook-tester/v2/internal/http/frontend"
+	"gh.tarampamp.am/webhook-tester/v2/internal/http/middleware/apiauth"
+	"gh.tarampamp.am/webhook-tester/v2/internal/http/middleware/httpsredirect"
 	"gh.tarampamp.am/webhook-tester/v2/internal/http/middleware/logreq"
+	"gh.tarampamp.am/webhook-tester/v2/internal/http/middleware/tracereq"
 	"gh.tarampamp.am/webhook-tester/v2/internal/http/middleware/webhook"
 	"gh.tarampamp.am/webhook-tester/v2/internal/http/openapi"
 	"gh.tarampamp.am/webhook-tester/v2/internal/pubsub"
@@ -20,6 +24,9 @@ import (
 	"gh.tarampamp.am/webhook-tester/v2/web"
 )
 
+// RouteMetrics is the route for the metrics in the Prometheus text format.
+const RouteMetrics = "/metrics"
+
 type Server struct {
 	http *http.Server
 
@@ -40,6 +47,11 @@ func WithIDLETimeout(d time.Duration) ServerOption {
 	return func(s *Server) { s.http.IdleTimeout = d }
 }
 
+// WithTLSConfig sets the TLS configuration for the HTTPS server (the certificates must be provided by it).
+func WithTLSConfig(cfg *tls.Config) ServerOption {
+	return func(s *Server) { s.http.TLSConfig = cfg }
+}
+
 func NewServer(baseCtx context.Context, log *zap.Logger, opts ...ServerOption) *Server {
 	var (
 		server = Server{
@@ -69,15 +81,22 @@ func (s *Server) Register(
 	useLiveFrontend bool,
 ) *Server {
 	var (
-		oAPI    = NewOpenAPI(ctx, log, rdyChk, lastAppVer, cfg, db, pubSub) // OpenAPI server implementation
-		spa     = frontend.New(web.Dist(useLiveFrontend))                   // file server for SPA (also handles 404 errors)
-		mux     = http.NewServeMux()                                        // base router for the OpenAPI server
-		handler = openapi.HandlerWithOptions(oAPI, openapi.StdHTTPServerOptions{
-			ErrorHandlerFunc: oAPI.HandleInternalError, // set error handler for internal server errors
-			BaseRouter:       mux,
-		})
+		oAPI = NewOpenAPI(ctx, log, rdyChk, lastAppVer, cfg, db, pubSub) // OpenAPI server implementation
+		spa  = frontend.New(web.Dist(useLiveFrontend))                   // file server for SPA (also handles 404 errors)
+		mux  = http.NewServeMux()                                        // base router for the OpenAPI server
+		mws  []openapi.MiddlewareFunc                                    // API routes middlewares
 	)
 
+	if cfg.Authenticator != nil { // authenticate the API requests, if the authentication is enabled
+		mws = append(mws, apiauth.New(log.Named("auth"), cfg.Authenticator, db))
+	}
+
+	var handler = openapi.HandlerWithOptions(oAPI, openapi.StdHTTPServerOptions{
+		ErrorHandlerFunc: oAPI.HandleInternalError, // set error handler for internal server errors
+		BaseRouter:       mux,
+		Middlewares:      mws,
+	})
+
 	mux.Handle("/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		// custom logic for handling 404 errors
 		if strings.HasPrefix(strings.TrimLeft(r.URL.Path, "/"), "api") {
@@ -89,15 +108,47 @@ func (s *Server) Register(
 		}
 	}))
 
+	if cfg.Metrics != nil && cfg.ServeMetrics { // expose the metrics on the main HTTP server
+		mux.Handle("GET "+RouteMetrics, cfg.Metrics.Handler())
+	}
+
+	// the metrics and probes are requested too often to log them (issue:
+	// https://github.com/tarampampam/webhook-tester/issues/575), and they must be accessible without the login
+	var isServiceRoute = func(r *http.Request) bool {
+		return r.URL.Path == openapi.RouteLivenessProbe || r.URL.Path == openapi.RouteReadinessProbe ||
+			(cfg.ServeMetrics && r.URL.Path == RouteMetrics)
+	}
+
+	if cfg.OIDC != nil { // require the login for the frontend (the API routes are authenticated by the authenticator)
+		handler = cfg.OIDC.Middleware(func(r *http.Request) bool {
+			return strings.HasPrefix(r.URL.Path, "/api/") || isServiceRoute(r)
+		})(handler)
+	}
+
+	// webhook capture as a middleware
+	handler = webhook.New(ctx, log.Named("webhook"), db, pubSub, cfg)(handler)
+
+	if cfg.HTTPSRedirectPort != 0 { // redirect the plain HTTP requests (except the service ones) to HTTPS
+		handler = httpsredirect.New(cfg.HTTPSRedirectPort, isServiceRoute)(handler)
+	}
+
+	if cfg.TracerProvider != nil { // trace the requests (the X-Trace-Id response header is logged by the logger)
+		handler = tracereq.New(cfg.TracerProvider, isServiceRoute)(handler)
+	}
+
 	// apply middlewares
-	s.http.Handler = logreq.New(log, func(r *http.Request) bool {
-		// issue: https://github.com/tarampampam/webhook-tester/issues/575
-		return r.URL.Path == openapi.RouteLivenessProbe || r.URL.Path == openapi.RouteReadinessProbe
-	})( // logger middleware
-		webhook.New(ctx, log.Named("webhook"), db, pubSub, cfg)( // webhook capture as a middleware
-			handler,
-		),
-	)
+	s.http.Handler = logreq.New(log, isServiceRoute)(handler) // logger middleware
+
+	return s
+}
+
+// RegisterMetrics registers the metrics handler only (for the server, which listens on a separate port).
+func (s *Server) RegisterMetrics(h http.Handler) *Server {
+	var mux = http.NewServeMux()
+
+	mux.Handle("GET "+RouteMetrics, h)
+
+	s.http.Handler = mux
 
 	return s
 }
@@ -107,21 +158,41 @@ func (s *Server) Register(
 //
 // It blocks until the context is canceled or the server is stopped by some error.
 func (s *Server) StartHTTP(ctx context.Context, ln net.Listener) error {
+	return s.serve(ctx, func() error { return s.http.Serve(ln) })
+}
+
+// StartHTTPS starts the HTTPS server, using the TLS configuration, set by the WithTLSConfig option. It may be called
+// along with StartHTTP (with another listener) to serve the same handlers over both HTTP and HTTPS.
+// To stop the server, cancel the provided context.
+//
+// It blocks until the context is canceled or the server is stopped by some error.
+func (s *Server) StartHTTPS(ctx context.Context, ln net.Listener) error {
+	if s.http.TLSConfig == nil {
+		return errors.New("missing TLS configuration")
+	}
+
+	return s.serve(ctx, func() error { return s.http.ServeTLS(ln, "", "") })
+}
+
+// serve runs the serving function and shuts the server down when the context is canceled.
+func (s *Server) serve(ctx context.Context, serve func() error) error {
 	var errCh = make(chan error)
 
-	go func(ch chan<- error) { defer close(ch); ch <- s.http.Serve(ln) }(errCh)
+	go func(ch chan<- error) { defer close(ch); ch <- serve() }(errCh)
 
 	select {
 	case <-ctx.Done():
 		shutdownCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), s.ShutdownTimeout)
 		defer cancel()
 
-		if err := s.http.Shutdown(shutdownCtx); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		// the server may be already shut down by another serving function (for another listener)
+		if err := s.http.Shutdown(shutdownCtx); err != nil &&
+			!errors.Is(err, http.ErrServerClosed) && !errors.Is(err, net.ErrClosed) {
 			return err
 		}
 	case err, isOpened := <-errCh:
 		switch {
-		case !isOpened:
+		case !isOpened, errors.Is(err, http.ErrServerClosed): // closed by another serving function
 			return nil
 		case err != nil:
 			return err
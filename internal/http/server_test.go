@@ -1,23 +1,55 @@
 package http_test
 
 import (
+	"bufio"
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"math/big"
 	"net"
 	"net/http"
+	"net/http/httptest"
 	"net/url"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/require"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
 
+	"gh.tarampamp.am/webhook-tester/v2/internal/archive"
+	"gh.tarampamp.am/webhook-tester/v2/internal/auth"
+	"gh.tarampamp.am/webhook-tester/v2/internal/auth/oidc"
+	"gh.tarampamp.am/webhook-tester/v2/internal/auth/oidc/oidctest"
+	"gh.tarampamp.am/webhook-tester/v2/internal/certs"
 	"gh.tarampamp.am/webhook-tester/v2/internal/config"
+	"gh.tarampamp.am/webhook-tester/v2/internal/forward"
 	appHttp "gh.tarampamp.am/webhook-tester/v2/internal/http"
+	"gh.tarampamp.am/webhook-tester/v2/internal/http/openapi"
+	"gh.tarampamp.am/webhook-tester/v2/internal/http/realip"
+	"gh.tarampamp.am/webhook-tester/v2/internal/ipfilter"
+	"gh.tarampamp.am/webhook-tester/v2/internal/metrics"
+	"gh.tarampamp.am/webhook-tester/v2/internal/notify"
 	"gh.tarampamp.am/webhook-tester/v2/internal/pubsub"
+	"gh.tarampamp.am/webhook-tester/v2/internal/ratelimit"
+	"gh.tarampamp.am/webhook-tester/v2/internal/slug"
 	"gh.tarampamp.am/webhook-tester/v2/internal/storage"
+	"gh.tarampamp.am/webhook-tester/v2/internal/tracing"
 )
 
 func TestServer_StartHTTP(t *testing.T) {
@@ -44,6 +76,14 @@ func TestServer_StartHTTP(t *testing.T) {
 	rID, err := db.NewRequest(ctx, sID, storage.Request{})
 	require.NoError(t, err)
 
+	// a separate session for the webhook capturing, because the "API routes exists" test deletes the main one
+	captureSID, err := db.NewSession(ctx, storage.Session{
+		Code:         http.StatusExpectationFailed,
+		ResponseBody: []byte(webhookResponse),
+		Headers:      []storage.HttpHeader{{Name: "Content-Type", Value: "text/someShit"}},
+	})
+	require.NoError(t, err)
+
 	srv.Register(
 		context.Background(),
 		log,
@@ -123,7 +163,7 @@ func TestServer_StartHTTP(t *testing.T) {
 	t.Run("webhook capture", func(t *testing.T) {
 		t.Parallel()
 
-		var status, body, headers = sendRequest(t, "POST", baseUrl+"/"+sID)
+		var status, body, headers = sendRequest(t, "POST", baseUrl+"/"+captureSID)
 
 		require.Equal(t, http.StatusExpectationFailed, status)
 		require.Contains(t, string(body), webhookResponse)
@@ -138,10 +178,28 @@ func TestServer_StartHTTP(t *testing.T) {
 
 		for i, params := range []struct{ method, url string }{ // order matters
 			{http.MethodPost, "/api/session"},
+			{http.MethodGet, "/api/sessions"},
 			{http.MethodGet, "/api/session/" + sID},
+			{http.MethodPut, "/api/session/" + sID},
+			{http.MethodPatch, "/api/session/" + sID},
+			{http.MethodGet, "/api/session/" + sID + "/rules"},
+			{http.MethodPut, "/api/session/" + sID + "/rules"},
+			{http.MethodGet, "/api/session/" + sID + "/forward"},
+			{http.MethodPut, "/api/session/" + sID + "/forward"},
+			{http.MethodGet, "/api/session/" + sID + "/signature"},
+			{http.MethodPut, "/api/session/" + sID + "/signature"},
+			{http.MethodGet, "/api/session/" + sID + "/ip-filter"},
+			{http.MethodPut, "/api/session/" + sID + "/ip-filter"},
+			{http.MethodGet, "/api/session/" + sID + "/notifications"},
+			{http.MethodPut, "/api/session/" + sID + "/notifications"},
+			{http.MethodGet, "/api/session/" + sID + "/slugs"},
+			{http.MethodPost, "/api/session/" + sID + "/slugs"},
 			{http.MethodGet, "/api/session/" + sID + "/requests"},
 			{http.MethodGet, "/api/session/" + sID + "/requests/subscribe"},
+			{http.MethodGet, "/api/session/" + sID + "/export"},
+			{http.MethodPost, "/api/session/" + sID + "/import"},
 			{http.MethodGet, "/api/session/" + sID + "/requests/" + rID},
+			{http.MethodPost, "/api/session/" + sID + "/requests/" + rID + "/replay"},
 			{http.MethodGet, "/api/settings"},
 			{http.MethodGet, "/api/version"},
 			{http.MethodGet, "/api/version/latest"},
@@ -202,6 +260,434 @@ func TestServer_PublicURLRoot(t *testing.T) {
 	})
 }
 
+func TestServer_PublicURLRootFromProxy(t *testing.T) {
+	t.Parallel()
+
+	var (
+		ctx = context.Background()
+		log = zap.NewNop()
+		srv = appHttp.NewServer(ctx, log)
+		db  = storage.NewInMemory(time.Minute, 8)
+	)
+
+	t.Cleanup(func() { require.NoError(t, db.Close()) })
+
+	trusted, err := ipfilter.ParsePrefixes([]string{"127.0.0.0/8", "::1"})
+	require.NoError(t, err)
+
+	srv.Register(
+		context.Background(),
+		log,
+		func(context.Context) error { return nil },
+		func(context.Context) (string, error) { return "v1.0.0", nil },
+		&config.AppSettings{RealIP: realip.NewResolver(trusted)},
+		db,
+		pubsub.NewInMemory[pubsub.RequestEvent](),
+		false,
+	)
+
+	var baseUrl, stop = startServer(t, ctx, srv)
+
+	t.Cleanup(stop)
+
+	// without the proxy headers, the public URL is not set (the UI uses its own origin)
+	var status, body, _ = sendRequest(t, http.MethodGet, baseUrl+"/api/settings")
+
+	require.Equal(t, http.StatusOK, status)
+	require.NotContains(t, string(body), "public_url_root")
+
+	status, body, _ = sendRequest(t, http.MethodGet, baseUrl+"/api/settings", map[string]string{
+		"Forwarded": `for=198.51.100.1;proto=https;host="hooks.example.org"`,
+	})
+
+	require.Equal(t, http.StatusOK, status)
+	require.Contains(t, string(body), `"public_url_root":"https://hooks.example.org"`)
+
+	// the webhook URL and addresses are derived the same way
+	sID, err := db.NewSession(ctx, storage.Session{Code: http.StatusOK})
+	require.NoError(t, err)
+
+	status, _, _ = sendRequest(t, http.MethodPost, baseUrl+"/"+sID+"/foo?bar=baz", map[string]string{
+		"X-Forwarded-For":   "198.51.100.1",
+		"X-Forwarded-Proto": "https",
+		"X-Forwarded-Host":  "hooks.example.org",
+	})
+	require.Equal(t, http.StatusOK, status)
+
+	status, body, _ = sendRequest(t, http.MethodGet, baseUrl+"/api/session/"+sID+"/requests")
+	require.Equal(t, http.StatusOK, status)
+
+	var list openapi.CapturedRequestsListResponse
+
+	require.NoError(t, json.Unmarshal(body, &list))
+	require.Len(t, list, 1)
+	require.Equal(t, "https://hooks.example.org/"+sID+"/foo?bar=baz", list[0].Url)
+	require.Equal(t, "198.51.100.1", list[0].ClientAddress)
+	require.NotNil(t, list[0].PeerAddress)
+	require.Equal(t, "127.0.0.1", *list[0].PeerAddress)
+}
+
+func TestServer_SessionRules(t *testing.T) {
+	t.Parallel()
+
+	var (
+		ctx = context.Background()
+		log = zap.NewNop()
+		srv = appHttp.NewServer(ctx, log)
+		db  = storage.NewInMemory(time.Minute, 8)
+	)
+
+	t.Cleanup(func() { require.NoError(t, db.Close()) })
+
+	srv.Register(
+		context.Background(),
+		log,
+		func(context.Context) error { return nil },
+		func(context.Context) (string, error) { return "v1.0.0", nil },
+		&config.AppSettings{},
+		db,
+		pubsub.NewInMemory[pubsub.RequestEvent](),
+		false,
+	)
+
+	var baseUrl, stop = startServer(t, ctx, srv)
+
+	t.Cleanup(stop)
+
+	sID, err := db.NewSession(ctx, storage.Session{Code: http.StatusOK, ResponseBody: []byte("default")})
+	require.NoError(t, err)
+
+	var setRules = func(t *testing.T, id, body string) (int, []byte) {
+		t.Helper()
+
+		req, rErr := http.NewRequestWithContext(ctx,
+			http.MethodPut, baseUrl+"/api/session/"+id+"/rules", strings.NewReader(body),
+		)
+		require.NoError(t, rErr)
+
+		resp, rErr := http.DefaultClient.Do(req)
+		require.NoError(t, rErr)
+
+		defer func() { _ = resp.Body.Close() }()
+
+		respBody, rErr := io.ReadAll(resp.Body)
+		require.NoError(t, rErr)
+
+		return resp.StatusCode, respBody
+	}
+
+	for _, give := range []string{
+		`[{"methods": [""], "path": "", "headers": [], "query": [], "json_body": [],
+			"response": {"status_code": 200, "headers": [], "response_body_base64": "", "delay": 0}}]`,
+		`[{"methods": [], "path": "[", "headers": [], "query": [], "json_body": [],
+			"response": {"status_code": 200, "headers": [], "response_body_base64": "", "delay": 0}}]`,
+		`{`,
+	} {
+		status, _ := setRules(t, sID, give)
+		require.Equal(t, http.StatusBadRequest, status, give)
+	}
+
+	status, _ := setRules(t, "9b6bbab9-c197-4dd3-bc3f-3cb6253820c7", `[]`)
+	require.Equal(t, http.StatusNotFound, status)
+
+	status, body := setRules(t, sID, `[{
+		"methods": ["post", "Put"],
+		"path": "/events/*",
+		"headers": [{"name": "X-Event", "value": "ping"}],
+		"query": [],
+		"json_body": [],
+		"response": {
+			"status_code": 202,
+			"headers": [{"name": "X-Foo", "value": "bar"}],
+			"response_body_base64": "`+base64.StdEncoding.EncodeToString([]byte("pong"))+`",
+			"delay": 0
+		}
+	}]`)
+	require.Equal(t, http.StatusOK, status)
+
+	var set openapi.ResponseRulesResponse
+
+	require.NoError(t, json.Unmarshal(body, &set))
+	require.Len(t, set, 1)
+	require.Equal(t, []string{"POST", "PUT"}, set[0].Methods) // the methods are upper-cased
+	require.EqualValues(t, http.StatusAccepted, set[0].Response.StatusCode)
+
+	status, body, _ = sendRequest(t, http.MethodGet, baseUrl+"/api/session/"+sID+"/rules")
+	require.Equal(t, http.StatusOK, status)
+
+	var got openapi.ResponseRulesResponse
+
+	require.NoError(t, json.Unmarshal(body, &got))
+	require.Equal(t, set, got)
+
+	// the matching request gets the rule response, and the other ones - the session default response
+	status, body, headers := sendRequest(t, http.MethodPost, baseUrl+"/"+sID+"/events/github",
+		map[string]string{"X-Event": "ping"},
+	)
+	require.Equal(t, http.StatusAccepted, status)
+	require.Equal(t, "pong", string(body))
+	require.Equal(t, "bar", headers.Get("X-Foo"))
+
+	status, body, _ = sendRequest(t, http.MethodPost, baseUrl+"/"+sID+"/events/github")
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, "default", string(body))
+
+	// the empty list removes the rules
+	status, body = setRules(t, sID, `[]`)
+	require.Equal(t, http.StatusOK, status)
+	require.JSONEq(t, `[]`, string(body))
+
+	sess, err := db.GetSession(ctx, sID)
+	require.NoError(t, err)
+	require.Empty(t, sess.Rules)
+	require.Equal(t, []byte("default"), sess.ResponseBody) // other options are untouched
+}
+
+func TestServer_SessionForward(t *testing.T) {
+	t.Parallel()
+
+	var upstream = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Path", r.URL.Path)
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte("upstream"))
+	}))
+
+	t.Cleanup(upstream.Close)
+
+	var (
+		ctx = context.Background()
+		log = zap.NewNop()
+		srv = appHttp.NewServer(ctx, log)
+		db  = storage.NewInMemory(time.Minute, 8)
+	)
+
+	t.Cleanup(func() { require.NoError(t, db.Close()) })
+
+	anyHost, err := forward.NewHostPolicy(nil, nil) // the upstream listens on the loopback interface
+	require.NoError(t, err)
+
+	srv.Register(
+		context.Background(),
+		log,
+		func(context.Context) error { return nil },
+		func(context.Context) (string, error) { return "v1.0.0", nil },
+		&config.AppSettings{OutboundHostPolicy: anyHost},
+		db,
+		pubsub.NewInMemory[pubsub.RequestEvent](),
+		false,
+	)
+
+	var baseUrl, stop = startServer(t, ctx, srv)
+
+	t.Cleanup(stop)
+
+	sID, err := db.NewSession(ctx, storage.Session{Code: http.StatusOK, ResponseBody: []byte("canned")})
+	require.NoError(t, err)
+
+	var setForward = func(t *testing.T, id, body string) (int, map[string]any) {
+		t.Helper()
+
+		req, rErr := http.NewRequestWithContext(ctx,
+			http.MethodPut, baseUrl+"/api/session/"+id+"/forward", strings.NewReader(body),
+		)
+		require.NoError(t, rErr)
+
+		resp, rErr := http.DefaultClient.Do(req)
+		require.NoError(t, rErr)
+
+		defer func() { _ = resp.Body.Close() }()
+
+		var payload map[string]any
+
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&payload))
+
+		return resp.StatusCode, payload
+	}
+
+	for _, body := range []string{
+		`{"url": "ftp://example.com", "pass_headers": false, "timeout": 5, "return_response": false}`,
+		`{"url": "https://", "pass_headers": false, "timeout": 5, "return_response": false}`,
+		`{"url": "https://example.com", "pass_headers": false, "timeout": 0, "return_response": false}`,
+		`{"url": "https://example.com", "pass_headers": false, "timeout": 31, "return_response": false}`,
+	} {
+		status, _ := setForward(t, sID, body)
+		require.Equal(t, http.StatusBadRequest, status, body)
+	}
+
+	status, _ := setForward(t, "9b6bbab9-c197-4dd3-bc3f-3cb6253820c7",
+		`{"url": "", "pass_headers": false, "timeout": 0, "return_response": false}`,
+	)
+	require.Equal(t, http.StatusNotFound, status)
+
+	var options = `{"url": "` + upstream.URL + `/hooks", "pass_headers": true, "timeout": 5, "return_response": true}`
+
+	status, payload := setForward(t, sID, options)
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, map[string]any{
+		"url": upstream.URL + "/hooks", "pass_headers": true, "timeout": float64(5), "return_response": true,
+	}, payload)
+
+	status, body, _ := sendRequest(t, http.MethodGet, baseUrl+"/api/session/"+sID+"/forward")
+	require.Equal(t, http.StatusOK, status)
+	require.JSONEq(t, options, string(body))
+
+	// the captured request is forwarded, and the upstream response is returned
+	status, body, headers := sendRequest(t, http.MethodPost, baseUrl+"/"+sID+"/github")
+	require.Equal(t, http.StatusCreated, status)
+	require.Equal(t, "upstream", string(body))
+	require.Equal(t, "/hooks/github", headers.Get("X-Path"))
+
+	// the empty URL disables the forwarding
+	status, payload = setForward(t, sID, `{"url": "", "pass_headers": true, "timeout": 0, "return_response": true}`)
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, "", payload["url"])
+	require.Equal(t, false, payload["pass_headers"])
+
+	sess, err := db.GetSession(ctx, sID)
+	require.NoError(t, err)
+	require.Nil(t, sess.Forward)
+	require.Equal(t, []byte("canned"), sess.ResponseBody) // other options are untouched
+
+	status, body, _ = sendRequest(t, http.MethodPost, baseUrl+"/"+sID+"/github")
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, "canned", string(body))
+}
+
+func TestServer_ReplayRequest(t *testing.T) {
+	t.Parallel()
+
+	var upstream = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		w.Header().Set("X-Method", r.Method)
+		w.Header().Set("X-Foo", r.Header.Get("X-Foo"))
+		w.Header().Set("X-Bar", r.Header.Get("X-Bar"))
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write(body)
+	}))
+
+	t.Cleanup(upstream.Close)
+
+	var (
+		ctx = context.Background()
+		log = zap.NewNop()
+		srv = appHttp.NewServer(ctx, log)
+		db  = storage.NewInMemory(time.Minute, 8)
+	)
+
+	t.Cleanup(func() { require.NoError(t, db.Close()) })
+
+	policy, err := forward.NewHostPolicy(nil, []string{"10.0.0.0/8", "localhost"})
+	require.NoError(t, err)
+
+	srv.Register(
+		context.Background(),
+		log,
+		func(context.Context) error { return nil },
+		func(context.Context) (string, error) { return "v1.0.0", nil },
+		&config.AppSettings{OutboundHostPolicy: policy},
+		db,
+		pubsub.NewInMemory[pubsub.RequestEvent](),
+		false,
+	)
+
+	var baseUrl, stop = startServer(t, ctx, srv)
+
+	t.Cleanup(stop)
+
+	sID, err := db.NewSession(ctx, storage.Session{})
+	require.NoError(t, err)
+
+	rID, err := db.NewRequest(ctx, sID, storage.Request{
+		Method:  http.MethodPut,
+		URL:     upstream.URL + "/original",
+		Headers: []storage.HttpHeader{{Name: "X-Foo", Value: "foo"}, {Name: "X-Bar", Value: "bar"}},
+		Body:    []byte("original body"),
+	})
+	require.NoError(t, err)
+
+	var replay = func(t *testing.T, id, body string) (int, map[string]any) {
+		t.Helper()
+
+		resp, rErr := http.Post( //nolint:noctx
+			baseUrl+"/api/session/"+sID+"/requests/"+id+"/replay",
+			"application/json",
+			strings.NewReader(body),
+		)
+		require.NoError(t, rErr)
+
+		defer func() { _ = resp.Body.Close() }()
+
+		var payload map[string]any
+
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&payload))
+
+		return resp.StatusCode, payload
+	}
+
+	t.Run("as is", func(t *testing.T) {
+		t.Parallel()
+
+		var status, payload = replay(t, rID, "")
+
+		require.Equal(t, http.StatusOK, status)
+		require.EqualValues(t, http.StatusCreated, payload["status_code"])
+		require.Equal(t, upstream.URL+"/original", payload["url"])
+		require.Equal(t, base64.StdEncoding.EncodeToString([]byte("original body")), payload["response_body_base64"])
+		require.Contains(t, payload["headers"], map[string]any{"name": "X-Method", "value": "PUT"})
+		require.Contains(t, payload["headers"], map[string]any{"name": "X-Foo", "value": "foo"})
+		require.Contains(t, payload["headers"], map[string]any{"name": "X-Bar", "value": "bar"})
+	})
+
+	t.Run("with overrides", func(t *testing.T) {
+		t.Parallel()
+
+		var status, payload = replay(t, rID, `{
+			"url": "`+upstream.URL+`/other",
+			"headers": [{"name": "X-Foo", "value": "baz"}, {"name": "X-Bar", "value": ""}],
+			"request_payload_base64": "`+base64.StdEncoding.EncodeToString([]byte("new body"))+`"
+		}`)
+
+		require.Equal(t, http.StatusOK, status)
+		require.Equal(t, upstream.URL+"/other", payload["url"])
+		require.Equal(t, base64.StdEncoding.EncodeToString([]byte("new body")), payload["response_body_base64"])
+		require.Contains(t, payload["headers"], map[string]any{"name": "X-Foo", "value": "baz"})
+		require.Contains(t, payload["headers"], map[string]any{"name": "X-Bar", "value": ""})
+	})
+
+	t.Run("denied host", func(t *testing.T) {
+		t.Parallel()
+
+		var status, _ = replay(t, rID, `{"url": "http://localhost:1/"}`)
+
+		require.Equal(t, http.StatusForbidden, status)
+	})
+
+	t.Run("upstream error", func(t *testing.T) {
+		t.Parallel()
+
+		var status, _ = replay(t, rID, `{"url": "http://127.0.0.1:1/"}`)
+
+		require.Equal(t, http.StatusBadGateway, status)
+	})
+
+	t.Run("wrong options", func(t *testing.T) {
+		t.Parallel()
+
+		var status, _ = replay(t, rID, `{"url": "ftp://example.com/"}`)
+
+		require.Equal(t, http.StatusBadRequest, status)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		t.Parallel()
+
+		var status, _ = replay(t, "9b6bbab9-c197-4dd3-bc3f-3cb6253820c7", "")
+
+		require.Equal(t, http.StatusNotFound, status)
+	})
+}
+
 // sendRequest is a helper function to send an HTTP request and return its status code, body, and headers.
 func sendRequest(t *testing.T, method, url string, headers ...map[string]string) (
 	status int,
@@ -291,3 +777,2522 @@ func getFreeTcpPort(t *testing.T) uint16 {
 
 	return uint16(port) //nolint:gosec
 }
+
+func TestServer_ListRequests(t *testing.T) {
+	t.Parallel()
+
+	var (
+		ctx = context.Background()
+		log = zap.NewNop()
+		srv = appHttp.NewServer(ctx, log)
+		db  = storage.NewInMemory(time.Minute, 8)
+	)
+
+	t.Cleanup(func() { require.NoError(t, db.Close()) })
+
+	srv.Register(
+		context.Background(),
+		log,
+		func(context.Context) error { return nil },
+		func(context.Context) (string, error) { return "v1.0.0", nil },
+		&config.AppSettings{},
+		db,
+		pubsub.NewInMemory[pubsub.RequestEvent](),
+		false,
+	)
+
+	var baseUrl, stop = startServer(t, ctx, srv)
+
+	t.Cleanup(stop)
+
+	sID, err := db.NewSession(ctx, storage.Session{})
+	require.NoError(t, err)
+
+	for i, method := range []string{http.MethodGet, http.MethodPost, http.MethodPost, http.MethodPut} {
+		_, err = db.NewRequest(ctx, sID, storage.Request{
+			Method:  method,
+			URL:     fmt.Sprintf("http://example.com/%d", i),
+			Headers: []storage.HttpHeader{{Name: "X-Index", Value: fmt.Sprint(i)}},
+			Body:    []byte(fmt.Sprintf(`{"index":%d}`, i)),
+		})
+		require.NoError(t, err)
+
+		time.Sleep(2 * time.Millisecond) // the accuracy is one millisecond
+	}
+
+	var list = func(t *testing.T, query string) (int, []map[string]any, string) {
+		t.Helper()
+
+		resp, rErr := http.Get(baseUrl + "/api/session/" + sID + "/requests?" + query) //nolint:noctx
+		require.NoError(t, rErr)
+
+		defer func() { _ = resp.Body.Close() }()
+
+		if resp.StatusCode != http.StatusOK {
+			return resp.StatusCode, nil, ""
+		}
+
+		var payload []map[string]any
+
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&payload))
+
+		return resp.StatusCode, payload, resp.Header.Get("X-Next-Cursor")
+	}
+
+	var urls = func(items []map[string]any) (out []string) {
+		for _, item := range items {
+			out = append(out, item["url"].(string))
+		}
+
+		return
+	}
+
+	t.Run("all", func(t *testing.T) {
+		t.Parallel()
+
+		var status, items, cursor = list(t, "")
+
+		require.Equal(t, http.StatusOK, status)
+		require.Equal(t, []string{
+			"http://example.com/3", "http://example.com/2", "http://example.com/1", "http://example.com/0",
+		}, urls(items))
+		require.Empty(t, cursor)
+	})
+
+	t.Run("filters", func(t *testing.T) {
+		t.Parallel()
+
+		for query, want := range map[string][]string{
+			"method=post&order=oldest":               {"http://example.com/1", "http://example.com/2"},
+			"header=x-index&header_value=3":          {"http://example.com/3"},
+			"url=%2F0":                               {"http://example.com/0"},
+			"url_regex=%2F%5B12%5D%24&order=oldest":  {"http://example.com/1", "http://example.com/2"},
+			"body=%22index%22%3A2":                   {"http://example.com/2"},
+			"body_regex=%5E%5C%7B%22index%22%3A0%7D": {"http://example.com/0"},
+			"method=patch":                           nil,
+		} {
+			var status, items, _ = list(t, query)
+
+			require.Equal(t, http.StatusOK, status, query)
+			require.Equal(t, want, urls(items), query)
+		}
+	})
+
+	t.Run("pagination", func(t *testing.T) {
+		t.Parallel()
+
+		var status, items, cursor = list(t, "order=oldest&limit=3")
+
+		require.Equal(t, http.StatusOK, status)
+		require.Equal(t, []string{"http://example.com/0", "http://example.com/1", "http://example.com/2"}, urls(items))
+		require.NotEmpty(t, cursor)
+
+		status, items, cursor = list(t, "order=oldest&limit=3&cursor="+url.QueryEscape(cursor))
+
+		require.Equal(t, http.StatusOK, status)
+		require.Equal(t, []string{"http://example.com/3"}, urls(items))
+		require.Empty(t, cursor)
+	})
+
+	t.Run("bad request", func(t *testing.T) {
+		t.Parallel()
+
+		for _, query := range []string{
+			"url_regex=%28",
+			"body_regex=%5B",
+			"order=foo",
+			"limit=0",
+			"limit=1001",
+			"since=2&until=1",
+			"header_value=foo",
+			"cursor=foo",
+		} {
+			var status, _, _ = list(t, query)
+
+			require.Equal(t, http.StatusBadRequest, status, query)
+		}
+	})
+}
+
+func TestServer_ListSessions(t *testing.T) {
+	t.Parallel()
+
+	var (
+		ctx = context.Background()
+		log = zap.NewNop()
+		srv = appHttp.NewServer(ctx, log)
+		db  = storage.NewInMemory(time.Minute, 8)
+	)
+
+	t.Cleanup(func() { require.NoError(t, db.Close()) })
+
+	srv.Register(
+		context.Background(),
+		log,
+		func(context.Context) error { return nil },
+		func(context.Context) (string, error) { return "v1.0.0", nil },
+		&config.AppSettings{Authenticator: auth.NewStaticTokens([]auth.Credential{
+			{User: "alice", Secret: "alice-token"},
+			{User: "bob", Secret: "bob-token"},
+		})},
+		db,
+		pubsub.NewInMemory[pubsub.RequestEvent](),
+		false,
+	)
+
+	var baseUrl, stop = startServer(t, ctx, srv)
+
+	t.Cleanup(stop)
+
+	var sIDs = make([]string, 0, 4) // in the creation order
+
+	for _, owner := range []string{"alice", "", "bob", "alice"} {
+		sID, err := db.NewSession(ctx, storage.Session{Owner: owner})
+		require.NoError(t, err)
+
+		sIDs = append(sIDs, sID)
+
+		time.Sleep(2 * time.Millisecond) // the accuracy is one millisecond
+	}
+
+	for range 2 {
+		_, err := db.NewRequest(ctx, sIDs[0], storage.Request{Method: http.MethodPost})
+		require.NoError(t, err)
+	}
+
+	var list = func(t *testing.T, token, query string) (int, []openapi.ListedSession, string) {
+		t.Helper()
+
+		var status, body, headers = sendRequest(t, http.MethodGet, baseUrl+"/api/sessions?"+query,
+			map[string]string{"Authorization": "Bearer " + token},
+		)
+
+		if status != http.StatusOK {
+			return status, nil, ""
+		}
+
+		var payload []openapi.ListedSession
+
+		require.NoError(t, json.Unmarshal(body, &payload))
+
+		return status, payload, headers.Get("X-Next-Cursor")
+	}
+
+	var ids = func(items []openapi.ListedSession) (out []string) {
+		for _, item := range items {
+			out = append(out, item.Uuid.String())
+		}
+
+		return
+	}
+
+	t.Run("owner", func(t *testing.T) {
+		t.Parallel()
+
+		var status, items, cursor = list(t, "alice-token", "")
+
+		require.Equal(t, http.StatusOK, status)
+		require.Equal(t, []string{sIDs[3], sIDs[1], sIDs[0]}, ids(items))
+		require.Empty(t, cursor)
+
+		require.EqualValues(t, 2, items[2].RequestsCount)
+		require.NotNil(t, items[2].LastRequestAtUnixMilli)
+		require.Zero(t, items[0].RequestsCount)
+		require.Nil(t, items[0].LastRequestAtUnixMilli)
+		require.Greater(t, items[0].ExpiresAtUnixMilli, items[0].CreatedAtUnixMilli)
+
+		status, items, _ = list(t, "bob-token", "")
+
+		require.Equal(t, http.StatusOK, status)
+		require.Equal(t, []string{sIDs[2], sIDs[1]}, ids(items))
+	})
+
+	t.Run("pagination", func(t *testing.T) {
+		t.Parallel()
+
+		var status, items, cursor = list(t, "alice-token", "limit=2")
+
+		require.Equal(t, http.StatusOK, status)
+		require.Equal(t, []string{sIDs[3], sIDs[1]}, ids(items))
+		require.NotEmpty(t, cursor)
+
+		status, items, cursor = list(t, "alice-token", "limit=2&cursor="+url.QueryEscape(cursor))
+
+		require.Equal(t, http.StatusOK, status)
+		require.Equal(t, []string{sIDs[0]}, ids(items))
+		require.Empty(t, cursor)
+	})
+
+	t.Run("bad request", func(t *testing.T) {
+		t.Parallel()
+
+		for _, query := range []string{"limit=0", "limit=1001", "cursor=foo"} {
+			var status, _, _ = list(t, "alice-token", query)
+
+			require.Equal(t, http.StatusBadRequest, status, query)
+		}
+	})
+
+	t.Run("not authenticated", func(t *testing.T) {
+		t.Parallel()
+
+		var status, _, _ = list(t, "wrong-token", "")
+
+		require.Equal(t, http.StatusUnauthorized, status)
+	})
+}
+
+func TestServer_ExportRequests(t *testing.T) {
+	t.Parallel()
+
+	var (
+		ctx = context.Background()
+		log = zap.NewNop()
+		srv = appHttp.NewServer(ctx, log)
+		db  = storage.NewInMemory(time.Minute, 300)
+	)
+
+	t.Cleanup(func() { require.NoError(t, db.Close()) })
+
+	srv.Register(
+		context.Background(),
+		log,
+		func(context.Context) error { return nil },
+		func(context.Context) (string, error) { return "v1.0.0", nil },
+		&config.AppSettings{},
+		db,
+		pubsub.NewInMemory[pubsub.RequestEvent](),
+		false,
+	)
+
+	var baseUrl, stop = startServer(t, ctx, srv)
+
+	t.Cleanup(stop)
+
+	sID, err := db.NewSession(ctx, storage.Session{})
+	require.NoError(t, err)
+
+	const total = 250 // more than one storage page
+
+	for i := range total {
+		_, err = db.NewRequest(ctx, sID, storage.Request{
+			Method: http.MethodPost,
+			URL:    fmt.Sprintf("http://example.com/%d", i),
+			Body:   []byte{0x00, byte(i)},
+		})
+		require.NoError(t, err)
+	}
+
+	var export = func(t *testing.T, id, format string) (*http.Response, []byte) {
+		t.Helper()
+
+		resp, rErr := http.Get(baseUrl + "/api/session/" + id + "/export?format=" + format) //nolint:noctx
+		require.NoError(t, rErr)
+
+		defer func() { _ = resp.Body.Close() }()
+
+		body, rErr := io.ReadAll(resp.Body)
+		require.NoError(t, rErr)
+
+		return resp, body
+	}
+
+	t.Run("har", func(t *testing.T) {
+		t.Parallel()
+
+		var resp, body = export(t, sID, "har")
+
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Contains(t, resp.Header.Get("Content-Type"), "application/json")
+		require.Equal(t, `attachment; filename="`+sID+`.har"`, resp.Header.Get("Content-Disposition"))
+
+		var har archive.HAR
+
+		require.NoError(t, json.Unmarshal(body, &har))
+		require.Len(t, har.Log.Entries, total)
+
+		for _, entry := range har.Log.Entries {
+			require.NotNil(t, entry.Request.PostData)
+			require.Equal(t, "base64", entry.Request.PostData.Encoding)
+		}
+	})
+
+	t.Run("jsonl", func(t *testing.T) {
+		t.Parallel()
+
+		var resp, body = export(t, sID, "jsonl")
+
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Equal(t, `attachment; filename="`+sID+`.jsonl"`, resp.Header.Get("Content-Disposition"))
+
+		var lines = strings.Split(strings.TrimSpace(string(body)), "\n")
+
+		require.Len(t, lines, total)
+
+		var first archive.JSONLRecord
+
+		require.NoError(t, json.Unmarshal([]byte(lines[0]), &first))
+		require.Len(t, first.RequestPayloadBase64, 4) // 2 bytes in base64
+	})
+
+	t.Run("curl and postman", func(t *testing.T) {
+		t.Parallel()
+
+		for _, format := range []string{"curl", "postman"} {
+			var resp, body = export(t, sID, format)
+
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+			require.Contains(t, string(body), "http://example.com/249")
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		t.Parallel()
+
+		var resp, _ = export(t, sID, "foo")
+
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+		resp, _ = export(t, "9b6bbab9-c197-4dd3-bc3f-3cb6253820c7", "har")
+
+		require.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+}
+
+func TestServer_ImportRequests(t *testing.T) {
+	t.Parallel()
+
+	var (
+		ctx = context.Background()
+		log = zap.NewNop()
+		srv = appHttp.NewServer(ctx, log)
+		db  = storage.NewInMemory(time.Minute, 3)
+		ps  = pubsub.NewInMemory[pubsub.RequestEvent]()
+	)
+
+	t.Cleanup(func() { require.NoError(t, db.Close()) })
+
+	srv.Register(
+		context.Background(),
+		log,
+		func(context.Context) error { return nil },
+		func(context.Context) (string, error) { return "v1.0.0", nil },
+		&config.AppSettings{MaxRequestBodySize: 8},
+		db,
+		ps,
+		false,
+	)
+
+	var baseUrl, stop = startServer(t, ctx, srv)
+
+	t.Cleanup(stop)
+
+	var doImport = func(t *testing.T, id, format, body string) (int, map[string]any) {
+		t.Helper()
+
+		resp, rErr := http.Post( //nolint:noctx
+			baseUrl+"/api/session/"+id+"/import?format="+format,
+			"application/json",
+			strings.NewReader(body),
+		)
+		require.NoError(t, rErr)
+
+		defer func() { _ = resp.Body.Close() }()
+
+		var payload map[string]any
+
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&payload))
+
+		return resp.StatusCode, payload
+	}
+
+	t.Run("har", func(t *testing.T) {
+		t.Parallel()
+
+		sID, err := db.NewSession(ctx, storage.Session{})
+		require.NoError(t, err)
+
+		sub, unsubscribe, err := ps.Subscribe(ctx, sID)
+		require.NoError(t, err)
+
+		t.Cleanup(unsubscribe)
+
+		var status, payload = doImport(t, sID, "har", `{"log": {"version": "1.2", "entries": [{
+			"startedDateTime": "2024-01-02T03:04:05.006Z",
+			"_clientAddress": "10.0.0.1",
+			"request": {"method": "POST", "url": "https://example.com/foo", "postData": {"text": "too large body"}}
+		}, {
+			"startedDateTime": "2024-01-02T03:04:06Z",
+			"_clientAddress": "10.0.0.2",
+			"request": {"method": "PUT", "url": "https://example.com/bar", "postData": {"text": "AP8=", "encoding": "base64"}}
+		}]}}`)
+
+		require.Equal(t, http.StatusOK, status)
+		require.Equal(t, map[string]any{"imported": float64(1), "skipped": float64(1)}, payload)
+
+		all, err := db.GetAllRequests(ctx, sID)
+		require.NoError(t, err)
+		require.Len(t, all, 1)
+
+		for rID, r := range all {
+			require.Equal(t, "10.0.0.2", r.ClientAddr)
+			require.Equal(t, []byte{0x00, 0xff}, r.Body)
+			require.Equal(t, time.Date(2024, 1, 2, 3, 4, 6, 0, time.UTC).UnixMilli(), r.CreatedAtUnixMilli)
+
+			select {
+			case event := <-sub:
+				require.Equal(t, pubsub.RequestActionCreate, event.Action)
+				require.Equal(t, rID, event.Request.ID)
+				require.Equal(t, r.CreatedAtUnixMilli, event.Request.CreatedAtUnixMilli)
+			case <-time.After(time.Second):
+				t.Fatal("no event received")
+			}
+		}
+	})
+
+	t.Run("jsonl with the requests limit", func(t *testing.T) {
+		t.Parallel()
+
+		sID, err := db.NewSession(ctx, storage.Session{})
+		require.NoError(t, err)
+
+		var lines []string
+
+		for i := range 5 {
+			lines = append(lines, fmt.Sprintf(
+				`{"method":"GET","url":"https://example.com/%d","client_address":"10.0.0.%d","captured_at_unix_milli":%d}`,
+				i, i, 1_700_000_000_000+i,
+			))
+		}
+
+		var status, payload = doImport(t, sID, "jsonl", strings.Join(lines, "\n"))
+
+		require.Equal(t, http.StatusOK, status)
+		require.Equal(t, map[string]any{"imported": float64(5), "skipped": float64(0)}, payload)
+
+		all, err := db.GetAllRequests(ctx, sID)
+		require.NoError(t, err)
+		require.Len(t, all, 3) // the oldest requests are removed
+
+		for _, r := range all {
+			require.GreaterOrEqual(t, r.CreatedAtUnixMilli, int64(1_700_000_000_002))
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		t.Parallel()
+
+		sID, err := db.NewSession(ctx, storage.Session{})
+		require.NoError(t, err)
+
+		var status, _ = doImport(t, sID, "har", "foo")
+
+		require.Equal(t, http.StatusBadRequest, status)
+
+		status, _ = doImport(t, sID, "curl", "")
+
+		require.Equal(t, http.StatusBadRequest, status)
+
+		status, _ = doImport(t, "9b6bbab9-c197-4dd3-bc3f-3cb6253820c7", "har", `{"log": {"entries": []}}`)
+
+		require.Equal(t, http.StatusNotFound, status)
+	})
+}
+
+func TestServer_SessionSignature(t *testing.T) {
+	t.Parallel()
+
+	const secret = "It's a Secret to Everybody"
+
+	var (
+		ctx = context.Background()
+		log = zap.NewNop()
+		srv = appHttp.NewServer(ctx, log)
+		db  = storage.NewInMemory(time.Minute, 8)
+	)
+
+	t.Cleanup(func() { require.NoError(t, db.Close()) })
+
+	srv.Register(
+		context.Background(),
+		log,
+		func(context.Context) error { return nil },
+		func(context.Context) (string, error) { return "v1.0.0", nil },
+		&config.AppSettings{},
+		db,
+		pubsub.NewInMemory[pubsub.RequestEvent](),
+		false,
+	)
+
+	var baseUrl, stop = startServer(t, ctx, srv)
+
+	t.Cleanup(stop)
+
+	sID, err := db.NewSession(ctx, storage.Session{Code: http.StatusOK})
+	require.NoError(t, err)
+
+	var setSignature = func(t *testing.T, id, body string) (int, map[string]any) {
+		t.Helper()
+
+		req, rErr := http.NewRequestWithContext(ctx,
+			http.MethodPut, baseUrl+"/api/session/"+id+"/signature", strings.NewReader(body),
+		)
+		require.NoError(t, rErr)
+
+		resp, rErr := http.DefaultClient.Do(req)
+		require.NoError(t, rErr)
+
+		defer func() { _ = resp.Body.Close() }()
+
+		var payload map[string]any
+
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&payload))
+
+		return resp.StatusCode, payload
+	}
+
+	for _, body := range []string{
+		`{"provider": "foo", "secret": "bar", "tolerance": 0, "reject_code": 0}`,
+		`{"provider": "standard", "secret": "whsec_!!!", "tolerance": 0, "reject_code": 0}`,
+		`{"provider": "github", "secret": "bar", "tolerance": 86401, "reject_code": 0}`,
+		`{"provider": "github", "secret": "bar", "tolerance": 0, "reject_code": 200}`,
+	} {
+		status, _ := setSignature(t, sID, body)
+		require.Equal(t, http.StatusBadRequest, status, body)
+	}
+
+	status, _ := setSignature(t, "9b6bbab9-c197-4dd3-bc3f-3cb6253820c7",
+		`{"provider": "github", "secret": "bar", "tolerance": 0, "reject_code": 0}`,
+	)
+	require.Equal(t, http.StatusNotFound, status)
+
+	status, payload := setSignature(t, sID,
+		`{"provider": "github", "secret": "`+secret+`", "tolerance": 300, "reject_code": 401}`,
+	)
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, map[string]any{
+		"provider": "github", "secret": secret, "tolerance": float64(300), "reject_code": float64(401),
+	}, payload)
+
+	status, body, _ := sendRequest(t, http.MethodGet, baseUrl+"/api/session/"+sID+"/signature")
+	require.Equal(t, http.StatusOK, status)
+	require.JSONEq(t, `{"provider": "github", "secret": "`+secret+`", "tolerance": 300, "reject_code": 401}`, string(body))
+
+	{ // capture the requests with valid and invalid signatures
+		var mac = hmac.New(sha256.New, []byte(secret))
+
+		_, _ = mac.Write([]byte("payload"))
+
+		for sig, wantCode := range map[string]int{
+			"sha256=" + hex.EncodeToString(mac.Sum(nil)): http.StatusOK,
+			"sha256=deadbeef": http.StatusUnauthorized,
+		} {
+			req, rErr := http.NewRequestWithContext(ctx, http.MethodPost, baseUrl+"/"+sID, strings.NewReader("payload"))
+			require.NoError(t, rErr)
+
+			req.Header.Set("X-Hub-Signature-256", sig)
+
+			resp, rErr := http.DefaultClient.Do(req)
+			require.NoError(t, rErr)
+			require.NoError(t, resp.Body.Close())
+			require.Equal(t, wantCode, resp.StatusCode)
+		}
+	}
+
+	status, body, _ = sendRequest(t, http.MethodGet, baseUrl+"/api/session/"+sID+"/requests")
+	require.Equal(t, http.StatusOK, status)
+
+	var list []openapi.CapturedRequest
+
+	require.NoError(t, json.Unmarshal(body, &list))
+	require.Len(t, list, 2)
+
+	var valid int
+
+	for _, r := range list {
+		require.NotNil(t, r.Signature)
+		require.Equal(t, "github", r.Signature.Provider)
+
+		if r.Signature.Valid {
+			valid++
+		} else {
+			require.Equal(t, "signature mismatch", r.Signature.Error)
+		}
+	}
+
+	require.Equal(t, 1, valid)
+
+	// disable the verification
+	status, payload = setSignature(t, sID, `{"provider": "", "secret": "", "tolerance": 0, "reject_code": 0}`)
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, "", payload["secret"])
+
+	sess, err := db.GetSession(ctx, sID)
+	require.NoError(t, err)
+	require.Nil(t, sess.Signature)
+}
+
+func TestServer_SessionUpdate(t *testing.T) {
+	t.Parallel()
+
+	var (
+		ctx = context.Background()
+		log = zap.NewNop()
+		srv = appHttp.NewServer(ctx, log)
+		db  = storage.NewInMemory(time.Minute, 8)
+		ps  = pubsub.NewInMemory[pubsub.RequestEvent]()
+	)
+
+	t.Cleanup(func() { require.NoError(t, db.Close()) })
+
+	srv.Register(
+		context.Background(),
+		log,
+		func(context.Context) error { return nil },
+		func(context.Context) (string, error) { return "v1.0.0", nil },
+		&config.AppSettings{},
+		db,
+		ps,
+		false,
+	)
+
+	var baseUrl, stop = startServer(t, ctx, srv)
+
+	t.Cleanup(stop)
+
+	var put = func(t *testing.T, id, body string) (int, *openapi.SessionOptionsResponse) {
+		t.Helper()
+
+		req, rErr := http.NewRequestWithContext(ctx, http.MethodPut, baseUrl+"/api/session/"+id, strings.NewReader(body))
+		require.NoError(t, rErr)
+
+		resp, rErr := http.DefaultClient.Do(req)
+		require.NoError(t, rErr)
+
+		defer func() { _ = resp.Body.Close() }()
+
+		if resp.StatusCode != http.StatusOK {
+			return resp.StatusCode, nil
+		}
+
+		var payload openapi.SessionOptionsResponse
+
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&payload))
+
+		return resp.StatusCode, &payload
+	}
+
+	t.Run("update", func(t *testing.T) {
+		t.Parallel()
+
+		sID, err := db.NewSession(ctx, storage.Session{Code: http.StatusOK, SessionMeta: storage.SessionMeta{Name: "foo"}})
+		require.NoError(t, err)
+
+		_, err = db.NewRequest(ctx, sID, storage.Request{Method: http.MethodPost})
+		require.NoError(t, err)
+
+		sub, unsubscribe, err := ps.Subscribe(ctx, sID)
+		require.NoError(t, err)
+
+		t.Cleanup(unsubscribe)
+
+		var status, resp = put(t, sID, `{
+			"status_code": 418,
+			"headers": [{"name": "X-Foo", "value": "bar"}],
+			"delay": 0,
+			"response_body_base64": "`+base64.StdEncoding.EncodeToString([]byte("I'm a teapot"))+`"
+		}`)
+
+		require.Equal(t, http.StatusOK, status)
+		require.Equal(t, sID, resp.Uuid.String())
+		require.EqualValues(t, http.StatusTeapot, resp.Response.StatusCode)
+		require.Equal(t, "foo", resp.Name) // the description is preserved
+
+		select {
+		case event := <-sub:
+			require.Equal(t, pubsub.RequestActionSessionUpdate, event.Action)
+		case <-time.After(time.Second):
+			t.Fatal("the session update event is not received")
+		}
+
+		// the webhook responds with the new options
+		var whStatus, whBody, whHeaders = sendRequest(t, http.MethodGet, baseUrl+"/"+sID)
+
+		require.Equal(t, http.StatusTeapot, whStatus)
+		require.Equal(t, "I'm a teapot", string(whBody))
+		require.Equal(t, "bar", whHeaders.Get("X-Foo"))
+
+		all, err := db.GetAllRequests(ctx, sID) // the requests are preserved
+		require.NoError(t, err)
+		require.Len(t, all, 2)
+	})
+
+	t.Run("bad request", func(t *testing.T) {
+		t.Parallel()
+
+		sID, err := db.NewSession(ctx, storage.Session{Code: http.StatusOK})
+		require.NoError(t, err)
+
+		for _, body := range []string{
+			`{"status_code": 100, "headers": [], "delay": 0, "response_body_base64": ""}`,
+			`{"status_code": 200, "headers": [], "delay": 31, "response_body_base64": ""}`,
+			`{"status_code": 200, "headers": [], "delay": 0, "response_body_base64": "!"}`,
+			`{"status_code": 200, "headers": [], "delay": 0, "response_body_base64": "e3s=", "templated": true}`,
+			`{`,
+		} {
+			var status, _ = put(t, sID, body)
+
+			require.Equal(t, http.StatusBadRequest, status, body)
+		}
+
+		sess, err := db.GetSession(ctx, sID) // untouched
+		require.NoError(t, err)
+		require.EqualValues(t, http.StatusOK, sess.Code)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		t.Parallel()
+
+		var status, _ = put(t, "9b6bbab9-c197-4dd3-bc3f-3cb6253820c7",
+			`{"status_code": 200, "headers": [], "delay": 0, "response_body_base64": ""}`,
+		)
+
+		require.Equal(t, http.StatusNotFound, status)
+	})
+}
+
+func TestServer_SessionSlugs(t *testing.T) {
+	t.Parallel()
+
+	var (
+		ctx = context.Background()
+		log = zap.NewNop()
+		srv = appHttp.NewServer(ctx, log)
+		db  = storage.NewInMemory(time.Minute, 8)
+	)
+
+	t.Cleanup(func() { require.NoError(t, db.Close()) })
+
+	policy, err := slug.NewPolicy(slug.DefaultPattern, "api", "ready", "healthz", "robots.txt")
+	require.NoError(t, err)
+
+	srv.Register(
+		context.Background(),
+		log,
+		func(context.Context) error { return nil },
+		func(context.Context) (string, error) { return "v1.0.0", nil },
+		&config.AppSettings{SlugPolicy: policy},
+		db,
+		pubsub.NewInMemory[pubsub.RequestEvent](),
+		false,
+	)
+
+	var baseUrl, stop = startServer(t, ctx, srv)
+
+	t.Cleanup(stop)
+
+	var do = func(t *testing.T, method, url, body string) (int, []string) {
+		t.Helper()
+
+		req, rErr := http.NewRequestWithContext(ctx, method, baseUrl+url, strings.NewReader(body))
+		require.NoError(t, rErr)
+
+		resp, rErr := http.DefaultClient.Do(req)
+		require.NoError(t, rErr)
+
+		defer func() { _ = resp.Body.Close() }()
+
+		if resp.StatusCode != http.StatusOK {
+			return resp.StatusCode, nil
+		}
+
+		var payload openapi.SessionSlugsResponse
+
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&payload))
+
+		return resp.StatusCode, payload
+	}
+
+	t.Run("settings", func(t *testing.T) {
+		t.Parallel()
+
+		var status, body, _ = sendRequest(t, http.MethodGet, baseUrl+"/api/settings")
+
+		require.Equal(t, http.StatusOK, status)
+		require.Contains(t, string(body), `"slug_pattern"`)
+	})
+
+	t.Run("add, capture and delete", func(t *testing.T) {
+		t.Parallel()
+
+		sID, sErr := db.NewSession(ctx, storage.Session{Code: http.StatusAccepted})
+		require.NoError(t, sErr)
+
+		var status, slugs = do(t, http.MethodPost, "/api/session/"+sID+"/slugs", `{"slug": "hooks/stripe-staging"}`)
+
+		require.Equal(t, http.StatusOK, status)
+		require.Equal(t, []string{"hooks/stripe-staging"}, slugs)
+
+		status, slugs = do(t, http.MethodPost, "/api/session/"+sID+"/slugs", `{"slug": "github"}`)
+
+		require.Equal(t, http.StatusOK, status)
+		require.Equal(t, []string{"github", "hooks/stripe-staging"}, slugs)
+
+		status, slugs = do(t, http.MethodGet, "/api/session/"+sID+"/slugs", "")
+
+		require.Equal(t, http.StatusOK, status)
+		require.Equal(t, []string{"github", "hooks/stripe-staging"}, slugs)
+
+		// the webhook is captured by the slug
+		status, _, _ = sendRequest(t, http.MethodPost, baseUrl+"/hooks/stripe-staging/some/path")
+		require.Equal(t, http.StatusAccepted, status)
+
+		requests, rErr := db.GetAllRequests(ctx, sID)
+		require.NoError(t, rErr)
+		require.Len(t, requests, 1)
+
+		for _, r := range requests {
+			require.True(t, strings.HasSuffix(r.URL, "/hooks/stripe-staging/some/path"), r.URL)
+		}
+
+		status, slugs = do(t, http.MethodDelete, "/api/session/"+sID+"/slugs?slug=hooks%2Fstripe-staging", "")
+
+		require.Equal(t, http.StatusOK, status)
+		require.Equal(t, []string{"github"}, slugs)
+
+		status, _ = do(t, http.MethodDelete, "/api/session/"+sID+"/slugs?slug=hooks%2Fstripe-staging", "")
+		require.Equal(t, http.StatusNotFound, status)
+
+		// the released slug is not captured anymore
+		status, _, _ = sendRequest(t, http.MethodPost, baseUrl+"/hooks/stripe-staging")
+		require.NotEqual(t, http.StatusAccepted, status)
+	})
+
+	t.Run("taken", func(t *testing.T) {
+		t.Parallel()
+
+		sID1, sErr := db.NewSession(ctx, storage.Session{})
+		require.NoError(t, sErr)
+
+		sID2, sErr := db.NewSession(ctx, storage.Session{})
+		require.NoError(t, sErr)
+
+		var status, _ = do(t, http.MethodPost, "/api/session/"+sID1+"/slugs", `{"slug": "taken"}`)
+		require.Equal(t, http.StatusOK, status)
+
+		status, _ = do(t, http.MethodPost, "/api/session/"+sID2+"/slugs", `{"slug": "taken"}`)
+		require.Equal(t, http.StatusConflict, status)
+	})
+
+	t.Run("bad requests", func(t *testing.T) {
+		t.Parallel()
+
+		sID, sErr := db.NewSession(ctx, storage.Session{})
+		require.NoError(t, sErr)
+
+		for _, body := range []string{
+			`{"slug": "api/foo"}`,
+			`{"slug": "healthz"}`,
+			`{"slug": "robots.txt"}`,
+			`{"slug": "Stripe"}`,
+			`{"slug": ""}`,
+			`{"slug": "` + sID + `"}`,
+			`{broken json`,
+		} {
+			var status, _ = do(t, http.MethodPost, "/api/session/"+sID+"/slugs", body)
+
+			require.Equal(t, http.StatusBadRequest, status, body)
+		}
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		t.Parallel()
+
+		const unknown = "00000000-0000-0000-0000-000000000000"
+
+		var status, _ = do(t, http.MethodGet, "/api/session/"+unknown+"/slugs", "")
+		require.Equal(t, http.StatusNotFound, status)
+
+		status, _ = do(t, http.MethodPost, "/api/session/"+unknown+"/slugs", `{"slug": "unknown"}`)
+		require.Equal(t, http.StatusNotFound, status)
+
+		status, _ = do(t, http.MethodDelete, "/api/session/"+unknown+"/slugs?slug=unknown", "")
+		require.Equal(t, http.StatusNotFound, status)
+	})
+}
+
+func TestServer_Metrics(t *testing.T) {
+	t.Parallel()
+
+	var (
+		ctx = context.Background()
+		log = zap.NewNop()
+		m   = metrics.New()
+		db  = metrics.NewStorage(storage.NewInMemory(time.Minute, 8), "memory", m)
+	)
+
+	sID, err := db.NewSession(ctx, storage.Session{Code: http.StatusAccepted})
+	require.NoError(t, err)
+
+	t.Run("main port", func(t *testing.T) {
+		t.Parallel()
+
+		var srv = appHttp.NewServer(ctx, log).Register(
+			context.Background(),
+			log,
+			func(context.Context) error { return nil },
+			func(context.Context) (string, error) { return "v1.0.0", nil },
+			&config.AppSettings{Metrics: m, ServeMetrics: true},
+			db,
+			metrics.NewPubSub(pubsub.NewInMemory[pubsub.RequestEvent](), "memory", m),
+			false,
+		)
+
+		var baseUrl, stop = startServer(t, ctx, srv)
+
+		t.Cleanup(stop)
+
+		var status, _, _ = sendRequest(t, http.MethodPut, baseUrl+"/"+sID+"/foo")
+		require.Equal(t, http.StatusAccepted, status)
+
+		status, _, _ = sendRequest(t, http.MethodPatch, baseUrl+"/"+sID+"/404")
+		require.Equal(t, http.StatusNotFound, status)
+
+		status, body, headers := sendRequest(t, http.MethodGet, baseUrl+"/metrics")
+
+		require.Equal(t, http.StatusOK, status)
+		require.Contains(t, headers.Get("Content-Type"), "text/plain")
+		require.Contains(t, string(body), `webhook_tester_captured_webhooks_total{method="PUT",status="202"} 1`)
+		require.Contains(t, string(body), `webhook_tester_captured_webhooks_total{method="PATCH",status="404"} 1`)
+		require.Contains(t, string(body), `webhook_tester_sessions_total{action="created",driver="memory"} 1`)
+		require.Contains(t, string(body), `webhook_tester_storage_operation_duration_seconds_count{driver="memory",operation="new_request"}`) //nolint:lll
+	})
+
+	t.Run("separate port", func(t *testing.T) {
+		t.Parallel()
+
+		var srv = appHttp.NewServer(ctx, log).Register(
+			context.Background(),
+			log,
+			func(context.Context) error { return nil },
+			func(context.Context) (string, error) { return "v1.0.0", nil },
+			&config.AppSettings{Metrics: m},
+			db,
+			pubsub.NewInMemory[pubsub.RequestEvent](),
+			false,
+		)
+
+		var baseUrl, stop = startServer(t, ctx, srv)
+
+		t.Cleanup(stop)
+
+		var _, body, _ = sendRequest(t, http.MethodGet, baseUrl+"/metrics")
+
+		require.NotContains(t, string(body), "webhook_tester_") // not exposed on the main port (the SPA is served)
+
+		var metricsUrl, stopMetrics = startServer(t, ctx, appHttp.NewServer(ctx, log).RegisterMetrics(m.Handler()))
+
+		t.Cleanup(stopMetrics)
+
+		var status int
+
+		status, body, _ = sendRequest(t, http.MethodGet, metricsUrl+"/metrics")
+
+		require.Equal(t, http.StatusOK, status)
+		require.Contains(t, string(body), "webhook_tester_sessions_total")
+
+		status, _, _ = sendRequest(t, http.MethodGet, metricsUrl+"/")
+		require.Equal(t, http.StatusNotFound, status)
+	})
+}
+
+func TestServer_Tracing(t *testing.T) {
+	t.Parallel()
+
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+
+	var (
+		ctx = context.Background()
+		log = zap.NewNop()
+		sr  = tracetest.NewSpanRecorder()
+		tp  = sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr))
+		db  = tracing.NewStorage(storage.NewInMemory(time.Minute, 8), "memory", tp)
+	)
+
+	t.Cleanup(func() { _ = tp.Shutdown(ctx) })
+
+	var srv = appHttp.NewServer(ctx, log).Register(
+		context.Background(),
+		log,
+		func(context.Context) error { return nil },
+		func(context.Context) (string, error) { return "v1.0.0", nil },
+		&config.AppSettings{TracerProvider: tp},
+		db,
+		tracing.NewPubSub(pubsub.NewInMemory[pubsub.RequestEvent](), "memory", tp),
+		false,
+	)
+
+	var baseUrl, stop = startServer(t, ctx, srv)
+
+	t.Cleanup(stop)
+
+	sID, err := db.NewSession(ctx, storage.Session{Code: http.StatusOK})
+	require.NoError(t, err)
+
+	// the webhook with the incoming W3C trace context
+	var status, _, headers = sendRequest(t, http.MethodPost, baseUrl+"/"+sID, map[string]string{
+		"traceparent": "00-" + traceID + "-00f067aa0ba902b7-01",
+	})
+
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, traceID, headers.Get("X-Trace-Id"))
+
+	var rID = headers.Get("X-Wh-Request-Id")
+
+	// the trace ID is stored alongside the captured request
+	status, body, _ := sendRequest(t, http.MethodGet, baseUrl+"/api/session/"+sID+"/requests/"+rID)
+	require.Equal(t, http.StatusOK, status)
+
+	var captured openapi.CapturedRequest
+
+	require.NoError(t, json.Unmarshal(body, &captured))
+	require.NotNil(t, captured.TraceId)
+	require.Equal(t, traceID, *captured.TraceId)
+
+	// a new trace is started for the request without the trace context
+	status, _, headers = sendRequest(t, http.MethodGet, baseUrl+"/api/session/"+sID+"/requests")
+	require.Equal(t, http.StatusOK, status)
+	require.NotEmpty(t, headers.Get("X-Trace-Id"))
+	require.NotEqual(t, traceID, headers.Get("X-Trace-Id"))
+
+	// the service routes are not traced
+	status, _, headers = sendRequest(t, http.MethodGet, baseUrl+"/healthz")
+	require.Equal(t, http.StatusOK, status)
+	require.Empty(t, headers.Get("X-Trace-Id"))
+
+	require.Eventually(t, func() bool { // the event is published asynchronously
+		var names = make(map[string]struct{})
+
+		for _, span := range sr.Ended() {
+			if span.SpanContext().TraceID().String() == traceID {
+				names[span.Name()] = struct{}{}
+			}
+		}
+
+		for _, name := range []string{"HTTP POST", "webhook.capture", "storage.new_request", "pubsub.publish"} {
+			if _, ok := names[name]; !ok {
+				return false
+			}
+		}
+
+		return true
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestServer_HTTPS(t *testing.T) {
+	t.Parallel()
+
+	var (
+		ctx = context.Background()
+		log = zap.NewNop()
+		db  = storage.NewInMemory(time.Minute, 8)
+	)
+
+	t.Cleanup(func() { _ = db.Close() })
+
+	serverCert, err := certs.SelfSigned()
+	require.NoError(t, err)
+
+	clientCert, err := certs.SelfSigned()
+	require.NoError(t, err)
+
+	httpsLn, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	var httpsPort = uint16(httpsLn.Addr().(*net.TCPAddr).Port) //nolint:gosec
+
+	var srv = appHttp.NewServer(ctx, log, appHttp.WithTLSConfig(&tls.Config{ //nolint:gosec
+		Certificates: []tls.Certificate{*serverCert},
+		ClientAuth:   tls.RequestClientCert,
+	})).Register(
+		context.Background(),
+		log,
+		func(context.Context) error { return nil },
+		func(context.Context) (string, error) { return "v1.0.0", nil },
+		&config.AppSettings{HTTPSRedirectPort: httpsPort},
+		db,
+		pubsub.NewInMemory[pubsub.RequestEvent](),
+		false,
+	)
+
+	var httpUrl, stop = startServer(t, ctx, srv) // the same server serves the plain HTTP
+
+	t.Cleanup(stop)
+
+	go func() { _ = srv.StartHTTPS(ctx, httpsLn) }()
+
+	sID, err := db.NewSession(ctx, storage.Session{Code: http.StatusAccepted})
+	require.NoError(t, err)
+
+	var roots = x509.NewCertPool()
+
+	roots.AddCert(serverCert.Leaf)
+
+	var (
+		httpsUrl = fmt.Sprintf("https://127.0.0.1:%d", httpsPort)
+		client   = http.Client{
+			Transport: &http.Transport{TLSClientConfig: &tls.Config{ //nolint:gosec
+				RootCAs:      roots,
+				ServerName:   "localhost", // sent as SNI
+				Certificates: []tls.Certificate{*clientCert},
+			}},
+			CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+		}
+	)
+
+	var do = func(t *testing.T, method, url string) (int, []byte, http.Header) {
+		t.Helper()
+
+		req, rErr := http.NewRequest(method, url, http.NoBody)
+		require.NoError(t, rErr)
+
+		resp, rErr := client.Do(req)
+		require.NoError(t, rErr)
+
+		body, _ := io.ReadAll(resp.Body)
+		require.NoError(t, resp.Body.Close())
+
+		return resp.StatusCode, body, resp.Header
+	}
+
+	t.Run("redirect", func(t *testing.T) {
+		var status, _, headers = do(t, http.MethodPost, httpUrl+"/"+sID+"/foo?bar=baz")
+
+		require.Equal(t, http.StatusPermanentRedirect, status)
+		require.Equal(t, httpsUrl+"/"+sID+"/foo?bar=baz", headers.Get("Location"))
+
+		status, _, _ = do(t, http.MethodGet, httpUrl+"/healthz") // the probes are not redirected
+		require.Equal(t, http.StatusOK, status)
+	})
+
+	t.Run("tls details", func(t *testing.T) {
+		var status, _, headers = do(t, http.MethodPost, httpsUrl+"/"+sID)
+
+		require.Equal(t, http.StatusAccepted, status)
+
+		status, body, _ := do(t, http.MethodGet, httpsUrl+"/api/session/"+sID+"/requests/"+headers.Get("X-Wh-Request-Id"))
+		require.Equal(t, http.StatusOK, status)
+
+		var captured openapi.CapturedRequest
+
+		require.NoError(t, json.Unmarshal(body, &captured))
+		require.True(t, strings.HasPrefix(captured.Url, "https://"))
+		require.NotNil(t, captured.Tls)
+		require.Equal(t, "TLS 1.3", captured.Tls.Version)
+		require.NotEmpty(t, captured.Tls.CipherSuite)
+		require.Equal(t, "localhost", captured.Tls.ServerName)
+		require.NotNil(t, captured.Tls.ClientCertificates)
+		require.Len(t, *captured.Tls.ClientCertificates, 1)
+		require.Nil(t, captured.Tls.ClientAuth) // the client certificates are not verified by default
+
+		var leaf = (*captured.Tls.ClientCertificates)[0]
+
+		require.Equal(t, clientCert.Leaf.Subject.String(), leaf.Subject)
+		require.Equal(t, clientCert.Leaf.SerialNumber.Text(16), leaf.SerialNumber)
+		require.Equal(t, clientCert.Leaf.NotAfter.UnixMilli(), leaf.NotAfterUnixMilli)
+		require.Contains(t, leaf.Sans, "DNS:localhost")
+		require.Contains(t, leaf.Sans, "IP:127.0.0.1")
+		require.Len(t, leaf.Sha1Fingerprint, 40)
+		require.Len(t, leaf.Sha256Fingerprint, 64)
+	})
+
+	t.Run("session client auth without the default mode", func(t *testing.T) {
+		var put = func(t *testing.T, mode string) (int, []byte) {
+			t.Helper()
+
+			req, rErr := http.NewRequest(
+				http.MethodPut, httpsUrl+"/api/session/"+sID+"/client-auth", strings.NewReader(`{"mode":"`+mode+`"}`),
+			)
+			require.NoError(t, rErr)
+
+			resp, rErr := client.Do(req)
+			require.NoError(t, rErr)
+
+			body, _ := io.ReadAll(resp.Body)
+			require.NoError(t, resp.Body.Close())
+
+			return resp.StatusCode, body
+		}
+
+		// the client certificates are not requested by the server, so the session mode would be ignored
+		for _, mode := range []string{storage.ClientAuthRequest, storage.ClientAuthRequire} {
+			var status, body = put(t, mode)
+
+			require.Equal(t, http.StatusBadRequest, status)
+			require.Contains(t, string(body), "not requested by the server")
+		}
+
+		for _, mode := range []string{storage.ClientAuthNone, ""} {
+			var status, _ = put(t, mode)
+
+			require.Equal(t, http.StatusOK, status)
+		}
+
+		var status, body, _ = do(t, http.MethodGet, httpsUrl+"/api/session/"+sID+"/client-auth")
+
+		require.Equal(t, http.StatusOK, status)
+		require.JSONEq(t, `{"mode":""}`, string(body))
+	})
+
+	t.Run("plain http", func(t *testing.T) {
+		sID, err := db.NewSession(ctx, storage.Session{Code: http.StatusOK})
+		require.NoError(t, err)
+
+		// no redirect without the HTTPS port configured
+		var plain = appHttp.NewServer(ctx, log).Register(
+			context.Background(),
+			log,
+			func(context.Context) error { return nil },
+			func(context.Context) (string, error) { return "v1.0.0", nil },
+			&config.AppSettings{},
+			db,
+			pubsub.NewInMemory[pubsub.RequestEvent](),
+			false,
+		)
+
+		var baseUrl, stopPlain = startServer(t, ctx, plain)
+
+		t.Cleanup(stopPlain)
+
+		var status, _, headers = sendRequest(t, http.MethodPost, baseUrl+"/"+sID)
+		require.Equal(t, http.StatusOK, status)
+
+		captured, err := db.GetRequest(ctx, sID, headers.Get("X-Wh-Request-Id"))
+		require.NoError(t, err)
+		require.Nil(t, captured.TLS)
+
+		require.Error(t, plain.StartHTTPS(ctx, nil)) // no TLS configuration
+	})
+}
+
+func TestServer_MutualTLS(t *testing.T) {
+	t.Parallel()
+
+	var (
+		ctx = context.Background()
+		log = zap.NewNop()
+		db  = storage.NewInMemory(time.Minute, 8)
+	)
+
+	t.Cleanup(func() { _ = db.Close() })
+
+	serverCert, err := certs.SelfSigned()
+	require.NoError(t, err)
+
+	var (
+		ca, caKey         = newTestCA(t, "Partners CA")
+		trustedCert       = newTestClientCert(t, ca, caKey, "trusted-partner")
+		otherCA, otherKey = newTestCA(t, "Unknown CA")
+		untrustedCert     = newTestClientCert(t, otherCA, otherKey, "unknown-partner")
+		clientCAs         = x509.NewCertPool()
+	)
+
+	clientCAs.AddCert(ca)
+
+	httpsLn, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	var srv = appHttp.NewServer(ctx, log, appHttp.WithTLSConfig(&tls.Config{ //nolint:gosec
+		Certificates: []tls.Certificate{*serverCert},
+		ClientAuth:   tls.RequestClientCert, // the verification is done by the app
+	})).Register(
+		context.Background(),
+		log,
+		func(context.Context) error { return nil },
+		func(context.Context) (string, error) { return "v1.0.0", nil },
+		&config.AppSettings{TLSClientAuth: storage.ClientAuthRequest, TLSClientCAs: clientCAs},
+		db,
+		pubsub.NewInMemory[pubsub.RequestEvent](),
+		false,
+	)
+
+	var httpUrl, stop = startServer(t, ctx, srv)
+
+	t.Cleanup(stop)
+
+	go func() { _ = srv.StartHTTPS(ctx, httpsLn) }()
+
+	var httpsUrl = "https://" + httpsLn.Addr().String()
+
+	var do = func(t *testing.T, cert *tls.Certificate, method, url string, body ...string) (int, []byte, http.Header) {
+		t.Helper()
+
+		var tlsCfg = tls.Config{InsecureSkipVerify: true} //nolint:gosec
+
+		if cert != nil {
+			tlsCfg.Certificates = []tls.Certificate{*cert}
+		}
+
+		var reqBody io.Reader = http.NoBody
+
+		if len(body) > 0 {
+			reqBody = strings.NewReader(body[0])
+		}
+
+		req, rErr := http.NewRequest(method, url, reqBody)
+		require.NoError(t, rErr)
+
+		resp, rErr := (&http.Client{Transport: &http.Transport{TLSClientConfig: &tlsCfg}}).Do(req)
+		require.NoError(t, rErr)
+
+		respBody, _ := io.ReadAll(resp.Body)
+		require.NoError(t, resp.Body.Close())
+
+		return resp.StatusCode, respBody, resp.Header
+	}
+
+	var captured = func(t *testing.T, sID, rID string) *storage.Request {
+		t.Helper()
+
+		req, gErr := db.GetRequest(ctx, sID, rID)
+		require.NoError(t, gErr)
+		require.NotNil(t, req.TLS)
+
+		return req
+	}
+
+	sID, err := db.NewSession(ctx, storage.Session{Code: http.StatusOK})
+	require.NoError(t, err)
+
+	t.Run("settings", func(t *testing.T) {
+		var status, body, _ = sendRequest(t, http.MethodGet, httpUrl+"/api/settings")
+		require.Equal(t, http.StatusOK, status)
+
+		var settings openapi.AppSettings
+
+		require.NoError(t, json.Unmarshal(body, &settings))
+		require.NotNil(t, settings.TlsClientAuth)
+		require.Equal(t, storage.ClientAuthRequest, *settings.TlsClientAuth)
+	})
+
+	t.Run("request mode", func(t *testing.T) {
+		// the trusted certificate is verified
+		var status, _, headers = do(t, trustedCert, http.MethodPost, httpsUrl+"/"+sID)
+		require.Equal(t, http.StatusOK, status)
+
+		var req = captured(t, sID, headers.Get("X-Wh-Request-Id"))
+
+		require.Len(t, req.TLS.ClientCerts, 1)
+		require.Equal(t, "CN=trusted-partner,O=Partner", req.TLS.ClientCerts[0].Subject)
+		require.Equal(t, "CN=Partners CA,O=Partner", req.TLS.ClientCerts[0].Issuer)
+		require.Equal(t, []string{"DNS:trusted-partner.example.com", "email:ops@example.com"}, req.TLS.ClientCerts[0].SANs)
+		require.Equal(t, &storage.ClientAuthResult{Mode: storage.ClientAuthRequest, Verified: true}, req.TLS.ClientAuth)
+
+		// the untrusted one is flagged, but the request is handled as usual
+		status, _, headers = do(t, untrustedCert, http.MethodPost, httpsUrl+"/"+sID)
+		require.Equal(t, http.StatusOK, status)
+
+		req = captured(t, sID, headers.Get("X-Wh-Request-Id"))
+
+		require.Len(t, req.TLS.ClientCerts, 1)
+		require.False(t, req.TLS.ClientAuth.Verified)
+		require.False(t, req.TLS.ClientAuth.Rejected)
+		require.Contains(t, req.TLS.ClientAuth.Error, "unknown authority")
+
+		// as well as the missing one
+		status, _, headers = do(t, nil, http.MethodPost, httpsUrl+"/"+sID)
+		require.Equal(t, http.StatusOK, status)
+
+		req = captured(t, sID, headers.Get("X-Wh-Request-Id"))
+
+		require.Empty(t, req.TLS.ClientCerts)
+		require.Equal(t, "no client certificate presented", req.TLS.ClientAuth.Error)
+	})
+
+	t.Run("require mode (per session)", func(t *testing.T) {
+		var status, body, _ = sendRequest(t, http.MethodGet, httpUrl+"/api/session/"+sID+"/client-auth")
+		require.Equal(t, http.StatusOK, status)
+		require.JSONEq(t, `{"mode":""}`, string(body))
+
+		status, body, _ = do(t, nil, http.MethodPut, httpUrl+"/api/session/"+sID+"/client-auth", `{"mode":"foo"}`)
+		require.Equal(t, http.StatusBadRequest, status, string(body))
+
+		const unknown = "00000000-0000-0000-0000-000000000000"
+
+		status, _, _ = do(t, nil, http.MethodPut, httpUrl+"/api/session/"+unknown+"/client-auth", `{"mode":""}`)
+		require.Equal(t, http.StatusNotFound, status)
+
+		status, body, _ = do(t, nil, http.MethodPut, httpUrl+"/api/session/"+sID+"/client-auth", `{"mode":"require"}`)
+		require.Equal(t, http.StatusOK, status)
+		require.JSONEq(t, `{"mode":"require"}`, string(body))
+
+		// the request with the untrusted certificate is captured, but rejected
+		status, body, headers := do(t, untrustedCert, http.MethodPost, httpsUrl+"/"+sID)
+		require.Equal(t, http.StatusForbidden, status)
+		require.Contains(t, string(body), "Client certificate verification failed")
+
+		var req = captured(t, sID, headers.Get("X-Wh-Request-Id"))
+
+		require.True(t, req.TLS.ClientAuth.Rejected)
+		require.Equal(t, storage.ClientAuthRequire, req.TLS.ClientAuth.Mode)
+
+		// the request via the API contains the verification result
+		var rID = headers.Get("X-Wh-Request-Id")
+
+		status, body, _ = sendRequest(t, http.MethodGet, httpUrl+"/api/session/"+sID+"/requests/"+rID)
+		require.Equal(t, http.StatusOK, status)
+
+		var apiReq openapi.CapturedRequest
+
+		require.NoError(t, json.Unmarshal(body, &apiReq))
+		require.NotNil(t, apiReq.Tls.ClientAuth)
+		require.True(t, apiReq.Tls.ClientAuth.Rejected)
+		require.False(t, apiReq.Tls.ClientAuth.Verified)
+
+		// the trusted certificate is accepted
+		status, _, _ = do(t, trustedCert, http.MethodPost, httpsUrl+"/"+sID)
+		require.Equal(t, http.StatusOK, status)
+
+		// the plain HTTP requests are not affected
+		status, _, _ = sendRequest(t, http.MethodPost, httpUrl+"/"+sID)
+		require.Equal(t, http.StatusOK, status)
+
+		// the client certificates can be ignored for the session
+		status, _, _ = do(t, nil, http.MethodPut, httpUrl+"/api/session/"+sID+"/client-auth", `{"mode":"none"}`)
+		require.Equal(t, http.StatusOK, status)
+
+		status, _, headers = do(t, untrustedCert, http.MethodPost, httpsUrl+"/"+sID)
+		require.Equal(t, http.StatusOK, status)
+
+		req = captured(t, sID, headers.Get("X-Wh-Request-Id"))
+
+		require.Len(t, req.TLS.ClientCerts, 1) // still recorded
+		require.Nil(t, req.TLS.ClientAuth)
+	})
+}
+
+// newTestCA creates a new self-signed CA certificate (and its key) for the tests.
+func newTestCA(t *testing.T, name string) (*x509.Certificate, *ecdsa.PrivateKey) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	der, err := x509.CreateCertificate(rand.Reader, &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: name, Organization: []string{"Partner"}},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}, &x509.Certificate{Subject: pkix.Name{CommonName: name, Organization: []string{"Partner"}}}, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	return cert, key
+}
+
+// newTestClientCert issues a new client certificate, signed by the CA, for the tests.
+func newTestClientCert(t *testing.T, ca *x509.Certificate, caKey *ecdsa.PrivateKey, name string) *tls.Certificate {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	der, err := x509.CreateCertificate(rand.Reader, &x509.Certificate{
+		SerialNumber:   big.NewInt(time.Now().UnixNano()),
+		Subject:        pkix.Name{CommonName: name, Organization: []string{"Partner"}},
+		DNSNames:       []string{name + ".example.com"},
+		EmailAddresses: []string{"ops@example.com"},
+		NotBefore:      time.Now().Add(-time.Hour),
+		NotAfter:       time.Now().Add(time.Hour),
+		KeyUsage:       x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:    []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}, ca, &key.PublicKey, caKey)
+	require.NoError(t, err)
+
+	return &tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+func TestServer_RateLimits(t *testing.T) {
+	t.Parallel()
+
+	var (
+		ctx  = context.Background()
+		log  = zap.NewNop()
+		m    = metrics.New()
+		db   = storage.NewInMemory(time.Minute, 8)
+		rate = ratelimit.Rate{Limit: 1, Period: time.Hour}
+	)
+
+	t.Cleanup(func() { _ = db.Close() })
+
+	var srv = appHttp.NewServer(ctx, log).Register(
+		context.Background(),
+		log,
+		func(context.Context) error { return nil },
+		func(context.Context) (string, error) { return "v1.0.0", nil },
+		&config.AppSettings{
+			Metrics:      m,
+			ServeMetrics: true,
+			RateLimits: &ratelimit.Limits{
+				PerSession:    ratelimit.NewInMemory(rate),
+				SessionCreate: ratelimit.NewInMemory(rate),
+				RecordDropped: true,
+			},
+		},
+		db,
+		pubsub.NewInMemory[pubsub.RequestEvent](),
+		false,
+	)
+
+	var baseUrl, stop = startServer(t, ctx, srv)
+
+	t.Cleanup(stop)
+
+	// the sessions creation is limited per client IP address
+	var createSession = func() *http.Response {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, baseUrl+"/api/session", strings.NewReader(
+			`{"status_code": 200, "headers": [], "delay": 0, "response_body_base64": ""}`,
+		))
+		require.NoError(t, err)
+
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+
+		return resp
+	}
+
+	var resp = createSession()
+
+	var created openapi.SessionOptionsResponse
+
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&created))
+	require.NoError(t, resp.Body.Close())
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp = createSession()
+
+	var errResp openapi.ErrorResponse
+
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&errResp))
+	require.NoError(t, resp.Body.Close())
+	require.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	require.Equal(t, "3600", resp.Header.Get("Retry-After"))
+	require.Equal(t, "too many requests", errResp.Error)
+
+	// the webhooks are limited per session, and the rejected ones are recorded as dropped
+	var sID = created.Uuid.String()
+
+	status, _, _ := sendRequest(t, http.MethodPost, baseUrl+"/"+sID)
+	require.Equal(t, http.StatusOK, status)
+
+	status, body, headers := sendRequest(t, http.MethodPost, baseUrl+"/"+sID)
+	require.Equal(t, http.StatusTooManyRequests, status)
+	require.NotEmpty(t, headers.Get("Retry-After"))
+	require.Contains(t, string(body), "Too many requests")
+
+	status, body, _ = sendRequest(t, http.MethodGet, baseUrl+"/api/session/"+sID+"/requests")
+	require.Equal(t, http.StatusOK, status)
+
+	var list openapi.CapturedRequestsListResponse
+
+	require.NoError(t, json.Unmarshal(body, &list))
+	require.Len(t, list, 2)
+
+	var dropped []string
+
+	for _, r := range list {
+		if r.Dropped != nil {
+			dropped = append(dropped, *r.Dropped)
+		}
+	}
+
+	require.Equal(t, []string{"rate limited (per session)"}, dropped)
+
+	// the rejected requests are counted
+	status, body, _ = sendRequest(t, http.MethodGet, baseUrl+"/metrics")
+	require.Equal(t, http.StatusOK, status)
+	require.Contains(t, string(body), `webhook_tester_rate_limited_total{scope="session"} 1`)
+	require.Contains(t, string(body), `webhook_tester_rate_limited_total{scope="session_create"} 1`)
+}
+
+func TestServer_SessionIPFilter(t *testing.T) {
+	t.Parallel()
+
+	var (
+		ctx = context.Background()
+		log = zap.NewNop()
+		db  = storage.NewInMemory(time.Minute, 8)
+	)
+
+	t.Cleanup(func() { _ = db.Close() })
+
+	var srv = appHttp.NewServer(ctx, log).Register(
+		context.Background(),
+		log,
+		func(context.Context) error { return nil },
+		func(context.Context) (string, error) { return "v1.0.0", nil },
+		&config.AppSettings{RecordBlocked: true},
+		db,
+		pubsub.NewInMemory[pubsub.RequestEvent](),
+		false,
+	)
+
+	var baseUrl, stop = startServer(t, ctx, srv)
+
+	t.Cleanup(stop)
+
+	sID, err := db.NewSession(ctx, storage.Session{Code: http.StatusOK})
+	require.NoError(t, err)
+
+	var setFilter = func(t *testing.T, id, body string) (int, []byte) {
+		t.Helper()
+
+		req, rErr := http.NewRequestWithContext(ctx,
+			http.MethodPut, baseUrl+"/api/session/"+id+"/ip-filter", strings.NewReader(body),
+		)
+		require.NoError(t, rErr)
+
+		resp, rErr := http.DefaultClient.Do(req)
+		require.NoError(t, rErr)
+
+		defer func() { _ = resp.Body.Close() }()
+
+		respBody, rErr := io.ReadAll(resp.Body)
+		require.NoError(t, rErr)
+
+		return resp.StatusCode, respBody
+	}
+
+	// no filter by default
+	status, body, _ := sendRequest(t, http.MethodGet, baseUrl+"/api/session/"+sID+"/ip-filter")
+	require.Equal(t, http.StatusOK, status)
+	require.JSONEq(t, `{"allow": [], "deny": []}`, string(body))
+
+	for _, give := range []string{
+		`{"allow": ["foo"], "deny": []}`,
+		`{"allow": [], "deny": ["10.0.0.0/33"]}`,
+		`{"allow": [""], "deny": []}`,
+	} {
+		status, _ = setFilter(t, sID, give)
+		require.Equal(t, http.StatusBadRequest, status, give)
+	}
+
+	status, _ = setFilter(t, "00000000-0000-0000-0000-000000000000", `{"allow": [], "deny": []}`)
+	require.Equal(t, http.StatusNotFound, status)
+
+	// allow the loopback IPv6 address only (the test client connects over IPv4)
+	status, body = setFilter(t, sID, `{"allow": ["::1"], "deny": []}`)
+	require.Equal(t, http.StatusOK, status)
+	require.JSONEq(t, `{"allow": ["::1"], "deny": []}`, string(body))
+
+	status, body, _ = sendRequest(t, http.MethodPost, baseUrl+"/"+sID)
+	require.Equal(t, http.StatusForbidden, status)
+	require.Contains(t, string(body), "127.0.0.1 is not in the allow list")
+
+	status, body, _ = sendRequest(t, http.MethodGet, baseUrl+"/api/session/"+sID+"/requests")
+	require.Equal(t, http.StatusOK, status)
+
+	var list openapi.CapturedRequestsListResponse
+
+	require.NoError(t, json.Unmarshal(body, &list))
+	require.Len(t, list, 1)
+	require.NotNil(t, list[0].Dropped)
+	require.Contains(t, *list[0].Dropped, "blocked by the session IP filter")
+
+	// remove the filter
+	status, _ = setFilter(t, sID, `{"allow": [], "deny": []}`)
+	require.Equal(t, http.StatusOK, status)
+
+	sess, err := db.GetSession(ctx, sID)
+	require.NoError(t, err)
+	require.Nil(t, sess.IPFilter)
+
+	status, _, _ = sendRequest(t, http.MethodPost, baseUrl+"/"+sID)
+	require.Equal(t, http.StatusOK, status)
+}
+
+func TestServer_SessionNotifications(t *testing.T) {
+	t.Parallel()
+
+	var (
+		ctx = context.Background()
+		log = zap.NewNop()
+		db  = storage.NewInMemory(time.Minute, 8)
+		ps  = pubsub.NewInMemory[pubsub.RequestEvent]()
+	)
+
+	t.Cleanup(func() { _ = db.Close() })
+
+	var received = make(chan []byte, 16)
+
+	var target = httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		select {
+		case received <- body:
+		default: // the test has already got what it needs
+		}
+	}))
+
+	t.Cleanup(target.Close)
+
+	anyHost, err := forward.NewHostPolicy(nil, nil) // the target listens on the loopback interface
+	require.NoError(t, err)
+
+	var notifier = notify.New(log, db, ps,
+		notify.WithHostPolicy(anyHost),
+		notify.WithRescanInterval(10*time.Millisecond),
+	)
+
+	var notifierCtx, stopNotifier = context.WithCancel(ctx)
+
+	t.Cleanup(stopNotifier)
+
+	go notifier.Run(notifierCtx)
+
+	var srv = appHttp.NewServer(ctx, log).Register(
+		context.Background(),
+		log,
+		func(context.Context) error { return nil },
+		func(context.Context) (string, error) { return "v1.0.0", nil },
+		&config.AppSettings{Notifier: notifier},
+		db,
+		ps,
+		false,
+	)
+
+	var baseUrl, stop = startServer(t, ctx, srv)
+
+	t.Cleanup(stop)
+
+	sID, err := db.NewSession(ctx, storage.Session{Code: http.StatusOK})
+	require.NoError(t, err)
+
+	var setTargets = func(t *testing.T, id, body string) (int, []byte) {
+		t.Helper()
+
+		req, rErr := http.NewRequestWithContext(ctx,
+			http.MethodPut, baseUrl+"/api/session/"+id+"/notifications", strings.NewReader(body),
+		)
+		require.NoError(t, rErr)
+
+		resp, rErr := http.DefaultClient.Do(req)
+		require.NoError(t, rErr)
+
+		defer func() { _ = resp.Body.Close() }()
+
+		respBody, rErr := io.ReadAll(resp.Body)
+		require.NoError(t, rErr)
+
+		return resp.StatusCode, respBody
+	}
+
+	// no targets by default
+	status, body, _ := sendRequest(t, http.MethodGet, baseUrl+"/api/session/"+sID+"/notifications")
+	require.Equal(t, http.StatusOK, status)
+	require.JSONEq(t, `[]`, string(body))
+
+	for _, give := range []string{
+		`[{"kind": "foo", "url": "https://example.com"}]`,
+		`[{"kind": "http", "url": "ftp://example.com"}]`,
+		`[{"kind": "http", "url": "https://example.com", "secret": "not base64!"}]`,
+		`[{"kind": "template", "url": "https://example.com", "template": "{{ .Foo "}]`,
+		`[` + strings.TrimSuffix(strings.Repeat(`{"kind": "http", "url": "https://example.com"},`, 11), ",") + `]`,
+	} {
+		status, _ = setTargets(t, sID, give)
+		require.Equal(t, http.StatusBadRequest, status, give)
+	}
+
+	status, _ = setTargets(t, "00000000-0000-0000-0000-000000000000", `[]`)
+	require.Equal(t, http.StatusNotFound, status)
+
+	var targets = `[{"kind": "template", "url": "` + target.URL + `", "template": "{\"method\": \"{{ .Method }}\"}"}]`
+
+	status, body = setTargets(t, sID, targets)
+	require.Equal(t, http.StatusOK, status)
+
+	var set openapi.SessionNotificationsResponse
+
+	require.NoError(t, json.Unmarshal(body, &set))
+	require.Len(t, set, 1)
+	require.Equal(t, "template", set[0].Kind)
+	require.Equal(t, target.URL, set[0].Url)
+	require.Empty(t, set[0].Deliveries)
+
+	// the webhooks are sent until the dispatcher starts watching the session
+	require.Eventually(t, func() bool {
+		status, _, _ = sendRequest(t, http.MethodPut, baseUrl+"/"+sID)
+		require.Equal(t, http.StatusOK, status)
+
+		select {
+		case got := <-received:
+			require.JSONEq(t, `{"method": "PUT"}`, string(got))
+
+			return true
+		case <-time.After(50 * time.Millisecond):
+			return false
+		}
+	}, 5*time.Second, time.Millisecond)
+
+	var got openapi.SessionNotificationsResponse
+
+	require.Eventually(t, func() bool {
+		status, body, _ = sendRequest(t, http.MethodGet, baseUrl+"/api/session/"+sID+"/notifications")
+		require.Equal(t, http.StatusOK, status)
+		require.NoError(t, json.Unmarshal(body, &got))
+
+		return len(got) == 1 && len(got[0].Deliveries) > 0
+	}, 5*time.Second, 5*time.Millisecond)
+
+	require.Equal(t, set[0].Uuid, got[0].Uuid)
+	require.EqualValues(t, 1, got[0].Deliveries[0].Attempts)
+	require.EqualValues(t, http.StatusOK, got[0].Deliveries[0].StatusCode)
+	require.Empty(t, got[0].Deliveries[0].Error)
+
+	// re-saving the same target keeps its state
+	status, body = setTargets(t, sID, targets)
+	require.Equal(t, http.StatusOK, status)
+	require.NoError(t, json.Unmarshal(body, &set))
+	require.Equal(t, got[0].Uuid, set[0].Uuid)
+	require.NotEmpty(t, set[0].Deliveries)
+
+	// the secret is write-only
+	const secret = "whsec_MfKQ9r8GKYqrTwjUPD8ILPZIo2LaLaSw"
+
+	status, body = setTargets(t, sID, `[{"kind": "http", "url": "https://example.com", "secret": "`+secret+`"}]`)
+	require.Equal(t, http.StatusOK, status)
+	require.NotContains(t, string(body), secret)
+	require.NoError(t, json.Unmarshal(body, &set))
+	require.True(t, set[0].HasSecret)
+
+	status, body, _ = sendRequest(t, http.MethodGet, baseUrl+"/api/session/"+sID+"/notifications")
+	require.Equal(t, http.StatusOK, status)
+	require.NotContains(t, string(body), secret)
+
+	var withSecret = set[0].Uuid
+
+	// re-saving the target without the secret keeps it
+	status, body = setTargets(t, sID, `[{"kind": "http", "url": "https://example.com"}]`)
+	require.Equal(t, http.StatusOK, status)
+	require.NoError(t, json.Unmarshal(body, &set))
+	require.True(t, set[0].HasSecret)
+	require.Equal(t, withSecret, set[0].Uuid)
+
+	sess, err := db.GetSession(ctx, sID)
+	require.NoError(t, err)
+	require.Equal(t, secret, sess.Notifications[0].Secret)
+
+	// and the empty one removes it
+	status, body = setTargets(t, sID, `[{"kind": "http", "url": "https://example.com", "secret": ""}]`)
+	require.Equal(t, http.StatusOK, status)
+	require.NoError(t, json.Unmarshal(body, &set))
+	require.False(t, set[0].HasSecret)
+
+	// remove the targets
+	status, body = setTargets(t, sID, `[]`)
+	require.Equal(t, http.StatusOK, status)
+	require.JSONEq(t, `[]`, string(body))
+
+	sess, err = db.GetSession(ctx, sID)
+	require.NoError(t, err)
+	require.Empty(t, sess.Notifications)
+}
+
+func TestServer_RequestsEvents(t *testing.T) {
+	t.Parallel()
+
+	var (
+		ctx = context.Background()
+		log = zap.NewNop()
+		db  = storage.NewInMemory(time.Minute, 8)
+		ps  = pubsub.NewInMemory[pubsub.RequestEvent]()
+	)
+
+	t.Cleanup(func() { _ = db.Close() })
+
+	var srv = appHttp.NewServer(ctx, log, appHttp.WithWriteTimeout(time.Second)).Register(
+		context.Background(),
+		log,
+		func(context.Context) error { return nil },
+		func(context.Context) (string, error) { return "v1.0.0", nil },
+		&config.AppSettings{},
+		db,
+		ps,
+		false,
+	)
+
+	var baseUrl, stop = startServer(t, ctx, srv)
+
+	t.Cleanup(stop)
+
+	sID, err := db.NewSession(ctx, storage.Session{Code: http.StatusOK})
+	require.NoError(t, err)
+
+	type event struct{ id, action, request string }
+
+	// subscribe opens the events stream and returns the function to read the next event (the comments and the events
+	// without data are skipped)
+	var subscribe = func(t *testing.T, lastEventID string) func() event {
+		t.Helper()
+
+		var reqCtx, cancel = context.WithTimeout(ctx, 10*time.Second)
+
+		t.Cleanup(cancel)
+
+		req, rErr := http.NewRequestWithContext(reqCtx, http.MethodGet, baseUrl+"/api/session/"+sID+"/requests/events", nil)
+		require.NoError(t, rErr)
+
+		if lastEventID != "" {
+			req.Header.Set("Last-Event-ID", lastEventID)
+		}
+
+		resp, rErr := http.DefaultClient.Do(req)
+		require.NoError(t, rErr)
+
+		t.Cleanup(func() { _ = resp.Body.Close() })
+
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+		require.Equal(t, "no-cache", resp.Header.Get("Cache-Control"))
+
+		var reader = bufio.NewReader(resp.Body)
+
+		return func() event {
+			var e event
+
+			for {
+				line, lErr := reader.ReadString('\n')
+				require.NoError(t, lErr)
+
+				switch line = strings.TrimSuffix(line, "\n"); {
+				case line == "" && e.action != "":
+					return e
+				case strings.HasPrefix(line, "id: "):
+					e.id = strings.TrimPrefix(line, "id: ")
+				case strings.HasPrefix(line, "data: "):
+					var data struct {
+						Action  string `json:"action"`
+						Request *struct {
+							UUID string `json:"uuid"`
+						} `json:"request"`
+					}
+
+					require.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &data))
+
+					if e.action = data.Action; data.Request != nil {
+						e.request = data.Request.UUID
+					}
+				}
+			}
+		}
+	}
+
+	var capture = func(t *testing.T) {
+		t.Helper()
+
+		status, _, _ := sendRequest(t, http.MethodPost, baseUrl+"/"+sID)
+		require.Equal(t, http.StatusOK, status)
+	}
+
+	// the live events
+	var next = subscribe(t, "")
+
+	capture(t)
+
+	var first = next()
+
+	require.Equal(t, "create", first.action)
+	require.NotEmpty(t, first.request)
+	require.Equal(t, first.request, first.id)
+
+	// the stream outlives the server write timeout
+	<-time.After(1500 * time.Millisecond)
+
+	capture(t)
+
+	var second = next()
+
+	require.Equal(t, "create", second.action)
+	require.NotEqual(t, first.request, second.request)
+
+	// the requests, captured while the client is disconnected, are replayed
+	capture(t)
+	capture(t)
+
+	next = subscribe(t, second.id)
+
+	var replayed = []string{next().request, next().request}
+
+	all, err := db.GetAllRequests(ctx, sID)
+	require.NoError(t, err)
+	require.Len(t, all, 4)
+
+	for _, id := range replayed {
+		require.Contains(t, all, id)
+		require.NotContains(t, []string{first.request, second.request}, id)
+	}
+
+	require.NotEqual(t, replayed[0], replayed[1])
+
+	// and the live events follow them (without the duplicates)
+	capture(t)
+
+	var live = next()
+
+	require.Equal(t, "create", live.action)
+	require.NotContains(t, append(replayed, first.request, second.request), live.request)
+
+	// all the stored requests are replayed for the unknown event ID
+	next = subscribe(t, "unknown")
+
+	var got = make(map[string]struct{})
+
+	for range 5 {
+		got[next().request] = struct{}{}
+	}
+
+	require.Len(t, got, 5)
+
+	// the events, which are not about the captured requests, have no ID
+	status, _, _ := sendRequest(t, http.MethodDelete, baseUrl+"/api/session/"+sID+"/requests")
+	require.Equal(t, http.StatusOK, status)
+
+	require.Equal(t, event{action: "clear"}, next())
+
+	// unknown session
+	status, _, _ = sendRequest(t, http.MethodGet, baseUrl+"/api/session/00000000-0000-0000-0000-000000000000/requests/events")
+	require.Equal(t, http.StatusNotFound, status)
+}
+
+func TestServer_SessionMeta(t *testing.T) {
+	t.Parallel()
+
+	var (
+		ctx = context.Background()
+		log = zap.NewNop()
+		srv = appHttp.NewServer(ctx, log)
+		db  = storage.NewInMemory(time.Minute, 8)
+		ps  = pubsub.NewInMemory[pubsub.RequestEvent]()
+	)
+
+	t.Cleanup(func() { require.NoError(t, db.Close()) })
+
+	srv.Register(
+		context.Background(),
+		log,
+		func(context.Context) error { return nil },
+		func(context.Context) (string, error) { return "v1.0.0", nil },
+		&config.AppSettings{},
+		db,
+		ps,
+		false,
+	)
+
+	var baseUrl, stop = startServer(t, ctx, srv)
+
+	t.Cleanup(stop)
+
+	var patch = func(t *testing.T, id, body string) (int, *openapi.SessionOptionsResponse) {
+		t.Helper()
+
+		req, rErr := http.NewRequestWithContext(ctx, http.MethodPatch, baseUrl+"/api/session/"+id, strings.NewReader(body))
+		require.NoError(t, rErr)
+
+		resp, rErr := http.DefaultClient.Do(req)
+		require.NoError(t, rErr)
+
+		defer func() { _ = resp.Body.Close() }()
+
+		if resp.StatusCode != http.StatusOK {
+			return resp.StatusCode, nil
+		}
+
+		var payload openapi.SessionOptionsResponse
+
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&payload))
+
+		return resp.StatusCode, &payload
+	}
+
+	t.Run("update", func(t *testing.T) {
+		t.Parallel()
+
+		sID, err := db.NewSession(ctx, storage.Session{Code: http.StatusAccepted})
+		require.NoError(t, err)
+
+		sub, unsubscribe, err := ps.Subscribe(ctx, sID)
+		require.NoError(t, err)
+
+		t.Cleanup(unsubscribe)
+
+		// the session without the description
+		var status, body, _ = sendRequest(t, http.MethodGet, baseUrl+"/api/session/"+sID)
+
+		require.Equal(t, http.StatusOK, status)
+		require.Contains(t, string(body), `"name":""`)
+		require.Contains(t, string(body), `"labels":[]`)
+		require.Contains(t, string(body), `"note":""`)
+
+		status, resp := patch(t, sID, `{"name": "GitHub", "labels": ["ci", "prod"], "note": "# Hello"}`)
+
+		require.Equal(t, http.StatusOK, status)
+		require.Equal(t, "GitHub", resp.Name)
+		require.Equal(t, []string{"ci", "prod"}, resp.Labels)
+		require.Equal(t, "# Hello", resp.Note)
+		require.EqualValues(t, http.StatusAccepted, resp.Response.StatusCode) // other options are untouched
+
+		select {
+		case event := <-sub:
+			require.Equal(t, pubsub.RequestActionSessionUpdate, event.Action)
+			require.Nil(t, event.Request)
+		case <-time.After(time.Second):
+			t.Fatal("the session update event is not received")
+		}
+
+		// the missing fields are not changed
+		status, resp = patch(t, sID, `{"labels": []}`)
+
+		require.Equal(t, http.StatusOK, status)
+		require.Equal(t, "GitHub", resp.Name)
+		require.Empty(t, resp.Labels)
+		require.Equal(t, "# Hello", resp.Note)
+
+		sess, err := db.GetSession(ctx, sID)
+		require.NoError(t, err)
+		require.Equal(t, storage.SessionMeta{Name: "GitHub", Note: "# Hello"}, sess.SessionMeta)
+	})
+
+	t.Run("bad request", func(t *testing.T) {
+		t.Parallel()
+
+		sID, err := db.NewSession(ctx, storage.Session{})
+		require.NoError(t, err)
+
+		for _, body := range []string{
+			`{"name": "` + strings.Repeat("a", 129) + `"}`,
+			`{"labels": [""]}`,
+			`{"labels": ["foo", "foo"]}`,
+			`{"labels": ["` + strings.Repeat("a", 65) + `"]}`,
+			`{"note": "` + strings.Repeat("a", 65537) + `"}`,
+			`{`,
+		} {
+			var status, _ = patch(t, sID, body)
+
+			require.Equal(t, http.StatusBadRequest, status, body)
+		}
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		t.Parallel()
+
+		var status, _ = patch(t, "9b6bbab9-c197-4dd3-bc3f-3cb6253820c7", `{"name": "foo"}`)
+
+		require.Equal(t, http.StatusNotFound, status)
+	})
+}
+
+func TestServer_Auth(t *testing.T) {
+	t.Parallel()
+
+	var (
+		ctx = context.Background()
+		log = zap.NewNop()
+		srv = appHttp.NewServer(ctx, log)
+		db  = storage.NewInMemory(time.Minute, 8)
+	)
+
+	t.Cleanup(func() { require.NoError(t, db.Close()) })
+
+	srv.Register(
+		context.Background(),
+		log,
+		func(context.Context) error { return nil },
+		func(context.Context) (string, error) { return "v1.0.0", nil },
+		&config.AppSettings{Authenticator: auth.Chain{
+			auth.NewStaticTokens([]auth.Credential{{User: "alice", Secret: "alice-token"}}),
+			auth.NewBasic([]auth.Credential{{User: "bob", Secret: "bob-password"}}),
+		}},
+		db,
+		pubsub.NewInMemory[pubsub.RequestEvent](),
+		false,
+	)
+
+	var baseUrl, stop = startServer(t, ctx, srv)
+
+	t.Cleanup(stop)
+
+	var (
+		asAlice = map[string]string{"Authorization": "Bearer alice-token"}
+		asBob   = map[string]string{"Authorization": "Basic " + base64.StdEncoding.EncodeToString([]byte("bob:bob-password"))}
+	)
+
+	aliceSID, err := db.NewSession(ctx, storage.Session{Code: http.StatusOK, Owner: "alice"})
+	require.NoError(t, err)
+
+	commonSID, err := db.NewSession(ctx, storage.Session{Code: http.StatusOK}) // without an owner
+	require.NoError(t, err)
+
+	t.Run("not authenticated", func(t *testing.T) {
+		t.Parallel()
+
+		for _, headers := range []map[string]string{
+			{},
+			{"Authorization": "Bearer wrong-token"},
+			{"Authorization": "Basic " + base64.StdEncoding.EncodeToString([]byte("bob:wrong"))},
+		} {
+			var status, body, respHeaders = sendRequest(t, http.MethodGet, baseUrl+"/api/session/"+aliceSID, headers)
+
+			require.Equal(t, http.StatusUnauthorized, status)
+			require.Contains(t, string(body), "credentials")
+			require.Contains(t, respHeaders.Get("WWW-Authenticate"), "Bearer")
+			require.Contains(t, respHeaders.Get("WWW-Authenticate"), "Basic")
+		}
+	})
+
+	t.Run("public routes", func(t *testing.T) {
+		t.Parallel()
+
+		var status, _, _ = sendRequest(t, http.MethodGet, baseUrl+"/healthz")
+		require.Equal(t, http.StatusOK, status)
+
+		status, _, _ = sendRequest(t, http.MethodPost, baseUrl+"/"+aliceSID) // webhook capture
+		require.Equal(t, http.StatusOK, status)
+	})
+
+	t.Run("owner", func(t *testing.T) {
+		t.Parallel()
+
+		for _, headers := range []map[string]string{asAlice, {"X-Api-Key": "alice-token"}} {
+			var status, _, _ = sendRequest(t, http.MethodGet, baseUrl+"/api/session/"+aliceSID, headers)
+			require.Equal(t, http.StatusOK, status)
+		}
+
+		var status, _, _ = sendRequest(t, http.MethodGet,
+			baseUrl+"/api/session/"+aliceSID+"/requests?access_token=alice-token",
+		)
+		require.Equal(t, http.StatusOK, status)
+	})
+
+	t.Run("another user", func(t *testing.T) {
+		t.Parallel()
+
+		for _, params := range []struct{ method, url string }{
+			{http.MethodGet, "/api/session/" + aliceSID},
+			{http.MethodGet, "/api/session/" + aliceSID + "/requests"},
+			{http.MethodGet, "/api/session/" + aliceSID + "/export?format=har"},
+			{http.MethodDelete, "/api/session/" + aliceSID + "/requests"},
+			{http.MethodDelete, "/api/session/" + aliceSID},
+		} {
+			var status, body, _ = sendRequest(t, params.method, baseUrl+params.url, asBob)
+
+			require.Equal(t, http.StatusNotFound, status, params)
+			require.Contains(t, string(body), "session not found")
+		}
+
+		_, err := db.GetSession(ctx, aliceSID) // still exists
+		require.NoError(t, err)
+	})
+
+	t.Run("session without an owner", func(t *testing.T) {
+		t.Parallel()
+
+		for _, headers := range []map[string]string{asAlice, asBob} {
+			var status, _, _ = sendRequest(t, http.MethodGet, baseUrl+"/api/session/"+commonSID, headers)
+			require.Equal(t, http.StatusOK, status)
+		}
+	})
+
+	t.Run("create and check existence", func(t *testing.T) {
+		t.Parallel()
+
+		req, rErr := http.NewRequestWithContext(ctx, http.MethodPost, baseUrl+"/api/session", strings.NewReader(
+			`{"status_code": 200, "headers": [], "delay": 0, "response_body_base64": ""}`,
+		))
+		require.NoError(t, rErr)
+
+		req.SetBasicAuth("bob", "bob-password")
+
+		resp, rErr := http.DefaultClient.Do(req)
+		require.NoError(t, rErr)
+
+		var created openapi.SessionOptionsResponse
+
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&created))
+		require.NoError(t, resp.Body.Close())
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		sess, sErr := db.GetSession(ctx, created.Uuid.String())
+		require.NoError(t, sErr)
+		require.Equal(t, "bob", sess.Owner)
+
+		req, rErr = http.NewRequestWithContext(ctx, http.MethodPost, baseUrl+"/api/session/check/exists", strings.NewReader(
+			`["`+aliceSID+`", "`+commonSID+`", "`+created.Uuid.String()+`"]`,
+		))
+		require.NoError(t, rErr)
+
+		req.SetBasicAuth("bob", "bob-password")
+
+		resp, rErr = http.DefaultClient.Do(req)
+		require.NoError(t, rErr)
+
+		var exists map[string]bool
+
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&exists))
+		require.NoError(t, resp.Body.Close())
+		require.Equal(t, map[string]bool{aliceSID: false, commonSID: true, created.Uuid.String(): true}, exists)
+	})
+}
+
+func TestServer_AuthCredentialsNotLogged(t *testing.T) {
+	t.Parallel()
+
+	const token = "alice-secret-token"
+
+	var (
+		ctx          = context.Background()
+		core, logged = observer.New(zap.DebugLevel)
+		log          = zap.New(core)
+		srv          = appHttp.NewServer(ctx, log)
+		db           = storage.NewInMemory(time.Minute, 8)
+	)
+
+	t.Cleanup(func() { require.NoError(t, db.Close()) })
+
+	srv.Register(
+		context.Background(),
+		log,
+		func(context.Context) error { return nil },
+		func(context.Context) (string, error) { return "v1.0.0", nil },
+		&config.AppSettings{Authenticator: auth.NewStaticTokens([]auth.Credential{{User: "alice", Secret: token}})},
+		db,
+		pubsub.NewInMemory[pubsub.RequestEvent](),
+		false,
+	)
+
+	var baseUrl, stop = startServer(t, ctx, srv)
+
+	t.Cleanup(stop)
+
+	sID, err := db.NewSession(ctx, storage.Session{Code: http.StatusOK, Owner: "alice"})
+	require.NoError(t, err)
+
+	for _, req := range []struct {
+		url     string
+		headers map[string]string
+	}{
+		{url: baseUrl + "/api/session/" + sID + "/requests?access_token=" + token + "&foo=bar"},
+		{url: baseUrl + "/api/session/" + sID, headers: map[string]string{"Authorization": "Bearer " + token}},
+		{url: baseUrl + "/api/session/" + sID, headers: map[string]string{"X-Api-Key": token}},
+	} {
+		var status, _, _ = sendRequest(t, http.MethodGet, req.url, req.headers)
+		require.Equal(t, http.StatusOK, status)
+	}
+
+	var processed = func() []observer.LoggedEntry {
+		return logged.FilterMessage("HTTP request processed").All()
+	}
+
+	require.Eventually(t, func() bool { return len(processed()) == 3 }, 5*time.Second, 5*time.Millisecond)
+
+	for _, entry := range processed() {
+		require.NotContains(t, fmt.Sprint(entry.ContextMap()), token)
+	}
+
+	var urls = make([]string, 0, 3)
+
+	for _, entry := range processed() {
+		urls = append(urls, entry.ContextMap()["url"].(string)) //nolint:forcetypeassert
+	}
+
+	require.Contains(t, urls, "/api/session/"+sID+"/requests?access_token=xxxxx&foo=bar")
+}
+
+func TestServer_OIDC(t *testing.T) {
+	t.Parallel()
+
+	var idp = oidctest.NewServer()
+	defer idp.Close()
+
+	var (
+		ctx = context.Background()
+		log = zap.NewNop()
+		srv = appHttp.NewServer(ctx, log)
+		db  = storage.NewInMemory(time.Minute, 8)
+	)
+
+	t.Cleanup(func() { require.NoError(t, db.Close()) })
+
+	provider, err := oidc.New(ctx, oidc.Config{
+		IssuerURL:    idp.Issuer(),
+		ClientID:     idp.ClientID,
+		ClientSecret: idp.ClientSecret,
+		RedirectURL:  "http://127.0.0.1/auth/callback",
+	})
+	require.NoError(t, err)
+
+	srv.Register(
+		context.Background(),
+		log,
+		func(context.Context) error { return nil },
+		func(context.Context) (string, error) { return "v1.0.0", nil },
+		&config.AppSettings{Authenticator: auth.Chain{provider}, OIDC: provider},
+		db,
+		pubsub.NewInMemory[pubsub.RequestEvent](),
+		false,
+	)
+
+	var baseUrl, stop = startServer(t, ctx, srv)
+
+	t.Cleanup(stop)
+
+	var client = http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+
+	var get = func(t *testing.T, url string, headers map[string]string) *http.Response {
+		t.Helper()
+
+		req, rErr := http.NewRequestWithContext(ctx, http.MethodGet, url, http.NoBody)
+		require.NoError(t, rErr)
+
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+
+		resp, rErr := client.Do(req)
+		require.NoError(t, rErr)
+		require.NoError(t, resp.Body.Close())
+
+		return resp
+	}
+
+	sID, err := db.NewSession(ctx, storage.Session{Code: http.StatusOK})
+	require.NoError(t, err)
+
+	// the frontend requires the login
+	var resp = get(t, baseUrl+"/", nil)
+	require.Equal(t, http.StatusFound, resp.StatusCode)
+	require.Equal(t, "/auth/login?return=%2F", resp.Header.Get("Location"))
+
+	resp = get(t, baseUrl+"/auth/login", nil)
+	require.Equal(t, http.StatusFound, resp.StatusCode)
+	require.True(t, strings.HasPrefix(resp.Header.Get("Location"), idp.Issuer()+"/authorize?"))
+	require.Contains(t, resp.Header.Get("Location"), "code_challenge_method=S256")
+
+	// the API clients use the bearer JWTs
+	resp = get(t, baseUrl+"/api/settings", nil)
+	require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	resp = get(t, baseUrl+"/api/settings", map[string]string{"Authorization": "Bearer " + idp.AccessToken("alice")})
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// the probes and the webhook capture are public
+	resp = get(t, baseUrl+"/healthz", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp = get(t, baseUrl+"/"+sID, nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+}
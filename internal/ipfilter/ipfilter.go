@@ -0,0 +1,123 @@
+// Package ipfilter provides the client IP addresses access control - the allow and deny lists of the IP addresses
+// and networks (CIDRs).
+package ipfilter
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+	"strings"
+)
+
+// ErrNotAllowed is returned when the client IP address is not allowed by the Policy.
+var ErrNotAllowed = errors.New("client IP address is not allowed")
+
+// Policy decides which client IP addresses are allowed. Each rule is an IP address or a CIDR (e.g. 192.30.252.0/22).
+//
+// The address is allowed when the allow list is empty or any of the allow rules matches, and none of the deny rules
+// matches (deny rules take precedence). A nil policy allows everything.
+type Policy struct {
+	allow, deny []netip.Prefix
+}
+
+// NewPolicy creates a new Policy with the given allow and deny rules.
+func NewPolicy(allow, deny []string) (*Policy, error) {
+	var p Policy
+
+	for _, list := range []struct {
+		rules []string
+		to    *[]netip.Prefix
+	}{{allow, &p.allow}, {deny, &p.deny}} {
+		prefixes, err := ParsePrefixes(list.rules)
+		if err != nil {
+			return nil, err
+		}
+
+		*list.to = prefixes
+	}
+
+	return &p, nil
+}
+
+// ParsePrefix parses the IP address or CIDR (the single address is converted into the /32 or /128 network).
+func ParsePrefix(rule string) (netip.Prefix, error) {
+	rule = strings.TrimSpace(rule)
+
+	if rule == "" {
+		return netip.Prefix{}, errors.New("empty IP address or CIDR")
+	}
+
+	if strings.Contains(rule, "/") {
+		prefix, err := netip.ParsePrefix(rule)
+		if err != nil {
+			return netip.Prefix{}, fmt.Errorf("wrong CIDR [%s]: %w", rule, err)
+		}
+
+		if prefix.Addr().Is4In6() {
+			return netip.Prefix{}, fmt.Errorf("wrong CIDR [%s]: IPv4-mapped IPv6 networks are not supported", rule)
+		}
+
+		return prefix.Masked(), nil
+	}
+
+	addr, err := netip.ParseAddr(strings.Trim(rule, "[]"))
+	if err != nil {
+		return netip.Prefix{}, fmt.Errorf("wrong IP address [%s]: %w", rule, err)
+	}
+
+	addr = addr.Unmap().WithZone("")
+
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
+
+// ParsePrefixes parses the list of IP addresses and CIDRs (see [ParsePrefix]).
+func ParsePrefixes(rules []string) ([]netip.Prefix, error) {
+	var prefixes = make([]netip.Prefix, 0, len(rules))
+
+	for _, rule := range rules {
+		prefix, err := ParsePrefix(rule)
+		if err != nil {
+			return nil, err
+		}
+
+		prefixes = append(prefixes, prefix)
+	}
+
+	return prefixes, nil
+}
+
+// Contains checks if any of the networks contains the IP address.
+func Contains(prefixes []netip.Prefix, addr netip.Addr) bool {
+	addr = addr.Unmap().WithZone("")
+
+	for _, prefix := range prefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Check checks the client IP address against the policy. The returned error (wrapping the [ErrNotAllowed]) describes
+// the reason why the address is not allowed.
+func (p *Policy) Check(ip string) error {
+	if p == nil {
+		return nil
+	}
+
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return fmt.Errorf("%w: wrong IP address [%s]", ErrNotAllowed, ip)
+	}
+
+	if Contains(p.deny, addr) {
+		return fmt.Errorf("%w: %s is denied", ErrNotAllowed, ip)
+	}
+
+	if len(p.allow) > 0 && !Contains(p.allow, addr) {
+		return fmt.Errorf("%w: %s is not in the allow list", ErrNotAllowed, ip)
+	}
+
+	return nil
+}
@@ -0,0 +1,91 @@
+package ipfilter_test
+
+import (
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gh.tarampamp.am/webhook-tester/v2/internal/ipfilter"
+)
+
+func TestParsePrefix(t *testing.T) {
+	t.Parallel()
+
+	for give, want := range map[string]string{
+		"10.0.0.0/8":       "10.0.0.0/8",
+		"10.1.2.3/8":       "10.0.0.0/8",
+		" 192.0.2.1 ":      "192.0.2.1/32",
+		"::1":              "::1/128",
+		"[::1]":            "::1/128",
+		"::ffff:192.0.2.1": "192.0.2.1/32",
+		"fe80::1%eth0":     "fe80::1/128",
+		"2001:db8::/32":    "2001:db8::/32",
+	} {
+		got, err := ipfilter.ParsePrefix(give)
+		require.NoError(t, err, give)
+		assert.Equal(t, want, got.String(), give)
+	}
+
+	for _, give := range []string{"", " ", "10.0.0.0/33", "foo/bar", "example.com", "10.0.0", "::ffff:10.0.0.0/104"} {
+		_, err := ipfilter.ParsePrefix(give)
+		assert.Error(t, err, give)
+	}
+
+	_, err := ipfilter.ParsePrefixes([]string{"10.0.0.0/8", "foo"})
+	require.Error(t, err)
+}
+
+func TestContains(t *testing.T) {
+	t.Parallel()
+
+	prefixes, err := ipfilter.ParsePrefixes([]string{"10.0.0.0/8", "2001:db8::/32"})
+	require.NoError(t, err)
+
+	assert.True(t, ipfilter.Contains(prefixes, netip.MustParseAddr("10.1.2.3")))
+	assert.True(t, ipfilter.Contains(prefixes, netip.MustParseAddr("::ffff:10.1.2.3"))) // IPv4-mapped
+	assert.True(t, ipfilter.Contains(prefixes, netip.MustParseAddr("2001:db8::1")))
+	assert.False(t, ipfilter.Contains(prefixes, netip.MustParseAddr("192.0.2.1")))
+	assert.False(t, ipfilter.Contains(nil, netip.MustParseAddr("10.1.2.3")))
+}
+
+func TestPolicy_Check(t *testing.T) {
+	t.Parallel()
+
+	var nilPolicy *ipfilter.Policy
+
+	require.NoError(t, nilPolicy.Check("192.0.2.1"))
+
+	for name, tc := range map[string]struct {
+		giveAllow, giveDeny []string
+		giveIP              string
+		wantErr             string
+	}{
+		"no rules":              {giveIP: "192.0.2.1"},
+		"allowed":               {giveAllow: []string{"192.0.2.0/24"}, giveIP: "192.0.2.1"},
+		"not in the allow list": {giveAllow: []string{"192.0.2.0/24"}, giveIP: "198.51.100.1", wantErr: "198.51.100.1 is not in the allow list"},
+		"denied":                {giveDeny: []string{"198.51.100.1"}, giveIP: "198.51.100.1", wantErr: "198.51.100.1 is denied"},
+		"allowed, but denied":   {giveAllow: []string{"0.0.0.0/0"}, giveDeny: []string{"192.0.2.0/24"}, giveIP: "192.0.2.1", wantErr: "is denied"},
+		"other address denied":  {giveDeny: []string{"192.0.2.0/24"}, giveIP: "198.51.100.1"},
+		"IPv6 allowed":          {giveAllow: []string{"2001:db8::/32"}, giveIP: "2001:db8::1"},
+		"wrong address":         {giveAllow: []string{"192.0.2.0/24"}, giveIP: "foo", wantErr: "wrong IP address [foo]"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			policy, err := ipfilter.NewPolicy(tc.giveAllow, tc.giveDeny)
+			require.NoError(t, err)
+
+			if err = policy.Check(tc.giveIP); tc.wantErr == "" {
+				require.NoError(t, err)
+			} else {
+				require.ErrorIs(t, err, ipfilter.ErrNotAllowed)
+				require.ErrorContains(t, err, tc.wantErr)
+			}
+		})
+	}
+
+	_, err := ipfilter.NewPolicy(nil, []string{"foo"})
+	require.Error(t, err)
+}
@@ -0,0 +1,76 @@
+package jsonpath
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"strings"
+)
+
+// Decode decodes the JSON data. It returns nil if the data is not a valid JSON. Numbers are decoded as json.Number
+// to keep them as is (without float64 conversion).
+func Decode(data []byte) any {
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil
+	}
+
+	var (
+		dec = json.NewDecoder(bytes.NewReader(data))
+		v   any
+	)
+
+	dec.UseNumber()
+
+	if err := dec.Decode(&v); err != nil {
+		return nil
+	}
+
+	return v
+}
+
+// Lookup finds the value in the decoded (using Decode) JSON by the dot-separated path (e.g. "data.items.0.id") and
+// returns its string representation. Objects and arrays are returned as JSON.
+func Lookup(v any, path string) (string, bool) {
+	if v == nil {
+		return "", false
+	}
+
+	for key := range strings.SplitSeq(path, ".") {
+		switch node := v.(type) {
+		case map[string]any:
+			next, ok := node[key]
+			if !ok {
+				return "", false
+			}
+
+			v = next
+		case []any:
+			idx, err := strconv.Atoi(key)
+			if err != nil || idx < 0 || idx >= len(node) {
+				return "", false
+			}
+
+			v = node[idx]
+		default:
+			return "", false // scalar value, but the path is not finished
+		}
+	}
+
+	switch value := v.(type) {
+	case string:
+		return value, true
+	case json.Number:
+		return value.String(), true
+	case bool:
+		return strconv.FormatBool(value), true
+	case nil:
+		return "null", true
+	default:
+		b, err := json.Marshal(value)
+		if err != nil {
+			return "", false
+		}
+
+		return string(b), true
+	}
+}
@@ -0,0 +1,47 @@
+package jsonpath_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"gh.tarampamp.am/webhook-tester/v2/internal/jsonpath"
+)
+
+func TestLookup(t *testing.T) {
+	t.Parallel()
+
+	var doc = jsonpath.Decode([]byte(`{
+		"str": "foo",
+		"num": 12345678901234567890,
+		"float": 1.50,
+		"bool": true,
+		"null": null,
+		"obj": {"items": [{"id": 1}, {"id": 42, "tags": ["a", "b"]}]}
+	}`))
+
+	for path, want := range map[string]string{
+		"str":              "foo",
+		"num":              "12345678901234567890",
+		"float":            "1.50",
+		"bool":             "true",
+		"null":             "null",
+		"obj.items.1.id":   "42",
+		"obj.items.1.tags": `["a","b"]`,
+		"obj.items.0":      `{"id":1}`,
+	} {
+		got, found := jsonpath.Lookup(doc, path)
+
+		assert.True(t, found, path)
+		assert.Equal(t, want, got, path)
+	}
+
+	for _, path := range []string{"", "foo", "str.foo", "obj.items.2", "obj.items.-1", "obj.items.x", "obj.foo"} {
+		_, found := jsonpath.Lookup(doc, path)
+
+		assert.False(t, found, path)
+	}
+
+	_, found := jsonpath.Lookup(jsonpath.Decode([]byte("not a json")), "foo")
+	assert.False(t, found)
+}
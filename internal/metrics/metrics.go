@@ -0,0 +1,274 @@
+// Package metrics collects the application metrics (captured webhooks, storage and pub/sub operations, subscribers,
+// etc.) and exposes them in the Prometheus text format.
+//
+// All the Metrics methods are safe to call on a nil receiver (in this case, they do nothing), so the metrics
+// collection can be disabled by passing nil.
+package metrics
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"gh.tarampamp.am/webhook-tester/v2/internal/storage"
+)
+
+const namespace = "webhook_tester"
+
+// Metrics is the application metrics collector.
+type Metrics struct {
+	registry *prometheus.Registry
+
+	webhooks        *prometheus.CounterVec   // captured webhooks (by method and response status)
+	webhookBodySize prometheus.Histogram     // captured webhooks body size
+	storageDuration *prometheus.HistogramVec // storage operations latency (by driver and operation)
+	storageErrors   *prometheus.CounterVec   // storage operations errors (by driver and operation)
+	sessions        *prometheus.CounterVec   // created/deleted sessions (by driver and action)
+	published       *prometheus.CounterVec   // published pub/sub events (by driver)
+	publishErrors   *prometheus.CounterVec   // pub/sub publish failures (by driver)
+	wsSubscribers   prometheus.Gauge         // active WebSocket subscribers
+	sseSubscribers  prometheus.Gauge         // active Server-Sent Events subscribers
+	rateLimited     *prometheus.CounterVec   // requests rejected by the rate limiter (by scope)
+	blocked         *prometheus.CounterVec   // requests blocked by the IP filter (by scope)
+	notifications   *prometheus.CounterVec   // outbound notifications (by target kind and result)
+}
+
+// New creates a new Metrics collector with its own registry (the Go runtime and process metrics are included).
+func New() *Metrics {
+	var m = Metrics{
+		registry: prometheus.NewRegistry(),
+		webhooks: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "captured_webhooks_total",
+			Help:      "The total number of captured webhooks, by the HTTP method and response status code.",
+		}, []string{"method", "status"}),
+		webhookBodySize: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "captured_webhook_body_size_bytes",
+			Help:      "The body size of the captured webhooks.",
+			Buckets:   prometheus.ExponentialBuckets(64, 4, 10), //nolint:mnd // 64B .. 16MiB
+		}),
+		storageDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "storage",
+			Name:      "operation_duration_seconds",
+			Help:      "The storage operations latency, by the storage driver and operation.",
+			Buckets:   prometheus.ExponentialBuckets(0.0005, 2, 14), //nolint:mnd // 0.5ms .. ~4s
+		}, []string{"driver", "operation"}),
+		storageErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "storage",
+			Name:      "operation_errors_total",
+			Help:      "The total number of failed storage operations (the \"not found\" errors are not counted).",
+		}, []string{"driver", "operation"}),
+		sessions: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "sessions_total",
+			Help:      "The total number of created and deleted sessions (the expired ones are not counted as deleted).",
+		}, []string{"driver", "action"}),
+		published: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "pubsub",
+			Name:      "published_total",
+			Help:      "The total number of the events published to the pub/sub.",
+		}, []string{"driver"}),
+		publishErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "pubsub",
+			Name:      "publish_errors_total",
+			Help:      "The total number of the pub/sub publish failures.",
+		}, []string{"driver"}),
+		wsSubscribers: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "websocket_subscribers",
+			Help:      "The number of the active WebSocket subscribers.",
+		}),
+		sseSubscribers: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "sse_subscribers",
+			Help:      "The number of the active Server-Sent Events subscribers.",
+		}),
+		rateLimited: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "rate_limited_total",
+			Help:      "The total number of the requests rejected by the rate limiter, by the limit scope.",
+		}, []string{"scope"}),
+		blocked: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "blocked_total",
+			Help:      "The total number of the webhooks blocked by the client IP filter, by the filter scope.",
+		}, []string{"scope"}),
+		notifications: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "notifications_total",
+			Help:      "The total number of the outbound notifications, by the target kind and delivery result.",
+		}, []string{"kind", "result"}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.webhooks,
+		m.webhookBodySize,
+		m.storageDuration,
+		m.storageErrors,
+		m.sessions,
+		m.published,
+		m.publishErrors,
+		m.wsSubscribers,
+		m.sseSubscribers,
+		m.rateLimited,
+		m.blocked,
+		m.notifications,
+	)
+
+	return &m
+}
+
+// Handler returns the HTTP handler, which serves the metrics in the Prometheus text format.
+func (m *Metrics) Handler() http.Handler {
+	if m == nil {
+		return http.NotFoundHandler()
+	}
+
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// ObserveWebhook records the captured webhook with the given HTTP method and response status code.
+func (m *Metrics) ObserveWebhook(method string, status int) {
+	if m == nil {
+		return
+	}
+
+	m.webhooks.WithLabelValues(method, strconv.Itoa(status)).Inc()
+}
+
+// ObserveWebhookBody records the body size of the captured webhook.
+func (m *Metrics) ObserveWebhookBody(size int) {
+	if m == nil {
+		return
+	}
+
+	m.webhookBodySize.Observe(float64(size))
+}
+
+// ObserveStorage records the storage operation latency and its error (if any). The "not found" errors are the
+// part of the normal flow, so they are not counted.
+func (m *Metrics) ObserveStorage(driver, operation string, took time.Duration, err error) {
+	if m == nil {
+		return
+	}
+
+	m.storageDuration.WithLabelValues(driver, operation).Observe(took.Seconds())
+
+	if err != nil && !errors.Is(err, storage.ErrNotFound) {
+		m.storageErrors.WithLabelValues(driver, operation).Inc()
+	}
+}
+
+// SessionCreated records the created session.
+func (m *Metrics) SessionCreated(driver string) {
+	if m == nil {
+		return
+	}
+
+	m.sessions.WithLabelValues(driver, "created").Inc()
+}
+
+// SessionDeleted records the deleted session.
+func (m *Metrics) SessionDeleted(driver string) {
+	if m == nil {
+		return
+	}
+
+	m.sessions.WithLabelValues(driver, "deleted").Inc()
+}
+
+// ObserveActiveSessions registers the gauge of the active sessions, which are counted in the given storage at scrape
+// time. It should be called once per storage driver.
+func (m *Metrics) ObserveActiveSessions(driver string, db storage.Storage) {
+	if m == nil {
+		return
+	}
+
+	m.registry.MustRegister(&activeSessions{
+		db:     db,
+		driver: driver,
+		desc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "active_sessions"),
+			"The number of the active (not expired) sessions, counted in the storage at scrape time.",
+			[]string{"driver"}, nil,
+		),
+		m: m,
+	})
+}
+
+// ObservePublish records the event publishing to the pub/sub and its error (if any).
+func (m *Metrics) ObservePublish(driver string, err error) {
+	if m == nil {
+		return
+	}
+
+	m.published.WithLabelValues(driver).Inc()
+
+	if err != nil {
+		m.publishErrors.WithLabelValues(driver).Inc()
+	}
+}
+
+// WebSocketSubscribed records the new WebSocket subscriber. The returned function should be called when the
+// subscriber disconnects.
+func (m *Metrics) WebSocketSubscribed() (unsubscribed func()) {
+	if m == nil {
+		return func() {}
+	}
+
+	m.wsSubscribers.Inc()
+
+	return m.wsSubscribers.Dec
+}
+
+// SSESubscribed records the new Server-Sent Events subscriber. The returned function should be called when the
+// subscriber disconnects.
+func (m *Metrics) SSESubscribed() (unsubscribed func()) {
+	if m == nil {
+		return func() {}
+	}
+
+	m.sseSubscribers.Inc()
+
+	return m.sseSubscribers.Dec
+}
+
+// ObserveRateLimited records the request, rejected by the rate limiter of the given scope.
+func (m *Metrics) ObserveRateLimited(scope string) {
+	if m == nil {
+		return
+	}
+
+	m.rateLimited.WithLabelValues(scope).Inc()
+}
+
+// ObserveBlocked records the webhook, blocked by the client IP filter of the given scope.
+func (m *Metrics) ObserveBlocked(scope string) {
+	if m == nil {
+		return
+	}
+
+	m.blocked.WithLabelValues(scope).Inc()
+}
+
+// ObserveNotification records the outbound notification delivery result (e.g. "delivered", "failed" or "skipped")
+// for the target of the given kind.
+func (m *Metrics) ObserveNotification(kind, result string) {
+	if m == nil {
+		return
+	}
+
+	m.notifications.WithLabelValues(kind, result).Inc()
+}
@@ -0,0 +1,112 @@
+package metrics_test
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gh.tarampamp.am/webhook-tester/v2/internal/metrics"
+	"gh.tarampamp.am/webhook-tester/v2/internal/storage"
+)
+
+// scrape returns the metrics in the Prometheus text format.
+func scrape(t *testing.T, m *metrics.Metrics) string {
+	t.Helper()
+
+	var rec = httptest.NewRecorder()
+
+	m.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", http.NoBody))
+
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	body, err := io.ReadAll(rec.Body)
+	require.NoError(t, err)
+
+	return string(body)
+}
+
+func TestMetrics(t *testing.T) {
+	t.Parallel()
+
+	var m = metrics.New()
+
+	m.ObserveWebhook(http.MethodPost, http.StatusOK)
+	m.ObserveWebhook(http.MethodPost, http.StatusOK)
+	m.ObserveWebhook(http.MethodGet, http.StatusTeapot)
+	m.ObserveWebhookBody(100)
+	m.ObserveStorage("memory", "get_session", time.Millisecond, nil)
+	m.ObserveStorage("memory", "get_session", time.Millisecond, storage.ErrSessionNotFound) // not counted as an error
+	m.ObserveStorage("memory", "new_request", time.Millisecond, errors.New("boom"))
+	m.SessionCreated("memory")
+	m.SessionDeleted("memory")
+	m.ObservePublish("redis", nil)
+	m.ObservePublish("redis", errors.New("boom"))
+	m.ObserveRateLimited("ip")
+	m.ObserveRateLimited("ip")
+	m.ObserveBlocked("session")
+	m.ObserveNotification("http", "delivered")
+
+	var unsubscribe = m.WebSocketSubscribed()
+
+	m.WebSocketSubscribed()
+	unsubscribe()
+
+	m.SSESubscribed()
+
+	var out = scrape(t, m)
+
+	for _, line := range []string{
+		`webhook_tester_captured_webhooks_total{method="POST",status="200"} 2`,
+		`webhook_tester_captured_webhooks_total{method="GET",status="418"} 1`,
+		`webhook_tester_captured_webhook_body_size_bytes_count 1`,
+		`webhook_tester_captured_webhook_body_size_bytes_sum 100`,
+		`webhook_tester_storage_operation_duration_seconds_count{driver="memory",operation="get_session"} 2`,
+		`webhook_tester_storage_operation_errors_total{driver="memory",operation="new_request"} 1`,
+		`webhook_tester_sessions_total{action="created",driver="memory"} 1`,
+		`webhook_tester_sessions_total{action="deleted",driver="memory"} 1`,
+		`webhook_tester_pubsub_published_total{driver="redis"} 2`,
+		`webhook_tester_pubsub_publish_errors_total{driver="redis"} 1`,
+		`webhook_tester_websocket_subscribers 1`,
+		`webhook_tester_sse_subscribers 1`,
+		`webhook_tester_rate_limited_total{scope="ip"} 2`,
+		`webhook_tester_blocked_total{scope="session"} 1`,
+		`webhook_tester_notifications_total{kind="http",result="delivered"} 1`,
+		`go_goroutines`,
+	} {
+		assert.Contains(t, out, line)
+	}
+
+	assert.NotContains(t, out, `operation_errors_total{driver="memory",operation="get_session"}`)
+}
+
+func TestMetrics_Nil(t *testing.T) {
+	t.Parallel()
+
+	var m *metrics.Metrics // nil = disabled
+
+	assert.NotPanics(t, func() {
+		m.ObserveWebhook(http.MethodPost, http.StatusOK)
+		m.ObserveWebhookBody(1)
+		m.ObserveStorage("memory", "get_session", time.Millisecond, nil)
+		m.SessionCreated("memory")
+		m.SessionDeleted("memory")
+		m.ObservePublish("memory", nil)
+		m.WebSocketSubscribed()()
+		m.SSESubscribed()()
+		m.ObserveRateLimited("ip")
+		m.ObserveBlocked("app")
+		m.ObserveNotification("template", "failed")
+	})
+
+	var rec = httptest.NewRecorder()
+
+	m.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", http.NoBody))
+
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
@@ -0,0 +1,33 @@
+package metrics
+
+import (
+	"context"
+
+	"gh.tarampamp.am/webhook-tester/v2/internal/pubsub"
+)
+
+// PubSub is a pubsub.PubSub decorator, which collects the publishing metrics of the wrapped pub/sub driver.
+type PubSub[T any] struct {
+	ps     pubsub.PubSub[T]
+	driver string // the pub/sub driver name (used as the metrics label)
+	m      *Metrics
+}
+
+var _ pubsub.PubSub[any] = (*PubSub[any])(nil) // verify interface implementation
+
+// NewPubSub wraps the pub/sub with the metrics collection.
+func NewPubSub[T any](ps pubsub.PubSub[T], driver string, m *Metrics) *PubSub[T] {
+	return &PubSub[T]{ps: ps, driver: driver, m: m}
+}
+
+func (p *PubSub[T]) Publish(ctx context.Context, topic string, event T) error {
+	var err = p.ps.Publish(ctx, topic, event)
+
+	p.m.ObservePublish(p.driver, err)
+
+	return err
+}
+
+func (p *PubSub[T]) Subscribe(ctx context.Context, topic string) (<-chan T, func(), error) {
+	return p.ps.Subscribe(ctx, topic)
+}
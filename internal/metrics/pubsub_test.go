@@ -0,0 +1,42 @@
+package metrics_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gh.tarampamp.am/webhook-tester/v2/internal/metrics"
+	"gh.tarampamp.am/webhook-tester/v2/internal/pubsub"
+)
+
+func TestPubSub(t *testing.T) {
+	t.Parallel()
+
+	var (
+		ctx = context.Background()
+		m   = metrics.New()
+		ps  = metrics.NewPubSub[string](pubsub.NewInMemory[string](), "memory", m)
+	)
+
+	sub, unsubscribe, err := ps.Subscribe(ctx, "topic")
+	require.NoError(t, err)
+
+	t.Cleanup(unsubscribe)
+
+	require.NoError(t, ps.Publish(ctx, "topic", "event"))
+
+	select {
+	case event := <-sub:
+		assert.Equal(t, "event", event)
+	case <-time.After(time.Second):
+		t.Fatal("the event is not received")
+	}
+
+	var out = scrape(t, m)
+
+	assert.Contains(t, out, `webhook_tester_pubsub_published_total{driver="memory"} 1`)
+	assert.NotContains(t, out, "webhook_tester_pubsub_publish_errors_total{")
+}
@@ -0,0 +1,67 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"gh.tarampamp.am/webhook-tester/v2/internal/storage"
+)
+
+const (
+	sessionsCountTimeout  = 10 * time.Second // the maximum duration of the active sessions counting
+	sessionsCountPageSize = 1000             // the number of sessions read from the storage at once
+)
+
+// activeSessions is a prometheus.Collector, which counts the active (not expired) sessions in the storage at scrape
+// time (the expired sessions are removed by the storage itself, so they cannot be tracked by the counters).
+type activeSessions struct {
+	db     storage.Storage
+	driver string
+	desc   *prometheus.Desc
+	m      *Metrics
+}
+
+var _ prometheus.Collector = (*activeSessions)(nil) // verify interface implementation
+
+func (c *activeSessions) Describe(ch chan<- *prometheus.Desc) { ch <- c.desc }
+
+// Collect counts the sessions. On the storage error, the metric is omitted (and the error is counted), so the rest
+// of the metrics are still served.
+func (c *activeSessions) Collect(ch chan<- prometheus.Metric) {
+	var start = time.Now()
+
+	count, err := c.count()
+
+	c.m.ObserveStorage(c.driver, "count_sessions", time.Since(start), err)
+
+	if err != nil {
+		return
+	}
+
+	ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, float64(count), c.driver)
+}
+
+// count reads the sessions page by page (without the owner filter) and returns their number.
+func (c *activeSessions) count() (int, error) {
+	var ctx, cancel = context.WithTimeout(context.Background(), sessionsCountTimeout)
+	defer cancel()
+
+	var q = storage.SessionsQuery{Limit: sessionsCountPageSize}
+
+	for count := 0; ; {
+		page, err := c.db.ListSessions(ctx, q)
+		if err != nil {
+			return 0, err
+		}
+
+		count += len(page.Sessions)
+
+		if page.NextCursor == "" {
+			return count, nil
+		}
+
+		q.Cursor = page.NextCursor
+	}
+}
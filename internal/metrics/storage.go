@@ -0,0 +1,141 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"gh.tarampamp.am/webhook-tester/v2/internal/storage"
+)
+
+// Storage is a storage.Storage decorator, which collects the operations metrics (latency, errors, created/deleted and
+// active sessions count) of the wrapped storage driver.
+type Storage struct {
+	db     storage.Storage
+	driver string // the storage driver name (used as the metrics label)
+	m      *Metrics
+}
+
+var _ storage.Storage = (*Storage)(nil) // verify interface implementation
+
+// NewStorage wraps the storage with the metrics collection. The active sessions of the storage are counted at scrape
+// time, so it should be called once per storage driver.
+func NewStorage(db storage.Storage, driver string, m *Metrics) *Storage {
+	m.ObserveActiveSessions(driver, db)
+
+	return &Storage{db: db, driver: driver, m: m}
+}
+
+// measure starts the operation measuring. The returned function should be deferred with the operation error.
+func (s *Storage) measure(operation string) func(*error) {
+	var start = time.Now()
+
+	return func(err *error) { s.m.ObserveStorage(s.driver, operation, time.Since(start), *err) }
+}
+
+func (s *Storage) NewSession(ctx context.Context, session storage.Session, id ...string) (sID string, err error) {
+	defer s.measure("new_session")(&err)
+
+	if sID, err = s.db.NewSession(ctx, session, id...); err == nil {
+		s.m.SessionCreated(s.driver)
+	}
+
+	return sID, err
+}
+
+func (s *Storage) GetSession(ctx context.Context, sID string) (_ *storage.Session, err error) {
+	defer s.measure("get_session")(&err)
+
+	return s.db.GetSession(ctx, sID)
+}
+
+func (s *Storage) AddSessionTTL(ctx context.Context, sID string, howMuch time.Duration) (err error) {
+	defer s.measure("add_session_ttl")(&err)
+
+	return s.db.AddSessionTTL(ctx, sID, howMuch)
+}
+
+func (s *Storage) UpdateSession(ctx context.Context, sID string, update func(*storage.Session) error) (err error) {
+	defer s.measure("update_session")(&err)
+
+	return s.db.UpdateSession(ctx, sID, update)
+}
+
+func (s *Storage) ListSessions(ctx context.Context, q storage.SessionsQuery) (_ *storage.SessionsPage, err error) {
+	defer s.measure("list_sessions")(&err)
+
+	return s.db.ListSessions(ctx, q)
+}
+
+func (s *Storage) DeleteSession(ctx context.Context, sID string) (err error) {
+	defer s.measure("delete_session")(&err)
+
+	if err = s.db.DeleteSession(ctx, sID); err == nil {
+		s.m.SessionDeleted(s.driver)
+	}
+
+	return err
+}
+
+func (s *Storage) AddSessionSlug(ctx context.Context, sID, slug string) (err error) {
+	defer s.measure("add_session_slug")(&err)
+
+	return s.db.AddSessionSlug(ctx, sID, slug)
+}
+
+func (s *Storage) GetSessionSlugs(ctx context.Context, sID string) (_ []string, err error) {
+	defer s.measure("get_session_slugs")(&err)
+
+	return s.db.GetSessionSlugs(ctx, sID)
+}
+
+func (s *Storage) DeleteSessionSlug(ctx context.Context, sID, slug string) (err error) {
+	defer s.measure("delete_session_slug")(&err)
+
+	return s.db.DeleteSessionSlug(ctx, sID, slug)
+}
+
+func (s *Storage) ResolveSlug(ctx context.Context, slug string) (_ string, err error) {
+	defer s.measure("resolve_slug")(&err)
+
+	return s.db.ResolveSlug(ctx, slug)
+}
+
+func (s *Storage) NewRequest(ctx context.Context, sID string, r storage.Request) (_ string, err error) {
+	defer s.measure("new_request")(&err)
+
+	return s.db.NewRequest(ctx, sID, r)
+}
+
+func (s *Storage) GetRequest(ctx context.Context, sID, rID string) (_ *storage.Request, err error) {
+	defer s.measure("get_request")(&err)
+
+	return s.db.GetRequest(ctx, sID, rID)
+}
+
+func (s *Storage) GetAllRequests(ctx context.Context, sID string) (_ map[string]storage.Request, err error) {
+	defer s.measure("get_all_requests")(&err)
+
+	return s.db.GetAllRequests(ctx, sID)
+}
+
+func (s *Storage) SetRequestForwardResult(
+	ctx context.Context,
+	sID, rID string,
+	result storage.ForwardResult,
+) (err error) {
+	defer s.measure("set_request_forward_result")(&err)
+
+	return s.db.SetRequestForwardResult(ctx, sID, rID, result)
+}
+
+func (s *Storage) DeleteRequest(ctx context.Context, sID, rID string) (err error) {
+	defer s.measure("delete_request")(&err)
+
+	return s.db.DeleteRequest(ctx, sID, rID)
+}
+
+func (s *Storage) DeleteAllRequests(ctx context.Context, sID string) (err error) {
+	defer s.measure("delete_all_requests")(&err)
+
+	return s.db.DeleteAllRequests(ctx, sID)
+}
@@ -0,0 +1,104 @@
+package metrics_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gh.tarampamp.am/webhook-tester/v2/internal/metrics"
+	"gh.tarampamp.am/webhook-tester/v2/internal/storage"
+)
+
+func TestStorage(t *testing.T) {
+	t.Parallel()
+
+	var (
+		ctx = context.Background()
+		m   = metrics.New()
+		mem = storage.NewInMemory(time.Minute, 8)
+		db  = metrics.NewStorage(mem, "memory", m)
+	)
+
+	t.Cleanup(func() { require.NoError(t, mem.Close()) })
+
+	sID, err := db.NewSession(ctx, storage.Session{Code: 200})
+	require.NoError(t, err)
+
+	sess, err := db.GetSession(ctx, sID)
+	require.NoError(t, err)
+	assert.EqualValues(t, 200, sess.Code)
+
+	rID, err := db.NewRequest(ctx, sID, storage.Request{Method: "POST"})
+	require.NoError(t, err)
+
+	req, err := db.GetRequest(ctx, sID, rID)
+	require.NoError(t, err)
+	assert.Equal(t, "POST", req.Method)
+
+	_, err = db.GetSession(ctx, "unknown")
+	require.ErrorIs(t, err, storage.ErrSessionNotFound) // the errors are passed through
+
+	require.NoError(t, db.DeleteSession(ctx, sID))
+	require.ErrorIs(t, db.DeleteSession(ctx, sID), storage.ErrSessionNotFound)
+
+	var out = scrape(t, m)
+
+	for _, line := range []string{
+		`webhook_tester_storage_operation_duration_seconds_count{driver="memory",operation="new_session"} 1`,
+		`webhook_tester_storage_operation_duration_seconds_count{driver="memory",operation="get_session"} 2`,
+		`webhook_tester_storage_operation_duration_seconds_count{driver="memory",operation="new_request"} 1`,
+		`webhook_tester_storage_operation_duration_seconds_count{driver="memory",operation="get_request"} 1`,
+		`webhook_tester_storage_operation_duration_seconds_count{driver="memory",operation="delete_session"} 2`,
+		`webhook_tester_sessions_total{action="created",driver="memory"} 1`,
+		`webhook_tester_sessions_total{action="deleted",driver="memory"} 1`,
+	} {
+		assert.Contains(t, out, line)
+	}
+
+	assert.NotContains(t, out, "webhook_tester_storage_operation_errors_total")
+}
+
+func TestStorage_ActiveSessions(t *testing.T) {
+	t.Parallel()
+
+	var (
+		ctx = context.Background()
+		m   = metrics.New()
+		now = time.Now()
+		mem = storage.NewInMemory(time.Minute, 8, storage.WithInMemoryTimeNow(func() time.Time { return now }))
+		db  = metrics.NewStorage(mem, "memory", m)
+	)
+
+	assert.Contains(t, scrape(t, m), `webhook_tester_active_sessions{driver="memory"} 0`)
+
+	var ids = make([]string, 0, 1001) // more than one page
+
+	for range cap(ids) {
+		sID, err := db.NewSession(ctx, storage.Session{})
+		require.NoError(t, err)
+
+		ids = append(ids, sID)
+	}
+
+	require.NoError(t, db.DeleteSession(ctx, ids[0]))
+
+	var out = scrape(t, m)
+
+	assert.Contains(t, out, `webhook_tester_active_sessions{driver="memory"} 1000`)
+	assert.Contains(t, out, `webhook_tester_sessions_total{action="created",driver="memory"} 1001`)
+
+	now = now.Add(time.Hour) // the expired sessions are not active
+
+	assert.Contains(t, scrape(t, m), `webhook_tester_active_sessions{driver="memory"} 0`)
+
+	require.NoError(t, mem.Close())
+
+	// the storage errors do not break the scraping
+	assert.NotContains(t, scrape(t, m), "webhook_tester_active_sessions{")
+
+	// the counting error is observed while scraping, so it is exposed by the next scrape
+	assert.Contains(t, scrape(t, m), `webhook_tester_storage_operation_errors_total{driver="memory",operation="count_sessions"}`)
+}
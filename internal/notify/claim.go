@@ -0,0 +1,41 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Claimer ensures that every captured request is dispatched only once, when multiple app instances receive the same
+// pub/sub events (a nil Claimer is fine for a single instance).
+type Claimer interface {
+	// Claim reports whether the key is claimed by the caller (false means that it has already been claimed).
+	Claim(ctx context.Context, key string) (bool, error)
+}
+
+// RedisClaimer is the Redis-backed Claimer, shared between the app instances, which use the same Redis server.
+type RedisClaimer struct {
+	client redis.Cmdable
+	prefix string
+	ttl    time.Duration
+}
+
+var _ Claimer = (*RedisClaimer)(nil) // ensure interface implementation
+
+// NewRedisClaimer creates a new Redis-backed Claimer. The keys are prefixed with the prefix, and expire after the
+// ttl (it should be longer than the time between the event publishing and receiving).
+func NewRedisClaimer(c redis.Cmdable, prefix string, ttl time.Duration) *RedisClaimer {
+	return &RedisClaimer{client: c, prefix: prefix, ttl: ttl}
+}
+
+// Claim implements the [Claimer] interface.
+func (c *RedisClaimer) Claim(ctx context.Context, key string) (bool, error) {
+	ok, err := c.client.SetNX(ctx, c.prefix+key, 1, c.ttl).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to claim the key: %w", err)
+	}
+
+	return ok, nil
+}
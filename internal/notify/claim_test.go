@@ -0,0 +1,56 @@
+package notify_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gh.tarampamp.am/webhook-tester/v2/internal/notify"
+)
+
+func TestRedisClaimer(t *testing.T) {
+	t.Parallel()
+
+	var (
+		ctx = context.Background()
+		mr  = miniredis.RunT(t)
+		rdb = redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	)
+
+	t.Cleanup(func() { _ = rdb.Close() })
+
+	var (
+		first  = notify.NewRedisClaimer(rdb, "claim:", time.Minute)
+		second = notify.NewRedisClaimer(rdb, "claim:", time.Minute)
+	)
+
+	ok, err := first.Claim(ctx, "foo")
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = second.Claim(ctx, "foo") // already claimed by another instance
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	ok, err = second.Claim(ctx, "bar")
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	assert.Equal(t, time.Minute, mr.TTL("claim:foo"))
+
+	mr.FastForward(time.Minute) // the claim expires
+
+	ok, err = first.Claim(ctx, "foo")
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	mr.Close()
+
+	_, err = first.Claim(ctx, "baz")
+	require.Error(t, err)
+}
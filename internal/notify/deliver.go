@@ -0,0 +1,270 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"gh.tarampamp.am/webhook-tester/v2/internal/forward"
+	"gh.tarampamp.am/webhook-tester/v2/internal/pubsub"
+	"gh.tarampamp.am/webhook-tester/v2/internal/storage"
+)
+
+// Delivery results (used as the metrics label).
+const (
+	resultDelivered = "delivered"
+	resultFailed    = "failed"
+	resultSkipped   = "skipped"
+)
+
+type breakerState uint8
+
+const (
+	breakerClosed   breakerState = iota // the deliveries are allowed
+	breakerOpen                         // the deliveries are skipped
+	breakerHalfOpen                     // a single attempt is allowed to check if the target is back
+)
+
+// dispatch delivers the notifications about the captured request to all the session targets.
+func (d *Dispatcher) dispatch(ctx context.Context, sID string, event *pubsub.Request) {
+	if d.claimer != nil {
+		// on the claiming error, the notification may be delivered more than once, but it's better than losing it
+		if ok, err := d.claimer.Claim(ctx, sID+":"+event.ID); err != nil {
+			d.log.Error("failed to claim the notification", zap.String("session", sID), zap.Error(err))
+		} else if !ok {
+			return // another app instance dispatches it
+		}
+	}
+
+	sess, err := d.db.GetSession(ctx, sID)
+	if err != nil {
+		if !errors.Is(err, storage.ErrNotFound) {
+			d.log.Error("failed to get the session", zap.String("session", sID), zap.Error(err))
+		}
+
+		return
+	}
+
+	if len(sess.Notifications) == 0 {
+		return
+	}
+
+	states, err := d.store.States(ctx, sID)
+	if err != nil { // the circuit breaker is not applied this time
+		d.log.Error("failed to get the notification targets state", zap.String("session", sID), zap.Error(err))
+	}
+
+	req, err := d.db.GetRequest(ctx, sID, event.ID)
+	if err != nil { // e.g., it's already removed - notify using the event details (without the body)
+		req = requestFromEvent(event)
+	}
+
+	var wg sync.WaitGroup
+
+	for _, target := range sess.Notifications {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			d.deliver(ctx, sID, event.ID, target, states[target.ID], req)
+		}()
+	}
+
+	wg.Wait()
+}
+
+// deliver sends the notification to the target (with retries, unless the circuit breaker is open) and records the
+// delivery result.
+func (d *Dispatcher) deliver(
+	ctx context.Context,
+	sID, rID string,
+	t storage.NotificationTarget,
+	ts TargetState,
+	r *storage.Request,
+) {
+	var (
+		start    = d.timeNow()
+		delivery = Delivery{ID: uuid.NewString(), RequestID: rID, CreatedAtUnixMilli: start.UnixMilli()}
+		result   = resultDelivered
+	)
+
+	if state := d.breakerState(ts, start); state == breakerOpen {
+		delivery.Error, result = errCircuitOpen.Error(), resultSkipped
+	} else {
+		var maxAttempts = d.maxAttempts
+
+		if state == breakerHalfOpen {
+			maxAttempts = 1
+		}
+
+		if body, err := newPayload(ctx, t, sID, rID, r); err != nil {
+			delivery.Error = "failed to render the payload: " + err.Error()
+		} else {
+			d.send(ctx, t, body, maxAttempts, &delivery)
+		}
+
+		delivery.Latency = d.timeNow().Sub(start)
+
+		if delivery.Error != "" {
+			result = resultFailed
+		}
+	}
+
+	d.metrics.ObserveNotification(t.Kind, result)
+
+	var log = d.log.With(
+		zap.String("session", sID),
+		zap.String("request", rID),
+		zap.String("target", t.ID),
+		zap.Uint32("attempts", delivery.Attempts),
+	)
+
+	if delivery.Error != "" {
+		log.Warn("notification is not delivered", zap.String("error", delivery.Error))
+	} else {
+		log.Debug("notification delivered", zap.Duration("latency", delivery.Latency))
+	}
+
+	if err := d.record(ctx, sID, t.ID, delivery); err != nil {
+		log.Error("failed to record the notification delivery", zap.Error(err))
+	}
+}
+
+// send makes up to maxAttempts attempts to deliver the notification, with the exponential backoff between them. The
+// delivery attempts, status code and error are updated with the last attempt result.
+func (d *Dispatcher) send(
+	ctx context.Context,
+	t storage.NotificationTarget,
+	body []byte,
+	maxAttempts uint32,
+	delivery *Delivery,
+) {
+	for attempt := uint32(1); ; attempt++ {
+		delivery.Attempts = attempt
+
+		if retryable := d.attempt(ctx, t, body, delivery); !retryable || attempt >= maxAttempts {
+			return
+		}
+
+		var timer = time.NewTimer(d.backoffDelay(attempt))
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+// attempt sends the notification once, and reports whether the failed attempt can be retried (the network errors,
+// timeouts, and 408, 429 and 5xx responses).
+func (d *Dispatcher) attempt(
+	ctx context.Context,
+	t storage.NotificationTarget,
+	body []byte,
+	delivery *Delivery,
+) (retryable bool) {
+	headers, err := newHeaders(t, delivery.ID, d.timeNow(), body)
+	if err != nil {
+		delivery.Error = err.Error()
+
+		return false
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, d.attemptTimeout)
+	defer cancel()
+
+	resp, err := d.client.Do(ctx, forward.Request{Method: http.MethodPost, URL: t.URL, Headers: headers, Body: body})
+	if err != nil {
+		delivery.StatusCode, delivery.Error = 0, err.Error()
+
+		return true
+	}
+
+	delivery.StatusCode = uint16(resp.StatusCode) //nolint:gosec
+
+	if resp.StatusCode >= http.StatusOK && resp.StatusCode < http.StatusMultipleChoices {
+		delivery.Error = ""
+
+		return false
+	}
+
+	delivery.Error = "unexpected response status code " + strconv.Itoa(resp.StatusCode)
+
+	return resp.StatusCode == http.StatusRequestTimeout ||
+		resp.StatusCode == http.StatusTooManyRequests ||
+		resp.StatusCode >= http.StatusInternalServerError
+}
+
+// backoffDelay returns the delay before the next attempt (the first retry delay, doubled on each next retry).
+func (d *Dispatcher) backoffDelay(attempt uint32) time.Duration {
+	var delay = d.backoff
+
+	for i := uint32(1); i < attempt && delay < d.maxBackoff; i++ {
+		delay *= 2
+	}
+
+	return min(delay, d.maxBackoff)
+}
+
+// breakerState returns the circuit breaker state of the target.
+func (d *Dispatcher) breakerState(ts TargetState, now time.Time) breakerState {
+	switch {
+	case d.breakerThreshold == 0 || ts.Failures < d.breakerThreshold:
+		return breakerClosed
+	case now.UnixMilli() < ts.OpenUntilUnixMilli:
+		return breakerOpen
+	default:
+		return breakerHalfOpen
+	}
+}
+
+// record prepends the delivery to the target log, and updates the target circuit breaker state.
+func (d *Dispatcher) record(ctx context.Context, sID, targetID string, delivery Delivery) error {
+	var now = d.timeNow()
+
+	return d.store.UpdateState(ctx, sID, targetID, func(ts *TargetState) {
+		switch {
+		case delivery.Attempts == 0: // skipped, the circuit breaker state is not changed
+		case delivery.Error == "":
+			ts.Failures, ts.OpenUntilUnixMilli = 0, 0
+		default:
+			if ts.Failures++; d.breakerThreshold > 0 && ts.Failures >= d.breakerThreshold {
+				ts.OpenUntilUnixMilli = now.Add(d.breakerCooldown).UnixMilli()
+			}
+		}
+
+		ts.Deliveries = append([]Delivery{delivery}, ts.Deliveries...)
+
+		if len(ts.Deliveries) > MaxDeliveries {
+			ts.Deliveries = ts.Deliveries[:MaxDeliveries]
+		}
+	})
+}
+
+// requestFromEvent converts the event request details into the storage format (the body is not included).
+func requestFromEvent(r *pubsub.Request) *storage.Request {
+	var headers = make([]storage.HttpHeader, len(r.Headers))
+
+	for i, h := range r.Headers {
+		headers[i] = storage.HttpHeader{Name: h.Name, Value: h.Value}
+	}
+
+	return &storage.Request{
+		ClientAddr:         r.ClientAddr,
+		PeerAddr:           r.PeerAddr,
+		Method:             r.Method,
+		Headers:            headers,
+		URL:                r.URL,
+		CreatedAtUnixMilli: r.CreatedAtUnixMilli,
+	}
+}
@@ -0,0 +1,371 @@
+// Package notify sends the outbound notifications (HTTP callbacks or templated chat messages, e.g. for Slack or
+// Microsoft Teams) when the requests are captured by the sessions with the notification targets.
+//
+// The Dispatcher subscribes to the request events of such sessions using the pub/sub, so it works with any pub/sub
+// driver. The failed deliveries are retried with the exponential backoff, and the targets that keep failing are
+// skipped for a while (the circuit breaker). The index of the sessions with the targets, the deliveries log and the
+// circuit breaker state are kept in the Store (apart from the sessions), which may be shared between the app
+// instances.
+package notify
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"gh.tarampamp.am/webhook-tester/v2/internal/forward"
+	"gh.tarampamp.am/webhook-tester/v2/internal/metrics"
+	"gh.tarampamp.am/webhook-tester/v2/internal/pubsub"
+	"gh.tarampamp.am/webhook-tester/v2/internal/storage"
+)
+
+// TimeFunc returns the current time.
+type TimeFunc func() time.Time
+
+// Defaults.
+const (
+	DefaultMaxAttempts      uint32 = 5                // delivery attempts (including the first one)
+	DefaultBackoff                 = time.Second      // the delay before the first retry (doubled on each retry)
+	DefaultMaxBackoff              = 30 * time.Second // the maximal delay between the retries
+	DefaultAttemptTimeout          = 10 * time.Second // a single delivery attempt timeout
+	DefaultBreakerThreshold uint32 = 5                // consecutive failed deliveries to open the circuit breaker
+	DefaultBreakerCooldown         = time.Minute      // how long the open circuit breaker skips the deliveries
+	DefaultRescanInterval          = 30 * time.Second // how often the indexed sessions with the targets are checked
+)
+
+// maxConcurrentDispatches limits the number of the events, which are dispatched concurrently.
+const maxConcurrentDispatches = 64
+
+// maxResponseBodySize limits the target response body reading (it's not stored anyway).
+const maxResponseBodySize = 64 << 10 // 64 KiB
+
+// MaxDeliveries is the maximal number of the deliveries kept in the log of each target (the oldest are removed).
+const MaxDeliveries = 20
+
+type (
+	// Dispatcher watches the sessions with the notification targets and delivers the notifications about the
+	// captured requests.
+	Dispatcher struct {
+		log     *zap.Logger
+		db      storage.Storage
+		sub     pubsub.Subscriber[pubsub.RequestEvent]
+		policy  *forward.HostPolicy
+		client  *forward.Client
+		claimer Claimer
+		store   Store
+		metrics *metrics.Metrics
+		timeNow TimeFunc
+
+		maxAttempts      uint32
+		backoff          time.Duration
+		maxBackoff       time.Duration
+		attemptTimeout   time.Duration
+		breakerThreshold uint32
+		breakerCooldown  time.Duration
+		rescanInterval   time.Duration
+
+		mu      sync.Mutex
+		ctx     context.Context                     // the Run context (nil, if the dispatcher is not running)
+		watched map[ /* session ID */ string]func() // stops the session watching
+		sem     chan struct{}                       // limits the concurrent dispatching
+		wg      sync.WaitGroup
+	}
+
+	// Option allows you to configure the Dispatcher during creation.
+	Option func(*Dispatcher)
+)
+
+// WithHostPolicy sets the policy for the notification target hosts.
+func WithHostPolicy(p *forward.HostPolicy) Option { return func(d *Dispatcher) { d.policy = p } }
+
+// WithClaimer sets the claimer, which ensures the single delivery when multiple app instances share the pub/sub.
+func WithClaimer(c Claimer) Option { return func(d *Dispatcher) { d.claimer = c } }
+
+// WithStore sets the store for the index of the sessions with the targets and the delivery state (in-memory by
+// default).
+func WithStore(s Store) Option { return func(d *Dispatcher) { d.store = s } }
+
+// WithMetrics sets the metrics collector.
+func WithMetrics(m *metrics.Metrics) Option { return func(d *Dispatcher) { d.metrics = m } }
+
+// WithTimeNow sets the function that returns the current time.
+func WithTimeNow(fn TimeFunc) Option { return func(d *Dispatcher) { d.timeNow = fn } }
+
+// WithRetries sets the maximal number of the delivery attempts and the delay before the first retry (doubled on
+// each next retry, up to the maxBackoff).
+func WithRetries(maxAttempts uint32, backoff, maxBackoff time.Duration) Option {
+	return func(d *Dispatcher) { d.maxAttempts, d.backoff, d.maxBackoff = max(1, maxAttempts), backoff, maxBackoff }
+}
+
+// WithAttemptTimeout sets the single delivery attempt timeout.
+func WithAttemptTimeout(t time.Duration) Option { return func(d *Dispatcher) { d.attemptTimeout = t } }
+
+// WithCircuitBreaker sets the number of the consecutive failed deliveries, after which the target is skipped for
+// the cooldown duration (then, a single attempt is made to check if the target is back; zero threshold disables
+// the circuit breaker).
+func WithCircuitBreaker(threshold uint32, cooldown time.Duration) Option {
+	return func(d *Dispatcher) { d.breakerThreshold, d.breakerCooldown = threshold, cooldown }
+}
+
+// WithRescanInterval sets how often the indexed sessions with the notification targets are checked (to pick up the
+// targets, set using another app instance, and to stop watching the deleted sessions).
+func WithRescanInterval(i time.Duration) Option { return func(d *Dispatcher) { d.rescanInterval = i } }
+
+// New creates a new Dispatcher. Use the Run method to start it.
+func New(
+	log *zap.Logger,
+	db storage.Storage,
+	sub pubsub.Subscriber[pubsub.RequestEvent],
+	opts ...Option,
+) *Dispatcher {
+	var d = Dispatcher{
+		log:              log,
+		db:               db,
+		sub:              sub,
+		store:            NewInMemoryStore(),
+		timeNow:          time.Now,
+		maxAttempts:      DefaultMaxAttempts,
+		backoff:          DefaultBackoff,
+		maxBackoff:       DefaultMaxBackoff,
+		attemptTimeout:   DefaultAttemptTimeout,
+		breakerThreshold: DefaultBreakerThreshold,
+		breakerCooldown:  DefaultBreakerCooldown,
+		rescanInterval:   DefaultRescanInterval,
+		watched:          make(map[string]func()),
+		sem:              make(chan struct{}, maxConcurrentDispatches),
+	}
+
+	for _, opt := range opts {
+		opt(&d)
+	}
+
+	d.client = forward.New(forward.WithHostPolicy(d.policy), forward.WithMaxBodySize(maxResponseBodySize))
+
+	return &d
+}
+
+// Run watches the sessions with the notification targets until the context is canceled. It blocks until all the
+// pending deliveries are done.
+//
+// On start, the sessions are scanned once to index the ones with the targets (e.g. the in-memory index is lost on
+// restart). Later, only the indexed sessions are checked.
+func (d *Dispatcher) Run(ctx context.Context) {
+	d.mu.Lock()
+	d.ctx = ctx
+	d.mu.Unlock()
+
+	defer func() {
+		d.mu.Lock()
+
+		for sID, stop := range d.watched {
+			stop()
+			delete(d.watched, sID)
+		}
+
+		d.ctx = nil
+		d.mu.Unlock()
+
+		d.wg.Wait()
+	}()
+
+	if err := d.backfill(ctx); err != nil && ctx.Err() == nil {
+		d.log.Error("failed to index the sessions with the notification targets", zap.Error(err))
+	}
+
+	var ticker = time.NewTicker(d.rescanInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := d.rescan(ctx); err != nil && ctx.Err() == nil {
+			d.log.Error("failed to check the sessions with the notification targets", zap.Error(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// SetTargets must be called right after the session notification targets are set (or removed). It updates the
+// index and starts (or stops) watching the session. It's safe to call on a nil Dispatcher.
+func (d *Dispatcher) SetTargets(ctx context.Context, sID string, targetIDs []string) error {
+	if d == nil {
+		return nil
+	}
+
+	if err := d.store.SetTargets(ctx, sID, targetIDs); err != nil {
+		return err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if len(targetIDs) > 0 {
+		d.watch(sID)
+	} else {
+		d.unwatch(sID)
+	}
+
+	return nil
+}
+
+// States returns the delivery state of the session notification targets by the target ID. It's safe to call on a
+// nil Dispatcher (nil is returned).
+func (d *Dispatcher) States(ctx context.Context, sID string) (map[string]TargetState, error) {
+	if d == nil {
+		return nil, nil
+	}
+
+	return d.store.States(ctx, sID)
+}
+
+// unwatch stops watching the session (if watched). The caller must hold the lock.
+func (d *Dispatcher) unwatch(sID string) {
+	if stop, ok := d.watched[sID]; ok {
+		stop()
+		delete(d.watched, sID)
+	}
+}
+
+// watch subscribes to the session request events. The caller must hold the lock.
+func (d *Dispatcher) watch(sID string) {
+	if _, ok := d.watched[sID]; ok || d.ctx == nil || d.ctx.Err() != nil {
+		return
+	}
+
+	events, unsubscribe, err := d.sub.Subscribe(d.ctx, sID)
+	if err != nil {
+		d.log.Error("failed to subscribe to the session events", zap.String("session", sID), zap.Error(err))
+
+		return
+	}
+
+	var ctx, cancel = context.WithCancel(d.ctx)
+
+	d.watched[sID] = func() { cancel(); unsubscribe() }
+
+	d.wg.Add(1)
+
+	go func() {
+		defer d.wg.Done()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case event, isOpened := <-events:
+				if !isOpened {
+					return
+				}
+
+				if event.Action != pubsub.RequestActionCreate || event.Request == nil || event.Request.Dropped != "" {
+					continue
+				}
+
+				select { // limit the concurrent dispatching (the events are not dropped, but the reading is paused)
+				case <-ctx.Done():
+					return
+				case d.sem <- struct{}{}:
+				}
+
+				d.wg.Add(1)
+
+				go func(req *pubsub.Request) {
+					defer func() { <-d.sem; d.wg.Done() }()
+
+					d.dispatch(ctx, sID, req)
+				}(event.Request)
+			}
+		}
+	}()
+}
+
+// backfill scans all the sessions and indexes the ones with the notification targets.
+func (d *Dispatcher) backfill(ctx context.Context) error {
+	const pageSize = 100
+
+	var query = storage.SessionsQuery{Limit: pageSize}
+
+	for {
+		page, err := d.db.ListSessions(ctx, query)
+		if err != nil {
+			return err
+		}
+
+		for _, s := range page.Sessions {
+			if len(s.Notifications) > 0 {
+				if sErr := d.store.SetTargets(ctx, s.ID, targetIDs(s.Notifications)); sErr != nil {
+					return sErr
+				}
+			}
+		}
+
+		if page.NextCursor == "" {
+			return nil
+		}
+
+		query.Cursor = page.NextCursor
+	}
+}
+
+// rescan checks the indexed sessions, starts watching the new ones and stops watching (and removes from the index)
+// the sessions without the targets (or the deleted ones).
+func (d *Dispatcher) rescan(ctx context.Context) error {
+	indexed, err := d.store.Sessions(ctx)
+	if err != nil {
+		return err
+	}
+
+	var withTargets = make(map[string]struct{}, len(indexed))
+
+	for _, sID := range indexed {
+		sess, sErr := d.db.GetSession(ctx, sID)
+		if sErr != nil && !errors.Is(sErr, storage.ErrNotFound) {
+			return sErr
+		}
+
+		if sess == nil || len(sess.Notifications) == 0 {
+			if uErr := d.store.SetTargets(ctx, sID, nil); uErr != nil {
+				return uErr
+			}
+
+			continue
+		}
+
+		withTargets[sID] = struct{}{}
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for sID := range d.watched {
+		if _, ok := withTargets[sID]; !ok {
+			d.unwatch(sID)
+		}
+	}
+
+	for sID := range withTargets {
+		d.watch(sID)
+	}
+
+	return nil
+}
+
+// targetIDs returns the IDs of the targets.
+func targetIDs(targets []storage.NotificationTarget) []string {
+	var ids = make([]string, len(targets))
+
+	for i, t := range targets {
+		ids[i] = t.ID
+	}
+
+	return ids
+}
+
+// errCircuitOpen is recorded for the deliveries, skipped by the open circuit breaker.
+var errCircuitOpen = errors.New("skipped: the target keeps failing (circuit breaker is open)")
@@ -0,0 +1,411 @@
+package notify_test
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"gh.tarampamp.am/webhook-tester/v2/internal/forward"
+	"gh.tarampamp.am/webhook-tester/v2/internal/notify"
+	"gh.tarampamp.am/webhook-tester/v2/internal/pubsub"
+	"gh.tarampamp.am/webhook-tester/v2/internal/signature"
+	"gh.tarampamp.am/webhook-tester/v2/internal/storage"
+)
+
+// subscriber notifies about the subscriptions (after them, the published events are received).
+type subscriber struct {
+	pubsub.Subscriber[pubsub.RequestEvent]
+
+	subscribed chan string
+}
+
+func (s *subscriber) Subscribe(ctx context.Context, topic string) (<-chan pubsub.RequestEvent, func(), error) {
+	ch, unsubscribe, err := s.Subscriber.Subscribe(ctx, topic)
+
+	s.subscribed <- topic
+
+	return ch, unsubscribe, err
+}
+
+// fakeTime is the concurrency-safe adjustable clock.
+type fakeTime struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (f *fakeTime) Now() time.Time { f.mu.Lock(); defer f.mu.Unlock(); return f.now }
+
+func (f *fakeTime) Add(d time.Duration) { f.mu.Lock(); f.now = f.now.Add(d); f.mu.Unlock() }
+
+type testEnv struct {
+	db  *storage.InMemory
+	ps  *pubsub.InMemory[pubsub.RequestEvent]
+	sub *subscriber
+	d   *notify.Dispatcher
+}
+
+func newTestEnv(t *testing.T, opts ...notify.Option) *testEnv {
+	t.Helper()
+
+	var env = testEnv{
+		db: storage.NewInMemory(time.Minute, 32),
+		ps: pubsub.NewInMemory[pubsub.RequestEvent](),
+	}
+
+	t.Cleanup(func() { _ = env.db.Close() })
+
+	env.sub = &subscriber{Subscriber: env.ps, subscribed: make(chan string, 8)}
+	anyHost, err := forward.NewHostPolicy(nil, nil) // the test targets listen on the loopback interface
+	require.NoError(t, err)
+
+	env.d = notify.New(zap.NewNop(), env.db, env.sub, append([]notify.Option{
+		notify.WithHostPolicy(anyHost),
+		notify.WithRetries(3, time.Millisecond, 5*time.Millisecond),
+	}, opts...)...)
+
+	var ctx, cancel = context.WithCancel(context.Background())
+
+	var done = make(chan struct{})
+
+	go func() { defer close(done); env.d.Run(ctx) }()
+
+	t.Cleanup(func() { cancel(); <-done })
+
+	return &env
+}
+
+// capture stores the request and publishes the event about it.
+func (env *testEnv) capture(t *testing.T, sID string, r storage.Request) string {
+	t.Helper()
+
+	var ctx = context.Background()
+
+	rID, err := env.db.NewRequest(ctx, sID, r)
+	require.NoError(t, err)
+
+	require.NoError(t, env.ps.Publish(ctx, sID, pubsub.RequestEvent{
+		Action: pubsub.RequestActionCreate,
+		Request: &pubsub.Request{
+			ID:         rID,
+			ClientAddr: r.ClientAddr,
+			Method:     r.Method,
+			URL:        r.URL,
+			Dropped:    r.Dropped,
+		},
+	}))
+
+	return rID
+}
+
+// waitDeliveries waits until the session target has the given number of the logged deliveries.
+func (env *testEnv) waitDeliveries(t *testing.T, sID, targetID string, count int) notify.TargetState {
+	t.Helper()
+
+	var got notify.TargetState
+
+	require.Eventually(t, func() bool {
+		states, err := env.d.States(context.Background(), sID)
+		require.NoError(t, err)
+
+		got = states[targetID]
+
+		return len(got.Deliveries) >= count
+	}, 5*time.Second, 5*time.Millisecond)
+
+	require.Len(t, got.Deliveries, count)
+
+	return got
+}
+
+func TestDispatcher(t *testing.T) {
+	t.Parallel()
+
+	const secret = "whsec_MfKQ9r8GKYqrTwjUPD8ILPZIo2LaLaSw"
+
+	var (
+		callbackCalls atomic.Int32
+		callbackReqs  = make(chan *http.Request, 8)
+		callbackBody  = make(chan []byte, 8)
+	)
+
+	var callback = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if callbackCalls.Add(1) == 1 {
+			w.WriteHeader(http.StatusBadGateway) // the first attempt fails
+
+			return
+		}
+
+		body, _ := io.ReadAll(r.Body)
+
+		callbackReqs <- r
+		callbackBody <- body
+	}))
+
+	t.Cleanup(callback.Close)
+
+	var chatBody = make(chan []byte, 8)
+
+	var chat = httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		chatBody <- body
+	}))
+
+	t.Cleanup(chat.Close)
+
+	var env = newTestEnv(t)
+
+	sID, err := env.db.NewSession(context.Background(), storage.Session{
+		Notifications: []storage.NotificationTarget{
+			{ID: "callback", Kind: storage.NotificationKindHTTP, URL: callback.URL, Secret: secret},
+			{ID: "chat", Kind: storage.NotificationKindTemplate, URL: chat.URL},
+		},
+	})
+	require.NoError(t, err)
+
+	// the session may be already indexed by the initial scan (if the dispatcher is running)
+	require.NoError(t, env.d.SetTargets(context.Background(), sID, []string{"callback", "chat"}))
+
+	require.Equal(t, sID, <-env.sub.subscribed)
+
+	// the dropped requests are not notified about
+	env.capture(t, sID, storage.Request{Method: http.MethodPost, URL: "http://example.com/", Dropped: "rate limited"})
+
+	var rID = env.capture(t, sID, storage.Request{
+		ClientAddr: "198.51.100.1",
+		PeerAddr:   "10.0.0.1",
+		Method:     http.MethodPost,
+		Headers:    []storage.HttpHeader{{Name: "Content-Type", Value: "text/plain"}},
+		URL:        "http://example.com/" + sID + "/foo",
+		Body:       []byte("payload"),
+	})
+
+	// the callback is retried and signed
+	var (
+		req  = <-callbackReqs
+		body = <-callbackBody
+	)
+
+	assert.Equal(t, http.MethodPost, req.Method)
+	assert.Equal(t, "application/json", req.Header.Get("Content-Type"))
+	require.NoError(t, signature.Verify(signature.ProviderStandard, secret, req.Header, body, time.Now(), time.Minute))
+
+	var payload struct {
+		Type        string `json:"type"`
+		SessionUUID string `json:"session_uuid"`
+		Request     struct {
+			UUID          string `json:"uuid"`
+			ClientAddress string `json:"client_address"`
+			PeerAddress   string `json:"peer_address"`
+			Method        string `json:"method"`
+			URL           string `json:"url"`
+			Headers       []struct {
+				Name, Value string
+			} `json:"headers"`
+			RequestPayloadBase64 string `json:"request_payload_base64"`
+		} `json:"request"`
+	}
+
+	require.NoError(t, json.Unmarshal(body, &payload))
+	assert.Equal(t, notify.EventRequestCaptured, payload.Type)
+	assert.Equal(t, sID, payload.SessionUUID)
+	assert.Equal(t, rID, payload.Request.UUID)
+	assert.Equal(t, "198.51.100.1", payload.Request.ClientAddress)
+	assert.Equal(t, "10.0.0.1", payload.Request.PeerAddress)
+	assert.Equal(t, http.MethodPost, payload.Request.Method)
+	assert.Equal(t, "http://example.com/"+sID+"/foo", payload.Request.URL)
+	assert.Len(t, payload.Request.Headers, 1)
+	assert.Equal(t, base64.StdEncoding.EncodeToString([]byte("payload")), payload.Request.RequestPayloadBase64)
+
+	// the chat message is rendered using the default template
+	var chatMessage struct{ Text string }
+
+	require.NoError(t, json.Unmarshal(<-chatBody, &chatMessage))
+	assert.Equal(t, "New POST request captured by the session "+sID+": http://example.com/"+sID+"/foo", chatMessage.Text)
+
+	// the deliveries are logged
+	var target = env.waitDeliveries(t, sID, "callback", 1)
+
+	assert.Equal(t, rID, target.Deliveries[0].RequestID)
+	assert.EqualValues(t, 2, target.Deliveries[0].Attempts)
+	assert.EqualValues(t, http.StatusOK, target.Deliveries[0].StatusCode)
+	assert.Empty(t, target.Deliveries[0].Error)
+	assert.Zero(t, target.Failures)
+
+	target = env.waitDeliveries(t, sID, "chat", 1)
+
+	assert.EqualValues(t, 1, target.Deliveries[0].Attempts)
+	assert.Empty(t, target.Deliveries[0].Error)
+
+	assert.EqualValues(t, 2, callbackCalls.Load())
+}
+
+func TestDispatcher_CircuitBreaker(t *testing.T) {
+	t.Parallel()
+
+	var (
+		calls   atomic.Int32
+		healthy atomic.Bool
+		clock   = &fakeTime{now: time.Unix(1700000000, 0)}
+	)
+
+	var srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		calls.Add(1)
+
+		if !healthy.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+
+	t.Cleanup(srv.Close)
+
+	var env = newTestEnv(t,
+		notify.WithRetries(2, time.Millisecond, time.Millisecond),
+		notify.WithCircuitBreaker(2, time.Minute),
+		notify.WithTimeNow(clock.Now),
+	)
+
+	sID, err := env.db.NewSession(context.Background(), storage.Session{})
+	require.NoError(t, err)
+
+	// the targets are set after the dispatcher start
+	require.NoError(t, env.db.UpdateSession(context.Background(), sID, func(s *storage.Session) error {
+		s.Notifications = []storage.NotificationTarget{{ID: "t", Kind: storage.NotificationKindHTTP, URL: srv.URL}}
+
+		return nil
+	}))
+
+	require.Eventually(t, func() bool { // the dispatcher may be not running yet
+		require.NoError(t, env.d.SetTargets(context.Background(), sID, []string{"t"}))
+
+		select {
+		case <-env.sub.subscribed:
+			return true
+		default:
+			return false
+		}
+	}, 5*time.Second, 5*time.Millisecond)
+
+	var capture = func() { env.capture(t, sID, storage.Request{Method: http.MethodPost, URL: srv.URL}) }
+
+	// the failed deliveries are retried
+	capture()
+
+	var target = env.waitDeliveries(t, sID, "t", 1)
+
+	assert.EqualValues(t, 2, target.Deliveries[0].Attempts)
+	assert.EqualValues(t, http.StatusServiceUnavailable, target.Deliveries[0].StatusCode)
+	assert.Equal(t, "unexpected response status code 503", target.Deliveries[0].Error)
+	assert.EqualValues(t, 1, target.Failures)
+	assert.Zero(t, target.OpenUntilUnixMilli)
+
+	// the circuit breaker opens after the second failed delivery
+	capture()
+
+	target = env.waitDeliveries(t, sID, "t", 2)
+
+	assert.EqualValues(t, 2, target.Failures)
+	assert.Equal(t, clock.Now().Add(time.Minute).UnixMilli(), target.OpenUntilUnixMilli)
+	assert.EqualValues(t, 4, calls.Load())
+
+	// and the deliveries are skipped
+	capture()
+
+	target = env.waitDeliveries(t, sID, "t", 3)
+
+	assert.Zero(t, target.Deliveries[0].Attempts)
+	assert.Contains(t, target.Deliveries[0].Error, "circuit breaker is open")
+	assert.EqualValues(t, 4, calls.Load())
+
+	// after the cooldown, a single attempt is made (and it fails again)
+	clock.Add(time.Minute)
+	capture()
+
+	target = env.waitDeliveries(t, sID, "t", 4)
+
+	assert.EqualValues(t, 1, target.Deliveries[0].Attempts)
+	assert.EqualValues(t, 3, target.Failures)
+	assert.Equal(t, clock.Now().Add(time.Minute).UnixMilli(), target.OpenUntilUnixMilli)
+	assert.EqualValues(t, 5, calls.Load())
+
+	// the target is back
+	clock.Add(time.Minute)
+	healthy.Store(true)
+	capture()
+
+	target = env.waitDeliveries(t, sID, "t", 5)
+
+	assert.EqualValues(t, 1, target.Deliveries[0].Attempts)
+	assert.Empty(t, target.Deliveries[0].Error)
+	assert.Zero(t, target.Failures)
+	assert.Zero(t, target.OpenUntilUnixMilli)
+
+	// the deliveries log is limited
+	for range notify.MaxDeliveries {
+		capture()
+	}
+
+	require.Eventually(t, func() bool { return calls.Load() == 6+notify.MaxDeliveries }, 5*time.Second, time.Millisecond)
+	require.Eventually(t, func() bool {
+		states, gErr := env.d.States(context.Background(), sID)
+		require.NoError(t, gErr)
+
+		return len(states["t"].Deliveries) == notify.MaxDeliveries &&
+			!strings.Contains(states["t"].Deliveries[notify.MaxDeliveries-1].Error, "circuit")
+	}, 5*time.Second, 5*time.Millisecond)
+}
+
+func TestDispatcher_Rescan(t *testing.T) {
+	t.Parallel()
+
+	var (
+		ctx   = context.Background()
+		store = notify.NewInMemoryStore()
+		env   = newTestEnv(t, notify.WithStore(store), notify.WithRescanInterval(5*time.Millisecond))
+	)
+
+	sID, err := env.db.NewSession(ctx, storage.Session{
+		Notifications: []storage.NotificationTarget{{ID: "t", Kind: storage.NotificationKindHTTP, URL: "http://foo"}},
+	})
+	require.NoError(t, err)
+
+	// the targets are set using another app instance (sharing the store), so the session is picked up by the rescan
+	require.NoError(t, store.SetTargets(ctx, sID, []string{"t"}))
+	require.Equal(t, sID, <-env.sub.subscribed)
+
+	// the deleted session is removed from the index
+	require.NoError(t, env.db.DeleteSession(ctx, sID))
+
+	require.Eventually(t, func() bool {
+		indexed, sErr := store.Sessions(ctx)
+		require.NoError(t, sErr)
+
+		return len(indexed) == 0
+	}, 5*time.Second, 5*time.Millisecond)
+}
+
+func TestDispatcher_Nil(t *testing.T) {
+	t.Parallel()
+
+	var d *notify.Dispatcher
+
+	assert.NotPanics(t, func() {
+		assert.NoError(t, d.SetTargets(context.Background(), "foo", []string{"bar"}))
+
+		states, err := d.States(context.Background(), "foo")
+		assert.NoError(t, err)
+		assert.Nil(t, states)
+	})
+}
@@ -0,0 +1,129 @@
+package notify
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"gh.tarampamp.am/webhook-tester/v2/internal/signature"
+	"gh.tarampamp.am/webhook-tester/v2/internal/storage"
+	"gh.tarampamp.am/webhook-tester/v2/internal/template"
+	"gh.tarampamp.am/webhook-tester/v2/internal/version"
+)
+
+// DefaultTemplate is the payload template of the "template" targets without their own template. The {"text": ...}
+// payload is accepted by the Slack and Microsoft Teams (and many other chats) incoming webhooks.
+const DefaultTemplate = `{"text": {{ printf "New %s request captured by the session %s: %s" ` +
+	`.Method .SessionID .URL | toJSON }}}`
+
+// EventRequestCaptured is the "type" of the HTTP callback payload.
+const EventRequestCaptured = "request.captured"
+
+type (
+	// callbackPayload is the HTTP callback payload (the request field names are the same as in the API).
+	callbackPayload struct {
+		Type        string          `json:"type"`
+		SessionUUID string          `json:"session_uuid"`
+		Request     callbackRequest `json:"request"`
+	}
+
+	callbackRequest struct {
+		UUID                 string           `json:"uuid"`
+		ClientAddress        string           `json:"client_address"`
+		PeerAddress          string           `json:"peer_address,omitempty"`
+		Method               string           `json:"method"`
+		Headers              []callbackHeader `json:"headers"`
+		URL                  string           `json:"url"`
+		CapturedAtUnixMilli  int64            `json:"captured_at_unix_milli"`
+		RequestPayloadBase64 string           `json:"request_payload_base64"`
+	}
+
+	callbackHeader struct {
+		Name  string `json:"name"`
+		Value string `json:"value"`
+	}
+)
+
+// newPayload renders the notification body for the target.
+func newPayload(
+	ctx context.Context,
+	t storage.NotificationTarget,
+	sID, rID string,
+	r *storage.Request,
+) ([]byte, error) {
+	if t.Kind == storage.NotificationKindTemplate {
+		var (
+			tpl     = t.Template
+			headers = make(http.Header, len(r.Headers))
+		)
+
+		if tpl == "" {
+			tpl = DefaultTemplate
+		}
+
+		for _, h := range r.Headers {
+			headers.Add(h.Name, h.Value)
+		}
+
+		u, _ := url.Parse(r.URL) // nil on error, it's fine for the template
+
+		return template.Render(ctx, tpl, template.Request{
+			Method:     r.Method,
+			URL:        u,
+			Headers:    headers,
+			Body:       r.Body,
+			ClientAddr: r.ClientAddr,
+			SessionID:  sID,
+			RequestID:  rID,
+		}, 0)
+	}
+
+	var payload = callbackPayload{
+		Type:        EventRequestCaptured,
+		SessionUUID: sID,
+		Request: callbackRequest{
+			UUID:                 rID,
+			ClientAddress:        r.ClientAddr,
+			PeerAddress:          r.PeerAddr,
+			Method:               r.Method,
+			Headers:              make([]callbackHeader, len(r.Headers)),
+			URL:                  r.URL,
+			CapturedAtUnixMilli:  r.CreatedAtUnixMilli,
+			RequestPayloadBase64: base64.StdEncoding.EncodeToString(r.Body),
+		},
+	}
+
+	for i, h := range r.Headers {
+		payload.Request.Headers[i] = callbackHeader{Name: h.Name, Value: h.Value}
+	}
+
+	return json.Marshal(payload)
+}
+
+// newHeaders returns the notification request headers. If the target has a secret, the body is signed using the
+// Standard Webhooks scheme (https://www.standardwebhooks.com), so the receiver can verify it.
+func newHeaders(t storage.NotificationTarget, deliveryID string, now time.Time, body []byte) (http.Header, error) {
+	var h = http.Header{
+		"Content-Type": {"application/json"},
+		"User-Agent":   {"webhook-tester/" + version.Version()},
+	}
+
+	h.Set("Webhook-Id", deliveryID)
+	h.Set("Webhook-Timestamp", strconv.FormatInt(now.Unix(), 10))
+
+	if t.Secret != "" {
+		sig, err := signature.SignStandard(t.Secret, deliveryID, now, body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to sign the payload: %w", err)
+		}
+
+		h.Set("Webhook-Signature", sig)
+	}
+
+	return h, nil
+}
@@ -0,0 +1,293 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+type (
+	// Store keeps the index of the sessions with the notification targets, and the delivery state of the targets.
+	// They are kept apart from the sessions, so the dispatcher neither scans all the sessions, nor rewrites them on
+	// every delivery.
+	Store interface {
+		// Sessions returns the IDs of the indexed sessions (the ones with the notification targets).
+		Sessions(ctx context.Context) ([]string, error)
+
+		// SetTargets indexes the session with the given targets (or removes it from the index, if there are none),
+		// and removes the delivery state of the other (removed) targets.
+		SetTargets(ctx context.Context, sID string, targetIDs []string) error
+
+		// States returns the delivery state of the session targets by the target ID (the targets without the
+		// deliveries may be missing).
+		States(ctx context.Context, sID string) (map[string]TargetState, error)
+
+		// UpdateState atomically applies the update function to the delivery state of the target. It's a no-op if
+		// the session is not indexed (e.g., the targets have been removed in the meantime).
+		UpdateState(ctx context.Context, sID, targetID string, update func(*TargetState)) error
+	}
+
+	// TargetState is the delivery state of the notification target: the recent deliveries and the circuit breaker
+	// state.
+	TargetState struct {
+		Failures           uint32     `json:"failures,omitempty"`              // consecutive failures
+		OpenUntilUnixMilli int64      `json:"open_until_unix_milli,omitempty"` // circuit breaker is open
+		Deliveries         []Delivery `json:"deliveries,omitempty"`            // recent deliveries log (newest first)
+	}
+
+	// Delivery describes the notification delivery attempt(s) result.
+	Delivery struct {
+		ID                 string        `json:"id"`                    // unique delivery ID
+		RequestID          string        `json:"request_id"`            // the captured request ID
+		Attempts           uint32        `json:"attempts"`              // zero if skipped (circuit breaker is open)
+		StatusCode         uint16        `json:"status_code,omitempty"` // the last response code
+		Error              string        `json:"error,omitempty"`       // the last error (empty if delivered)
+		Latency            time.Duration `json:"latency"`               // the total time, including retries
+		CreatedAtUnixMilli int64         `json:"created_at_unix_milli"` // delivery start time
+	}
+)
+
+// clone returns the deep copy of the state.
+func (s TargetState) clone() TargetState {
+	s.Deliveries = slices.Clone(s.Deliveries)
+
+	return s
+}
+
+// InMemoryStore is the Store for a single app instance (the state is lost on restart).
+type InMemoryStore struct {
+	mu       sync.Mutex
+	sessions map[ /* session ID */ string]map[ /* target ID */ string]TargetState
+}
+
+var _ Store = (*InMemoryStore)(nil) // ensure interface implementation
+
+// NewInMemoryStore creates a new in-memory Store.
+func NewInMemoryStore() *InMemoryStore {
+	return &InMemoryStore{sessions: make(map[string]map[string]TargetState)}
+}
+
+// Sessions implements the [Store] interface.
+func (s *InMemoryStore) Sessions(context.Context) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var ids = make([]string, 0, len(s.sessions))
+
+	for sID := range s.sessions {
+		ids = append(ids, sID)
+	}
+
+	return ids, nil
+}
+
+// SetTargets implements the [Store] interface.
+func (s *InMemoryStore) SetTargets(_ context.Context, sID string, targetIDs []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(targetIDs) == 0 {
+		delete(s.sessions, sID)
+
+		return nil
+	}
+
+	var states, ok = s.sessions[sID]
+	if !ok {
+		states = make(map[string]TargetState, len(targetIDs))
+		s.sessions[sID] = states
+	}
+
+	for tID := range states {
+		if !slices.Contains(targetIDs, tID) {
+			delete(states, tID)
+		}
+	}
+
+	return nil
+}
+
+// States implements the [Store] interface.
+func (s *InMemoryStore) States(_ context.Context, sID string) (map[string]TargetState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var states = make(map[string]TargetState, len(s.sessions[sID]))
+
+	for tID, state := range s.sessions[sID] {
+		states[tID] = state.clone()
+	}
+
+	return states, nil
+}
+
+// UpdateState implements the [Store] interface.
+func (s *InMemoryStore) UpdateState(_ context.Context, sID, targetID string, update func(*TargetState)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	states, ok := s.sessions[sID]
+	if !ok {
+		return nil
+	}
+
+	var state = states[targetID].clone()
+
+	update(&state)
+
+	states[targetID] = state
+
+	return nil
+}
+
+// RedisStore is the Redis-backed Store, shared between the app instances, which use the same Redis server.
+type RedisStore struct {
+	client redis.Cmdable
+	prefix string
+	ttl    time.Duration
+}
+
+var _ Store = (*RedisStore)(nil) // ensure interface implementation
+
+// NewRedisStore creates a new Redis-backed Store. The keys are prefixed with the prefix, and the delivery state of
+// the session targets expires after the ttl without the deliveries (the index entries of the expired or deleted
+// sessions are removed by the dispatcher).
+func NewRedisStore(c redis.Cmdable, prefix string, ttl time.Duration) *RedisStore {
+	return &RedisStore{client: c, prefix: prefix, ttl: ttl}
+}
+
+func (s *RedisStore) indexKey() string           { return s.prefix + "sessions" }
+func (s *RedisStore) stateKey(sID string) string { return s.prefix + "state:" + sID }
+
+// Sessions implements the [Store] interface.
+func (s *RedisStore) Sessions(ctx context.Context) ([]string, error) {
+	ids, err := s.client.SMembers(ctx, s.indexKey()).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the sessions index: %w", err)
+	}
+
+	return ids, nil
+}
+
+// SetTargets implements the [Store] interface.
+func (s *RedisStore) SetTargets(ctx context.Context, sID string, targetIDs []string) error {
+	if len(targetIDs) == 0 {
+		if _, err := s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.SRem(ctx, s.indexKey(), sID)
+			pipe.Del(ctx, s.stateKey(sID))
+
+			return nil
+		}); err != nil {
+			return fmt.Errorf("failed to remove the session from the index: %w", err)
+		}
+
+		return nil
+	}
+
+	if err := s.client.SAdd(ctx, s.indexKey(), sID).Err(); err != nil {
+		return fmt.Errorf("failed to add the session to the index: %w", err)
+	}
+
+	existing, err := s.client.HKeys(ctx, s.stateKey(sID)).Result()
+	if err != nil {
+		return fmt.Errorf("failed to get the targets state: %w", err)
+	}
+
+	var removed []string
+
+	for _, tID := range existing {
+		if !slices.Contains(targetIDs, tID) {
+			removed = append(removed, tID)
+		}
+	}
+
+	if len(removed) > 0 {
+		if err = s.client.HDel(ctx, s.stateKey(sID), removed...).Err(); err != nil {
+			return fmt.Errorf("failed to remove the targets state: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// States implements the [Store] interface.
+func (s *RedisStore) States(ctx context.Context, sID string) (map[string]TargetState, error) {
+	values, err := s.client.HGetAll(ctx, s.stateKey(sID)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the targets state: %w", err)
+	}
+
+	var states = make(map[string]TargetState, len(values))
+
+	for tID, value := range values {
+		var state TargetState
+
+		if uErr := json.Unmarshal([]byte(value), &state); uErr != nil {
+			return nil, fmt.Errorf("failed to decode the target state: %w", uErr)
+		}
+
+		states[tID] = state
+	}
+
+	return states, nil
+}
+
+// redisCompareAndSetState replaces the target state (the hash field) only if the session is indexed and the current
+// state is equal to the expected one, and prolongs the states TTL (if set). It returns 1 on success, 0 if the state
+// has been changed and -1 if the session is not indexed.
+var redisCompareAndSetState = redis.NewScript(`
+if redis.call("SISMEMBER", KEYS[1], ARGV[1]) == 0 then return -1 end
+local current = redis.call("HGET", KEYS[2], ARGV[2]) or ""
+if current ~= ARGV[3] then return 0 end
+redis.call("HSET", KEYS[2], ARGV[2], ARGV[4])
+if tonumber(ARGV[5]) > 0 then redis.call("PEXPIRE", KEYS[2], ARGV[5]) end
+return 1
+`) //nolint:gochecknoglobals
+
+// UpdateState implements the [Store] interface. The state is written only if it has not been modified in the
+// meantime (otherwise, the update is retried).
+func (s *RedisStore) UpdateState(ctx context.Context, sID, targetID string, update func(*TargetState)) error {
+	const maxAttempts = 10
+
+	for range maxAttempts {
+		current, err := s.client.HGet(ctx, s.stateKey(sID), targetID).Result()
+		if err != nil && !errors.Is(err, redis.Nil) {
+			return fmt.Errorf("failed to get the target state: %w", err)
+		}
+
+		var state TargetState
+
+		if current != "" {
+			if uErr := json.Unmarshal([]byte(current), &state); uErr != nil {
+				return fmt.Errorf("failed to decode the target state: %w", uErr)
+			}
+		}
+
+		update(&state)
+
+		updated, mErr := json.Marshal(state)
+		if mErr != nil {
+			return fmt.Errorf("failed to encode the target state: %w", mErr)
+		}
+
+		res, rErr := redisCompareAndSetState.Run(ctx, s.client,
+			[]string{s.indexKey(), s.stateKey(sID)},
+			sID, targetID, current, updated, s.ttl.Milliseconds(),
+		).Int()
+		if rErr != nil {
+			return fmt.Errorf("failed to update the target state: %w", rErr)
+		}
+
+		if res != 0 {
+			return nil // updated, or the session is not indexed
+		}
+	}
+
+	return errors.New("too many concurrent target state modifications")
+}
@@ -0,0 +1,96 @@
+package notify_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gh.tarampamp.am/webhook-tester/v2/internal/notify"
+)
+
+func testStore(t *testing.T, store notify.Store) {
+	t.Helper()
+
+	var ctx = context.Background()
+
+	var addDelivery = func(id string) func(*notify.TargetState) {
+		return func(s *notify.TargetState) {
+			s.Failures++
+			s.Deliveries = append([]notify.Delivery{{ID: id}}, s.Deliveries...)
+		}
+	}
+
+	// the state of the not indexed sessions is not stored
+	require.NoError(t, store.UpdateState(ctx, "foo", "t1", addDelivery("d0")))
+
+	states, err := store.States(ctx, "foo")
+	require.NoError(t, err)
+	assert.Empty(t, states)
+
+	// index the session
+	require.NoError(t, store.SetTargets(ctx, "foo", []string{"t1", "t2"}))
+	require.NoError(t, store.SetTargets(ctx, "bar", []string{"t3"}))
+
+	indexed, err := store.Sessions(ctx)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"foo", "bar"}, indexed)
+
+	require.NoError(t, store.UpdateState(ctx, "foo", "t1", addDelivery("d1")))
+	require.NoError(t, store.UpdateState(ctx, "foo", "t1", addDelivery("d2")))
+	require.NoError(t, store.UpdateState(ctx, "foo", "t2", addDelivery("d3")))
+
+	states, err = store.States(ctx, "foo")
+	require.NoError(t, err)
+	require.Len(t, states, 2)
+	assert.EqualValues(t, 2, states["t1"].Failures)
+	assert.Equal(t, []notify.Delivery{{ID: "d2"}, {ID: "d1"}}, states["t1"].Deliveries)
+	assert.Equal(t, []notify.Delivery{{ID: "d3"}}, states["t2"].Deliveries)
+
+	// the state of the removed targets is removed
+	require.NoError(t, store.SetTargets(ctx, "foo", []string{"t2", "t4"}))
+
+	states, err = store.States(ctx, "foo")
+	require.NoError(t, err)
+	require.Len(t, states, 1)
+	assert.Equal(t, []notify.Delivery{{ID: "d3"}}, states["t2"].Deliveries)
+
+	// the session without the targets is removed from the index
+	require.NoError(t, store.SetTargets(ctx, "foo", nil))
+
+	indexed, err = store.Sessions(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"bar"}, indexed)
+
+	states, err = store.States(ctx, "foo")
+	require.NoError(t, err)
+	assert.Empty(t, states)
+}
+
+func TestInMemoryStore(t *testing.T) {
+	t.Parallel()
+
+	testStore(t, notify.NewInMemoryStore())
+}
+
+func TestRedisStore(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mr  = miniredis.RunT(t)
+		rdb = redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	)
+
+	t.Cleanup(func() { _ = rdb.Close() })
+
+	testStore(t, notify.NewRedisStore(rdb, "notify:", time.Minute))
+
+	require.NoError(t, notify.NewRedisStore(rdb, "notify:", time.Minute).
+		UpdateState(context.Background(), "bar", "t3", func(*notify.TargetState) {}))
+
+	assert.Equal(t, time.Minute, mr.TTL("notify:state:bar"))
+}
@@ -2,6 +2,7 @@ package pubsub
 
 import (
 	"context"
+	"time"
 )
 
 type (
@@ -29,12 +30,59 @@ type (
 	}
 
 	Request struct {
-		ID                 string       `json:"id"`
-		ClientAddr         string       `json:"client_addr"`
-		Method             string       `json:"method"`
-		Headers            []HttpHeader `json:"headers"`
-		URL                string       `json:"url"`
-		CreatedAtUnixMilli int64        `json:"created_at_unix_milli"`
+		ID                 string           `json:"id"`
+		ClientAddr         string           `json:"client_addr"`
+		PeerAddr           string           `json:"peer_addr,omitempty"`
+		Method             string           `json:"method"`
+		Headers            []HttpHeader     `json:"headers"`
+		URL                string           `json:"url"`
+		CreatedAtUnixMilli int64            `json:"created_at_unix_milli"`
+		Forwarded          *ForwardResult   `json:"forwarded,omitempty"`
+		Signature          *SignatureResult `json:"signature,omitempty"`
+		TraceID            string           `json:"trace_id,omitempty"`
+		TLS                *RequestTLS      `json:"tls,omitempty"`
+		Dropped            string           `json:"dropped,omitempty"`
+	}
+
+	ForwardResult struct {
+		URL        string        `json:"url"`
+		StatusCode uint16        `json:"status_code,omitempty"`
+		Headers    []HttpHeader  `json:"headers,omitempty"`
+		Body       []byte        `json:"body,omitempty"`
+		Latency    time.Duration `json:"latency"`
+		Error      string        `json:"error,omitempty"`
+	}
+
+	SignatureResult struct {
+		Provider string `json:"provider"`
+		Valid    bool   `json:"valid"`
+		Error    string `json:"error,omitempty"`
+	}
+
+	RequestTLS struct {
+		Version     string            `json:"version"`
+		CipherSuite string            `json:"cipher_suite"`
+		ServerName  string            `json:"server_name,omitempty"`
+		ClientCerts []TLSCertificate  `json:"client_certs,omitempty"`
+		ClientAuth  *ClientAuthResult `json:"client_auth,omitempty"`
+	}
+
+	TLSCertificate struct {
+		Subject            string   `json:"subject"`
+		Issuer             string   `json:"issuer"`
+		SerialNumber       string   `json:"serial_number"`
+		SANs               []string `json:"sans,omitempty"`
+		NotBeforeUnixMilli int64    `json:"not_before_unix_milli"`
+		NotAfterUnixMilli  int64    `json:"not_after_unix_milli"`
+		SHA1Fingerprint    string   `json:"sha1_fingerprint"`
+		SHA256Fingerprint  string   `json:"sha256_fingerprint"`
+	}
+
+	ClientAuthResult struct {
+		Mode     string `json:"mode"`
+		Verified bool   `json:"verified"`
+		Rejected bool   `json:"rejected"`
+		Error    string `json:"error,omitempty"`
 	}
 
 	HttpHeader struct {
@@ -46,7 +94,9 @@ type (
 )
 
 const (
-	RequestActionCreate RequestAction = "create" // create a request
-	RequestActionDelete RequestAction = "delete" // delete a request
-	RequestActionClear  RequestAction = "clear"  // delete all requests
+	RequestActionCreate        RequestAction = "create"         // create a request
+	RequestActionUpdate        RequestAction = "update"         // update a request (e.g., the forwarding result is ready)
+	RequestActionDelete        RequestAction = "delete"         // delete a request
+	RequestActionClear         RequestAction = "clear"          // delete all requests
+	RequestActionSessionUpdate RequestAction = "session_update" // the session options are updated
 )
@@ -0,0 +1,94 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// InMemory is the in-memory token bucket rate limiter. The limits are not shared between the app instances.
+type InMemory struct {
+	rate    Rate
+	timeNow TimeFunc
+
+	mu          sync.Mutex
+	buckets     map[string]*bucket
+	lastCleanup time.Time
+}
+
+// bucket is the token bucket state.
+type bucket struct {
+	tokens  float64   // available tokens
+	updated time.Time // the last refill time
+}
+
+var _ Limiter = (*InMemory)(nil) // ensure interface implementation
+
+type InMemoryOption func(*InMemory)
+
+// WithInMemoryTimeNow sets the function that returns the current time.
+func WithInMemoryTimeNow(fn TimeFunc) InMemoryOption { return func(l *InMemory) { l.timeNow = fn } }
+
+// NewInMemory creates a new in-memory rate limiter with the given rate.
+func NewInMemory(rate Rate, opts ...InMemoryOption) *InMemory {
+	var l = InMemory{
+		rate:    rate,
+		timeNow: defaultTimeFunc,
+		buckets: make(map[string]*bucket),
+	}
+
+	for _, opt := range opts {
+		opt(&l)
+	}
+
+	l.lastCleanup = l.timeNow()
+
+	return &l
+}
+
+// Allow implements the [Limiter] interface.
+func (l *InMemory) Allow(_ context.Context, key string) (bool, time.Duration, error) {
+	var now = l.timeNow()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.cleanup(now)
+
+	var (
+		capacity = float64(l.rate.Limit)
+		interval = l.rate.interval()
+		b, ok    = l.buckets[key]
+	)
+
+	if !ok {
+		b = &bucket{tokens: capacity, updated: now}
+		l.buckets[key] = b
+	} else if elapsed := now.Sub(b.updated); elapsed > 0 {
+		b.tokens, b.updated = min(capacity, b.tokens+float64(elapsed)/float64(interval)), now
+	}
+
+	if b.tokens >= 1 {
+		b.tokens--
+
+		return true, 0, nil
+	}
+
+	return false, time.Duration((1 - b.tokens) * float64(interval)), nil
+}
+
+// cleanup removes the buckets, which are full again (they are the same as missing ones). To keep the Allow calls
+// cheap, it runs at most once per the rate period.
+func (l *InMemory) cleanup(now time.Time) {
+	if now.Sub(l.lastCleanup) < l.rate.Period {
+		return
+	}
+
+	l.lastCleanup = now
+
+	for key, b := range l.buckets {
+		if now.Sub(b.updated) >= l.rate.Period {
+			delete(l.buckets, key)
+		}
+	}
+}
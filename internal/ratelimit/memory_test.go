@@ -0,0 +1,54 @@
+package ratelimit_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gh.tarampamp.am/webhook-tester/v2/internal/ratelimit"
+)
+
+func TestInMemory(t *testing.T) {
+	t.Parallel()
+
+	var ft = newFakeTime()
+
+	testLimiter(t,
+		func(rate ratelimit.Rate) ratelimit.Limiter {
+			return ratelimit.NewInMemory(rate, ratelimit.WithInMemoryTimeNow(ft.Get))
+		},
+		ft.Add,
+	)
+}
+
+func TestInMemory_Concurrent(t *testing.T) {
+	t.Parallel()
+
+	var (
+		l       = ratelimit.NewInMemory(ratelimit.Rate{Limit: 100, Period: time.Hour})
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		allowed int
+	)
+
+	for range 500 {
+		wg.Go(func() {
+			ok, _, err := l.Allow(context.Background(), "key")
+			require.NoError(t, err)
+
+			if ok {
+				mu.Lock()
+				allowed++
+				mu.Unlock()
+			}
+		})
+	}
+
+	wg.Wait()
+
+	assert.Equal(t, 100, allowed)
+}
@@ -0,0 +1,129 @@
+// Package ratelimit provides the token bucket rate limiters - the in-memory one (for the single instance) and the
+// Redis-backed one (the limits are shared between the replicas).
+//
+// All the Limits methods are safe to call on a nil receiver (in this case, everything is allowed), so the rate
+// limiting can be disabled by passing nil.
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Limiter limits the events rate per key (e.g. the client IP address or the session ID).
+type Limiter interface {
+	// Allow takes a token from the bucket of the key. If the bucket is empty, false is returned along with the time
+	// after which the token will be available.
+	Allow(ctx context.Context, key string) (allowed bool, retryAfter time.Duration, _ error)
+}
+
+// TimeFunc returns the current time.
+type TimeFunc func() time.Time
+
+var defaultTimeFunc TimeFunc = time.Now //nolint:gochecknoglobals
+
+// Rate is the token bucket rate - the bucket holds up to Limit tokens, and it's refilled completely in the Period
+// (so Limit events per Period are allowed on average, with bursts up to Limit).
+type Rate struct {
+	Limit  uint32
+	Period time.Duration
+}
+
+// ParseRate parses the rate in the "<limit>/<period>" format, e.g. "10/s", "60/m", "1000/h" or "5/30s" (the period
+// is a duration without a number or any valid Go duration).
+func ParseRate(s string) (Rate, error) {
+	limitStr, periodStr, ok := strings.Cut(strings.TrimSpace(s), "/")
+	if !ok {
+		return Rate{}, fmt.Errorf("wrong rate [%s] (expected format: <limit>/<period>, e.g. 60/m)", s)
+	}
+
+	limit, err := strconv.ParseUint(strings.TrimSpace(limitStr), 10, 32)
+	if err != nil || limit == 0 {
+		return Rate{}, fmt.Errorf("wrong rate limit [%s] (should be a positive integer)", limitStr)
+	}
+
+	var period time.Duration
+
+	switch periodStr = strings.TrimSpace(periodStr); periodStr {
+	case "s", "sec", "second":
+		period = time.Second
+	case "m", "min", "minute":
+		period = time.Minute
+	case "h", "hour":
+		period = time.Hour
+	default:
+		if period, err = time.ParseDuration(periodStr); err != nil || period <= 0 {
+			return Rate{}, fmt.Errorf("wrong rate period [%s]", periodStr)
+		}
+	}
+
+	return Rate{Limit: uint32(limit), Period: period}, nil
+}
+
+// String returns the rate in the "<limit>/<period>" format.
+func (r Rate) String() string {
+	return strconv.FormatUint(uint64(r.Limit), 10) + "/" + r.Period.String()
+}
+
+// interval returns the time needed to refill one token.
+func (r Rate) interval() time.Duration { return r.Period / time.Duration(r.Limit) }
+
+// Rate limiting scopes.
+const (
+	ScopeIP            = "ip"             // the webhooks per client IP address
+	ScopeSession       = "session"        // the webhooks per session
+	ScopeSessionCreate = "session_create" // the sessions creation per client IP address
+)
+
+// Limits is the set of the rate limiters by scope (a nil limiter means no limit for the scope).
+type Limits struct {
+	PerIP         Limiter // the webhooks per client IP address
+	PerSession    Limiter // the webhooks per session
+	SessionCreate Limiter // the sessions creation (including the automatic one) per client IP address
+
+	// RecordDropped enables recording of the rejected webhooks on the session (without the body, at most one per
+	// minute), so the user can see that the requests were throttled.
+	RecordDropped bool
+}
+
+// Allow takes a token for the key from the limiter of the scope. Everything is allowed if the limits are nil or
+// the scope has no limiter.
+func (l *Limits) Allow(ctx context.Context, scope, key string) (bool, time.Duration, error) {
+	if l == nil {
+		return true, 0, nil
+	}
+
+	var limiter Limiter
+
+	switch scope {
+	case ScopeIP:
+		limiter = l.PerIP
+	case ScopeSession:
+		limiter = l.PerSession
+	case ScopeSessionCreate:
+		limiter = l.SessionCreate
+	}
+
+	if limiter == nil {
+		return true, 0, nil
+	}
+
+	return limiter.Allow(ctx, key)
+}
+
+// ShouldRecordDropped reports whether the rejected webhooks should be recorded on the session.
+func (l *Limits) ShouldRecordDropped() bool { return l != nil && l.RecordDropped }
+
+// RetryAfterSeconds returns the Retry-After header value (in seconds, rounded up; at least 1) for the given duration.
+func RetryAfterSeconds(d time.Duration) string {
+	var secs = int64((d + time.Second - 1) / time.Second)
+
+	if secs < 1 {
+		secs = 1
+	}
+
+	return strconv.FormatInt(secs, 10)
+}
@@ -0,0 +1,187 @@
+package ratelimit_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gh.tarampamp.am/webhook-tester/v2/internal/ratelimit"
+)
+
+func TestParseRate(t *testing.T) {
+	t.Parallel()
+
+	for give, want := range map[string]ratelimit.Rate{
+		"10/s":         {Limit: 10, Period: time.Second},
+		" 60 / m ":     {Limit: 60, Period: time.Minute},
+		"1000/hour":    {Limit: 1000, Period: time.Hour},
+		"5/30s":        {Limit: 5, Period: 30 * time.Second},
+		"1/1h30m":      {Limit: 1, Period: 90 * time.Minute},
+		"4294967295/s": {Limit: 4294967295, Period: time.Second},
+	} {
+		got, err := ratelimit.ParseRate(give)
+		require.NoError(t, err, give)
+		assert.Equal(t, want, got, give)
+	}
+
+	for _, give := range []string{"", "10", "0/s", "-1/s", "foo/s", "10/", "10/d", "10/-1s", "4294967296/s"} {
+		_, err := ratelimit.ParseRate(give)
+		assert.Error(t, err, give)
+	}
+
+	assert.Equal(t, "60/1m0s", ratelimit.Rate{Limit: 60, Period: time.Minute}.String())
+}
+
+func TestRetryAfterSeconds(t *testing.T) {
+	t.Parallel()
+
+	for give, want := range map[time.Duration]string{
+		0:                    "1",
+		time.Millisecond:     "1",
+		time.Second:          "1",
+		time.Second + 1:      "2",
+		59*time.Second + 1e8: "60",
+		time.Hour:            "3600",
+	} {
+		assert.Equal(t, want, ratelimit.RetryAfterSeconds(give), give)
+	}
+}
+
+type fakeLimiter struct {
+	allowed bool
+	err     error
+	keys    []string
+}
+
+func (f *fakeLimiter) Allow(_ context.Context, key string) (bool, time.Duration, error) {
+	f.keys = append(f.keys, key)
+
+	return f.allowed, time.Second, f.err
+}
+
+func TestLimits(t *testing.T) {
+	t.Parallel()
+
+	var nilLimits *ratelimit.Limits
+
+	ok, _, err := nilLimits.Allow(context.Background(), ratelimit.ScopeIP, "1.1.1.1")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.False(t, nilLimits.ShouldRecordDropped())
+
+	var (
+		perIP   = &fakeLimiter{allowed: false}
+		create  = &fakeLimiter{err: errors.New("boom")}
+		limits  = ratelimit.Limits{PerIP: perIP, SessionCreate: create, RecordDropped: true}
+		ctx     = context.Background()
+		retryIn time.Duration
+	)
+
+	ok, retryIn, err = limits.Allow(ctx, ratelimit.ScopeIP, "1.1.1.1")
+	require.NoError(t, err)
+	assert.False(t, ok)
+	assert.Equal(t, time.Second, retryIn)
+
+	ok, _, err = limits.Allow(ctx, ratelimit.ScopeSession, "some-session") // no limiter for the scope
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	_, _, err = limits.Allow(ctx, ratelimit.ScopeSessionCreate, "2.2.2.2")
+	require.Error(t, err)
+
+	assert.Equal(t, []string{"1.1.1.1"}, perIP.keys)
+	assert.Equal(t, []string{"2.2.2.2"}, create.keys)
+	assert.True(t, limits.ShouldRecordDropped())
+}
+
+// fakeTime is the manually controlled clock.
+type fakeTime struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func newFakeTime() *fakeTime { return &fakeTime{now: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)} }
+
+func (f *fakeTime) Get() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.now
+}
+
+func (f *fakeTime) Add(d time.Duration) {
+	f.mu.Lock()
+	f.now = f.now.Add(d)
+	f.mu.Unlock()
+}
+
+// testLimiter runs the common token bucket tests against the limiter, created by the factory (the clock is moved
+// forward using the advance function).
+func testLimiter(
+	t *testing.T,
+	newLimiter func(ratelimit.Rate) ratelimit.Limiter,
+	advance func(time.Duration),
+) {
+	t.Helper()
+
+	var (
+		ctx = context.Background()
+		l   = newLimiter(ratelimit.Rate{Limit: 3, Period: 3 * time.Second}) // 1 token per second, burst 3
+	)
+
+	allow := func(key string) (bool, time.Duration) {
+		t.Helper()
+
+		ok, retryAfter, err := l.Allow(ctx, key)
+		require.NoError(t, err)
+
+		return ok, retryAfter
+	}
+
+	// the burst is allowed
+	for range 3 {
+		ok, _ := allow("foo")
+		require.True(t, ok)
+	}
+
+	// the bucket is empty now
+	ok, retryAfter := allow("foo")
+	assert.False(t, ok)
+	assert.InDelta(t, time.Second, retryAfter, float64(10*time.Millisecond))
+
+	// other keys have their own buckets
+	ok, _ = allow("bar")
+	assert.True(t, ok)
+
+	// half a token is refilled
+	advance(500 * time.Millisecond)
+
+	ok, retryAfter = allow("foo")
+	assert.False(t, ok)
+	assert.InDelta(t, 500*time.Millisecond, retryAfter, float64(10*time.Millisecond))
+
+	// one token is refilled
+	advance(500 * time.Millisecond)
+
+	ok, _ = allow("foo")
+	assert.True(t, ok)
+
+	ok, _ = allow("foo")
+	assert.False(t, ok)
+
+	// the bucket is refilled up to the capacity only
+	advance(time.Hour)
+
+	for range 3 {
+		ok, _ = allow("foo")
+		require.True(t, ok)
+	}
+
+	ok, _ = allow("foo")
+	assert.False(t, ok)
+}
@@ -0,0 +1,98 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Redis is the Redis-backed token bucket rate limiter. The limits are shared between all the app instances, which
+// use the same Redis server and key prefix (their clocks should be in sync).
+type Redis struct {
+	client  redis.Cmdable
+	prefix  string
+	rate    Rate
+	timeNow TimeFunc
+}
+
+var _ Limiter = (*Redis)(nil) // ensure interface implementation
+
+type RedisOption func(*Redis)
+
+// WithRedisTimeNow sets the function that returns the current time.
+func WithRedisTimeNow(fn TimeFunc) RedisOption { return func(l *Redis) { l.timeNow = fn } }
+
+// NewRedis creates a new Redis-backed rate limiter with the given rate. The bucket keys are prefixed with the
+// prefix (use different prefixes for the different limiters).
+func NewRedis(c redis.Cmdable, prefix string, rate Rate, opts ...RedisOption) *Redis {
+	var l = Redis{
+		client:  c,
+		prefix:  prefix,
+		rate:    rate,
+		timeNow: defaultTimeFunc,
+	}
+
+	for _, opt := range opts {
+		opt(&l)
+	}
+
+	return &l
+}
+
+// redisTokenBucket atomically refills the bucket (the hash with the "tokens" and "ts" fields), and takes a token
+// from it. The arguments are the bucket capacity, the time to refill one token (in milliseconds) and the current
+// time (in milliseconds). It returns {1, 0} if the token is taken, and {0, retry_after_millis} otherwise. The key
+// expires when the bucket is full again (the missing bucket is the same as the full one).
+var redisTokenBucket = redis.NewScript(`
+local capacity = tonumber(ARGV[1])
+local interval = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+
+local state = redis.call("HMGET", KEYS[1], "tokens", "ts")
+local tokens, ts = tonumber(state[1]), tonumber(state[2])
+
+if tokens == nil or ts == nil then
+  tokens, ts = capacity, now
+elseif now > ts then
+  tokens, ts = math.min(capacity, tokens + (now - ts) / interval), now
+end
+
+local allowed, retry = 0, 0
+
+if tokens >= 1 then
+  tokens, allowed = tokens - 1, 1
+else
+  retry = math.ceil((1 - tokens) * interval)
+end
+
+redis.call("HSET", KEYS[1], "tokens", tostring(tokens), "ts", tostring(ts))
+redis.call("PEXPIRE", KEYS[1], math.max(1, math.ceil((capacity - tokens) * interval)))
+
+return {allowed, retry}
+`) //nolint:gochecknoglobals
+
+// Allow implements the [Limiter] interface.
+func (l *Redis) Allow(ctx context.Context, key string) (bool, time.Duration, error) {
+	var (
+		intervalMs = float64(l.rate.interval()) / float64(time.Millisecond)
+		nowMs      = float64(l.timeNow().UnixMicro()) / 1000 //nolint:mnd
+	)
+
+	res, err := redisTokenBucket.Run(ctx, l.client, []string{l.prefix + key},
+		l.rate.Limit,
+		strconv.FormatFloat(intervalMs, 'f', -1, 64),
+		strconv.FormatFloat(nowMs, 'f', 3, 64), //nolint:mnd
+	).Int64Slice()
+	if err != nil {
+		return false, 0, fmt.Errorf("failed to take a token: %w", err)
+	}
+
+	if len(res) != 2 { //nolint:mnd
+		return false, 0, fmt.Errorf("unexpected token bucket script result: %v", res)
+	}
+
+	return res[0] == 1, time.Duration(res[1]) * time.Millisecond, nil
+}
@@ -0,0 +1,74 @@
+package ratelimit_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gh.tarampamp.am/webhook-tester/v2/internal/ratelimit"
+)
+
+func TestRedis(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mini = miniredis.RunT(t)
+		ft   = newFakeTime()
+	)
+
+	testLimiter(t,
+		func(rate ratelimit.Rate) ratelimit.Limiter {
+			return ratelimit.NewRedis(
+				redis.NewClient(&redis.Options{Addr: mini.Addr()}),
+				"test:",
+				rate,
+				ratelimit.WithRedisTimeNow(ft.Get),
+			)
+		},
+		func(d time.Duration) { mini.FastForward(d); ft.Add(d) },
+	)
+}
+
+func TestRedis_Shared(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mini = miniredis.RunT(t)
+		rate = ratelimit.Rate{Limit: 2, Period: time.Minute}
+		ctx  = context.Background()
+
+		// two replicas, sharing the same Redis server
+		first  = ratelimit.NewRedis(redis.NewClient(&redis.Options{Addr: mini.Addr()}), "rl:ip:", rate)
+		second = ratelimit.NewRedis(redis.NewClient(&redis.Options{Addr: mini.Addr()}), "rl:ip:", rate)
+		other  = ratelimit.NewRedis(redis.NewClient(&redis.Options{Addr: mini.Addr()}), "rl:session:", rate)
+	)
+
+	for _, l := range []*ratelimit.Redis{first, second} {
+		ok, _, err := l.Allow(ctx, "1.1.1.1")
+		require.NoError(t, err)
+		assert.True(t, ok)
+	}
+
+	ok, retryAfter, err := first.Allow(ctx, "1.1.1.1")
+	require.NoError(t, err)
+	assert.False(t, ok)
+	assert.Greater(t, retryAfter, 25*time.Second)
+
+	ok, _, err = other.Allow(ctx, "1.1.1.1") // the different prefix
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	// the key expires when the bucket is full again
+	assert.True(t, mini.Exists("rl:ip:1.1.1.1"))
+	assert.LessOrEqual(t, mini.TTL("rl:ip:1.1.1.1"), time.Minute)
+
+	mini.Close() // the Redis errors are returned
+
+	_, _, err = first.Allow(ctx, "1.1.1.1")
+	require.Error(t, err)
+}
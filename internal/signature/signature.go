@@ -0,0 +1,334 @@
+// Package signature verifies the HMAC signatures of the webhook requests, sent by the popular providers (GitHub,
+// Stripe, Slack, Shopify and the Standard Webhooks compatible ones, like Svix).
+package signature
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Provider is a webhook provider, which defines the signature scheme.
+type Provider string
+
+const (
+	ProviderGitHub   Provider = "github"   // X-Hub-Signature-256 header, hex-encoded HMAC-SHA256 of the body
+	ProviderStripe   Provider = "stripe"   // Stripe-Signature header, HMAC-SHA256 of the "{timestamp}.{body}"
+	ProviderSlack    Provider = "slack"    // X-Slack-Signature header, HMAC-SHA256 of the "v0:{timestamp}:{body}"
+	ProviderShopify  Provider = "shopify"  // X-Shopify-Hmac-Sha256 header, base64-encoded HMAC-SHA256 of the body
+	ProviderStandard Provider = "standard" // Standard Webhooks (https://www.standardwebhooks.com), e.g. Svix
+)
+
+// Providers returns all supported providers.
+func Providers() []Provider {
+	return []Provider{ProviderGitHub, ProviderStripe, ProviderSlack, ProviderShopify, ProviderStandard}
+}
+
+// DefaultTolerance is the recommended (by the providers) maximum difference between the signature timestamp and the
+// current time.
+const DefaultTolerance = 5 * time.Minute
+
+var (
+	// ErrUnsupportedProvider is returned when the provider is not supported.
+	ErrUnsupportedProvider = errors.New("unsupported provider")
+
+	// ErrInvalidSecret is returned when the secret cannot be used (e.g., it's not properly encoded).
+	ErrInvalidSecret = errors.New("invalid secret")
+
+	// ErrNoSignature is returned when the request has no signature (or timestamp) headers.
+	ErrNoSignature = errors.New("no signature")
+
+	// ErrMalformedSignature is returned when the signature (or timestamp) header cannot be parsed.
+	ErrMalformedSignature = errors.New("malformed signature")
+
+	// ErrSignatureMismatch is returned when none of the request signatures matches the expected one.
+	ErrSignatureMismatch = errors.New("signature mismatch")
+
+	// ErrTimestampOutOfTolerance is returned when the signature timestamp is too old (or too far in the future).
+	ErrTimestampOutOfTolerance = errors.New("timestamp is out of the tolerance")
+)
+
+// standardSecretPrefix is the prefix of the Standard Webhooks secrets (the rest is base64-encoded key).
+const standardSecretPrefix = "whsec_"
+
+// ValidateSecret checks if the secret can be used with the provider.
+func (p Provider) ValidateSecret(secret string) error {
+	if _, err := p.key(secret); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// key returns the HMAC key for the provider secret.
+func (p Provider) key(secret string) ([]byte, error) {
+	if secret == "" {
+		return nil, fmt.Errorf("%w: empty", ErrInvalidSecret)
+	}
+
+	switch p {
+	case ProviderGitHub, ProviderStripe, ProviderSlack, ProviderShopify:
+		return []byte(secret), nil
+	case ProviderStandard:
+		key, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(secret, standardSecretPrefix))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidSecret, err)
+		}
+
+		return key, nil
+	}
+
+	return nil, fmt.Errorf("%w: %s", ErrUnsupportedProvider, p)
+}
+
+// Verify checks the request signature using the provider scheme. For the providers that sign the timestamp, the
+// difference between the signature timestamp and now must not exceed the tolerance (zero disables the check).
+// It returns nil if the signature is valid.
+func Verify(p Provider, secret string, h http.Header, body []byte, now time.Time, tolerance time.Duration) error {
+	key, err := p.key(secret)
+	if err != nil {
+		return err
+	}
+
+	var ts int64 // signature timestamp (unix seconds, zero if the scheme does not sign it)
+
+	switch p {
+	case ProviderGitHub:
+		err = verifyGitHub(key, h, body)
+	case ProviderStripe:
+		ts, err = verifyStripe(key, h, body)
+	case ProviderSlack:
+		ts, err = verifySlack(key, h, body)
+	case ProviderShopify:
+		err = verifyShopify(key, h, body)
+	case ProviderStandard:
+		ts, err = verifyStandard(key, h, body)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if ts != 0 && tolerance > 0 {
+		if diff := now.Sub(time.Unix(ts, 0)).Abs(); diff > tolerance {
+			return fmt.Errorf("%w (%s)", ErrTimestampOutOfTolerance, diff.Round(time.Second))
+		}
+	}
+
+	return nil
+}
+
+// SignStandard signs the message using the Standard Webhooks scheme, and returns the "webhook-signature" header
+// value ("v1,{base64}"). The id and timestamp should be sent in the "webhook-id" and "webhook-timestamp" headers.
+func SignStandard(secret, id string, ts time.Time, body []byte) (string, error) {
+	key, err := ProviderStandard.key(secret)
+	if err != nil {
+		return "", err
+	}
+
+	var sig = sign(key, []byte(id+"."+strconv.FormatInt(ts.Unix(), 10)+"."), body)
+
+	return "v1," + base64.StdEncoding.EncodeToString(sig), nil
+}
+
+// sign returns the HMAC-SHA256 of the message parts.
+func sign(key []byte, parts ...[]byte) []byte {
+	var mac = hmac.New(sha256.New, key)
+
+	for _, part := range parts {
+		_, _ = mac.Write(part)
+	}
+
+	return mac.Sum(nil)
+}
+
+// header returns the (trimmed) value of the header, or ErrNoSignature if it's missing.
+func header(h http.Header, name string) (string, error) {
+	if v := strings.TrimSpace(h.Get(name)); v != "" {
+		return v, nil
+	}
+
+	return "", fmt.Errorf("%w: %s header is missing", ErrNoSignature, name)
+}
+
+// parseTimestamp parses the unix timestamp (in seconds).
+func parseTimestamp(s string) (int64, error) {
+	ts, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || ts <= 0 {
+		return 0, fmt.Errorf("%w: wrong timestamp %q", ErrMalformedSignature, s)
+	}
+
+	return ts, nil
+}
+
+// equalHex compares the expected MAC with the hex-encoded one in constant time.
+func equalHex(expected []byte, got string) bool {
+	decoded, err := hex.DecodeString(got)
+
+	return err == nil && hmac.Equal(expected, decoded)
+}
+
+// equalBase64 compares the expected MAC with the base64-encoded one in constant time.
+func equalBase64(expected []byte, got string) bool {
+	decoded, err := base64.StdEncoding.DecodeString(got)
+
+	return err == nil && hmac.Equal(expected, decoded)
+}
+
+// verifyGitHub verifies the "X-Hub-Signature-256: sha256={hex}" header.
+// See https://docs.github.com/en/webhooks/using-webhooks/validating-webhook-deliveries.
+func verifyGitHub(key []byte, h http.Header, body []byte) error {
+	value, err := header(h, "X-Hub-Signature-256")
+	if err != nil {
+		return err
+	}
+
+	sig, found := strings.CutPrefix(value, "sha256=")
+	if !found {
+		return fmt.Errorf("%w: the sha256= prefix is missing", ErrMalformedSignature)
+	}
+
+	if !equalHex(sign(key, body), sig) {
+		return ErrSignatureMismatch
+	}
+
+	return nil
+}
+
+// verifyStripe verifies the "Stripe-Signature: t={timestamp},v1={hex}[,v1={hex}...]" header.
+// See https://docs.stripe.com/webhooks#verify-manually.
+func verifyStripe(key []byte, h http.Header, body []byte) (int64, error) {
+	value, err := header(h, "Stripe-Signature")
+	if err != nil {
+		return 0, err
+	}
+
+	var (
+		ts   int64
+		sigs []string
+	)
+
+	for item := range strings.SplitSeq(value, ",") {
+		k, v, _ := strings.Cut(strings.TrimSpace(item), "=")
+
+		switch k {
+		case "t":
+			if ts, err = parseTimestamp(v); err != nil {
+				return 0, err
+			}
+		case "v1":
+			sigs = append(sigs, v)
+		}
+	}
+
+	if ts == 0 {
+		return 0, fmt.Errorf("%w: the timestamp is missing", ErrMalformedSignature)
+	}
+
+	if len(sigs) == 0 {
+		return 0, fmt.Errorf("%w: no v1 signatures", ErrNoSignature)
+	}
+
+	var expected = sign(key, []byte(strconv.FormatInt(ts, 10)+"."), body)
+
+	for _, sig := range sigs {
+		if equalHex(expected, sig) {
+			return ts, nil
+		}
+	}
+
+	return 0, ErrSignatureMismatch
+}
+
+// verifySlack verifies the "X-Slack-Signature: v0={hex}" header (with the "X-Slack-Request-Timestamp" one).
+// See https://api.slack.com/authentication/verifying-requests-from-slack.
+func verifySlack(key []byte, h http.Header, body []byte) (int64, error) {
+	tsValue, err := header(h, "X-Slack-Request-Timestamp")
+	if err != nil {
+		return 0, err
+	}
+
+	value, err := header(h, "X-Slack-Signature")
+	if err != nil {
+		return 0, err
+	}
+
+	ts, err := parseTimestamp(tsValue)
+	if err != nil {
+		return 0, err
+	}
+
+	sig, found := strings.CutPrefix(value, "v0=")
+	if !found {
+		return 0, fmt.Errorf("%w: the v0= prefix is missing", ErrMalformedSignature)
+	}
+
+	if !equalHex(sign(key, []byte("v0:"+tsValue+":"), body), sig) {
+		return 0, ErrSignatureMismatch
+	}
+
+	return ts, nil
+}
+
+// verifyShopify verifies the "X-Shopify-Hmac-Sha256: {base64}" header.
+// See https://shopify.dev/docs/apps/build/webhooks/subscribe/https#step-5-verify-the-webhook.
+func verifyShopify(key []byte, h http.Header, body []byte) error {
+	value, err := header(h, "X-Shopify-Hmac-Sha256")
+	if err != nil {
+		return err
+	}
+
+	if !equalBase64(sign(key, body), value) {
+		return ErrSignatureMismatch
+	}
+
+	return nil
+}
+
+// verifyStandard verifies the "webhook-signature: v1,{base64}[ v1,{base64}...]" header (with the "webhook-id" and
+// "webhook-timestamp" ones). The "svix-" prefixed headers are used when the "webhook-" ones are missing.
+// See https://github.com/standard-webhooks/standard-webhooks/blob/main/spec/standard-webhooks.md.
+func verifyStandard(key []byte, h http.Header, body []byte) (int64, error) {
+	var prefix = "Webhook-"
+
+	if h.Get(prefix+"Signature") == "" && h.Get("Svix-Signature") != "" {
+		prefix = "Svix-"
+	}
+
+	id, err := header(h, prefix+"Id")
+	if err != nil {
+		return 0, err
+	}
+
+	tsValue, err := header(h, prefix+"Timestamp")
+	if err != nil {
+		return 0, err
+	}
+
+	value, err := header(h, prefix+"Signature")
+	if err != nil {
+		return 0, err
+	}
+
+	ts, err := parseTimestamp(tsValue)
+	if err != nil {
+		return 0, err
+	}
+
+	var expected = sign(key, []byte(id+"."+tsValue+"."), body)
+
+	for item := range strings.FieldsSeq(value) {
+		if version, sig, _ := strings.Cut(item, ","); version == "v1" && equalBase64(expected, sig) {
+			return ts, nil
+		}
+	}
+
+	return 0, ErrSignatureMismatch
+}
@@ -0,0 +1,267 @@
+package signature_test
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gh.tarampamp.am/webhook-tester/v2/internal/signature"
+)
+
+func mac(key string, msg string) []byte {
+	var h = hmac.New(sha256.New, []byte(key))
+
+	_, _ = h.Write([]byte(msg))
+
+	return h.Sum(nil)
+}
+
+func headers(kv ...string) http.Header {
+	var h = make(http.Header)
+
+	for i := 0; i < len(kv); i += 2 {
+		h.Add(kv[i], kv[i+1])
+	}
+
+	return h
+}
+
+func TestVerify(t *testing.T) {
+	t.Parallel()
+
+	const (
+		secret = "It's a Secret to Everybody"
+		body   = `{"hello":"world"}`
+	)
+
+	var (
+		now   = time.Unix(1700000000, 0)
+		ts    = strconv.FormatInt(now.Unix(), 10)
+		oldTs = strconv.FormatInt(now.Add(-time.Hour).Unix(), 10)
+	)
+
+	for name, tc := range map[string]struct {
+		giveProvider  signature.Provider
+		giveSecret    string
+		giveHeaders   http.Header
+		giveBody      string
+		giveTolerance time.Duration
+		wantErr       error
+	}{
+		"github - valid": {
+			giveProvider: signature.ProviderGitHub,
+			giveHeaders:  headers("X-Hub-Signature-256", "sha256="+hex.EncodeToString(mac(secret, body))),
+		},
+		"github - mismatch": {
+			giveProvider: signature.ProviderGitHub,
+			giveHeaders:  headers("X-Hub-Signature-256", "sha256="+hex.EncodeToString(mac("foo", body))),
+			wantErr:      signature.ErrSignatureMismatch,
+		},
+		"github - no prefix": {
+			giveProvider: signature.ProviderGitHub,
+			giveHeaders:  headers("X-Hub-Signature-256", hex.EncodeToString(mac(secret, body))),
+			wantErr:      signature.ErrMalformedSignature,
+		},
+		"github - no header": {
+			giveProvider: signature.ProviderGitHub,
+			giveHeaders:  headers(),
+			wantErr:      signature.ErrNoSignature,
+		},
+		"stripe - valid (with the second signature)": {
+			giveProvider: signature.ProviderStripe,
+			giveHeaders: headers("Stripe-Signature", "t="+ts+
+				",v1=deadbeef,v1="+hex.EncodeToString(mac(secret, ts+"."+body))+",v0=foo"),
+			giveTolerance: time.Minute,
+		},
+		"stripe - mismatch": {
+			giveProvider:  signature.ProviderStripe,
+			giveHeaders:   headers("Stripe-Signature", "t="+ts+",v1="+hex.EncodeToString(mac(secret, body))),
+			giveTolerance: time.Minute,
+			wantErr:       signature.ErrSignatureMismatch,
+		},
+		"stripe - too old": {
+			giveProvider:  signature.ProviderStripe,
+			giveHeaders:   headers("Stripe-Signature", "t="+oldTs+",v1="+hex.EncodeToString(mac(secret, oldTs+"."+body))),
+			giveTolerance: time.Minute,
+			wantErr:       signature.ErrTimestampOutOfTolerance,
+		},
+		"stripe - too old, but the check is disabled": {
+			giveProvider: signature.ProviderStripe,
+			giveHeaders:  headers("Stripe-Signature", "t="+oldTs+",v1="+hex.EncodeToString(mac(secret, oldTs+"."+body))),
+		},
+		"stripe - no timestamp": {
+			giveProvider: signature.ProviderStripe,
+			giveHeaders:  headers("Stripe-Signature", "v1="+hex.EncodeToString(mac(secret, ts+"."+body))),
+			wantErr:      signature.ErrMalformedSignature,
+		},
+		"stripe - no v1 signatures": {
+			giveProvider: signature.ProviderStripe,
+			giveHeaders:  headers("Stripe-Signature", "t="+ts+",v0=foo"),
+			wantErr:      signature.ErrNoSignature,
+		},
+		"slack - valid": {
+			giveProvider: signature.ProviderSlack,
+			giveHeaders: headers(
+				"X-Slack-Request-Timestamp", ts,
+				"X-Slack-Signature", "v0="+hex.EncodeToString(mac(secret, "v0:"+ts+":"+body)),
+			),
+			giveTolerance: time.Minute,
+		},
+		"slack - too old": {
+			giveProvider: signature.ProviderSlack,
+			giveHeaders: headers(
+				"X-Slack-Request-Timestamp", oldTs,
+				"X-Slack-Signature", "v0="+hex.EncodeToString(mac(secret, "v0:"+oldTs+":"+body)),
+			),
+			giveTolerance: time.Minute,
+			wantErr:       signature.ErrTimestampOutOfTolerance,
+		},
+		"slack - wrong timestamp": {
+			giveProvider: signature.ProviderSlack,
+			giveHeaders: headers(
+				"X-Slack-Request-Timestamp", "foo",
+				"X-Slack-Signature", "v0="+hex.EncodeToString(mac(secret, "v0:foo:"+body)),
+			),
+			wantErr: signature.ErrMalformedSignature,
+		},
+		"slack - no timestamp": {
+			giveProvider: signature.ProviderSlack,
+			giveHeaders:  headers("X-Slack-Signature", "v0="+hex.EncodeToString(mac(secret, "v0:"+ts+":"+body))),
+			wantErr:      signature.ErrNoSignature,
+		},
+		"shopify - valid": {
+			giveProvider: signature.ProviderShopify,
+			giveHeaders:  headers("X-Shopify-Hmac-Sha256", base64.StdEncoding.EncodeToString(mac(secret, body))),
+		},
+		"shopify - body is changed": {
+			giveProvider: signature.ProviderShopify,
+			giveHeaders:  headers("X-Shopify-Hmac-Sha256", base64.StdEncoding.EncodeToString(mac(secret, body))),
+			giveBody:     body + " ",
+			wantErr:      signature.ErrSignatureMismatch,
+		},
+		"standard - valid (the spec example)": {
+			giveProvider: signature.ProviderStandard,
+			giveSecret:   "whsec_MfKQ9r8GKYqrTwjUPD8ILPZIo2LaLaSw",
+			giveHeaders: headers(
+				"Webhook-Id", "msg_p5jXN8AQM9LWM0D4loKWxJek",
+				"Webhook-Timestamp", "1614265330",
+				"Webhook-Signature", "v1,foo v1,g0hM9SsE+OTPJTGt/tmIKtSyZlE3uFJELVlNIOLJ1OE=",
+			),
+			giveBody: `{"test": 2432232314}`,
+		},
+		"standard - svix headers": {
+			giveProvider: signature.ProviderStandard,
+			giveSecret:   "whsec_MfKQ9r8GKYqrTwjUPD8ILPZIo2LaLaSw",
+			giveHeaders: headers(
+				"Svix-Id", "msg_p5jXN8AQM9LWM0D4loKWxJek",
+				"Svix-Timestamp", "1614265330",
+				"Svix-Signature", "v1,g0hM9SsE+OTPJTGt/tmIKtSyZlE3uFJELVlNIOLJ1OE=",
+			),
+			giveBody: `{"test": 2432232314}`,
+		},
+		"standard - too old": {
+			giveProvider: signature.ProviderStandard,
+			giveSecret:   "whsec_MfKQ9r8GKYqrTwjUPD8ILPZIo2LaLaSw",
+			giveHeaders: headers(
+				"Webhook-Id", "msg_p5jXN8AQM9LWM0D4loKWxJek",
+				"Webhook-Timestamp", "1614265330",
+				"Webhook-Signature", "v1,g0hM9SsE+OTPJTGt/tmIKtSyZlE3uFJELVlNIOLJ1OE=",
+			),
+			giveBody:      `{"test": 2432232314}`,
+			giveTolerance: signature.DefaultTolerance,
+			wantErr:       signature.ErrTimestampOutOfTolerance,
+		},
+		"standard - unknown version": {
+			giveProvider: signature.ProviderStandard,
+			giveSecret:   "whsec_MfKQ9r8GKYqrTwjUPD8ILPZIo2LaLaSw",
+			giveHeaders: headers(
+				"Webhook-Id", "msg_p5jXN8AQM9LWM0D4loKWxJek",
+				"Webhook-Timestamp", "1614265330",
+				"Webhook-Signature", "v2,g0hM9SsE+OTPJTGt/tmIKtSyZlE3uFJELVlNIOLJ1OE=",
+			),
+			giveBody: `{"test": 2432232314}`,
+			wantErr:  signature.ErrSignatureMismatch,
+		},
+		"standard - invalid secret": {
+			giveProvider: signature.ProviderStandard,
+			giveSecret:   "whsec_!!!",
+			giveHeaders:  headers(),
+			wantErr:      signature.ErrInvalidSecret,
+		},
+		"unsupported provider": {
+			giveProvider: "foo",
+			giveHeaders:  headers(),
+			wantErr:      signature.ErrUnsupportedProvider,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var (
+				giveSecret = tc.giveSecret
+				giveBody   = tc.giveBody
+			)
+
+			if giveSecret == "" {
+				giveSecret = secret
+			}
+
+			if giveBody == "" {
+				giveBody = body
+			}
+
+			var err = signature.Verify(tc.giveProvider, giveSecret, tc.giveHeaders, []byte(giveBody), now, tc.giveTolerance)
+
+			if tc.wantErr == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestProvider_ValidateSecret(t *testing.T) {
+	t.Parallel()
+
+	for _, p := range signature.Providers() {
+		require.NoError(t, p.ValidateSecret("whsec_MfKQ9r8GKYqrTwjUPD8ILPZIo2LaLaSw"), p)
+		require.ErrorIs(t, p.ValidateSecret(""), signature.ErrInvalidSecret, p)
+	}
+
+	require.NoError(t, signature.ProviderGitHub.ValidateSecret("whsec_!!!"))
+	require.ErrorIs(t, signature.ProviderStandard.ValidateSecret("whsec_!!!"), signature.ErrInvalidSecret)
+	require.ErrorIs(t, signature.Provider("foo").ValidateSecret("bar"), signature.ErrUnsupportedProvider)
+}
+
+func TestSignStandard(t *testing.T) {
+	t.Parallel()
+
+	const secret = "whsec_MfKQ9r8GKYqrTwjUPD8ILPZIo2LaLaSw"
+
+	var (
+		now  = time.Unix(1700000000, 0)
+		body = []byte(`{"foo":"bar"}`)
+	)
+
+	sig, err := signature.SignStandard(secret, "msg_1", now, body)
+	require.NoError(t, err)
+	assert.Regexp(t, `^v1,[A-Za-z0-9+/]+=*$`, sig)
+
+	require.NoError(t, signature.Verify(signature.ProviderStandard, secret, headers(
+		"webhook-id", "msg_1",
+		"webhook-timestamp", strconv.FormatInt(now.Unix(), 10),
+		"webhook-signature", sig,
+	), body, now, signature.DefaultTolerance))
+
+	_, err = signature.SignStandard("whsec_!!!", "msg_1", now, body)
+	require.ErrorIs(t, err, signature.ErrInvalidSecret)
+}
@@ -0,0 +1,133 @@
+// Package slug validates the session slugs - human-readable aliases, which are used in the webhook URLs instead of
+// the session IDs (e.g. /hooks/stripe-staging instead of /{session-uuid}).
+package slug
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// DefaultPattern is the suggested pattern for the slugs (they are disabled by default) - up to 4 path segments,
+	// each of them starts with a lower-cased letter or digit, followed by up to 63 lower-cased letters, digits, dashes
+	// or underscores.
+	DefaultPattern = `^[a-z0-9][a-z0-9_-]{0,63}(/[a-z0-9][a-z0-9_-]{0,63}){0,3}$`
+
+	// MaxLength is the maximal slug length (regardless of the pattern).
+	MaxLength = 128
+
+	// MaxSegments is the maximal number of the path segments in the slug (regardless of the pattern).
+	MaxSegments = 4
+
+	// MaxPerSession is the maximal number of the slugs, which can be reserved by a single session.
+	MaxPerSession = 16
+)
+
+var (
+	ErrInvalid  = errors.New("invalid slug")
+	ErrReserved = errors.New("slug is reserved")
+	ErrTooMany  = fmt.Errorf("too many slugs (max %d per session)", MaxPerSession)
+	ErrDisabled = errors.New("slugs are disabled")
+)
+
+// Policy decides which slugs are allowed. The slug and its first path segment must match the pattern (so the
+// requests to the other paths are not looked up), and the first path segment must not be reserved (the reserved
+// segments are the application routes, like "api" or "healthz", and the frontend assets) or look like a session ID.
+type Policy struct {
+	pattern  *regexp.Regexp
+	reserved map[string]struct{} // lower-cased
+}
+
+// NewPolicy creates a new Policy with the given pattern (regular expression) and reserved first path segments.
+func NewPolicy(pattern string, reserved ...string) (*Policy, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("wrong slug pattern: %w", err)
+	}
+
+	var p = Policy{pattern: re, reserved: make(map[string]struct{}, len(reserved))}
+
+	for _, segment := range reserved {
+		if segment = strings.ToLower(strings.Trim(segment, "/")); segment != "" {
+			p.reserved[segment] = struct{}{}
+		}
+	}
+
+	return &p, nil
+}
+
+// Pattern returns the slug pattern.
+func (p *Policy) Pattern() string { return p.pattern.String() }
+
+// Validate checks the slug. It returns ErrInvalid (wrapped) if the slug is malformed or does not match the pattern
+// (or its first path segment does not), and ErrReserved (wrapped) if the slug collides with the application routes
+// or session IDs.
+func (p *Policy) Validate(slug string) error {
+	var segments = strings.Split(slug, "/")
+
+	switch {
+	case slug == "":
+		return fmt.Errorf("%w: empty", ErrInvalid)
+	case len(slug) > MaxLength:
+		return fmt.Errorf("%w: too long (max %d characters)", ErrInvalid, MaxLength)
+	case len(segments) > MaxSegments:
+		return fmt.Errorf("%w: too many path segments (max %d)", ErrInvalid, MaxSegments)
+	case slices.ContainsFunc(segments, func(s string) bool { return s == "" || s == "." || s == ".." }):
+		return fmt.Errorf("%w: empty or relative path segment", ErrInvalid)
+	case !p.pattern.MatchString(slug):
+		return fmt.Errorf("%w: does not match the pattern %s", ErrInvalid, p.pattern.String())
+	case !p.pattern.MatchString(segments[0]):
+		return fmt.Errorf("%w: the first path segment does not match the pattern %s", ErrInvalid, p.pattern.String())
+	case p.isReserved(segments[0]):
+		return fmt.Errorf("%w: the path /%s is used by the application", ErrReserved, segments[0])
+	case looksLikeSessionID(slug):
+		return fmt.Errorf("%w: looks like a session ID", ErrReserved)
+	}
+
+	return nil
+}
+
+// Candidates returns the slugs the request path may start with, the longest first (e.g. "hooks/stripe/foo",
+// "hooks/stripe" and "hooks" for the "/hooks/stripe/foo" path). Nothing is returned for the reserved paths, and the
+// paths with the first segment, which does not match the pattern.
+func (p *Policy) Candidates(path string) []string {
+	var segments = strings.Split(strings.Trim(path, "/"), "/")
+
+	if segments[0] == "" || p.isReserved(segments[0]) || !p.pattern.MatchString(segments[0]) {
+		return nil
+	}
+
+	var candidates = make([]string, 0, MaxSegments)
+
+	for n := min(len(segments), MaxSegments); n > 0; n-- {
+		if candidate := strings.Join(segments[:n], "/"); p.Validate(candidate) == nil {
+			candidates = append(candidates, candidate)
+		}
+	}
+
+	return candidates
+}
+
+func (p *Policy) isReserved(segment string) bool {
+	_, ok := p.reserved[strings.ToLower(segment)]
+
+	return ok
+}
+
+// looksLikeSessionID checks if the slug starts with a UUID (such paths are captured as the session ID ones).
+func looksLikeSessionID(slug string) bool {
+	const uuidLength = 36
+
+	if len(slug) < uuidLength {
+		return false
+	}
+
+	_, err := uuid.Parse(slug[:uuidLength])
+
+	return err == nil
+}
@@ -0,0 +1,110 @@
+package slug_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gh.tarampamp.am/webhook-tester/v2/internal/slug"
+)
+
+func TestNewPolicy(t *testing.T) {
+	t.Parallel()
+
+	_, err := slug.NewPolicy(`^[a-z`)
+	require.Error(t, err)
+
+	p, err := slug.NewPolicy(slug.DefaultPattern, "api", "/healthz", "")
+	require.NoError(t, err)
+	assert.Equal(t, slug.DefaultPattern, p.Pattern())
+}
+
+func TestPolicy_Validate(t *testing.T) {
+	t.Parallel()
+
+	p, err := slug.NewPolicy(slug.DefaultPattern, "api", "healthz", "favicon.ico")
+	require.NoError(t, err)
+
+	for _, s := range []string{
+		"stripe-staging",
+		"hooks/stripe-staging",
+		"a/b/c/d",
+		"github_ci",
+		"0",
+		"apis",
+	} {
+		assert.NoError(t, p.Validate(s), s)
+	}
+
+	for _, s := range []string{
+		"",
+		"Stripe",
+		"-stripe",
+		"hooks/",
+		"/hooks",
+		"hooks//stripe",
+		"hooks/../api",
+		"a/b/c/d/e",
+		"stripe staging",
+		strings.Repeat("a", 64) + "/" + strings.Repeat("b", 64),
+	} {
+		assert.ErrorIs(t, p.Validate(s), slug.ErrInvalid, s)
+	}
+
+	for _, s := range []string{
+		"api",
+		"api/foo",
+		"healthz",
+		"9b6bbab9-c197-4dd3-bc3f-a6a3a8f06e3b",
+		"9b6bbab9-c197-4dd3-bc3f-a6a3a8f06e3b/foo",
+	} {
+		assert.ErrorIs(t, p.Validate(s), slug.ErrReserved, s)
+	}
+
+	// the custom pattern
+	p, err = slug.NewPolicy(`^hooks(/[A-Z]+)?$`, "api")
+	require.NoError(t, err)
+
+	assert.NoError(t, p.Validate("hooks/STRIPE"))
+	assert.ErrorIs(t, p.Validate("stripe"), slug.ErrInvalid)
+
+	// the first path segment must match the pattern as well
+	p, err = slug.NewPolicy(`^hooks/[A-Z]+$`, "api")
+	require.NoError(t, err)
+
+	assert.ErrorIs(t, p.Validate("hooks/STRIPE"), slug.ErrInvalid)
+}
+
+func TestPolicy_Candidates(t *testing.T) {
+	t.Parallel()
+
+	p, err := slug.NewPolicy(slug.DefaultPattern, "api", "assets")
+	require.NoError(t, err)
+
+	for path, expected := range map[string][]string{
+		"/hooks/stripe/foo":      {"hooks/stripe/foo", "hooks/stripe", "hooks"},
+		"/hooks/stripe/":         {"hooks/stripe", "hooks"},
+		"/hooks":                 {"hooks"},
+		"/a/b/c/d/e/f":           {"a/b/c/d", "a/b/c", "a/b", "a"},
+		"/hooks//stripe":         {"hooks"},
+		"/hooks/Stripe/foo":      {"hooks"},
+		"/":                      nil,
+		"":                       nil,
+		"/api/session":           nil,
+		"/assets/index.js":       nil,
+		"/Foo/bar":               nil,
+		"/some.file/with/dots":   nil,
+		"/hooks/some.file/stuff": {"hooks"},
+	} {
+		assert.Equal(t, expected, p.Candidates(path), path)
+	}
+
+	// the paths with the first segment, which does not match the pattern, are not looked up
+	p, err = slug.NewPolicy(`^hooks(/[a-z]+)?$`)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"hooks/stripe", "hooks"}, p.Candidates("/hooks/stripe/foo"))
+	assert.Nil(t, p.Candidates("/stripe/foo"))
+}
@@ -2,6 +2,7 @@ package storage
 
 import (
 	"context"
+	"encoding/base64"
 	"errors"
 	"fmt"
 	"io"
@@ -27,6 +28,9 @@ import (
 //	│   ├── 📄 session.<expiration-time-unix-millis>.json
 //	│   ├── 📄 request.<created-time-unix-millis>.{request-uuid}.json
 //	│   └── …
+//	├── 📂 slugs
+//	│   ├── 📄 {base64url-encoded-slug} (contains the session ID)
+//	│   └── …
 //	└── …
 type FS struct {
 	sessionTTL      time.Duration
@@ -46,8 +50,9 @@ type FS struct {
 }
 
 var ( // ensure interface implementation
-	_ Storage   = (*FS)(nil)
-	_ io.Closer = (*FS)(nil)
+	_ Storage         = (*FS)(nil)
+	_ RequestsQuerier = (*FS)(nil)
+	_ io.Closer       = (*FS)(nil)
 )
 
 type FSOption func(*FS)
@@ -166,13 +171,24 @@ func (s *FS) isOpenAndNotDone(ctx context.Context) error {
 // sessionDir returns the path to the session directory (e.g. {root}/{sID}).
 func (s *FS) sessionDir(sID string) string { return path.Join(s.root, sID) }
 
-func (s *FS) findSessionFile(sID string) (filePath string, _ *time.Time, _ error) {
-	var (
-		dir   = s.sessionDir(sID)
-		files []os.DirEntry
-	)
+func (s *FS) findSessionFile(sID string) (filePath string, expiresAt *time.Time, _ error) {
+	if err := s.withLock(true, func() (err error) {
+		filePath, expiresAt, err = s.lookupSessionFile(sID)
 
-	if err := s.withLock(true, func() (err error) { files, err = os.ReadDir(dir); return }); err != nil { //nolint:nlreturn,lll
+		return
+	}); err != nil {
+		return "", nil, err
+	}
+
+	return filePath, expiresAt, nil
+}
+
+// lookupSessionFile is the same as findSessionFile, but without locking. Should be called under the lock.
+func (s *FS) lookupSessionFile(sID string) (filePath string, _ *time.Time, _ error) {
+	var dir = s.sessionDir(sID)
+
+	files, err := os.ReadDir(dir)
+	if err != nil {
 		return "", nil, err // directory reading failed
 	}
 
@@ -184,9 +200,9 @@ func (s *FS) findSessionFile(sID string) (filePath string, _ *time.Time, _ error
 		}
 
 		if n := file.Name(); strings.HasPrefix(n, prefix) && strings.HasSuffix(n, postfix) {
-			var ts, err = strconv.ParseInt(strings.TrimSuffix(strings.TrimPrefix(n, prefix), postfix), 10, 64)
-			if err != nil {
-				return "", nil, err // timestamp parsing failed
+			var ts, pErr = strconv.ParseInt(strings.TrimSuffix(strings.TrimPrefix(n, prefix), postfix), 10, 64)
+			if pErr != nil {
+				return "", nil, pErr // timestamp parsing failed
 			}
 
 			var t = time.UnixMilli(ts)
@@ -411,6 +427,134 @@ func (s *FS) AddSessionTTL(ctx context.Context, sID string, howMuch time.Duratio
 	})
 }
 
+// UpdateSession reads the session file, applies the update function and writes it back. The session expiration
+// time is preserved.
+func (s *FS) UpdateSession(ctx context.Context, sID string, update func(*Session) error) error {
+	if err := s.isOpenAndNotDone(ctx); err != nil {
+		return err // closed, or context is done
+	}
+
+	var now = s.timeNow()
+
+	filePath, expiresAt, sErr := s.findSessionFile(sID)
+	if sErr != nil {
+		if errors.Is(sErr, os.ErrNotExist) {
+			return ErrSessionNotFound
+		}
+
+		return sErr
+	} else if expiresAt.Before(now) {
+		if dErr := s.DeleteSession(ctx, sID); dErr != nil { // delete the expired session
+			return dErr
+		}
+
+		return ErrSessionNotFound
+	}
+
+	if err := s.withLock(false, func() error {
+		data, rErr := os.ReadFile(filePath)
+		if rErr != nil {
+			return rErr
+		}
+
+		var session Session
+		if err := s.encDec.Decode(data, &session); err != nil {
+			return err
+		}
+
+		if err := applyUpdate(&session, update); err != nil {
+			return err
+		}
+
+		updated, mErr := s.encDec.Encode(session)
+		if mErr != nil {
+			return mErr
+		}
+
+		return s.replaceFile(filePath, updated)
+	}); err != nil {
+		if errors.Is(err, os.ErrNotExist) { // probably, another thread has deleted the session
+			return ErrSessionNotFound
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+// replaceFile writes the data into a temporary file and replaces the file with it (atomic operation). Should be
+// called under the write lock.
+func (s *FS) replaceFile(filePath string, data []byte) error {
+	var tmpPath = path.Join(path.Dir(filePath), "."+path.Base(filePath)+".tmp")
+
+	if err := os.WriteFile(tmpPath, data, s.filePerm); err != nil {
+		return err
+	}
+
+	return os.Rename(tmpPath, filePath)
+}
+
+func (s *FS) ListSessions(ctx context.Context, q SessionsQuery) (*SessionsPage, error) {
+	if err := s.isOpenAndNotDone(ctx); err != nil {
+		return nil, err // closed, or context is done
+	}
+
+	if _, err := decodeCursor(q.Cursor); err != nil {
+		return nil, err
+	}
+
+	var dirs []os.DirEntry
+
+	if err := s.withLock(true, func() (err error) { dirs, err = os.ReadDir(s.root); return }); err != nil { //nolint:nlreturn,lll
+		return nil, err
+	}
+
+	var matched = make([]ListedSession, 0, len(dirs))
+
+	for _, dir := range dirs {
+		if !dir.IsDir() || len(dir.Name()) != 36 { // UUID length
+			continue
+		}
+
+		session, err := s.GetSession(ctx, dir.Name())
+		if err != nil {
+			if errors.Is(err, ErrSessionNotFound) {
+				continue // expired or deleted in the meantime
+			}
+
+			return nil, err
+		}
+
+		if q.Match(*session) {
+			matched = append(matched, ListedSession{ID: dir.Name(), Session: *session})
+		}
+	}
+
+	page, pErr := q.page(matched)
+	if pErr != nil {
+		return nil, pErr
+	}
+
+	// the requests statistics are collected for the sessions on the page only
+	for i := range page.Sessions {
+		list, err := s.listRequestFiles(page.Sessions[i].ID)
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				continue // deleted in the meantime
+			}
+
+			return nil, err
+		}
+
+		if page.Sessions[i].RequestsCount = uint32(len(list)); len(list) > 0 { //nolint:gosec
+			page.Sessions[i].LastRequestAtUnixMilli = list[0].createdAt.UnixMilli() // the list is sorted, newest first
+		}
+	}
+
+	return page, nil
+}
+
 func (s *FS) DeleteSession(ctx context.Context, sID string) error {
 	if err := s.isOpenAndNotDone(ctx); err != nil {
 		return err // closed, or context is done
@@ -424,8 +568,225 @@ func (s *FS) DeleteSession(ctx context.Context, sID string) error {
 		return err
 	}
 
-	// delete the session directory with all its content
-	return s.withLock(false, func() error { return os.RemoveAll(s.sessionDir(sID)) })
+	return s.withLock(false, func() error {
+		// delete the session directory with all its content
+		if err := os.RemoveAll(s.sessionDir(sID)); err != nil {
+			return err
+		}
+
+		// and release the session slugs
+		files, err := s.listSlugFiles()
+		if err != nil {
+			return err
+		}
+
+		for _, file := range files {
+			if file.sID == sID {
+				if rErr := os.Remove(file.path); rErr != nil && !errors.Is(rErr, os.ErrNotExist) {
+					return rErr
+				}
+			}
+		}
+
+		return nil
+	})
+}
+
+// slugsDir returns the path to the slugs directory (e.g. {root}/slugs).
+func (s *FS) slugsDir() string { return path.Join(s.root, "slugs") }
+
+// slugFile returns the path to the slug file. The slug is encoded, since it may contain the characters that are
+// not allowed in the file names (e.g. slashes).
+func (s *FS) slugFile(slug string) string {
+	return path.Join(s.slugsDir(), base64.RawURLEncoding.EncodeToString([]byte(slug)))
+}
+
+type fsSlugFile struct {
+	slug, sID, path string
+}
+
+// listSlugFiles returns a list of the slug files with the IDs of the sessions they belong to. Should be called under
+// the lock.
+func (s *FS) listSlugFiles() ([]fsSlugFile, error) {
+	files, err := os.ReadDir(s.slugsDir())
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil // no slugs yet
+		}
+
+		return nil, err
+	}
+
+	var list = make([]fsSlugFile, 0, len(files))
+
+	for _, file := range files {
+		if !file.Type().IsRegular() || strings.HasPrefix(file.Name(), ".") {
+			continue // is not a regular file, or a temporary one
+		}
+
+		slug, dErr := base64.RawURLEncoding.DecodeString(file.Name())
+		if dErr != nil {
+			continue // invalid file name
+		}
+
+		var filePath = path.Join(s.slugsDir(), file.Name())
+
+		sID, rErr := os.ReadFile(filePath)
+		if rErr != nil {
+			return nil, rErr
+		}
+
+		list = append(list, fsSlugFile{slug: string(slug), sID: string(sID), path: filePath})
+	}
+
+	return list, nil
+}
+
+// isSessionAlive checks if the session with the specified ID exists and is not expired. Should be called under
+// the lock.
+func (s *FS) isSessionAlive(sID string) (bool, error) {
+	if _, expiresAt, err := s.lookupSessionFile(sID); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return false, nil
+		}
+
+		return false, err
+	} else if expiresAt.Before(s.timeNow()) {
+		return false, nil
+	}
+
+	return true, nil
+}
+
+func (s *FS) AddSessionSlug(ctx context.Context, sID, slug string) error {
+	if err := s.isOpenAndNotDone(ctx); err != nil {
+		return err // closed, or context is done
+	}
+
+	return s.withLock(false, func() error {
+		if alive, err := s.isSessionAlive(sID); err != nil {
+			return err
+		} else if !alive {
+			return ErrSessionNotFound
+		}
+
+		var filePath = s.slugFile(slug)
+
+		if owner, err := os.ReadFile(filePath); err == nil {
+			if string(owner) == sID {
+				return nil // already belongs to the session
+			}
+
+			// the slug of the expired session can be taken over
+			if alive, aErr := s.isSessionAlive(string(owner)); aErr != nil {
+				return aErr
+			} else if alive {
+				return ErrSlugTaken
+			}
+		} else if !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+
+		if err := os.MkdirAll(s.slugsDir(), s.dirPerm); err != nil {
+			return err
+		}
+
+		return s.replaceFile(filePath, []byte(sID))
+	})
+}
+
+func (s *FS) GetSessionSlugs(ctx context.Context, sID string) ([]string, error) {
+	if err := s.isOpenAndNotDone(ctx); err != nil {
+		return nil, err // closed, or context is done
+	}
+
+	var slugs = make([]string, 0)
+
+	if err := s.withLock(true, func() error {
+		if alive, err := s.isSessionAlive(sID); err != nil {
+			return err
+		} else if !alive {
+			return ErrSessionNotFound
+		}
+
+		files, err := s.listSlugFiles()
+		if err != nil {
+			return err
+		}
+
+		for _, file := range files {
+			if file.sID == sID {
+				slugs = append(slugs, file.slug)
+			}
+		}
+
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	slices.Sort(slugs)
+
+	return slugs, nil
+}
+
+func (s *FS) DeleteSessionSlug(ctx context.Context, sID, slug string) error {
+	if err := s.isOpenAndNotDone(ctx); err != nil {
+		return err // closed, or context is done
+	}
+
+	return s.withLock(false, func() error {
+		if alive, err := s.isSessionAlive(sID); err != nil {
+			return err
+		} else if !alive {
+			return ErrSessionNotFound
+		}
+
+		var filePath = s.slugFile(slug)
+
+		if owner, err := os.ReadFile(filePath); err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				return ErrSlugNotFound
+			}
+
+			return err
+		} else if string(owner) != sID {
+			return ErrSlugNotFound // belongs to another session
+		}
+
+		return os.Remove(filePath)
+	})
+}
+
+func (s *FS) ResolveSlug(ctx context.Context, slug string) (sID string, _ error) {
+	if err := s.isOpenAndNotDone(ctx); err != nil {
+		return "", err // closed, or context is done
+	}
+
+	if err := s.withLock(true, func() error {
+		owner, err := os.ReadFile(s.slugFile(slug))
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				return ErrSlugNotFound
+			}
+
+			return err
+		}
+
+		if alive, aErr := s.isSessionAlive(string(owner)); aErr != nil {
+			return aErr
+		} else if !alive {
+			return ErrSlugNotFound // the session has been expired
+		}
+
+		sID = string(owner)
+
+		return nil
+	}); err != nil {
+		return "", err
+	}
+
+	return sID, nil
 }
 
 func (s *FS) NewRequest(ctx context.Context, sID string, r Request) (rID string, _ error) { //nolint:funlen,gocyclo
@@ -450,7 +811,11 @@ func (s *FS) NewRequest(ctx context.Context, sID string, r Request) (rID string,
 		return "", ErrSessionNotFound
 	}
 
-	rID, r.CreatedAtUnixMilli = s.newID(), now.UnixMilli()
+	rID = s.newID()
+
+	if r.CreatedAtUnixMilli == 0 {
+		r.CreatedAtUnixMilli = now.UnixMilli()
+	}
 
 	data, mErr := s.encDec.Encode(r)
 	if mErr != nil {
@@ -586,6 +951,68 @@ func (s *FS) GetRequest(ctx context.Context, sID, rID string) (*Request, error)
 	return &request, nil
 }
 
+func (s *FS) SetRequestForwardResult(ctx context.Context, sID, rID string, result ForwardResult) error {
+	if err := s.isOpenAndNotDone(ctx); err != nil {
+		return err
+	}
+
+	var now = s.timeNow()
+
+	// check the session existence
+	if _, expiresAt, err := s.findSessionFile(sID); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return ErrSessionNotFound
+		}
+
+		return err
+	} else if expiresAt.Before(now) {
+		if dErr := s.DeleteSession(ctx, sID); dErr != nil { // delete the expired session
+			return dErr
+		}
+
+		return ErrSessionNotFound
+	}
+
+	list, lErr := s.listRequestFiles(sID)
+	if lErr != nil {
+		return lErr
+	}
+
+	var idx = slices.IndexFunc(list, func(f fsRequestFile) bool { return f.rID == rID })
+	if idx < 0 {
+		return ErrRequestNotFound
+	}
+
+	if err := s.withLock(false, func() error {
+		data, rErr := os.ReadFile(list[idx].path)
+		if rErr != nil {
+			return rErr
+		}
+
+		var request Request
+		if err := s.encDec.Decode(data, &request); err != nil {
+			return err
+		}
+
+		request.Forwarded = &result
+
+		updated, mErr := s.encDec.Encode(request)
+		if mErr != nil {
+			return mErr
+		}
+
+		return s.replaceFile(list[idx].path, updated)
+	}); err != nil {
+		if errors.Is(err, os.ErrNotExist) { // probably, another thread has deleted the request
+			return ErrRequestNotFound
+		}
+
+		return err
+	}
+
+	return nil
+}
+
 func (s *FS) GetAllRequests(ctx context.Context, sID string) (map[string]Request, error) { //nolint:funlen
 	if err := s.isOpenAndNotDone(ctx); err != nil {
 		return nil, err
@@ -622,33 +1049,13 @@ func (s *FS) GetAllRequests(ctx context.Context, sID string) (map[string]Request
 
 	for _, file := range list {
 		eg.Go(func() error {
-			var data []byte
-
-			if err := s.withLock(true, func() (err error) {
-				var f *os.File
-
-				if f, err = os.OpenFile(file.path, os.O_RDONLY, 0); err != nil {
-					return // file opening failed
-				}
-
-				if data, err = io.ReadAll(f); err != nil {
-					_ = f.Close() // do not forget to close the file in case of an error
-
-					return // reading failed
-				}
-
-				return f.Close()
-			}); err != nil {
+			request, err := s.readRequestFile(file.path)
+			if err != nil {
 				return err
 			}
 
-			var request Request
-			if err := s.encDec.Decode(data, &request); err != nil {
-				return err // decoding failed
-			}
-
 			mu.Lock()
-			m[file.rID] = request
+			m[file.rID] = *request
 			mu.Unlock()
 
 			return nil
@@ -658,6 +1065,106 @@ func (s *FS) GetAllRequests(ctx context.Context, sID string) (map[string]Request
 	return m, eg.Wait()
 }
 
+// readRequestFile reads and decodes the request file.
+func (s *FS) readRequestFile(filePath string) (*Request, error) {
+	var data []byte
+
+	if err := s.withLock(true, func() (err error) {
+		var f *os.File
+
+		if f, err = os.OpenFile(filePath, os.O_RDONLY, 0); err != nil {
+			return // file opening failed
+		}
+
+		if data, err = io.ReadAll(f); err != nil {
+			_ = f.Close() // do not forget to close the file in case of an error
+
+			return // reading failed
+		}
+
+		return f.Close()
+	}); err != nil {
+		return nil, err
+	}
+
+	var request Request
+	if err := s.encDec.Decode(data, &request); err != nil {
+		return nil, err // decoding failed
+	}
+
+	return &request, nil
+}
+
+func (s *FS) QueryRequests(ctx context.Context, sID string, q RequestsQuery) (*RequestsPage, error) {
+	if err := s.isOpenAndNotDone(ctx); err != nil {
+		return nil, err
+	}
+
+	after, cErr := q.cursor()
+	if cErr != nil {
+		return nil, cErr
+	}
+
+	var now = s.timeNow()
+
+	// check the session existence
+	if _, expiresAt, err := s.findSessionFile(sID); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, ErrSessionNotFound
+		}
+
+		return nil, err
+	} else if expiresAt.Before(now) {
+		if dErr := s.DeleteSession(ctx, sID); dErr != nil { // delete the expired session
+			return nil, dErr
+		}
+
+		return nil, ErrSessionNotFound
+	}
+
+	// list all request files
+	var list, lErr = s.listRequestFiles(sID)
+	if lErr != nil {
+		return nil, lErr
+	}
+
+	// the creation time is a part of the file name, so the files out of the time range or before the cursor can be
+	// skipped without reading them
+	list = slices.DeleteFunc(list, func(f fsRequestFile) bool {
+		var ts = f.createdAt.UnixMilli()
+
+		return !q.inTimeRange(ts) || (after != nil && q.compare(ts, f.rID, after.ts, after.id) <= 0)
+	})
+
+	slices.SortFunc(list, func(a, b fsRequestFile) int {
+		return q.compare(a.createdAt.UnixMilli(), a.rID, b.createdAt.UnixMilli(), b.rID)
+	})
+
+	// read the files one by one until the page is full
+	return q.collect(func(yield func(string, Request) bool) error {
+		for _, file := range list {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+
+			request, err := s.readRequestFile(file.path)
+			if err != nil {
+				if errors.Is(err, os.ErrNotExist) {
+					continue // the request has been deleted in the meantime
+				}
+
+				return err
+			}
+
+			if !yield(file.rID, *request) {
+				break
+			}
+		}
+
+		return nil
+	})
+}
+
 func (s *FS) DeleteRequest(ctx context.Context, sID, rID string) error {
 	if err := s.isOpenAndNotDone(ctx); err != nil {
 		return err
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"slices"
 	"sort"
 	"sync"
 	"sync/atomic"
@@ -18,6 +19,7 @@ type (
 		sessionTTL      time.Duration
 		maxRequests     uint32
 		sessions        syncMap[ /* sID */ string, *sessionData]
+		slugs           syncMap[ /* slug */ string /* sID */, string]
 		cleanupInterval time.Duration
 
 		// this function returns the current time, it's used to mock the time in tests
@@ -35,8 +37,9 @@ type (
 )
 
 var ( // ensure interface implementation
-	_ Storage   = (*InMemory)(nil)
-	_ io.Closer = (*InMemory)(nil)
+	_ Storage         = (*InMemory)(nil)
+	_ RequestsQuerier = (*InMemory)(nil)
+	_ io.Closer       = (*InMemory)(nil)
 )
 
 type InMemoryOption func(*InMemory)
@@ -189,16 +192,16 @@ func (s *InMemory) GetSession(ctx context.Context, sID string) (*Session, error)
 	}
 
 	data.Lock()
-	var expiresAt = data.session.ExpiresAt //nolint:wsl_v5
+	var session = data.session //nolint:wsl_v5 // copy the session data to prevent data races
 	data.Unlock()
 
-	if expiresAt.Before(s.timeNow()) {
+	if session.ExpiresAt.Before(s.timeNow()) {
 		s.sessions.Delete(sID)
 
 		return nil, ErrSessionNotFound // session has been expired
 	}
 
-	return &data.session, nil
+	return &session, nil
 }
 
 func (s *InMemory) AddSessionTTL(ctx context.Context, sID string, howMuch time.Duration) error {
@@ -222,6 +225,75 @@ func (s *InMemory) AddSessionTTL(ctx context.Context, sID string, howMuch time.D
 	return nil
 }
 
+// UpdateSession applies the update function to the session with the specified ID under the lock.
+func (s *InMemory) UpdateSession(ctx context.Context, sID string, update func(*Session) error) error {
+	if err := s.isOpenAndNotDone(ctx); err != nil {
+		return err
+	}
+
+	if !s.isSessionExists(sID) {
+		return ErrSessionNotFound // session not found
+	}
+
+	data, ok := s.sessions.Load(sID)
+	if !ok {
+		return ErrSessionNotFound // like a fuse, because we already checked it
+	}
+
+	data.Lock()
+	defer data.Unlock()
+
+	var updated = data.session // the update is applied to the copy, so the failed update leaves the session untouched
+
+	if err := applyUpdate(&updated, update); err != nil {
+		return err
+	}
+
+	data.session = updated
+
+	return nil
+}
+
+func (s *InMemory) ListSessions(ctx context.Context, q SessionsQuery) (*SessionsPage, error) {
+	if err := s.isOpenAndNotDone(ctx); err != nil {
+		return nil, err
+	}
+
+	if _, err := decodeCursor(q.Cursor); err != nil {
+		return nil, err
+	}
+
+	var (
+		now     = s.timeNow()
+		matched = make([]ListedSession, 0)
+	)
+
+	s.sessions.Range(func(sID string, data *sessionData) bool {
+		data.Lock()
+		var session = data.session //nolint:wsl_v5 // copy the session data to prevent data races
+		data.Unlock()
+
+		if session.ExpiresAt.Before(now) || !q.Match(session) {
+			return true // skip the expired and not matched sessions
+		}
+
+		var listed = ListedSession{ID: sID, Session: session}
+
+		data.requests.Range(func(_ string, r Request) bool {
+			listed.RequestsCount++
+			listed.LastRequestAtUnixMilli = max(listed.LastRequestAtUnixMilli, r.CreatedAtUnixMilli)
+
+			return true
+		})
+
+		matched = append(matched, listed)
+
+		return true
+	})
+
+	return q.page(matched)
+}
+
 func (s *InMemory) DeleteSession(ctx context.Context, sID string) error {
 	if err := s.isOpenAndNotDone(ctx); err != nil {
 		return err
@@ -237,9 +309,95 @@ func (s *InMemory) DeleteSession(ctx context.Context, sID string) error {
 		})
 	}
 
+	s.slugs.Range(func(slug, owner string) bool { // release the session slugs
+		if owner == sID {
+			s.slugs.CompareAndDelete(slug, sID)
+		}
+
+		return true
+	})
+
 	return nil
 }
 
+func (s *InMemory) AddSessionSlug(ctx context.Context, sID, slug string) error {
+	if err := s.isOpenAndNotDone(ctx); err != nil {
+		return err
+	}
+
+	if !s.isSessionExists(sID) {
+		return ErrSessionNotFound // session not found
+	}
+
+	for {
+		owner, loaded := s.slugs.LoadOrStore(slug, sID)
+		if !loaded || owner == sID {
+			return nil // reserved now, or already belongs to the session
+		}
+
+		if s.isSessionExists(owner) {
+			return ErrSlugTaken
+		}
+
+		// the slug belongs to the expired session, so it can be taken over (if not changed in the meantime)
+		if s.slugs.CompareAndSwap(slug, owner, sID) {
+			return nil
+		}
+	}
+}
+
+func (s *InMemory) GetSessionSlugs(ctx context.Context, sID string) ([]string, error) {
+	if err := s.isOpenAndNotDone(ctx); err != nil {
+		return nil, err
+	}
+
+	if !s.isSessionExists(sID) {
+		return nil, ErrSessionNotFound // session not found
+	}
+
+	var slugs = make([]string, 0)
+
+	s.slugs.Range(func(slug, owner string) bool {
+		if owner == sID {
+			slugs = append(slugs, slug)
+		}
+
+		return true
+	})
+
+	slices.Sort(slugs)
+
+	return slugs, nil
+}
+
+func (s *InMemory) DeleteSessionSlug(ctx context.Context, sID, slug string) error {
+	if err := s.isOpenAndNotDone(ctx); err != nil {
+		return err
+	}
+
+	if !s.isSessionExists(sID) {
+		return ErrSessionNotFound // session not found
+	}
+
+	if !s.slugs.CompareAndDelete(slug, sID) {
+		return ErrSlugNotFound // the slug does not belong to the session
+	}
+
+	return nil
+}
+
+func (s *InMemory) ResolveSlug(ctx context.Context, slug string) (string, error) {
+	if err := s.isOpenAndNotDone(ctx); err != nil {
+		return "", err
+	}
+
+	if sID, ok := s.slugs.Load(slug); ok && s.isSessionExists(sID) {
+		return sID, nil
+	}
+
+	return "", ErrSlugNotFound // not reserved, or the session has been expired
+}
+
 func (s *InMemory) NewRequest(ctx context.Context, sID string, r Request) (rID string, _ error) {
 	if err := s.isOpenAndNotDone(ctx); err != nil {
 		return "", err
@@ -254,7 +412,11 @@ func (s *InMemory) NewRequest(ctx context.Context, sID string, r Request) (rID s
 		return "", ErrSessionNotFound // like a fuse, because we already checked it
 	}
 
-	rID, r.CreatedAtUnixMilli = s.newID(), s.timeNow().UnixMilli()
+	rID = s.newID()
+
+	if r.CreatedAtUnixMilli == 0 {
+		r.CreatedAtUnixMilli = s.timeNow().UnixMilli()
+	}
 
 	data.requests.Store(rID, r)
 
@@ -305,6 +467,35 @@ func (s *InMemory) GetRequest(ctx context.Context, sID, rID string) (*Request, e
 	return nil, ErrRequestNotFound // request not found
 }
 
+func (s *InMemory) SetRequestForwardResult(ctx context.Context, sID, rID string, result ForwardResult) error {
+	if err := s.isOpenAndNotDone(ctx); err != nil {
+		return err
+	}
+
+	if !s.isSessionExists(sID) {
+		return ErrSessionNotFound // session not found
+	}
+
+	session, sessionOk := s.sessions.Load(sID)
+	if !sessionOk {
+		return ErrSessionNotFound // like a fuse, because we already checked it
+	}
+
+	session.Lock()
+	defer session.Unlock()
+
+	request, ok := session.requests.Load(rID)
+	if !ok {
+		return ErrRequestNotFound // request not found
+	}
+
+	request.Forwarded = &result
+
+	session.requests.Store(rID, request)
+
+	return nil
+}
+
 func (s *InMemory) GetAllRequests(ctx context.Context, sID string) (map[string]Request, error) {
 	if err := s.isOpenAndNotDone(ctx); err != nil {
 		return nil, err
@@ -330,6 +521,38 @@ func (s *InMemory) GetAllRequests(ctx context.Context, sID string) (map[string]R
 	return all, nil
 }
 
+func (s *InMemory) QueryRequests(ctx context.Context, sID string, q RequestsQuery) (*RequestsPage, error) {
+	if err := s.isOpenAndNotDone(ctx); err != nil {
+		return nil, err
+	}
+
+	if _, err := q.cursor(); err != nil {
+		return nil, err
+	}
+
+	if !s.isSessionExists(sID) {
+		return nil, ErrSessionNotFound // session not found
+	}
+
+	session, sessionOk := s.sessions.Load(sID)
+	if !sessionOk {
+		return nil, ErrSessionNotFound // like a fuse, because we already checked it
+	}
+
+	var matched = make([]QueriedRequest, 0)
+
+	// only the matched requests are copied
+	session.requests.Range(func(id string, req Request) bool {
+		if q.Match(req) {
+			matched = append(matched, QueriedRequest{ID: id, Request: req})
+		}
+
+		return true
+	})
+
+	return q.page(matched)
+}
+
 func (s *InMemory) DeleteRequest(ctx context.Context, sID, rID string) error {
 	if err := s.isOpenAndNotDone(ctx); err != nil {
 		return err
@@ -390,6 +613,18 @@ func (s *InMemory) Close() error {
 // syncMap is a thread-safe map with strong-typed keys and values.
 type syncMap[K comparable, V any] struct{ m sync.Map }
 
+// CompareAndDelete deletes the entry for key if its value is equal to old.
+// The deleted result reports whether the entry was deleted.
+func (m *syncMap[K, V]) CompareAndDelete(key K, old V) (deleted bool) {
+	return m.m.CompareAndDelete(key, old)
+}
+
+// CompareAndSwap stores the value for key if the value stored in the map is equal to old.
+// The swapped result reports whether the value was swapped.
+func (m *syncMap[K, V]) CompareAndSwap(key K, old, value V) (swapped bool) {
+	return m.m.CompareAndSwap(key, old, value)
+}
+
 // Delete deletes the value for a key.
 func (m *syncMap[K, V]) Delete(key K) { m.m.Delete(key) }
 
@@ -415,6 +650,14 @@ func (m *syncMap[K, V]) LoadAndDelete(key K) (value V, loaded bool) {
 	return v.(V), loaded
 }
 
+// LoadOrStore returns the existing value for the key if present. Otherwise, it stores and returns the given value.
+// The loaded result is true if the value was loaded, false if stored.
+func (m *syncMap[K, V]) LoadOrStore(key K, value V) (actual V, loaded bool) {
+	v, loaded := m.m.LoadOrStore(key, value)
+
+	return v.(V), loaded
+}
+
 // Range calls f sequentially for each key and value present in the map.
 // If f returns false, range stops the iteration.
 func (m *syncMap[K, V]) Range(f func(key K, value V) bool) {
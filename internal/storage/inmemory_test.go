@@ -37,6 +37,32 @@ func TestInMemory_Request_CreateReadDelete(t *testing.T) {
 	)
 }
 
+func TestInMemory_RequestsQuery(t *testing.T) {
+	t.Parallel()
+
+	var ft = newFakeTime(t)
+
+	testRequestsQuery(t,
+		func(sTTL time.Duration, maxReq uint32) storage.Storage {
+			return storage.NewInMemory(sTTL, maxReq, storage.WithInMemoryTimeNow(ft.Get))
+		},
+		func(t time.Duration) { ft.Add(t) },
+	)
+}
+
+func TestInMemory_SessionsList(t *testing.T) {
+	t.Parallel()
+
+	var ft = newFakeTime(t)
+
+	testSessionsList(t,
+		func(sTTL time.Duration, maxReq uint32) storage.Storage {
+			return storage.NewInMemory(sTTL, maxReq, storage.WithInMemoryTimeNow(ft.Get))
+		},
+		func(t time.Duration) { ft.Add(t) },
+	)
+}
+
 func TestInMemory_Close(t *testing.T) {
 	t.Parallel()
 
@@ -52,18 +78,42 @@ func TestInMemory_Close(t *testing.T) {
 	_, err = impl.GetSession(ctx, "foo")
 	require.ErrorIs(t, err, storage.ErrClosed)
 
+	err = impl.UpdateSession(ctx, "foo", func(*storage.Session) error { return nil })
+	require.ErrorIs(t, err, storage.ErrClosed)
+
+	_, err = impl.ListSessions(ctx, storage.SessionsQuery{})
+	require.ErrorIs(t, err, storage.ErrClosed)
+
 	err = impl.DeleteSession(ctx, "foo")
 	require.ErrorIs(t, err, storage.ErrClosed)
 
+	err = impl.AddSessionSlug(ctx, "foo", "bar")
+	require.ErrorIs(t, err, storage.ErrClosed)
+
+	_, err = impl.GetSessionSlugs(ctx, "foo")
+	require.ErrorIs(t, err, storage.ErrClosed)
+
+	err = impl.DeleteSessionSlug(ctx, "foo", "bar")
+	require.ErrorIs(t, err, storage.ErrClosed)
+
+	_, err = impl.ResolveSlug(ctx, "bar")
+	require.ErrorIs(t, err, storage.ErrClosed)
+
 	_, err = impl.NewRequest(ctx, "foo", storage.Request{})
 	require.ErrorIs(t, err, storage.ErrClosed)
 
 	_, err = impl.GetRequest(ctx, "foo", "bar")
 	require.ErrorIs(t, err, storage.ErrClosed)
 
+	err = impl.SetRequestForwardResult(ctx, "foo", "bar", storage.ForwardResult{})
+	require.ErrorIs(t, err, storage.ErrClosed)
+
 	_, err = impl.GetAllRequests(ctx, "foo")
 	require.ErrorIs(t, err, storage.ErrClosed)
 
+	_, err = impl.QueryRequests(ctx, "foo", storage.RequestsQuery{})
+	require.ErrorIs(t, err, storage.ErrClosed)
+
 	err = impl.DeleteRequest(ctx, "foo", "bar")
 	require.ErrorIs(t, err, storage.ErrClosed)
 
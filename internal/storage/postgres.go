@@ -0,0 +1,917 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"gh.tarampamp.am/webhook-tester/v2/internal/encoding"
+)
+
+// Postgres is an implementation of the Storage interface that keeps the data in a PostgreSQL database. It's safe
+// to share the same database between multiple app instances (e.g. behind a load balancer). Sessions and requests
+// are stored in separate (indexed) tables, the payload is encoded and stored as a blob:
+//
+//	webhook_tester_sessions: id, data, created_at, expires_at
+//	webhook_tester_requests: seq, id, session_id, created_at, data
+//	webhook_tester_session_slugs: slug, session_id
+//
+// Expired sessions (and their requests and slugs) are removed by the background cleanup goroutine.
+type Postgres struct {
+	sessionTTL      time.Duration
+	maxRequests     uint32
+	pool            *pgxpool.Pool
+	cleanupInterval time.Duration
+	encDec          encoding.EncoderDecoder
+
+	// this function returns the current time, it's used to mock the time in tests
+	timeNow TimeFunc
+
+	close  chan struct{}
+	closed atomic.Bool
+	wg     sync.WaitGroup
+}
+
+var ( // ensure interface implementation
+	_ Storage         = (*Postgres)(nil)
+	_ RequestsQuerier = (*Postgres)(nil)
+	_ io.Closer       = (*Postgres)(nil)
+)
+
+// postgresMigrations is a list of the database schema migrations. The index of the migration (+1) is its version,
+// which is stored in the webhook_tester_migrations table. Never change the existing migrations - append new ones
+// instead.
+var postgresMigrations = []string{ //nolint:gochecknoglobals
+	`CREATE TABLE webhook_tester_sessions (
+		id         TEXT   NOT NULL PRIMARY KEY,
+		data       BYTEA  NOT NULL,
+		created_at BIGINT NOT NULL,
+		expires_at BIGINT NOT NULL
+	);
+	CREATE INDEX webhook_tester_sessions_expires_at_idx ON webhook_tester_sessions (expires_at);
+	CREATE TABLE webhook_tester_requests (
+		seq        BIGSERIAL NOT NULL PRIMARY KEY,
+		id         TEXT      NOT NULL UNIQUE,
+		session_id TEXT      NOT NULL REFERENCES webhook_tester_sessions (id) ON DELETE CASCADE,
+		created_at BIGINT    NOT NULL,
+		data       BYTEA     NOT NULL
+	);
+	CREATE INDEX webhook_tester_requests_session_id_created_at_idx ON webhook_tester_requests (session_id, created_at);`,
+	`CREATE INDEX webhook_tester_sessions_created_at_id_idx ON webhook_tester_sessions (created_at, id);`,
+	`CREATE TABLE webhook_tester_session_slugs (
+		slug       TEXT NOT NULL PRIMARY KEY,
+		session_id TEXT NOT NULL REFERENCES webhook_tester_sessions (id) ON DELETE CASCADE
+	);
+	CREATE INDEX webhook_tester_session_slugs_session_id_idx ON webhook_tester_session_slugs (session_id);`,
+	// the session owner is stored in a separate column to filter the listed sessions by the database
+	`ALTER TABLE webhook_tester_sessions ADD COLUMN owner TEXT NOT NULL DEFAULT '';
+	UPDATE webhook_tester_sessions SET owner = COALESCE(convert_from(data, 'UTF8')::jsonb->>'owner', '');
+	CREATE INDEX webhook_tester_sessions_owner_created_at_id_idx ON webhook_tester_sessions (owner, created_at, id);`,
+}
+
+// postgresMigrationsLockID is an advisory lock ID, used to prevent concurrent migrations by multiple app instances.
+const postgresMigrationsLockID int64 = 0x77685f7465737472 // "wh_testr"
+
+type PostgresOption func(*Postgres)
+
+// WithPostgresCleanupInterval sets the cleanup interval for expired sessions.
+func WithPostgresCleanupInterval(v time.Duration) PostgresOption {
+	return func(s *Postgres) { s.cleanupInterval = v }
+}
+
+// WithPostgresTimeNow sets the function that returns the current time.
+func WithPostgresTimeNow(fn TimeFunc) PostgresOption { return func(s *Postgres) { s.timeNow = fn } }
+
+// NewPostgres applies the schema migrations and returns a new PostgreSQL storage with the given session TTL and the
+// maximum number of stored requests.
+// Note that the cleanup goroutine is started automatically if the cleanup interval is greater than zero.
+// To stop the cleanup goroutine, call the Postgres.Close method (the connections pool is not closed).
+func NewPostgres(
+	ctx context.Context,
+	pool *pgxpool.Pool,
+	sessionTTL time.Duration,
+	maxRequests uint32,
+	opts ...PostgresOption,
+) (*Postgres, error) {
+	var s = Postgres{
+		sessionTTL:      sessionTTL,
+		maxRequests:     maxRequests,
+		pool:            pool,
+		cleanupInterval: time.Second, // default cleanup interval
+		encDec:          encoding.JSON{},
+		timeNow:         defaultTimeFunc,
+		close:           make(chan struct{}),
+	}
+
+	for _, opt := range opts {
+		opt(&s)
+	}
+
+	if err := s.migrate(ctx); err != nil {
+		return nil, fmt.Errorf("failed to migrate the database: %w", err)
+	}
+
+	if s.cleanupInterval > time.Duration(0) {
+		s.wg.Go(func() { s.cleanup(context.Background()) }) // start cleanup goroutine
+	}
+
+	return &s, nil
+}
+
+// migrate applies the missing schema migrations in a single transaction.
+func (s *Postgres) migrate(ctx context.Context) error {
+	return pgx.BeginFunc(ctx, s.pool, func(tx pgx.Tx) error {
+		// the lock is released automatically at the end of the transaction
+		if _, err := tx.Exec(ctx, "SELECT pg_advisory_xact_lock($1::BIGINT)", postgresMigrationsLockID); err != nil {
+			return err
+		}
+
+		if _, err := tx.Exec(ctx,
+			"CREATE TABLE IF NOT EXISTS webhook_tester_migrations (version INTEGER NOT NULL PRIMARY KEY)",
+		); err != nil {
+			return err
+		}
+
+		var current int // zero means no migrations were applied yet
+
+		if err := tx.QueryRow(ctx,
+			"SELECT version FROM webhook_tester_migrations ORDER BY version DESC LIMIT 1",
+		).Scan(&current); err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			return err
+		}
+
+		if current > len(postgresMigrations) {
+			return fmt.Errorf("unsupported database schema version %d (the latest known is %d)",
+				current, len(postgresMigrations),
+			)
+		}
+
+		for version := current + 1; version <= len(postgresMigrations); version++ {
+			if _, err := tx.Exec(ctx, postgresMigrations[version-1]); err != nil {
+				return fmt.Errorf("migration %d: %w", version, err)
+			}
+
+			if _, err := tx.Exec(ctx, "INSERT INTO webhook_tester_migrations (version) VALUES ($1)", version); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// newID generates a new (unique) ID.
+func (*Postgres) newID() string { return uuid.New().String() }
+
+func (s *Postgres) cleanup(ctx context.Context) {
+	var timer = time.NewTimer(s.cleanupInterval)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-s.close: // close signal received
+			return
+		case <-timer.C:
+			// the requests are deleted by the foreign key (cascade)
+			_, _ = s.pool.Exec(ctx, "DELETE FROM webhook_tester_sessions WHERE expires_at <= $1", s.timeNow().UnixMilli())
+
+			timer.Reset(s.cleanupInterval)
+		}
+	}
+}
+
+// isOpenAndNotDone checks if the storage is open and the context is not done.
+func (s *Postgres) isOpenAndNotDone(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err // context is done
+	} else if s.closed.Load() {
+		return ErrClosed // storage is closed
+	}
+
+	return nil
+}
+
+// postgresQueryer is an interface for the *pgxpool.Pool and pgx.Tx.
+type postgresQueryer interface {
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// isSessionExists checks if the session with the specified ID exists and is not expired. If the forUpdate is true,
+// the session row is locked until the end of the transaction (so the session requests can be modified safely).
+func (s *Postgres) isSessionExists(ctx context.Context, q postgresQueryer, sID string, forUpdate bool) (bool, error) {
+	var query = "SELECT 1 FROM webhook_tester_sessions WHERE id = $1 AND expires_at > $2"
+
+	if forUpdate {
+		query += " FOR UPDATE"
+	}
+
+	var found int
+
+	if err := q.QueryRow(ctx, query, sID, s.timeNow().UnixMilli()).Scan(&found); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, nil
+		}
+
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (s *Postgres) NewSession(ctx context.Context, session Session, id ...string) (sID string, _ error) {
+	if err := s.isOpenAndNotDone(ctx); err != nil {
+		return "", err
+	}
+
+	if len(id) > 0 { // use the specified ID
+		if len(id[0]) == 0 {
+			return "", errors.New("empty session ID")
+		}
+
+		sID = id[0]
+	} else {
+		sID = s.newID() // generate a new ID
+	}
+
+	var now = s.timeNow()
+
+	session.CreatedAtUnixMilli = now.UnixMilli()
+
+	data, mErr := s.encDec.Encode(session)
+	if mErr != nil {
+		return "", mErr
+	}
+
+	if err := pgx.BeginFunc(ctx, s.pool, func(tx pgx.Tx) error {
+		// remove the expired session with the same ID (if any)
+		if _, err := tx.Exec(ctx,
+			"DELETE FROM webhook_tester_sessions WHERE id = $1 AND expires_at <= $2", sID, now.UnixMilli(),
+		); err != nil {
+			return err
+		}
+
+		tag, err := tx.Exec(ctx,
+			`INSERT INTO webhook_tester_sessions (id, data, owner, created_at, expires_at) VALUES ($1, $2, $3, $4, $5)
+			ON CONFLICT (id) DO NOTHING`,
+			sID, data, session.Owner, now.UnixMilli(), now.Add(s.sessionTTL).UnixMilli(),
+		)
+		if err != nil {
+			return err
+		}
+
+		if tag.RowsAffected() == 0 {
+			return fmt.Errorf("session %s already exists", sID)
+		}
+
+		return nil
+	}); err != nil {
+		return "", err
+	}
+
+	return sID, nil
+}
+
+func (s *Postgres) GetSession(ctx context.Context, sID string) (*Session, error) {
+	if err := s.isOpenAndNotDone(ctx); err != nil {
+		return nil, err
+	}
+
+	var (
+		data      []byte
+		expiresAt int64
+	)
+
+	if err := s.pool.QueryRow(ctx,
+		"SELECT data, expires_at FROM webhook_tester_sessions WHERE id = $1 AND expires_at > $2",
+		sID, s.timeNow().UnixMilli(),
+	).Scan(&data, &expiresAt); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrSessionNotFound // not found or expired
+		}
+
+		return nil, err
+	}
+
+	var session Session
+	if err := s.encDec.Decode(data, &session); err != nil {
+		return nil, err
+	}
+
+	session.ExpiresAt = time.UnixMilli(expiresAt)
+
+	return &session, nil
+}
+
+func (s *Postgres) AddSessionTTL(ctx context.Context, sID string, howMuch time.Duration) error {
+	if err := s.isOpenAndNotDone(ctx); err != nil {
+		return err
+	}
+
+	tag, err := s.pool.Exec(ctx,
+		"UPDATE webhook_tester_sessions SET expires_at = expires_at + $1 WHERE id = $2 AND expires_at > $3",
+		howMuch.Milliseconds(), sID, s.timeNow().UnixMilli(),
+	)
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return ErrSessionNotFound
+	}
+
+	return nil
+}
+
+// UpdateSession applies the update function to the session with the specified ID in a transaction (the session
+// row is locked). The session expiration time is preserved.
+func (s *Postgres) UpdateSession(ctx context.Context, sID string, update func(*Session) error) error {
+	if err := s.isOpenAndNotDone(ctx); err != nil {
+		return err
+	}
+
+	return pgx.BeginFunc(ctx, s.pool, func(tx pgx.Tx) error {
+		var data []byte
+
+		if err := tx.QueryRow(ctx,
+			"SELECT data FROM webhook_tester_sessions WHERE id = $1 AND expires_at > $2 FOR UPDATE",
+			sID, s.timeNow().UnixMilli(),
+		).Scan(&data); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return ErrSessionNotFound
+			}
+
+			return err
+		}
+
+		var session Session
+		if err := s.encDec.Decode(data, &session); err != nil {
+			return err
+		}
+
+		if err := applyUpdate(&session, update); err != nil {
+			return err
+		}
+
+		data, err := s.encDec.Encode(session)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(ctx, "UPDATE webhook_tester_sessions SET data = $1 WHERE id = $2", data, sID)
+
+		return err
+	})
+}
+
+func (s *Postgres) ListSessions(ctx context.Context, q SessionsQuery) (*SessionsPage, error) {
+	if err := s.isOpenAndNotDone(ctx); err != nil {
+		return nil, err
+	}
+
+	after, cErr := decodeCursor(q.Cursor)
+	if cErr != nil {
+		return nil, cErr
+	}
+
+	var (
+		where = []string{"s.expires_at > $1"}
+		args  = []any{s.timeNow().UnixMilli()}
+	)
+
+	if q.Owner != "" {
+		args = append(args, q.Owner)
+		where = append(where, fmt.Sprintf("s.owner IN ('', $%d)", len(args)))
+	}
+
+	if after != nil {
+		args = append(args, after.ts, after.id)
+		where = append(where, fmt.Sprintf(
+			"(s.created_at < $%[1]d OR (s.created_at = $%[1]d AND s.id < $%[2]d))", len(args)-1, len(args),
+		))
+	}
+
+	var limit string
+
+	if q.Limit > 0 { // one more session is fetched to know if the next page exists
+		args = append(args, int64(q.Limit)+1)
+		limit = fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+
+	// the requests statistics are calculated for the sessions of the page only (using the index)
+	rows, qErr := s.pool.Query(ctx,
+		"SELECT s.id, s.data, s.expires_at, "+
+			"(SELECT COUNT(*) FROM webhook_tester_requests r WHERE r.session_id = s.id), "+
+			"COALESCE((SELECT MAX(r.created_at) FROM webhook_tester_requests r WHERE r.session_id = s.id), 0)::BIGINT "+
+			"FROM (SELECT id, data, created_at, expires_at FROM webhook_tester_sessions s "+
+			"WHERE "+strings.Join(where, " AND ")+" "+
+			"ORDER BY s.created_at DESC, s.id DESC"+limit+") s "+
+			"ORDER BY s.created_at DESC, s.id DESC",
+		args...,
+	)
+	if qErr != nil {
+		return nil, qErr
+	}
+
+	defer rows.Close()
+
+	return q.collect(func(yield func(ListedSession) bool) error {
+		for rows.Next() {
+			var (
+				item             ListedSession
+				data             []byte
+				expiresAt, count int64
+			)
+
+			if err := rows.Scan(&item.ID, &data, &expiresAt, &count, &item.LastRequestAtUnixMilli); err != nil {
+				return err
+			}
+
+			if err := s.encDec.Decode(data, &item.Session); err != nil {
+				return err
+			}
+
+			item.ExpiresAt, item.RequestsCount = time.UnixMilli(expiresAt), uint32(count) //nolint:gosec
+
+			if !yield(item) {
+				return nil
+			}
+		}
+
+		return rows.Err()
+	})
+}
+
+func (s *Postgres) DeleteSession(ctx context.Context, sID string) error {
+	if err := s.isOpenAndNotDone(ctx); err != nil {
+		return err
+	}
+
+	// the requests and slugs are deleted by the foreign key (cascade)
+	tag, err := s.pool.Exec(ctx, "DELETE FROM webhook_tester_sessions WHERE id = $1", sID)
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return ErrSessionNotFound
+	}
+
+	return nil
+}
+
+func (s *Postgres) AddSessionSlug(ctx context.Context, sID, slug string) error {
+	if err := s.isOpenAndNotDone(ctx); err != nil {
+		return err
+	}
+
+	return pgx.BeginFunc(ctx, s.pool, func(tx pgx.Tx) error {
+		// lock the session row to prevent its deletion in the meantime
+		if exists, err := s.isSessionExists(ctx, tx, sID, true); err != nil {
+			return err
+		} else if !exists {
+			return ErrSessionNotFound
+		}
+
+		// reserve the slug, if it's not reserved yet (the concurrent reservation waits for this transaction)
+		if _, err := tx.Exec(ctx,
+			"INSERT INTO webhook_tester_session_slugs (slug, session_id) VALUES ($1, $2) ON CONFLICT (slug) DO NOTHING",
+			slug, sID,
+		); err != nil {
+			return err
+		}
+
+		var (
+			owner          string
+			ownerExpiresAt int64
+		)
+
+		if err := tx.QueryRow(ctx,
+			"SELECT sl.session_id, s.expires_at FROM webhook_tester_session_slugs sl "+
+				"JOIN webhook_tester_sessions s ON s.id = sl.session_id WHERE sl.slug = $1",
+			slug,
+		).Scan(&owner, &ownerExpiresAt); err != nil {
+			return err
+		}
+
+		switch {
+		case owner == sID:
+			return nil // reserved now, or already belongs to the session
+		case ownerExpiresAt > s.timeNow().UnixMilli():
+			return ErrSlugTaken
+		}
+
+		// the slug belongs to the expired session, so it can be taken over (if not changed in the meantime)
+		tag, err := tx.Exec(ctx,
+			"UPDATE webhook_tester_session_slugs SET session_id = $1 WHERE slug = $2 AND session_id = $3",
+			sID, slug, owner,
+		)
+		if err != nil {
+			return err
+		}
+
+		if tag.RowsAffected() == 0 {
+			return ErrSlugTaken
+		}
+
+		return nil
+	})
+}
+
+func (s *Postgres) GetSessionSlugs(ctx context.Context, sID string) ([]string, error) {
+	if err := s.isOpenAndNotDone(ctx); err != nil {
+		return nil, err
+	}
+
+	if exists, err := s.isSessionExists(ctx, s.pool, sID, false); err != nil {
+		return nil, err
+	} else if !exists {
+		return nil, ErrSessionNotFound
+	}
+
+	rows, qErr := s.pool.Query(ctx,
+		"SELECT slug FROM webhook_tester_session_slugs WHERE session_id = $1 ORDER BY slug", sID,
+	)
+	if qErr != nil {
+		return nil, qErr
+	}
+
+	defer rows.Close()
+
+	var slugs = make([]string, 0)
+
+	for rows.Next() {
+		var slug string
+
+		if err := rows.Scan(&slug); err != nil {
+			return nil, err
+		}
+
+		slugs = append(slugs, slug)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return slugs, nil
+}
+
+func (s *Postgres) DeleteSessionSlug(ctx context.Context, sID, slug string) error {
+	if err := s.isOpenAndNotDone(ctx); err != nil {
+		return err
+	}
+
+	if exists, err := s.isSessionExists(ctx, s.pool, sID, false); err != nil {
+		return err
+	} else if !exists {
+		return ErrSessionNotFound
+	}
+
+	tag, err := s.pool.Exec(ctx,
+		"DELETE FROM webhook_tester_session_slugs WHERE slug = $1 AND session_id = $2", slug, sID,
+	)
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return ErrSlugNotFound
+	}
+
+	return nil
+}
+
+func (s *Postgres) ResolveSlug(ctx context.Context, slug string) (string, error) {
+	if err := s.isOpenAndNotDone(ctx); err != nil {
+		return "", err
+	}
+
+	var sID string
+
+	if err := s.pool.QueryRow(ctx,
+		"SELECT sl.session_id FROM webhook_tester_session_slugs sl "+
+			"JOIN webhook_tester_sessions s ON s.id = sl.session_id WHERE sl.slug = $1 AND s.expires_at > $2",
+		slug, s.timeNow().UnixMilli(),
+	).Scan(&sID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", ErrSlugNotFound // not reserved, or the session has been expired
+		}
+
+		return "", err
+	}
+
+	return sID, nil
+}
+
+func (s *Postgres) NewRequest(ctx context.Context, sID string, r Request) (rID string, _ error) {
+	if err := s.isOpenAndNotDone(ctx); err != nil {
+		return "", err
+	}
+
+	rID = s.newID()
+
+	if r.CreatedAtUnixMilli == 0 {
+		r.CreatedAtUnixMilli = s.timeNow().UnixMilli()
+	}
+
+	data, mErr := s.encDec.Encode(r)
+	if mErr != nil {
+		return "", mErr
+	}
+
+	if err := pgx.BeginFunc(ctx, s.pool, func(tx pgx.Tx) error {
+		// lock the session row to serialize the requests trimming
+		if exists, err := s.isSessionExists(ctx, tx, sID, true); err != nil {
+			return err
+		} else if !exists {
+			return ErrSessionNotFound
+		}
+
+		if _, err := tx.Exec(ctx,
+			"INSERT INTO webhook_tester_requests (id, session_id, created_at, data) VALUES ($1, $2, $3, $4)",
+			rID, sID, r.CreatedAtUnixMilli, data,
+		); err != nil {
+			return err
+		}
+
+		if s.maxRequests > 0 { // limit stored requests count (delete the oldest ones)
+			if _, err := tx.Exec(ctx,
+				`DELETE FROM webhook_tester_requests WHERE session_id = $1 AND seq NOT IN (
+					SELECT seq FROM webhook_tester_requests WHERE session_id = $1 ORDER BY created_at DESC, seq DESC LIMIT $2
+				)`,
+				sID, s.maxRequests,
+			); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}); err != nil {
+		return "", err
+	}
+
+	return rID, nil
+}
+
+func (s *Postgres) GetRequest(ctx context.Context, sID, rID string) (*Request, error) {
+	if err := s.isOpenAndNotDone(ctx); err != nil {
+		return nil, err
+	}
+
+	if exists, err := s.isSessionExists(ctx, s.pool, sID, false); err != nil {
+		return nil, err
+	} else if !exists {
+		return nil, ErrSessionNotFound
+	}
+
+	var data []byte
+
+	if err := s.pool.QueryRow(ctx,
+		"SELECT data FROM webhook_tester_requests WHERE session_id = $1 AND id = $2", sID, rID,
+	).Scan(&data); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrRequestNotFound
+		}
+
+		return nil, err
+	}
+
+	var request Request
+	if err := s.encDec.Decode(data, &request); err != nil {
+		return nil, err
+	}
+
+	return &request, nil
+}
+
+func (s *Postgres) GetAllRequests(ctx context.Context, sID string) (map[string]Request, error) {
+	if err := s.isOpenAndNotDone(ctx); err != nil {
+		return nil, err
+	}
+
+	if exists, err := s.isSessionExists(ctx, s.pool, sID, false); err != nil {
+		return nil, err
+	} else if !exists {
+		return nil, ErrSessionNotFound
+	}
+
+	rows, qErr := s.pool.Query(ctx, "SELECT id, data FROM webhook_tester_requests WHERE session_id = $1", sID)
+	if qErr != nil {
+		return nil, qErr
+	}
+
+	defer rows.Close()
+
+	var all = make(map[string]Request)
+
+	for rows.Next() {
+		var (
+			rID     string
+			data    []byte
+			request Request
+		)
+
+		if err := rows.Scan(&rID, &data); err != nil {
+			return nil, err
+		}
+
+		if err := s.encDec.Decode(data, &request); err != nil {
+			return nil, err
+		}
+
+		all[rID] = request
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return all, nil
+}
+
+func (s *Postgres) QueryRequests(ctx context.Context, sID string, q RequestsQuery) (*RequestsPage, error) { //nolint:funlen,lll
+	if err := s.isOpenAndNotDone(ctx); err != nil {
+		return nil, err
+	}
+
+	after, cErr := q.cursor()
+	if cErr != nil {
+		return nil, cErr
+	}
+
+	if exists, err := s.isSessionExists(ctx, s.pool, sID, false); err != nil {
+		return nil, err
+	} else if !exists {
+		return nil, ErrSessionNotFound
+	}
+
+	var (
+		where = []string{"session_id = $1"}
+		args  = []any{sID}
+	)
+
+	// the time range and cursor are applied by the database (using the index), the rest of the filters - in Go
+	if since, ok := q.sinceMilli(); ok {
+		args = append(args, since)
+		where = append(where, fmt.Sprintf("created_at >= $%d", len(args)))
+	}
+
+	if until, ok := q.untilMilli(); ok {
+		args = append(args, until)
+		where = append(where, fmt.Sprintf("created_at <= $%d", len(args)))
+	}
+
+	var cmpOp, order = "<", "DESC"
+
+	if q.Order == RequestsOldestFirst {
+		cmpOp, order = ">", "ASC"
+	}
+
+	if after != nil {
+		args = append(args, after.ts, after.id)
+		where = append(where, fmt.Sprintf(
+			"(created_at %[1]s $%[2]d OR (created_at = $%[2]d AND id %[1]s $%[3]d))", cmpOp, len(args)-1, len(args),
+		))
+	}
+
+	rows, qErr := s.pool.Query(ctx,
+		"SELECT id, data FROM webhook_tester_requests WHERE "+strings.Join(where, " AND ")+
+			" ORDER BY created_at "+order+", id "+order,
+		args...,
+	)
+	if qErr != nil {
+		return nil, qErr
+	}
+
+	defer rows.Close()
+
+	// the rows are read until the page is full
+	return q.collect(func(yield func(string, Request) bool) error {
+		for rows.Next() {
+			var (
+				rID     string
+				data    []byte
+				request Request
+			)
+
+			if err := rows.Scan(&rID, &data); err != nil {
+				return err
+			}
+
+			if err := s.encDec.Decode(data, &request); err != nil {
+				return err
+			}
+
+			if !yield(rID, request) {
+				return nil
+			}
+		}
+
+		return rows.Err()
+	})
+}
+
+func (s *Postgres) SetRequestForwardResult(ctx context.Context, sID, rID string, result ForwardResult) error {
+	if err := s.isOpenAndNotDone(ctx); err != nil {
+		return err
+	}
+
+	return pgx.BeginFunc(ctx, s.pool, func(tx pgx.Tx) error {
+		if exists, err := s.isSessionExists(ctx, tx, sID, false); err != nil {
+			return err
+		} else if !exists {
+			return ErrSessionNotFound
+		}
+
+		var data []byte
+
+		if err := tx.QueryRow(ctx,
+			"SELECT data FROM webhook_tester_requests WHERE session_id = $1 AND id = $2 FOR UPDATE", sID, rID,
+		).Scan(&data); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return ErrRequestNotFound
+			}
+
+			return err
+		}
+
+		var request Request
+		if err := s.encDec.Decode(data, &request); err != nil {
+			return err
+		}
+
+		request.Forwarded = &result
+
+		data, err := s.encDec.Encode(request)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(ctx,
+			"UPDATE webhook_tester_requests SET data = $1 WHERE session_id = $2 AND id = $3", data, sID, rID,
+		)
+
+		return err
+	})
+}
+
+func (s *Postgres) DeleteRequest(ctx context.Context, sID, rID string) error {
+	if err := s.isOpenAndNotDone(ctx); err != nil {
+		return err
+	}
+
+	if exists, err := s.isSessionExists(ctx, s.pool, sID, false); err != nil {
+		return err
+	} else if !exists {
+		return ErrSessionNotFound
+	}
+
+	tag, err := s.pool.Exec(ctx, "DELETE FROM webhook_tester_requests WHERE session_id = $1 AND id = $2", sID, rID)
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return ErrRequestNotFound
+	}
+
+	return nil
+}
+
+func (s *Postgres) DeleteAllRequests(ctx context.Context, sID string) error {
+	if err := s.isOpenAndNotDone(ctx); err != nil {
+		return err
+	}
+
+	if exists, err := s.isSessionExists(ctx, s.pool, sID, false); err != nil {
+		return err
+	} else if !exists {
+		return ErrSessionNotFound
+	}
+
+	_, err := s.pool.Exec(ctx, "DELETE FROM webhook_tester_requests WHERE session_id = $1", sID)
+
+	return err
+}
+
+// Close stops the cleanup goroutine. Any further calls to the storage methods will return ErrClosed. Note that the
+// connections pool is not closed - it's the caller's responsibility.
+func (s *Postgres) Close() error {
+	if s.closed.CompareAndSwap(false, true) {
+		close(s.close)
+		s.wg.Wait()
+
+		return nil
+	}
+
+	return ErrClosed
+}
@@ -0,0 +1,342 @@
+package storage
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidCursor is returned when the pagination cursor cannot be decoded.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// RequestsQuerier is an optional Storage capability - drivers that implement it can filter, sort and paginate the
+// session requests efficiently (without loading all of them). Use the QueryRequests function to query any Storage
+// (it falls back to the GetAllRequests method for drivers that do not implement this interface).
+type RequestsQuerier interface {
+	// QueryRequests returns a page of the session requests that match the query.
+	// If the session is not found, ErrSessionNotFound will be returned. If the cursor is invalid, ErrInvalidCursor
+	// will be returned.
+	QueryRequests(_ context.Context, sID string, _ RequestsQuery) (*RequestsPage, error)
+}
+
+// RequestsOrder is the sort order of the queried requests.
+type RequestsOrder uint8
+
+const (
+	RequestsNewestFirst RequestsOrder = iota // sort by the creation time, newest first (default)
+	RequestsOldestFirst                      // sort by the creation time, oldest first
+)
+
+type (
+	// RequestsQuery describes the session requests filters, sort order and pagination. The zero value matches all
+	// the requests (newest first, without a limit). All the filters are combined with the logical AND.
+	RequestsQuery struct {
+		Method      string         // HTTP method (case-insensitive)
+		HeaderName  string         // the request must have this header (case-insensitive)
+		HeaderValue string         // the HeaderName header value must contain this substring (case-sensitive)
+		URL         string         // the URL must contain this substring
+		URLRegexp   *regexp.Regexp // the URL must match this regular expression
+		Body        []byte         // the body must contain this substring
+		BodyRegexp  *regexp.Regexp // the body must match this regular expression
+		ClientAddr  string         // the client address must be equal to this one
+		Since       time.Time      // the request must be created at or after this time
+		Until       time.Time      // the request must be created at or before this time
+		Order       RequestsOrder  // sort order
+		Cursor      string         // the cursor from the previous page (RequestsPage.NextCursor)
+		Limit       uint32         // the maximum number of requests per page (zero means unlimited)
+	}
+
+	// RequestsPage is a page of the queried requests.
+	RequestsPage struct {
+		Requests   []QueriedRequest // the requests in the query order
+		NextCursor string           // the cursor for the next page (empty if there are no more requests)
+	}
+
+	// QueriedRequest is a request with its ID.
+	QueriedRequest struct {
+		ID string
+		Request
+	}
+)
+
+// QueryRequests queries the session requests using the storage RequestsQuerier implementation, if available.
+// Otherwise, all the session requests are loaded using the GetAllRequests method, and filtered in memory.
+func QueryRequests(ctx context.Context, s Storage, sID string, q RequestsQuery) (*RequestsPage, error) {
+	if querier, ok := s.(RequestsQuerier); ok {
+		return querier.QueryRequests(ctx, sID, q)
+	}
+
+	if _, err := q.cursor(); err != nil {
+		return nil, err
+	}
+
+	all, err := s.GetAllRequests(ctx, sID)
+	if err != nil {
+		return nil, err
+	}
+
+	var list = make([]QueriedRequest, 0, len(all))
+
+	for id, r := range all {
+		if q.Match(r) {
+			list = append(list, QueriedRequest{ID: id, Request: r})
+		}
+	}
+
+	return q.page(list)
+}
+
+// listCursor is a position in the requests or sessions list (the creation time and ID of the last returned item).
+type listCursor struct {
+	ts int64
+	id string
+}
+
+func (c listCursor) encode() string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(c.ts, 10) + "." + c.id))
+}
+
+// cursor decodes the query cursor (nil if the cursor is not set).
+func (q *RequestsQuery) cursor() (*listCursor, error) { return decodeCursor(q.Cursor) }
+
+// decodeCursor decodes the list cursor (nil if the cursor is empty).
+func decodeCursor(c string) (*listCursor, error) {
+	if c == "" {
+		return nil, nil //nolint:nilnil
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(c)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidCursor, err)
+	}
+
+	tsStr, id, found := strings.Cut(string(raw), ".")
+	if !found || id == "" {
+		return nil, ErrInvalidCursor
+	}
+
+	ts, err := strconv.ParseInt(tsStr, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidCursor, err)
+	}
+
+	return &listCursor{ts: ts, id: id}, nil
+}
+
+// compare compares two requests positions according to the query order (negative if a goes before b).
+func (q *RequestsQuery) compare(aTs int64, aID string, bTs int64, bID string) int {
+	var c = cmp.Or(cmp.Compare(aTs, bTs), strings.Compare(aID, bID))
+
+	if q.Order == RequestsNewestFirst {
+		return -c
+	}
+
+	return c
+}
+
+// sinceMilli and untilMilli return the time range bounds in unix milliseconds (inclusive).
+func (q *RequestsQuery) sinceMilli() (int64, bool) { return q.Since.UnixMilli(), !q.Since.IsZero() }
+func (q *RequestsQuery) untilMilli() (int64, bool) { return q.Until.UnixMilli(), !q.Until.IsZero() }
+
+// inTimeRange checks if the request creation time is within the query time range.
+func (q *RequestsQuery) inTimeRange(ts int64) bool {
+	if since, ok := q.sinceMilli(); ok && ts < since {
+		return false
+	}
+
+	if until, ok := q.untilMilli(); ok && ts > until {
+		return false
+	}
+
+	return true
+}
+
+// Match checks if the request matches the query filters (the cursor, order and limit are not considered).
+func (q *RequestsQuery) Match(r Request) bool { //nolint:gocyclo
+	if !q.inTimeRange(r.CreatedAtUnixMilli) {
+		return false
+	}
+
+	if q.Method != "" && !strings.EqualFold(q.Method, r.Method) {
+		return false
+	}
+
+	if q.ClientAddr != "" && q.ClientAddr != r.ClientAddr {
+		return false
+	}
+
+	if q.HeaderName != "" && !slices.ContainsFunc(r.Headers, func(h HttpHeader) bool {
+		return strings.EqualFold(h.Name, q.HeaderName) && strings.Contains(h.Value, q.HeaderValue)
+	}) {
+		return false
+	}
+
+	if q.URL != "" && !strings.Contains(r.URL, q.URL) {
+		return false
+	}
+
+	if q.URLRegexp != nil && !q.URLRegexp.MatchString(r.URL) {
+		return false
+	}
+
+	if len(q.Body) > 0 && !bytes.Contains(r.Body, q.Body) {
+		return false
+	}
+
+	if q.BodyRegexp != nil && !q.BodyRegexp.Match(r.Body) {
+		return false
+	}
+
+	return true
+}
+
+// page sorts the matched requests and returns the requested page.
+func (q *RequestsQuery) page(matched []QueriedRequest) (*RequestsPage, error) {
+	slices.SortFunc(matched, func(a, b QueriedRequest) int {
+		return q.compare(a.CreatedAtUnixMilli, a.ID, b.CreatedAtUnixMilli, b.ID)
+	})
+
+	return q.collect(func(yield func(string, Request) bool) error {
+		for _, r := range matched {
+			if !yield(r.ID, r.Request) {
+				break
+			}
+		}
+
+		return nil
+	})
+}
+
+// collect builds a page from the candidates, provided by the scan function in the query order. The candidates
+// that do not match the query filters or are not after the cursor are skipped, so the scan function may narrow the
+// candidates list (e.g. by the time range or cursor) as a performance optimization only.
+func (q *RequestsQuery) collect(scan func(yield func(id string, r Request) bool) error) (*RequestsPage, error) {
+	after, err := q.cursor()
+	if err != nil {
+		return nil, err
+	}
+
+	var page = RequestsPage{Requests: make([]QueriedRequest, 0)}
+
+	if err = scan(func(id string, r Request) bool {
+		if after != nil && q.compare(r.CreatedAtUnixMilli, id, after.ts, after.id) <= 0 {
+			return true // skip the requests before the cursor
+		}
+
+		if !q.Match(r) {
+			return true
+		}
+
+		if q.Limit > 0 && len(page.Requests) == int(q.Limit) { // one more matched request - the next page exists
+			var last = page.Requests[len(page.Requests)-1]
+
+			page.NextCursor = listCursor{ts: last.CreatedAtUnixMilli, id: last.ID}.encode()
+
+			return false
+		}
+
+		page.Requests = append(page.Requests, QueriedRequest{ID: id, Request: r})
+
+		return true
+	}); err != nil {
+		return nil, err
+	}
+
+	return &page, nil
+}
+
+type (
+	// SessionsQuery describes the sessions filter and pagination. The sessions are sorted by the creation time (newest
+	// first). The zero value matches all the sessions (without a limit).
+	SessionsQuery struct {
+		Owner  string // if set, only the sessions owned by this user and the sessions without an owner are matched
+		Cursor string // the cursor from the previous page (SessionsPage.NextCursor)
+		Limit  uint32 // the maximum number of sessions per page (zero means unlimited)
+	}
+
+	// SessionsPage is a page of the listed sessions.
+	SessionsPage struct {
+		Sessions   []ListedSession // the sessions, newest first
+		NextCursor string          // the cursor for the next page (empty if there are no more sessions)
+	}
+
+	// ListedSession is a session with its ID and the requests statistics.
+	ListedSession struct {
+		ID string
+		Session
+		RequestsCount          uint32 // the number of stored requests
+		LastRequestAtUnixMilli int64  // the creation time of the newest request (zero if there are no requests)
+	}
+)
+
+// Match checks if the session matches the query filter (the cursor and limit are not considered).
+func (q *SessionsQuery) Match(s Session) bool {
+	return q.Owner == "" || s.Owner == "" || s.Owner == q.Owner
+}
+
+// compare compares two sessions positions in the list (negative if a goes before b).
+func (*SessionsQuery) compare(aTs int64, aID string, bTs int64, bID string) int {
+	return -cmp.Or(cmp.Compare(aTs, bTs), strings.Compare(aID, bID)) // newest first
+}
+
+// page sorts the sessions and returns the requested page.
+func (q *SessionsQuery) page(all []ListedSession) (*SessionsPage, error) {
+	slices.SortFunc(all, func(a, b ListedSession) int {
+		return q.compare(a.CreatedAtUnixMilli, a.ID, b.CreatedAtUnixMilli, b.ID)
+	})
+
+	return q.collect(func(yield func(ListedSession) bool) error {
+		for _, s := range all {
+			if !yield(s) {
+				break
+			}
+		}
+
+		return nil
+	})
+}
+
+// collect builds a page from the candidates, provided by the scan function in the list order. The candidates that do
+// not match the query filter or are not after the cursor are skipped.
+func (q *SessionsQuery) collect(scan func(yield func(ListedSession) bool) error) (*SessionsPage, error) {
+	after, err := decodeCursor(q.Cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	var page = SessionsPage{Sessions: make([]ListedSession, 0)}
+
+	if err = scan(func(s ListedSession) bool {
+		if after != nil && q.compare(s.CreatedAtUnixMilli, s.ID, after.ts, after.id) <= 0 {
+			return true // skip the sessions before the cursor
+		}
+
+		if !q.Match(s.Session) {
+			return true
+		}
+
+		if q.Limit > 0 && len(page.Sessions) == int(q.Limit) { // one more matched session - the next page exists
+			var last = page.Sessions[len(page.Sessions)-1]
+
+			page.NextCursor = listCursor{ts: last.CreatedAtUnixMilli, id: last.ID}.encode()
+
+			return false
+		}
+
+		page.Sessions = append(page.Sessions, s)
+
+		return true
+	}); err != nil {
+		return nil, err
+	}
+
+	return &page, nil
+}
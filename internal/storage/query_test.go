@@ -0,0 +1,27 @@
+package storage_test
+
+import (
+	"testing"
+	"time"
+
+	"gh.tarampamp.am/webhook-tester/v2/internal/storage"
+)
+
+// nonQuerier hides the RequestsQuerier implementation of the wrapped storage.
+type nonQuerier struct{ storage.Storage }
+
+func TestQueryRequests_Fallback(t *testing.T) {
+	t.Parallel()
+
+	var ft = newFakeTime(t)
+
+	testRequestsQuery(t,
+		func(sTTL time.Duration, maxReq uint32) storage.Storage {
+			var impl = storage.NewInMemory(sTTL, maxReq, storage.WithInMemoryTimeNow(ft.Get))
+			t.Cleanup(func() { _ = impl.Close() })
+
+			return nonQuerier{impl}
+		},
+		func(t time.Duration) { ft.Add(t) },
+	)
+}
@@ -4,6 +4,10 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
@@ -19,10 +23,16 @@ type (
 		client      redis.Cmdable
 		encDec      encoding.EncoderDecoder
 		timeNow     TimeFunc
+
+		backfillMu sync.Mutex
+		backfilled bool // the sessions, created before the sessions index was introduced, are indexed
 	}
 )
 
-var _ Storage = (*Redis)(nil) // ensure interface implementation
+var ( // ensure interface implementation
+	_ Storage         = (*Redis)(nil)
+	_ RequestsQuerier = (*Redis)(nil)
+)
 
 type RedisOption func(*Redis)
 
@@ -49,6 +59,19 @@ func NewRedis(c redis.Cmdable, sTTL time.Duration, maxReq uint32, opts ...RedisO
 	return &s
 }
 
+// sessionsKey returns the key for the sessions index (a sorted set of the session IDs, scored by the creation time).
+// The index entries of the expired sessions are trimmed on write (using the expiration index), and when the sessions
+// are listed.
+func (*Redis) sessionsKey() string { return "webhook-tester-v2:sessions" }
+
+// sessionsExpireKey returns the key for the sessions expiration index (a sorted set of the session IDs, scored by the
+// expiration time).
+func (*Redis) sessionsExpireKey() string { return "webhook-tester-v2:sessions:expire" }
+
+// sessionsBackfilledKey returns the key, which is set once the sessions, created before the sessions index was
+// introduced, are indexed.
+func (*Redis) sessionsBackfilledKey() string { return "webhook-tester-v2:sessions:backfilled" }
+
 // sessionKey returns the key for the session data.
 func (*Redis) sessionKey(sID string) string { return "webhook-tester-v2:session:" + sID }
 
@@ -58,6 +81,13 @@ func (s *Redis) requestsKey(sID string) string { return s.sessionKey(sID) + ":re
 // requestKey returns the key for the request data.
 func (s *Redis) requestKey(sID, rID string) string { return s.sessionKey(sID) + ":requests:" + rID }
 
+// slugsKey returns the key for the session slugs (a set of the slugs, reserved by the session).
+func (s *Redis) slugsKey(sID string) string { return s.sessionKey(sID) + ":slugs" }
+
+// slugKey returns the key for the slug (the value is the ID of the session the slug belongs to). The key has the
+// same TTL as the session.
+func (*Redis) slugKey(slug string) string { return "webhook-tester-v2:slug:" + slug }
+
 // newID generates a new (unique) ID.
 func (*Redis) newID() string { return uuid.New().String() }
 
@@ -92,14 +122,25 @@ func (s *Redis) NewSession(ctx context.Context, session Session, id ...string) (
 		sID = s.newID()
 	}
 
-	session.CreatedAtUnixMilli = s.timeNow().UnixMilli()
+	var now = s.timeNow()
+
+	session.CreatedAtUnixMilli = now.UnixMilli()
 
 	data, mErr := s.encDec.Encode(session)
 	if mErr != nil {
 		return "", mErr
 	}
 
-	if err := s.client.Set(ctx, s.sessionKey(sID), data, s.sessionTTL).Err(); err != nil {
+	if _, err := s.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, s.sessionKey(sID), data, s.sessionTTL)
+		pipe.ZAdd(ctx, s.sessionsKey(), redis.Z{Score: float64(session.CreatedAtUnixMilli), Member: sID})
+		pipe.ZAdd(ctx, s.sessionsExpireKey(), redis.Z{Score: float64(now.Add(s.sessionTTL).UnixMilli()), Member: sID})
+		redisTrimSessions.Eval(ctx, pipe,
+			[]string{s.sessionsKey(), s.sessionsExpireKey()}, now.UnixMilli(), redisQueryBatchSize,
+		)
+
+		return nil
+	}); err != nil {
 		return "", err
 	}
 
@@ -163,7 +204,13 @@ func (s *Redis) AddSessionTTL(ctx context.Context, sID string, howMuch time.Dura
 		return rErr
 	}
 
-	// update the expiration date for the session and all requests
+	// and the session slugs
+	slugs, sErr := s.client.SMembers(ctx, s.slugsKey(sID)).Result()
+	if sErr != nil {
+		return sErr
+	}
+
+	// update the expiration date for the session, all requests and slugs
 	// https://redis.io/docs/latest/commands/expire/
 	if _, err := s.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
 		var newTTL = currentTTL + howMuch
@@ -171,8 +218,14 @@ func (s *Redis) AddSessionTTL(ctx context.Context, sID string, howMuch time.Dura
 			pipe.PExpire(ctx, s.requestKey(sID, rID), newTTL)
 		}
 
+		for _, slug := range slugs {
+			pipe.PExpire(ctx, s.slugKey(slug), newTTL)
+		}
+
+		pipe.PExpire(ctx, s.slugsKey(sID), newTTL)
 		pipe.PExpire(ctx, s.requestsKey(sID), newTTL)
 		pipe.PExpire(ctx, s.sessionKey(sID), newTTL)
+		pipe.ZAdd(ctx, s.sessionsExpireKey(), redis.Z{Score: float64(s.timeNow().Add(newTTL).UnixMilli()), Member: sID})
 
 		return nil
 	}); err != nil {
@@ -182,6 +235,300 @@ func (s *Redis) AddSessionTTL(ctx context.Context, sID string, howMuch time.Dura
 	return nil
 }
 
+// redisCompareAndSet replaces the value of the key (keeping its TTL) only if the current value is equal to the
+// expected one. It returns 1 on success, 0 if the value has been changed and -1 if the key does not exist.
+var redisCompareAndSet = redis.NewScript(`
+local current = redis.call("GET", KEYS[1])
+if not current then return -1 end
+if current ~= ARGV[1] then return 0 end
+redis.call("SET", KEYS[1], ARGV[2], "KEEPTTL")
+return 1
+`) //nolint:gochecknoglobals
+
+// UpdateSession reads the session with the specified ID, applies the update function and writes it back, keeping
+// the session TTL untouched. The session is written only if it has not been modified in the meantime (otherwise,
+// the update is retried).
+func (s *Redis) UpdateSession(ctx context.Context, sID string, update func(*Session) error) error {
+	const maxAttempts = 10
+
+	for range maxAttempts {
+		if err := ctx.Err(); err != nil {
+			return err // context is done
+		}
+
+		data, rErr := s.client.Get(ctx, s.sessionKey(sID)).Bytes()
+		if rErr != nil {
+			if errors.Is(rErr, redis.Nil) {
+				return ErrSessionNotFound
+			}
+
+			return rErr
+		}
+
+		var session Session
+		if uErr := s.encDec.Decode(data, &session); uErr != nil {
+			return uErr
+		}
+
+		if err := applyUpdate(&session, update); err != nil {
+			return err
+		}
+
+		updated, mErr := s.encDec.Encode(session)
+		if mErr != nil {
+			return mErr
+		}
+
+		switch res, err := redisCompareAndSet.Run(ctx, s.client, []string{s.sessionKey(sID)}, data, updated).Int(); {
+		case err != nil:
+			return err
+		case res > 0:
+			return nil
+		case res < 0:
+			return ErrSessionNotFound // the session has been deleted or expired in the meantime
+		}
+	}
+
+	return errors.New("too many concurrent session modifications")
+}
+
+// redisTrimSessions removes up to ARGV[2] entries of the sessions, expired before ARGV[1] (unix millis) according to
+// the expiration index (KEYS[2]), from both the sessions index (KEYS[1]) and the expiration index. It returns the
+// number of the removed entries.
+var redisTrimSessions = redis.NewScript(`
+local expired = redis.call("ZRANGEBYSCORE", KEYS[2], "-inf", ARGV[1], "LIMIT", 0, ARGV[2])
+if #expired > 0 then
+  redis.call("ZREM", KEYS[1], unpack(expired))
+  redis.call("ZREM", KEYS[2], unpack(expired))
+end
+return #expired
+`) //nolint:gochecknoglobals
+
+// backfillIndex adds the sessions, created before the sessions index was introduced, to the index. The sessions are
+// scanned once (the marker key is set after the successful scan, and it's shared between the app instances).
+func (s *Redis) backfillIndex(ctx context.Context) error {
+	s.backfillMu.Lock()
+	defer s.backfillMu.Unlock()
+
+	if s.backfilled {
+		return nil
+	}
+
+	if count, err := s.client.Exists(ctx, s.sessionsBackfilledKey()).Result(); err != nil {
+		return err
+	} else if count == 1 {
+		s.backfilled = true
+
+		return nil
+	}
+
+	var (
+		prefix = s.sessionKey("")
+		cursor uint64
+	)
+
+	for {
+		keys, next, err := s.client.Scan(ctx, cursor, prefix+"*", redisQueryBatchSize).Result()
+		if err != nil {
+			return err
+		}
+
+		var sIDs = make([]string, 0, len(keys))
+
+		for _, key := range keys {
+			// skip the session requests and slugs keys
+			if sID := strings.TrimPrefix(key, prefix); sID != "" && !strings.Contains(sID, ":") {
+				sIDs = append(sIDs, sID)
+			}
+		}
+
+		if err = s.indexSessions(ctx, sIDs); err != nil {
+			return err
+		}
+
+		if next == 0 {
+			break
+		}
+
+		cursor = next
+	}
+
+	if err := s.client.Set(ctx, s.sessionsBackfilledKey(), s.timeNow().UnixMilli(), 0).Err(); err != nil {
+		return err
+	}
+
+	s.backfilled = true
+
+	return nil
+}
+
+// indexSessions adds the existing sessions to the sessions and expiration indexes (unless they are already indexed).
+func (s *Redis) indexSessions(ctx context.Context, sIDs []string) error {
+	if len(sIDs) == 0 {
+		return nil
+	}
+
+	type sessionCmds struct {
+		data *redis.StringCmd
+		ttl  *redis.DurationCmd
+	}
+
+	var cmds = make([]sessionCmds, len(sIDs))
+
+	if _, err := s.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, sID := range sIDs {
+			cmds[i] = sessionCmds{data: pipe.Get(ctx, s.sessionKey(sID)), ttl: pipe.PTTL(ctx, s.sessionKey(sID))}
+		}
+
+		return nil
+	}); err != nil && !errors.Is(err, redis.Nil) {
+		return err
+	}
+
+	var now = s.timeNow()
+
+	_, err := s.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, sID := range sIDs {
+			data, dErr := cmds[i].data.Bytes()
+			if dErr != nil || cmds[i].ttl.Val() <= 0 {
+				continue // the session has been expired or deleted in the meantime
+			}
+
+			var session Session
+
+			if uErr := s.encDec.Decode(data, &session); uErr != nil {
+				return uErr
+			}
+
+			var expiresAt = now.Add(cmds[i].ttl.Val()).UnixMilli()
+
+			pipe.ZAddNX(ctx, s.sessionsKey(), redis.Z{Score: float64(session.CreatedAtUnixMilli), Member: sID})
+			pipe.ZAddNX(ctx, s.sessionsExpireKey(), redis.Z{Score: float64(expiresAt), Member: sID})
+		}
+
+		return nil
+	})
+
+	return err
+}
+
+func (s *Redis) ListSessions(ctx context.Context, q SessionsQuery) (*SessionsPage, error) { //nolint:funlen,gocognit
+	if err := ctx.Err(); err != nil {
+		return nil, err // context is done
+	}
+
+	if err := s.backfillIndex(ctx); err != nil {
+		return nil, fmt.Errorf("failed to index the sessions: %w", err)
+	}
+
+	after, cErr := decodeCursor(q.Cursor)
+	if cErr != nil {
+		return nil, cErr
+	}
+
+	// the index score is the creation time, so the cursor is applied by redis (the sessions with the same score as
+	// the cursor are filtered out by the ID later)
+	var maxScore = "+inf"
+
+	if after != nil {
+		maxScore = strconv.FormatInt(after.ts, 10)
+	}
+
+	var expired []any // the index entries of the expired sessions
+
+	defer func() {
+		if len(expired) > 0 {
+			var bgCtx = context.WithoutCancel(ctx)
+
+			_, _ = s.client.Pipelined(bgCtx, func(pipe redis.Pipeliner) error {
+				pipe.ZRem(bgCtx, s.sessionsKey(), expired...)
+				pipe.ZRem(bgCtx, s.sessionsExpireKey(), expired...)
+
+				return nil
+			})
+		}
+	}()
+
+	return q.collect(func(yield func(ListedSession) bool) error {
+		for offset := int64(0); ; offset += redisQueryBatchSize {
+			// the members with the same score are ordered lexicographically (in reverse), like the query does
+			ids, err := s.client.ZRevRangeByScoreWithScores(ctx, s.sessionsKey(), &redis.ZRangeBy{
+				Min: "-inf", Max: maxScore, Offset: offset, Count: redisQueryBatchSize,
+			}).Result()
+			if err != nil {
+				return err
+			}
+
+			if len(ids) == 0 {
+				return nil // no more sessions
+			}
+
+			type sessionCmds struct {
+				data  *redis.StringCmd
+				ttl   *redis.DurationCmd
+				count *redis.IntCmd
+				last  *redis.ZSliceCmd
+			}
+
+			var cmds = make([]sessionCmds, len(ids))
+
+			// read the sessions data and the requests statistics at once
+			if _, err = s.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+				for i, z := range ids {
+					var sID, _ = z.Member.(string)
+
+					cmds[i] = sessionCmds{
+						data:  pipe.Get(ctx, s.sessionKey(sID)),
+						ttl:   pipe.PTTL(ctx, s.sessionKey(sID)),
+						count: pipe.ZCard(ctx, s.requestsKey(sID)),
+						last:  pipe.ZRevRangeWithScores(ctx, s.requestsKey(sID), 0, 0),
+					}
+				}
+
+				return nil
+			}); err != nil && !errors.Is(err, redis.Nil) {
+				return err
+			}
+
+			for i, z := range ids {
+				var sID, _ = z.Member.(string)
+
+				data, dErr := cmds[i].data.Bytes()
+				if dErr != nil {
+					if errors.Is(dErr, redis.Nil) {
+						expired = append(expired, sID) // the session has been expired or deleted
+
+						continue
+					}
+
+					return dErr
+				}
+
+				var listed = ListedSession{ID: sID}
+
+				if uErr := s.encDec.Decode(data, &listed.Session); uErr != nil {
+					return uErr
+				}
+
+				listed.ExpiresAt = s.timeNow().Add(cmds[i].ttl.Val())
+				listed.RequestsCount = uint32(cmds[i].count.Val()) //nolint:gosec
+
+				if last := cmds[i].last.Val(); len(last) > 0 {
+					listed.LastRequestAtUnixMilli = int64(last[0].Score)
+				}
+
+				if !yield(listed) {
+					return nil
+				}
+			}
+
+			if len(ids) < redisQueryBatchSize {
+				return nil // no more sessions
+			}
+		}
+	})
+}
+
 func (s *Redis) DeleteSession(ctx context.Context, sID string) error {
 	if err := ctx.Err(); err != nil {
 		return err // context is done
@@ -195,9 +542,136 @@ func (s *Redis) DeleteSession(ctx context.Context, sID string) error {
 		return ErrSessionNotFound
 	}
 
+	// release the session slugs
+	slugs, sErr := s.client.SMembers(ctx, s.slugsKey(sID)).Result()
+	if sErr != nil {
+		return sErr
+	}
+
+	if _, err := s.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, slug := range slugs {
+			redisDeleteSlug.Eval(ctx, pipe, []string{s.slugKey(slug), s.slugsKey(sID)}, sID, slug)
+		}
+
+		pipe.Del(ctx, s.slugsKey(sID))
+
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	_, err := s.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.ZRem(ctx, s.sessionsKey(), sID)
+		pipe.ZRem(ctx, s.sessionsExpireKey(), sID)
+
+		return nil
+	})
+
+	return err
+}
+
+// redisAddSlug reserves the slug (KEYS[2]) for the session (KEYS[1]), adding it to the session slugs (KEYS[3]) with
+// the session TTL. It returns 1 on success, 0 if the slug belongs to another session and -1 if the session does not
+// exist.
+var redisAddSlug = redis.NewScript(`
+local ttl = redis.call("PTTL", KEYS[1])
+if ttl < 0 then return -1 end
+local owner = redis.call("GET", KEYS[2])
+if owner and owner ~= ARGV[1] then return 0 end
+redis.call("SET", KEYS[2], ARGV[1], "PX", ttl)
+redis.call("SADD", KEYS[3], ARGV[2])
+redis.call("PEXPIRE", KEYS[3], ttl)
+return 1
+`) //nolint:gochecknoglobals
+
+// redisDeleteSlug removes the slug (KEYS[1]) and its entry in the session slugs (KEYS[2]) only if the slug belongs
+// to the session. It returns 1 on success and 0 if the slug does not belong to the session.
+var redisDeleteSlug = redis.NewScript(`
+if redis.call("GET", KEYS[1]) ~= ARGV[1] then return 0 end
+redis.call("DEL", KEYS[1])
+redis.call("SREM", KEYS[2], ARGV[2])
+return 1
+`) //nolint:gochecknoglobals
+
+func (s *Redis) AddSessionSlug(ctx context.Context, sID, slug string) error {
+	if err := ctx.Err(); err != nil {
+		return err // context is done
+	}
+
+	switch res, err := redisAddSlug.Run(ctx, s.client,
+		[]string{s.sessionKey(sID), s.slugKey(slug), s.slugsKey(sID)}, sID, slug,
+	).Int(); {
+	case err != nil:
+		return err
+	case res < 0:
+		return ErrSessionNotFound
+	case res == 0:
+		return ErrSlugTaken
+	}
+
 	return nil
 }
 
+func (s *Redis) GetSessionSlugs(ctx context.Context, sID string) ([]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err // context is done
+	}
+
+	if exists, err := s.isSessionExists(ctx, sID); err != nil {
+		return nil, err
+	} else if !exists {
+		return nil, ErrSessionNotFound
+	}
+
+	slugs, err := s.client.SMembers(ctx, s.slugsKey(sID)).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	slices.Sort(slugs)
+
+	return slugs, nil
+}
+
+func (s *Redis) DeleteSessionSlug(ctx context.Context, sID, slug string) error {
+	if err := ctx.Err(); err != nil {
+		return err // context is done
+	}
+
+	if exists, err := s.isSessionExists(ctx, sID); err != nil {
+		return err
+	} else if !exists {
+		return ErrSessionNotFound
+	}
+
+	if res, err := redisDeleteSlug.Run(ctx, s.client,
+		[]string{s.slugKey(slug), s.slugsKey(sID)}, sID, slug,
+	).Int(); err != nil {
+		return err
+	} else if res == 0 {
+		return ErrSlugNotFound
+	}
+
+	return nil
+}
+
+func (s *Redis) ResolveSlug(ctx context.Context, slug string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err // context is done
+	}
+
+	sID, err := s.client.Get(ctx, s.slugKey(slug)).Result()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return "", ErrSlugNotFound
+		}
+
+		return "", err
+	}
+
+	return sID, nil
+}
+
 func (s *Redis) NewRequest(ctx context.Context, sID string, r Request) (rID string, _ error) {
 	if err := ctx.Err(); err != nil {
 		return "", err // context is done
@@ -210,9 +684,11 @@ func (s *Redis) NewRequest(ctx context.Context, sID string, r Request) (rID stri
 		return "", ErrSessionNotFound
 	}
 
-	var now = s.timeNow()
+	rID = s.newID()
 
-	rID, r.CreatedAtUnixMilli = s.newID(), now.UnixMilli()
+	if r.CreatedAtUnixMilli == 0 {
+		r.CreatedAtUnixMilli = s.timeNow().UnixMilli()
+	}
 
 	data, mErr := s.encDec.Encode(r)
 	if mErr != nil {
@@ -221,7 +697,7 @@ func (s *Redis) NewRequest(ctx context.Context, sID string, r Request) (rID stri
 
 	// save the request data
 	if _, err := s.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
-		pipe.ZAdd(ctx, s.requestsKey(sID), redis.Z{Score: float64(now.UnixMilli()), Member: rID})
+		pipe.ZAdd(ctx, s.requestsKey(sID), redis.Z{Score: float64(r.CreatedAtUnixMilli), Member: rID})
 		pipe.Set(ctx, s.requestKey(sID, rID), data, s.sessionTTL)
 
 		return nil
@@ -281,6 +757,49 @@ func (s *Redis) GetRequest(ctx context.Context, sID, rID string) (*Request, erro
 	return &request, nil
 }
 
+func (s *Redis) SetRequestForwardResult(ctx context.Context, sID, rID string, result ForwardResult) error {
+	if err := ctx.Err(); err != nil {
+		return err // context is done
+	}
+
+	// check the session existence
+	if exists, err := s.isSessionExists(ctx, sID); err != nil {
+		return err
+	} else if !exists {
+		return ErrSessionNotFound
+	}
+
+	data, rErr := s.client.Get(ctx, s.requestKey(sID, rID)).Bytes()
+	if rErr != nil {
+		if errors.Is(rErr, redis.Nil) {
+			return ErrRequestNotFound
+		}
+
+		return rErr
+	}
+
+	var request Request
+	if uErr := s.encDec.Decode(data, &request); uErr != nil {
+		return uErr
+	}
+
+	request.Forwarded = &result
+
+	updated, mErr := s.encDec.Encode(request)
+	if mErr != nil {
+		return mErr
+	}
+
+	// overwrite the existing request only, keeping its TTL untouched
+	if ok, err := s.client.SetXX(ctx, s.requestKey(sID, rID), updated, redis.KeepTTL).Result(); err != nil {
+		return err
+	} else if !ok {
+		return ErrRequestNotFound // the request has been deleted or expired in the meantime
+	}
+
+	return nil
+}
+
 func (s *Redis) GetAllRequests(ctx context.Context, sID string) (map[string]Request, error) {
 	if err := ctx.Err(); err != nil {
 		return nil, err // context is done
@@ -339,6 +858,110 @@ func (s *Redis) GetAllRequests(ctx context.Context, sID string) (map[string]Requ
 	return all, nil
 }
 
+// redisQueryBatchSize is the number of requests (sessions) read at once by the QueryRequests (ListSessions) method.
+const redisQueryBatchSize = 100
+
+func (s *Redis) QueryRequests(ctx context.Context, sID string, q RequestsQuery) (*RequestsPage, error) { //nolint:funlen,gocognit,lll
+	if err := ctx.Err(); err != nil {
+		return nil, err // context is done
+	}
+
+	after, cErr := q.cursor()
+	if cErr != nil {
+		return nil, cErr
+	}
+
+	// check the session existence
+	if exists, err := s.isSessionExists(ctx, sID); err != nil {
+		return nil, err
+	} else if !exists {
+		return nil, ErrSessionNotFound
+	}
+
+	// the requests set score is the creation time, so the time range and cursor are applied by redis
+	var minScore, maxScore = "-inf", "+inf"
+
+	if since, ok := q.sinceMilli(); ok {
+		minScore = strconv.FormatInt(since, 10)
+	}
+
+	if until, ok := q.untilMilli(); ok {
+		maxScore = strconv.FormatInt(until, 10)
+	}
+
+	if after != nil { // the requests with the same score as the cursor are filtered out by the ID later
+		if q.Order == RequestsNewestFirst {
+			if until, ok := q.untilMilli(); !ok || after.ts < until {
+				maxScore = strconv.FormatInt(after.ts, 10)
+			}
+		} else {
+			if since, ok := q.sinceMilli(); !ok || after.ts > since {
+				minScore = strconv.FormatInt(after.ts, 10)
+			}
+		}
+	}
+
+	return q.collect(func(yield func(string, Request) bool) error {
+		for offset := int64(0); ; offset += redisQueryBatchSize {
+			var (
+				rng = redis.ZRangeBy{Min: minScore, Max: maxScore, Offset: offset, Count: redisQueryBatchSize}
+				ids []string
+				err error
+			)
+
+			// the members with the same score are ordered lexicographically, like the query does
+			if q.Order == RequestsNewestFirst {
+				ids, err = s.client.ZRevRangeByScore(ctx, s.requestsKey(sID), &rng).Result()
+			} else {
+				ids, err = s.client.ZRangeByScore(ctx, s.requestsKey(sID), &rng).Result()
+			}
+
+			if err != nil {
+				return err
+			}
+
+			if len(ids) == 0 {
+				return nil // no more requests
+			}
+
+			var keys = make([]string, len(ids))
+
+			for i, id := range ids {
+				keys[i] = s.requestKey(sID, id)
+			}
+
+			data, mErr := s.client.MGet(ctx, keys...).Result()
+			if mErr != nil {
+				return mErr
+			}
+
+			for i, d := range data {
+				if d == nil {
+					continue // the request has been deleted or expired in the meantime
+				}
+
+				str, ok := d.(string)
+				if !ok {
+					return errors.New("unexpected data type")
+				}
+
+				var request Request
+				if uErr := s.encDec.Decode([]byte(str), &request); uErr != nil {
+					return uErr
+				}
+
+				if !yield(ids[i], request) {
+					return nil // the page is full
+				}
+			}
+
+			if len(ids) < redisQueryBatchSize {
+				return nil // the last batch
+			}
+		}
+	})
+}
+
 func (s *Redis) DeleteRequest(ctx context.Context, sID, rID string) error {
 	if err := ctx.Err(); err != nil {
 		return err // context is done
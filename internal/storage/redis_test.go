@@ -1,11 +1,14 @@
 package storage_test
 
 import (
+	"context"
 	"testing"
 	"time"
 
 	"github.com/alicebob/miniredis/v2"
 	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"gh.tarampamp.am/webhook-tester/v2/internal/storage"
 )
@@ -53,6 +56,115 @@ func TestRedis_Request_CreateReadDelete(t *testing.T) {
 	)
 }
 
+func TestRedis_RequestsQuery(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mini = miniredis.RunT(t)
+		ft   = newFakeTime(t)
+	)
+
+	testRequestsQuery(t,
+		func(sTTL time.Duration, maxReq uint32) storage.Storage {
+			return storage.NewRedis(
+				redis.NewClient(&redis.Options{Addr: mini.Addr()}),
+				sTTL,
+				maxReq,
+				storage.WithRedisTimeNow(ft.Get),
+			)
+		},
+		func(t time.Duration) { mini.FastForward(t); ft.Add(t) },
+	)
+}
+
+func TestRedis_SessionsList(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mini = miniredis.RunT(t)
+		ft   = newFakeTime(t)
+	)
+
+	testSessionsList(t,
+		func(sTTL time.Duration, maxReq uint32) storage.Storage {
+			return storage.NewRedis(
+				redis.NewClient(&redis.Options{Addr: mini.Addr()}),
+				sTTL,
+				maxReq,
+				storage.WithRedisTimeNow(ft.Get),
+			)
+		},
+		func(t time.Duration) { mini.FastForward(t); ft.Add(t) },
+	)
+}
+
+func TestRedis_SessionsIndex(t *testing.T) {
+	t.Parallel()
+
+	var (
+		ctx  = context.Background()
+		mini = miniredis.RunT(t)
+		ft   = newFakeTime(t)
+	)
+
+	var newStorage = func() *storage.Redis {
+		return storage.NewRedis(
+			redis.NewClient(&redis.Options{Addr: mini.Addr()}),
+			time.Minute,
+			10,
+			storage.WithRedisTimeNow(ft.Get),
+		)
+	}
+
+	var indexed = func(t *testing.T) []string {
+		t.Helper()
+
+		members, err := mini.ZMembers("webhook-tester-v2:sessions")
+		require.NoError(t, err)
+
+		return members
+	}
+
+	var impl = newStorage()
+
+	// the sessions, created before the index was introduced, are indexed once they are listed
+	oldID, err := impl.NewSession(ctx, storage.Session{})
+	require.NoError(t, err)
+
+	mini.Del("webhook-tester-v2:sessions")
+	mini.Del("webhook-tester-v2:sessions:expire")
+
+	page, err := impl.ListSessions(ctx, storage.SessionsQuery{})
+	require.NoError(t, err)
+	require.Len(t, page.Sessions, 1)
+	assert.Equal(t, oldID, page.Sessions[0].ID)
+	assert.Equal(t, []string{oldID}, indexed(t))
+	assert.True(t, mini.Exists("webhook-tester-v2:sessions:backfilled"))
+
+	// the prolonged session is kept in the index, while the expired ones are trimmed on write
+	extendedID, err := impl.NewSession(ctx, storage.Session{})
+	require.NoError(t, err)
+	require.NoError(t, impl.AddSessionTTL(ctx, extendedID, time.Hour))
+
+	mini.FastForward(2 * time.Minute)
+	ft.Add(2 * time.Minute)
+
+	newID, err := impl.NewSession(ctx, storage.Session{})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{extendedID, newID}, indexed(t))
+
+	// the deleted sessions are removed from the index
+	require.NoError(t, impl.DeleteSession(ctx, newID))
+	assert.Equal(t, []string{extendedID}, indexed(t))
+
+	// the backfill is not repeated by another instance
+	mini.Del("webhook-tester-v2:sessions")
+
+	page, err = newStorage().ListSessions(ctx, storage.SessionsQuery{})
+	require.NoError(t, err)
+	assert.Empty(t, page.Sessions)
+}
+
 //	func TestRedis_RaceProvocation(t *testing.T) {
 //		t.Parallel()
 //
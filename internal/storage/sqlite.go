@@ -0,0 +1,887 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	_ "modernc.org/sqlite" // pure-Go SQLite driver
+
+	"gh.tarampamp.am/webhook-tester/v2/internal/encoding"
+)
+
+// SQLite is an implementation of the Storage interface that keeps the data in a single SQLite database file. Sessions
+// and requests are stored in separate (indexed) tables, the payload is encoded and stored as a blob:
+//
+//	sessions: id, data, created_at, expires_at
+//	requests: seq, id, session_id, created_at, data
+//	session_slugs: slug, session_id
+//
+// Expired sessions (and their requests and slugs) are removed by the background cleanup goroutine.
+type SQLite struct {
+	sessionTTL      time.Duration
+	maxRequests     uint32
+	db              *sql.DB
+	cleanupInterval time.Duration
+	encDec          encoding.EncoderDecoder
+
+	// this function returns the current time, it's used to mock the time in tests
+	timeNow TimeFunc
+
+	close  chan struct{}
+	closed atomic.Bool
+	wg     sync.WaitGroup
+}
+
+var ( // ensure interface implementation
+	_ Storage         = (*SQLite)(nil)
+	_ RequestsQuerier = (*SQLite)(nil)
+	_ io.Closer       = (*SQLite)(nil)
+)
+
+// sqliteMigrations is a list of the database schema migrations. The index of the migration (+1) is its version, which
+// is stored in the database (PRAGMA user_version). Never change the existing migrations - append new ones instead.
+var sqliteMigrations = []string{ //nolint:gochecknoglobals
+	`CREATE TABLE sessions (
+		id         TEXT    NOT NULL PRIMARY KEY,
+		data       BLOB    NOT NULL,
+		created_at INTEGER NOT NULL,
+		expires_at INTEGER NOT NULL
+	);
+	CREATE INDEX sessions_expires_at_idx ON sessions (expires_at);
+	CREATE TABLE requests (
+		seq        INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+		id         TEXT    NOT NULL UNIQUE,
+		session_id TEXT    NOT NULL REFERENCES sessions (id) ON DELETE CASCADE,
+		created_at INTEGER NOT NULL,
+		data       BLOB    NOT NULL
+	);
+	CREATE INDEX requests_session_id_created_at_idx ON requests (session_id, created_at);`,
+	`CREATE INDEX sessions_created_at_id_idx ON sessions (created_at, id);`,
+	`CREATE TABLE session_slugs (
+		slug       TEXT NOT NULL PRIMARY KEY,
+		session_id TEXT NOT NULL REFERENCES sessions (id) ON DELETE CASCADE
+	);
+	CREATE INDEX session_slugs_session_id_idx ON session_slugs (session_id);`,
+}
+
+type SQLiteOption func(*SQLite)
+
+// WithSQLiteCleanupInterval sets the cleanup interval for expired sessions.
+func WithSQLiteCleanupInterval(v time.Duration) SQLiteOption {
+	return func(s *SQLite) { s.cleanupInterval = v }
+}
+
+// WithSQLiteTimeNow sets the function that returns the current time.
+func WithSQLiteTimeNow(fn TimeFunc) SQLiteOption { return func(s *SQLite) { s.timeNow = fn } }
+
+// NewSQLite opens (or creates) the SQLite database at the given path, applies the schema migrations and returns a new
+// storage with the given session TTL and the maximum number of stored requests.
+// Note that the cleanup goroutine is started automatically if the cleanup interval is greater than zero.
+// To stop the cleanup goroutine and close the database, call the SQLite.Close method.
+func NewSQLite(
+	ctx context.Context,
+	filePath string,
+	sessionTTL time.Duration,
+	maxRequests uint32,
+	opts ...SQLiteOption,
+) (*SQLite, error) {
+	var s = SQLite{
+		sessionTTL:      sessionTTL,
+		maxRequests:     maxRequests,
+		cleanupInterval: time.Second, // default cleanup interval
+		encDec:          encoding.JSON{},
+		timeNow:         defaultTimeFunc,
+		close:           make(chan struct{}),
+	}
+
+	for _, opt := range opts {
+		opt(&s)
+	}
+
+	var dsn = url.URL{Scheme: "file", Opaque: filePath, RawQuery: url.Values{
+		"_pragma": []string{"busy_timeout(10000)", "journal_mode(WAL)", "synchronous(NORMAL)", "foreign_keys(1)"},
+		"_txlock": []string{"immediate"}, // take the write lock at the beginning of the transaction
+	}.Encode()}
+
+	db, err := sql.Open("sqlite", dsn.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to open the database: %w", err)
+	}
+
+	s.db = db
+
+	if mErr := s.migrate(ctx); mErr != nil {
+		_ = db.Close()
+
+		return nil, fmt.Errorf("failed to migrate the database: %w", mErr)
+	}
+
+	if s.cleanupInterval > time.Duration(0) {
+		s.wg.Go(func() { s.cleanup(context.Background()) }) // start cleanup goroutine
+	}
+
+	return &s, nil
+}
+
+// migrate applies the missing schema migrations (each one in a separate transaction).
+func (s *SQLite) migrate(ctx context.Context) error {
+	var current int
+
+	if err := s.db.QueryRowContext(ctx, "PRAGMA user_version").Scan(&current); err != nil {
+		return err
+	}
+
+	if current > len(sqliteMigrations) {
+		return fmt.Errorf("unsupported database schema version %d (the latest known is %d)",
+			current, len(sqliteMigrations),
+		)
+	}
+
+	for version := current + 1; version <= len(sqliteMigrations); version++ {
+		if err := s.withTx(ctx, func(tx *sql.Tx) error {
+			if _, err := tx.ExecContext(ctx, sqliteMigrations[version-1]); err != nil {
+				return err
+			}
+
+			_, err := tx.ExecContext(ctx, fmt.Sprintf("PRAGMA user_version = %d", version))
+
+			return err
+		}); err != nil {
+			return fmt.Errorf("migration %d: %w", version, err)
+		}
+	}
+
+	return nil
+}
+
+// newID generates a new (unique) ID.
+func (*SQLite) newID() string { return uuid.New().String() }
+
+func (s *SQLite) cleanup(ctx context.Context) {
+	var timer = time.NewTimer(s.cleanupInterval)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-s.close: // close signal received
+			return
+		case <-timer.C:
+			// the requests are deleted by the foreign key (cascade)
+			_, _ = s.db.ExecContext(ctx, "DELETE FROM sessions WHERE expires_at <= ?", s.timeNow().UnixMilli())
+
+			timer.Reset(s.cleanupInterval)
+		}
+	}
+}
+
+// isOpenAndNotDone checks if the storage is open and the context is not done.
+func (s *SQLite) isOpenAndNotDone(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err // context is done
+	} else if s.closed.Load() {
+		return ErrClosed // storage is closed
+	}
+
+	return nil
+}
+
+// withTx runs the function in a transaction. The transaction is committed if the function returns nil, otherwise
+// it is rolled back.
+func (s *SQLite) withTx(ctx context.Context, fn func(*sql.Tx) error) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if fnErr := fn(tx); fnErr != nil {
+		_ = tx.Rollback()
+
+		return fnErr
+	}
+
+	return tx.Commit()
+}
+
+// sqliteQueryer is an interface for the *sql.DB and *sql.Tx.
+type sqliteQueryer interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// isSessionExists checks if the session with the specified ID exists and is not expired.
+func (s *SQLite) isSessionExists(ctx context.Context, q sqliteQueryer, sID string) (bool, error) {
+	var found int
+
+	if err := q.QueryRowContext(ctx,
+		"SELECT 1 FROM sessions WHERE id = ? AND expires_at > ?", sID, s.timeNow().UnixMilli(),
+	).Scan(&found); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (s *SQLite) NewSession(ctx context.Context, session Session, id ...string) (sID string, _ error) {
+	if err := s.isOpenAndNotDone(ctx); err != nil {
+		return "", err
+	}
+
+	if len(id) > 0 { // use the specified ID
+		if len(id[0]) == 0 {
+			return "", errors.New("empty session ID")
+		}
+
+		sID = id[0]
+	} else {
+		sID = s.newID() // generate a new ID
+	}
+
+	var now = s.timeNow()
+
+	session.CreatedAtUnixMilli = now.UnixMilli()
+
+	data, mErr := s.encDec.Encode(session)
+	if mErr != nil {
+		return "", mErr
+	}
+
+	if err := s.withTx(ctx, func(tx *sql.Tx) error {
+		if exists, err := s.isSessionExists(ctx, tx, sID); err != nil {
+			return err
+		} else if exists {
+			return fmt.Errorf("session %s already exists", sID)
+		}
+
+		// remove the expired session with the same ID (if any)
+		if _, err := tx.ExecContext(ctx, "DELETE FROM sessions WHERE id = ?", sID); err != nil {
+			return err
+		}
+
+		_, err := tx.ExecContext(ctx,
+			"INSERT INTO sessions (id, data, created_at, expires_at) VALUES (?, ?, ?, ?)",
+			sID, data, now.UnixMilli(), now.Add(s.sessionTTL).UnixMilli(),
+		)
+
+		return err
+	}); err != nil {
+		return "", err
+	}
+
+	return sID, nil
+}
+
+func (s *SQLite) GetSession(ctx context.Context, sID string) (*Session, error) {
+	if err := s.isOpenAndNotDone(ctx); err != nil {
+		return nil, err
+	}
+
+	var (
+		data      []byte
+		expiresAt int64
+	)
+
+	if err := s.db.QueryRowContext(ctx,
+		"SELECT data, expires_at FROM sessions WHERE id = ? AND expires_at > ?", sID, s.timeNow().UnixMilli(),
+	).Scan(&data, &expiresAt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrSessionNotFound // not found or expired
+		}
+
+		return nil, err
+	}
+
+	var session Session
+	if err := s.encDec.Decode(data, &session); err != nil {
+		return nil, err
+	}
+
+	session.ExpiresAt = time.UnixMilli(expiresAt)
+
+	return &session, nil
+}
+
+func (s *SQLite) AddSessionTTL(ctx context.Context, sID string, howMuch time.Duration) error {
+	if err := s.isOpenAndNotDone(ctx); err != nil {
+		return err
+	}
+
+	res, err := s.db.ExecContext(ctx,
+		"UPDATE sessions SET expires_at = expires_at + ? WHERE id = ? AND expires_at > ?",
+		howMuch.Milliseconds(), sID, s.timeNow().UnixMilli(),
+	)
+	if err != nil {
+		return err
+	}
+
+	if affected, aErr := res.RowsAffected(); aErr != nil {
+		return aErr
+	} else if affected == 0 {
+		return ErrSessionNotFound
+	}
+
+	return nil
+}
+
+// UpdateSession applies the update function to the session with the specified ID in a transaction. The session
+// expiration time is preserved.
+func (s *SQLite) UpdateSession(ctx context.Context, sID string, update func(*Session) error) error {
+	if err := s.isOpenAndNotDone(ctx); err != nil {
+		return err
+	}
+
+	return s.withTx(ctx, func(tx *sql.Tx) error {
+		var data []byte
+
+		if err := tx.QueryRowContext(ctx,
+			"SELECT data FROM sessions WHERE id = ? AND expires_at > ?", sID, s.timeNow().UnixMilli(),
+		).Scan(&data); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return ErrSessionNotFound
+			}
+
+			return err
+		}
+
+		var session Session
+		if err := s.encDec.Decode(data, &session); err != nil {
+			return err
+		}
+
+		if err := applyUpdate(&session, update); err != nil {
+			return err
+		}
+
+		data, err := s.encDec.Encode(session)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, "UPDATE sessions SET data = ? WHERE id = ?", data, sID)
+
+		return err
+	})
+}
+
+func (s *SQLite) ListSessions(ctx context.Context, q SessionsQuery) (*SessionsPage, error) {
+	if err := s.isOpenAndNotDone(ctx); err != nil {
+		return nil, err
+	}
+
+	after, cErr := decodeCursor(q.Cursor)
+	if cErr != nil {
+		return nil, cErr
+	}
+
+	var (
+		where = []string{"s.expires_at > ?"}
+		args  = []any{s.timeNow().UnixMilli()}
+	)
+
+	if after != nil {
+		where = append(where, "(s.created_at < ? OR (s.created_at = ? AND s.id < ?))")
+		args = append(args, after.ts, after.ts, after.id)
+	}
+
+	// the owner filter is applied in Go (the owner is a part of the session data)
+	rows, qErr := s.db.QueryContext(ctx, //nolint:gosec // the query contains no user input
+		"SELECT s.id, s.data, s.expires_at, COUNT(r.id), COALESCE(MAX(r.created_at), 0) "+
+			"FROM sessions s LEFT JOIN requests r ON r.session_id = s.id "+
+			"WHERE "+strings.Join(where, " AND ")+" "+
+			"GROUP BY s.id ORDER BY s.created_at DESC, s.id DESC",
+		args...,
+	)
+	if qErr != nil {
+		return nil, qErr
+	}
+
+	defer func() { _ = rows.Close() }()
+
+	return q.collect(func(yield func(ListedSession) bool) error {
+		for rows.Next() {
+			var (
+				item             ListedSession
+				data             []byte
+				expiresAt, count int64
+			)
+
+			if err := rows.Scan(&item.ID, &data, &expiresAt, &count, &item.LastRequestAtUnixMilli); err != nil {
+				return err
+			}
+
+			if err := s.encDec.Decode(data, &item.Session); err != nil {
+				return err
+			}
+
+			item.ExpiresAt, item.RequestsCount = time.UnixMilli(expiresAt), uint32(count) //nolint:gosec
+
+			if !yield(item) {
+				return nil
+			}
+		}
+
+		return rows.Err()
+	})
+}
+
+func (s *SQLite) DeleteSession(ctx context.Context, sID string) error {
+	if err := s.isOpenAndNotDone(ctx); err != nil {
+		return err
+	}
+
+	// the requests and slugs are deleted by the foreign key (cascade)
+	res, err := s.db.ExecContext(ctx, "DELETE FROM sessions WHERE id = ?", sID)
+	if err != nil {
+		return err
+	}
+
+	if affected, aErr := res.RowsAffected(); aErr != nil {
+		return aErr
+	} else if affected == 0 {
+		return ErrSessionNotFound
+	}
+
+	return nil
+}
+
+func (s *SQLite) AddSessionSlug(ctx context.Context, sID, slug string) error {
+	if err := s.isOpenAndNotDone(ctx); err != nil {
+		return err
+	}
+
+	return s.withTx(ctx, func(tx *sql.Tx) error {
+		if exists, err := s.isSessionExists(ctx, tx, sID); err != nil {
+			return err
+		} else if !exists {
+			return ErrSessionNotFound
+		}
+
+		// reserve the slug, if it's not reserved yet
+		if _, err := tx.ExecContext(ctx,
+			"INSERT INTO session_slugs (slug, session_id) VALUES (?, ?) ON CONFLICT (slug) DO NOTHING", slug, sID,
+		); err != nil {
+			return err
+		}
+
+		var (
+			owner          string
+			ownerExpiresAt int64
+		)
+
+		if err := tx.QueryRowContext(ctx,
+			"SELECT sl.session_id, s.expires_at FROM session_slugs sl JOIN sessions s ON s.id = sl.session_id "+
+				"WHERE sl.slug = ?",
+			slug,
+		).Scan(&owner, &ownerExpiresAt); err != nil {
+			return err
+		}
+
+		switch {
+		case owner == sID:
+			return nil // reserved now, or already belongs to the session
+		case ownerExpiresAt > s.timeNow().UnixMilli():
+			return ErrSlugTaken
+		}
+
+		// the slug belongs to the expired session, so it can be taken over
+		_, err := tx.ExecContext(ctx,
+			"UPDATE session_slugs SET session_id = ? WHERE slug = ? AND session_id = ?", sID, slug, owner,
+		)
+
+		return err
+	})
+}
+
+func (s *SQLite) GetSessionSlugs(ctx context.Context, sID string) ([]string, error) {
+	if err := s.isOpenAndNotDone(ctx); err != nil {
+		return nil, err
+	}
+
+	if exists, err := s.isSessionExists(ctx, s.db, sID); err != nil {
+		return nil, err
+	} else if !exists {
+		return nil, ErrSessionNotFound
+	}
+
+	rows, qErr := s.db.QueryContext(ctx, "SELECT slug FROM session_slugs WHERE session_id = ? ORDER BY slug", sID)
+	if qErr != nil {
+		return nil, qErr
+	}
+
+	defer func() { _ = rows.Close() }()
+
+	var slugs = make([]string, 0)
+
+	for rows.Next() {
+		var slug string
+
+		if err := rows.Scan(&slug); err != nil {
+			return nil, err
+		}
+
+		slugs = append(slugs, slug)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return slugs, nil
+}
+
+func (s *SQLite) DeleteSessionSlug(ctx context.Context, sID, slug string) error {
+	if err := s.isOpenAndNotDone(ctx); err != nil {
+		return err
+	}
+
+	if exists, err := s.isSessionExists(ctx, s.db, sID); err != nil {
+		return err
+	} else if !exists {
+		return ErrSessionNotFound
+	}
+
+	res, err := s.db.ExecContext(ctx, "DELETE FROM session_slugs WHERE slug = ? AND session_id = ?", slug, sID)
+	if err != nil {
+		return err
+	}
+
+	if affected, aErr := res.RowsAffected(); aErr != nil {
+		return aErr
+	} else if affected == 0 {
+		return ErrSlugNotFound
+	}
+
+	return nil
+}
+
+func (s *SQLite) ResolveSlug(ctx context.Context, slug string) (string, error) {
+	if err := s.isOpenAndNotDone(ctx); err != nil {
+		return "", err
+	}
+
+	var sID string
+
+	if err := s.db.QueryRowContext(ctx,
+		"SELECT sl.session_id FROM session_slugs sl JOIN sessions s ON s.id = sl.session_id "+
+			"WHERE sl.slug = ? AND s.expires_at > ?",
+		slug, s.timeNow().UnixMilli(),
+	).Scan(&sID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", ErrSlugNotFound // not reserved, or the session has been expired
+		}
+
+		return "", err
+	}
+
+	return sID, nil
+}
+
+func (s *SQLite) NewRequest(ctx context.Context, sID string, r Request) (rID string, _ error) {
+	if err := s.isOpenAndNotDone(ctx); err != nil {
+		return "", err
+	}
+
+	rID = s.newID()
+
+	if r.CreatedAtUnixMilli == 0 {
+		r.CreatedAtUnixMilli = s.timeNow().UnixMilli()
+	}
+
+	data, mErr := s.encDec.Encode(r)
+	if mErr != nil {
+		return "", mErr
+	}
+
+	if err := s.withTx(ctx, func(tx *sql.Tx) error {
+		if exists, err := s.isSessionExists(ctx, tx, sID); err != nil {
+			return err
+		} else if !exists {
+			return ErrSessionNotFound
+		}
+
+		if _, err := tx.ExecContext(ctx,
+			"INSERT INTO requests (id, session_id, created_at, data) VALUES (?, ?, ?, ?)",
+			rID, sID, r.CreatedAtUnixMilli, data,
+		); err != nil {
+			return err
+		}
+
+		if s.maxRequests > 0 { // limit stored requests count (delete the oldest ones)
+			if _, err := tx.ExecContext(ctx,
+				`DELETE FROM requests WHERE session_id = ? AND seq NOT IN (
+					SELECT seq FROM requests WHERE session_id = ? ORDER BY created_at DESC, seq DESC LIMIT ?
+				)`,
+				sID, sID, s.maxRequests,
+			); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}); err != nil {
+		return "", err
+	}
+
+	return rID, nil
+}
+
+func (s *SQLite) GetRequest(ctx context.Context, sID, rID string) (*Request, error) {
+	if err := s.isOpenAndNotDone(ctx); err != nil {
+		return nil, err
+	}
+
+	if exists, err := s.isSessionExists(ctx, s.db, sID); err != nil {
+		return nil, err
+	} else if !exists {
+		return nil, ErrSessionNotFound
+	}
+
+	var data []byte
+
+	if err := s.db.QueryRowContext(ctx,
+		"SELECT data FROM requests WHERE session_id = ? AND id = ?", sID, rID,
+	).Scan(&data); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrRequestNotFound
+		}
+
+		return nil, err
+	}
+
+	var request Request
+	if err := s.encDec.Decode(data, &request); err != nil {
+		return nil, err
+	}
+
+	return &request, nil
+}
+
+func (s *SQLite) GetAllRequests(ctx context.Context, sID string) (map[string]Request, error) {
+	if err := s.isOpenAndNotDone(ctx); err != nil {
+		return nil, err
+	}
+
+	if exists, err := s.isSessionExists(ctx, s.db, sID); err != nil {
+		return nil, err
+	} else if !exists {
+		return nil, ErrSessionNotFound
+	}
+
+	rows, qErr := s.db.QueryContext(ctx, "SELECT id, data FROM requests WHERE session_id = ?", sID)
+	if qErr != nil {
+		return nil, qErr
+	}
+
+	defer func() { _ = rows.Close() }()
+
+	var all = make(map[string]Request)
+
+	for rows.Next() {
+		var (
+			rID     string
+			data    []byte
+			request Request
+		)
+
+		if err := rows.Scan(&rID, &data); err != nil {
+			return nil, err
+		}
+
+		if err := s.encDec.Decode(data, &request); err != nil {
+			return nil, err
+		}
+
+		all[rID] = request
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return all, nil
+}
+
+func (s *SQLite) QueryRequests(ctx context.Context, sID string, q RequestsQuery) (*RequestsPage, error) { //nolint:funlen,lll
+	if err := s.isOpenAndNotDone(ctx); err != nil {
+		return nil, err
+	}
+
+	after, cErr := q.cursor()
+	if cErr != nil {
+		return nil, cErr
+	}
+
+	if exists, err := s.isSessionExists(ctx, s.db, sID); err != nil {
+		return nil, err
+	} else if !exists {
+		return nil, ErrSessionNotFound
+	}
+
+	var (
+		where = []string{"session_id = ?"}
+		args  = []any{sID}
+	)
+
+	// the time range and cursor are applied by the database (using the index), the rest of the filters - in Go
+	if since, ok := q.sinceMilli(); ok {
+		where, args = append(where, "created_at >= ?"), append(args, since)
+	}
+
+	if until, ok := q.untilMilli(); ok {
+		where, args = append(where, "created_at <= ?"), append(args, until)
+	}
+
+	var cmpOp, order = "<", "DESC"
+
+	if q.Order == RequestsOldestFirst {
+		cmpOp, order = ">", "ASC"
+	}
+
+	if after != nil {
+		where = append(where, "(created_at "+cmpOp+" ? OR (created_at = ? AND id "+cmpOp+" ?))")
+		args = append(args, after.ts, after.ts, after.id)
+	}
+
+	rows, qErr := s.db.QueryContext(ctx, //nolint:gosec // the query contains no user input
+		"SELECT id, data FROM requests WHERE "+strings.Join(where, " AND ")+
+			" ORDER BY created_at "+order+", id "+order,
+		args...,
+	)
+	if qErr != nil {
+		return nil, qErr
+	}
+
+	defer func() { _ = rows.Close() }()
+
+	// the rows are read until the page is full
+	return q.collect(func(yield func(string, Request) bool) error {
+		for rows.Next() {
+			var (
+				rID     string
+				data    []byte
+				request Request
+			)
+
+			if err := rows.Scan(&rID, &data); err != nil {
+				return err
+			}
+
+			if err := s.encDec.Decode(data, &request); err != nil {
+				return err
+			}
+
+			if !yield(rID, request) {
+				return nil
+			}
+		}
+
+		return rows.Err()
+	})
+}
+
+func (s *SQLite) SetRequestForwardResult(ctx context.Context, sID, rID string, result ForwardResult) error {
+	if err := s.isOpenAndNotDone(ctx); err != nil {
+		return err
+	}
+
+	return s.withTx(ctx, func(tx *sql.Tx) error {
+		if exists, err := s.isSessionExists(ctx, tx, sID); err != nil {
+			return err
+		} else if !exists {
+			return ErrSessionNotFound
+		}
+
+		var data []byte
+
+		if err := tx.QueryRowContext(ctx,
+			"SELECT data FROM requests WHERE session_id = ? AND id = ?", sID, rID,
+		).Scan(&data); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return ErrRequestNotFound
+			}
+
+			return err
+		}
+
+		var request Request
+		if err := s.encDec.Decode(data, &request); err != nil {
+			return err
+		}
+
+		request.Forwarded = &result
+
+		data, err := s.encDec.Encode(request)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, "UPDATE requests SET data = ? WHERE session_id = ? AND id = ?", data, sID, rID)
+
+		return err
+	})
+}
+
+func (s *SQLite) DeleteRequest(ctx context.Context, sID, rID string) error {
+	if err := s.isOpenAndNotDone(ctx); err != nil {
+		return err
+	}
+
+	if exists, err := s.isSessionExists(ctx, s.db, sID); err != nil {
+		return err
+	} else if !exists {
+		return ErrSessionNotFound
+	}
+
+	res, err := s.db.ExecContext(ctx, "DELETE FROM requests WHERE session_id = ? AND id = ?", sID, rID)
+	if err != nil {
+		return err
+	}
+
+	if affected, aErr := res.RowsAffected(); aErr != nil {
+		return aErr
+	} else if affected == 0 {
+		return ErrRequestNotFound
+	}
+
+	return nil
+}
+
+func (s *SQLite) DeleteAllRequests(ctx context.Context, sID string) error {
+	if err := s.isOpenAndNotDone(ctx); err != nil {
+		return err
+	}
+
+	if exists, err := s.isSessionExists(ctx, s.db, sID); err != nil {
+		return err
+	} else if !exists {
+		return ErrSessionNotFound
+	}
+
+	_, err := s.db.ExecContext(ctx, "DELETE FROM requests WHERE session_id = ?", sID)
+
+	return err
+}
+
+// Close stops the cleanup goroutine and closes the database. Any further calls to the storage methods will
+// return ErrClosed.
+func (s *SQLite) Close() error {
+	if s.closed.CompareAndSwap(false, true) {
+		close(s.close)
+		s.wg.Wait()
+
+		return s.db.Close()
+	}
+
+	return ErrClosed
+}
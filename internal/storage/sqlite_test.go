@@ -0,0 +1,208 @@
+package storage_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"gh.tarampamp.am/webhook-tester/v2/internal/storage"
+)
+
+func newSQLite(t *testing.T, sTTL time.Duration, maxReq uint32, opts ...storage.SQLiteOption) *storage.SQLite {
+	t.Helper()
+
+	s, err := storage.NewSQLite(context.Background(), filepath.Join(t.TempDir(), "db.sqlite"), sTTL, maxReq, opts...)
+	require.NoError(t, err)
+
+	return s
+}
+
+func TestSQLite_Session_CreateReadDelete(t *testing.T) {
+	t.Parallel()
+
+	var ft = newFakeTime(t)
+
+	testSessionCreateReadDelete(t,
+		func(sTTL time.Duration, maxReq uint32) storage.Storage {
+			return newSQLite(t, sTTL, maxReq, storage.WithSQLiteTimeNow(ft.Get))
+		},
+		func(t time.Duration) { ft.Add(t) },
+		ft.Get,
+	)
+}
+
+func TestSQLite_Request_CreateReadDelete(t *testing.T) {
+	t.Parallel()
+
+	var ft = newFakeTime(t)
+
+	testRequestCreateReadDelete(t,
+		func(sTTL time.Duration, maxReq uint32) storage.Storage {
+			return newSQLite(t, sTTL, maxReq, storage.WithSQLiteTimeNow(ft.Get))
+		},
+		func(t time.Duration) { ft.Add(t) },
+	)
+}
+
+func TestSQLite_RequestsQuery(t *testing.T) {
+	t.Parallel()
+
+	var ft = newFakeTime(t)
+
+	testRequestsQuery(t,
+		func(sTTL time.Duration, maxReq uint32) storage.Storage {
+			return newSQLite(t, sTTL, maxReq, storage.WithSQLiteTimeNow(ft.Get))
+		},
+		func(t time.Duration) { ft.Add(t) },
+	)
+}
+
+func TestSQLite_SessionsList(t *testing.T) {
+	t.Parallel()
+
+	var ft = newFakeTime(t)
+
+	testSessionsList(t,
+		func(sTTL time.Duration, maxReq uint32) storage.Storage {
+			return newSQLite(t, sTTL, maxReq, storage.WithSQLiteTimeNow(ft.Get))
+		},
+		func(t time.Duration) { ft.Add(t) },
+	)
+}
+
+func TestSQLite_Reopen(t *testing.T) {
+	t.Parallel()
+
+	var (
+		ctx      = context.Background()
+		filePath = filepath.Join(t.TempDir(), "db.sqlite")
+	)
+
+	impl, err := storage.NewSQLite(ctx, filePath, time.Minute, 10)
+	require.NoError(t, err)
+
+	sID, err := impl.NewSession(ctx, storage.Session{Code: 201})
+	require.NoError(t, err)
+
+	rID, err := impl.NewRequest(ctx, sID, storage.Request{ClientAddr: "foo"})
+	require.NoError(t, err)
+
+	require.NoError(t, impl.Close())
+
+	// open the same database again (the migrations must not be applied twice)
+	impl, err = storage.NewSQLite(ctx, filePath, time.Minute, 10)
+	require.NoError(t, err)
+
+	t.Cleanup(func() { _ = impl.Close() })
+
+	sess, err := impl.GetSession(ctx, sID)
+	require.NoError(t, err)
+	require.EqualValues(t, 201, sess.Code)
+
+	req, err := impl.GetRequest(ctx, sID, rID)
+	require.NoError(t, err)
+	require.Equal(t, "foo", req.ClientAddr)
+}
+
+func TestSQLite_Cleanup(t *testing.T) {
+	t.Parallel()
+
+	var (
+		ctx  = context.Background()
+		ft   = newFakeTime(t)
+		impl = newSQLite(t, time.Minute, 10,
+			storage.WithSQLiteTimeNow(ft.Get),
+			storage.WithSQLiteCleanupInterval(time.Millisecond),
+		)
+	)
+
+	t.Cleanup(func() { _ = impl.Close() })
+
+	sID, err := impl.NewSession(ctx, storage.Session{})
+	require.NoError(t, err)
+
+	_, err = impl.NewRequest(ctx, sID, storage.Request{})
+	require.NoError(t, err)
+
+	ft.Add(time.Minute * 2) // expire the session
+
+	// the expired session is not visible anymore
+	require.ErrorIs(t, impl.DeleteAllRequests(ctx, sID), storage.ErrSessionNotFound)
+
+	// and is removed by the cleanup goroutine
+	require.Eventually(t, func() bool {
+		return impl.DeleteSession(ctx, sID) != nil
+	}, time.Second, time.Millisecond*5)
+}
+
+func TestSQLite_Close(t *testing.T) {
+	t.Parallel()
+
+	var ctx = context.Background()
+
+	impl := newSQLite(t, time.Minute, 1)
+	require.NoError(t, impl.Close())
+	require.ErrorIs(t, impl.Close(), storage.ErrClosed) // second close
+
+	_, err := impl.NewSession(ctx, storage.Session{})
+	require.ErrorIs(t, err, storage.ErrClosed)
+
+	_, err = impl.GetSession(ctx, "foo")
+	require.ErrorIs(t, err, storage.ErrClosed)
+
+	err = impl.AddSessionTTL(ctx, "foo", time.Minute)
+	require.ErrorIs(t, err, storage.ErrClosed)
+
+	err = impl.UpdateSession(ctx, "foo", func(*storage.Session) error { return nil })
+	require.ErrorIs(t, err, storage.ErrClosed)
+
+	_, err = impl.ListSessions(ctx, storage.SessionsQuery{})
+	require.ErrorIs(t, err, storage.ErrClosed)
+
+	err = impl.DeleteSession(ctx, "foo")
+	require.ErrorIs(t, err, storage.ErrClosed)
+
+	err = impl.AddSessionSlug(ctx, "foo", "bar")
+	require.ErrorIs(t, err, storage.ErrClosed)
+
+	_, err = impl.GetSessionSlugs(ctx, "foo")
+	require.ErrorIs(t, err, storage.ErrClosed)
+
+	err = impl.DeleteSessionSlug(ctx, "foo", "bar")
+	require.ErrorIs(t, err, storage.ErrClosed)
+
+	_, err = impl.ResolveSlug(ctx, "bar")
+	require.ErrorIs(t, err, storage.ErrClosed)
+
+	_, err = impl.NewRequest(ctx, "foo", storage.Request{})
+	require.ErrorIs(t, err, storage.ErrClosed)
+
+	_, err = impl.GetRequest(ctx, "foo", "bar")
+	require.ErrorIs(t, err, storage.ErrClosed)
+
+	err = impl.SetRequestForwardResult(ctx, "foo", "bar", storage.ForwardResult{})
+	require.ErrorIs(t, err, storage.ErrClosed)
+
+	_, err = impl.GetAllRequests(ctx, "foo")
+	require.ErrorIs(t, err, storage.ErrClosed)
+
+	_, err = impl.QueryRequests(ctx, "foo", storage.RequestsQuery{})
+	require.ErrorIs(t, err, storage.ErrClosed)
+
+	err = impl.DeleteRequest(ctx, "foo", "bar")
+	require.ErrorIs(t, err, storage.ErrClosed)
+
+	err = impl.DeleteAllRequests(ctx, "foo")
+	require.ErrorIs(t, err, storage.ErrClosed)
+}
+
+func TestSQLite_RaceProvocation(t *testing.T) {
+	t.Parallel()
+
+	testRaceProvocation(t, func(sTTL time.Duration, maxReq uint32) storage.Storage {
+		return newSQLite(t, sTTL, maxReq, storage.WithSQLiteCleanupInterval(10*time.Nanosecond))
+	})
+}
@@ -11,8 +11,23 @@ var (
 	ErrNotFound        = errors.New("not found")
 	ErrSessionNotFound = fmt.Errorf("session %w", ErrNotFound)
 	ErrRequestNotFound = fmt.Errorf("request %w", ErrNotFound)
+	ErrSlugNotFound    = fmt.Errorf("slug %w", ErrNotFound)
 
-	ErrClosed = errors.New("closed")
+	ErrSlugTaken = errors.New("slug is already taken")
+	ErrClosed    = errors.New("closed")
+)
+
+// Notification target kinds.
+const (
+	NotificationKindHTTP     = "http"     // JSON callback with the captured request details
+	NotificationKindTemplate = "template" // rendered template body (e.g. Slack or Microsoft Teams message)
+)
+
+// TLS client certificates (mutual TLS) modes.
+const (
+	ClientAuthNone    = "none"    // the client certificates are not verified
+	ClientAuthRequest = "request" // the client certificates are verified (if presented), the result is recorded
+	ClientAuthRequire = "require" // the requests without a valid client certificate are recorded, but rejected
 )
 
 // Storage manages Session and Request data.
@@ -28,14 +43,46 @@ type Storage interface {
 	// AddSessionTTL adds the specified TTL to the session (and all its requests) with the specified ID.
 	AddSessionTTL(_ context.Context, sID string, howMuch time.Duration) error
 
-	// DeleteSession removes the session with the specified ID.
+	// UpdateSession atomically applies the update function to the session with the specified ID. The session ID,
+	// owner, creation time, TTL and its requests are preserved (the changes of the owner and creation time made by
+	// the function are discarded). If the function returns an error, the session is left untouched and the error is
+	// returned. The function may be called more than once (e.g., on concurrent modification retries), so it should
+	// have no side effects.
+	// If the session is not found, ErrSessionNotFound will be returned.
+	UpdateSession(_ context.Context, sID string, update func(*Session) error) error
+
+	// ListSessions returns a page of the sessions that match the query, sorted by the creation time (newest first),
+	// with the requests statistics. If the cursor is invalid, ErrInvalidCursor will be returned.
+	ListSessions(_ context.Context, _ SessionsQuery) (*SessionsPage, error)
+
+	// DeleteSession removes the session with the specified ID (and releases its slugs).
 	// If the session is not found, ErrSessionNotFound will be returned.
 	DeleteSession(_ context.Context, sID string) error
 
+	// AddSessionSlug reserves the slug (a human-readable alias, used in the webhook URLs instead of the session ID)
+	// for the session with the specified ID. Reserving the slug that already belongs to the session is a no-op. The
+	// slug is released when the session is deleted or expired.
+	// If the session is not found, ErrSessionNotFound will be returned. If the slug belongs to another session,
+	// ErrSlugTaken will be returned.
+	AddSessionSlug(_ context.Context, sID, slug string) error
+
+	// GetSessionSlugs returns the slugs of the session with the specified ID (sorted alphabetically).
+	// If the session is not found, ErrSessionNotFound will be returned.
+	GetSessionSlugs(_ context.Context, sID string) ([]string, error)
+
+	// DeleteSessionSlug releases the slug of the session with the specified ID.
+	// If the session is not found, ErrSessionNotFound will be returned. If the slug does not belong to the session,
+	// ErrSlugNotFound will be returned.
+	DeleteSessionSlug(_ context.Context, sID, slug string) error
+
+	// ResolveSlug returns the ID of the (not expired) session the slug belongs to.
+	// If the slug is not reserved, ErrSlugNotFound will be returned.
+	ResolveSlug(_ context.Context, slug string) (sID string, _ error)
+
 	// NewRequest creates a new request for the session with the specified ID and returns a request ID on success.
-	// The session with the specified ID must exist. The Request.CreatedAtUnixMilli field will be set to the
-	// current time. The storage may limit the number of requests per session - in this case the oldest request
-	// will be removed.
+	// The session with the specified ID must exist. If the Request.CreatedAtUnixMilli field is not set, it will be
+	// set to the current time (otherwise, it's preserved - e.g., for the imported requests). The storage may limit
+	// the number of requests per session - in this case the oldest request will be removed.
 	// If the session is not found, ErrSessionNotFound will be returned.
 	NewRequest(_ context.Context, sID string, _ Request) (rID string, _ error)
 
@@ -48,6 +95,10 @@ type Storage interface {
 	// will be returned.
 	GetAllRequests(_ context.Context, sID string) (map[string]Request, error)
 
+	// SetRequestForwardResult stores the result of the request forwarding alongside the request.
+	// If the session or request is not found, ErrSessionNotFound or ErrRequestNotFound will be returned.
+	SetRequestForwardResult(_ context.Context, sID, rID string, _ ForwardResult) error
+
 	// DeleteRequest removes the request with the specified ID.
 	// If the request or session is not found, ErrNotFound (ErrSessionNotFound or ErrRequestNotFound) will be returned.
 	DeleteRequest(_ context.Context, sID, rID string) error
@@ -60,22 +111,149 @@ type Storage interface {
 type (
 	// Session describes session settings (like response data and any additional information).
 	Session struct {
-		Code               uint16        `json:"code"`                  // default server response code
-		Headers            []HttpHeader  `json:"headers"`               // server response headers
-		ResponseBody       []byte        `json:"body"`                  // server response body (payload)
-		Delay              time.Duration `json:"delay"`                 // delay before response sending
-		CreatedAtUnixMilli int64         `json:"created_at_unit_milli"` // creation time
-		ExpiresAt          time.Time     `json:"-"`                     // expiration time
+		SessionMeta // human-friendly description (name, labels and note)
+
+		Code               uint16               `json:"code"`                    // default server response code
+		Headers            []HttpHeader         `json:"headers"`                 // server response headers
+		ResponseBody       []byte               `json:"body"`                    // server response body (payload)
+		Delay              time.Duration        `json:"delay"`                   // delay before response sending
+		Templated          bool                 `json:"templated,omitempty"`     // render the body and headers as templates
+		Rules              []ResponseRule       `json:"rules,omitempty"`         // conditional responses (first match wins)
+		Forward            *ForwardOptions      `json:"forward,omitempty"`       // forward captured requests to the target
+		Signature          *SignatureOptions    `json:"signature,omitempty"`     // verify captured requests signature
+		ClientAuth         string               `json:"client_auth,omitempty"`   // TLS client certs mode (empty = default)
+		IPFilter           *IPFilterOptions     `json:"ip_filter,omitempty"`     // allowed/denied client IP addresses
+		Notifications      []NotificationTarget `json:"notifications,omitempty"` // notify about the captured requests
+		Owner              string               `json:"owner,omitempty"`         // the user who created the session (if any)
+		CreatedAtUnixMilli int64                `json:"created_at_unit_milli"`   // creation time
+		ExpiresAt          time.Time            `json:"-"`                       // expiration time
+	}
+
+	// SessionMeta is a human-friendly session description, which has no effect on the requests capturing.
+	SessionMeta struct {
+		Name   string   `json:"name,omitempty"`   // short name
+		Labels []string `json:"labels,omitempty"` // free-form labels
+		Note   string   `json:"note,omitempty"`   // markdown note
+	}
+
+	// ResponseRule describes a conditional response. If the incoming request matches all the rule conditions, the
+	// rule response is used instead of the session default one. Empty conditions match any request.
+	ResponseRule struct {
+		Methods  []string        `json:"methods,omitempty"`   // HTTP methods (case-insensitive)
+		Path     string          `json:"path,omitempty"`      // glob pattern for the path after the session ID
+		Headers  []RuleCondition `json:"headers,omitempty"`   // request headers
+		Query    []RuleCondition `json:"query,omitempty"`     // URL query parameters
+		JSONBody []RuleCondition `json:"json_body,omitempty"` // JSON body fields (the name is a dot-separated path)
+		Response RuleResponse    `json:"response"`            // the response to send if the rule matches
+	}
+
+	// RuleCondition describes a single named value condition. If the Value is empty, only the presence of the
+	// named value is checked.
+	RuleCondition struct {
+		Name  string `json:"name"`
+		Value string `json:"value"`
+	}
+
+	// RuleResponse describes the response that is sent when the ResponseRule matches.
+	RuleResponse struct {
+		Code         uint16        `json:"code"`                // response code
+		Headers      []HttpHeader  `json:"headers"`             // response headers
+		ResponseBody []byte        `json:"body"`                // response body (payload)
+		Delay        time.Duration `json:"delay"`               // delay before response sending
+		Templated    bool          `json:"templated,omitempty"` // render the body and headers as templates
+	}
+
+	// ForwardOptions describes where and how the captured requests should be forwarded.
+	ForwardOptions struct {
+		URL            string        `json:"url"`             // target URL (the request path and query are appended)
+		PassHeaders    bool          `json:"pass_headers"`    // pass the original request headers
+		Timeout        time.Duration `json:"timeout"`         // forwarding timeout
+		ReturnResponse bool          `json:"return_response"` // respond with the upstream response
+	}
+
+	// ForwardResult describes the result of the request forwarding.
+	ForwardResult struct {
+		URL        string        `json:"url"`                   // the URL the request was forwarded to
+		StatusCode uint16        `json:"status_code,omitempty"` // upstream response code
+		Headers    []HttpHeader  `json:"headers,omitempty"`     // upstream response headers
+		Body       []byte        `json:"body,omitempty"`        // upstream response body
+		Latency    time.Duration `json:"latency"`               // how long the forwarding took
+		Error      string        `json:"error,omitempty"`       // forwarding error (if any)
+	}
+
+	// IPFilterOptions describes which client IP addresses are allowed to send the webhooks to the session.
+	IPFilterOptions struct {
+		Allow []string `json:"allow,omitempty"` // allowed IP addresses and CIDRs (empty = any address is allowed)
+		Deny  []string `json:"deny,omitempty"`  // denied IP addresses and CIDRs (take precedence over the allowed)
+	}
+
+	// NotificationTarget describes where the notifications about the captured requests are sent.
+	NotificationTarget struct {
+		ID       string `json:"id"`                 // unique target ID
+		Kind     string `json:"kind"`               // NotificationKind*
+		URL      string `json:"url"`                // target URL
+		Secret   string `json:"secret,omitempty"`   // HMAC signing secret
+		Template string `json:"template,omitempty"` // payload (body) template
+	}
+
+	// SignatureOptions describes how the captured requests signature (HMAC) should be verified.
+	SignatureOptions struct {
+		Provider   string        `json:"provider"`              // webhook provider (defines the signature scheme)
+		Secret     string        `json:"secret"`                // signing secret
+		Tolerance  time.Duration `json:"tolerance"`             // max signature timestamp age (zero disables the check)
+		RejectCode uint16        `json:"reject_code,omitempty"` // respond with this code if invalid (zero to accept)
+	}
+
+	// SignatureResult describes the result of the request signature verification.
+	SignatureResult struct {
+		Provider string `json:"provider"`        // webhook provider
+		Valid    bool   `json:"valid"`           // whether the signature is valid
+		Error    string `json:"error,omitempty"` // the reason why the signature is invalid
 	}
 
 	// Request describes recorded request and additional meta-data.
 	Request struct {
-		ClientAddr         string       `json:"client_addr"`           // client hostname or IP address
-		Method             string       `json:"method"`                // HTTP method name (i.e., 'GET', 'POST')
-		Body               []byte       `json:"body"`                  // request body (payload)
-		Headers            []HttpHeader `json:"headers"`               // HTTP request headers
-		URL                string       `json:"url"`                   // Uniform Resource Identifier
-		CreatedAtUnixMilli int64        `json:"created_at_unit_milli"` // creation time
+		ClientAddr         string           `json:"client_addr"`           // client hostname or IP address
+		PeerAddr           string           `json:"peer_addr,omitempty"`   // connected peer (the proxy, if any) IP address
+		Method             string           `json:"method"`                // HTTP method name (i.e., 'GET', 'POST')
+		Body               []byte           `json:"body"`                  // request body (payload)
+		Headers            []HttpHeader     `json:"headers"`               // HTTP request headers
+		URL                string           `json:"url"`                   // Uniform Resource Identifier
+		CreatedAtUnixMilli int64            `json:"created_at_unit_milli"` // creation time
+		Forwarded          *ForwardResult   `json:"forwarded,omitempty"`   // the forwarding result (if forwarded)
+		Signature          *SignatureResult `json:"signature,omitempty"`   // the signature verification result
+		TraceID            string           `json:"trace_id,omitempty"`    // the trace ID of the capturing (if traced)
+		TLS                *RequestTLS      `json:"tls,omitempty"`         // the TLS connection details (if HTTPS)
+		Dropped            string           `json:"dropped,omitempty"`     // the reason why the request was dropped
+	}
+
+	// RequestTLS describes the TLS connection the request was received over.
+	RequestTLS struct {
+		Version     string            `json:"version"`                // TLS version, e.g. "TLS 1.3"
+		CipherSuite string            `json:"cipher_suite"`           // negotiated cipher suite name
+		ServerName  string            `json:"server_name,omitempty"`  // server name requested by the client (SNI)
+		ClientCerts []TLSCertificate  `json:"client_certs,omitempty"` // presented client certificate chain (leaf first)
+		ClientAuth  *ClientAuthResult `json:"client_auth,omitempty"`  // client certificate verification result
+	}
+
+	// TLSCertificate describes the X.509 certificate.
+	TLSCertificate struct {
+		Subject            string   `json:"subject"`               // subject distinguished name
+		Issuer             string   `json:"issuer"`                // issuer distinguished name
+		SerialNumber       string   `json:"serial_number"`         // serial number (hex-encoded)
+		SANs               []string `json:"sans,omitempty"`        // subject alternative names, e.g. "DNS:example.com"
+		NotBeforeUnixMilli int64    `json:"not_before_unix_milli"` // validity period start
+		NotAfterUnixMilli  int64    `json:"not_after_unix_milli"`  // validity period end
+		SHA1Fingerprint    string   `json:"sha1_fingerprint"`      // SHA-1 fingerprint of the DER-encoded certificate
+		SHA256Fingerprint  string   `json:"sha256_fingerprint"`    // SHA-256 fingerprint of the DER-encoded certificate
+	}
+
+	// ClientAuthResult describes the result of the TLS client certificate verification (mutual TLS).
+	ClientAuthResult struct {
+		Mode     string `json:"mode"`            // the client auth mode (ClientAuthRequest or ClientAuthRequire)
+		Verified bool   `json:"verified"`        // whether the client certificate chain is verified
+		Rejected bool   `json:"rejected"`        // whether the request was rejected (captured, but not handled)
+		Error    string `json:"error,omitempty"` // the reason why the certificate is not verified
 	}
 
 	HttpHeader struct {
@@ -84,6 +262,19 @@ type (
 	}
 )
 
+// applyUpdate applies the update function to the session, restoring the fields that cannot be updated.
+func applyUpdate(s *Session, update func(*Session) error) error {
+	var owner, createdAt, expiresAt = s.Owner, s.CreatedAtUnixMilli, s.ExpiresAt
+
+	if err := update(s); err != nil {
+		return err
+	}
+
+	s.Owner, s.CreatedAtUnixMilli, s.ExpiresAt = owner, createdAt, expiresAt
+
+	return nil
+}
+
 // TimeFunc is a function that returns the current time.
 type TimeFunc func() time.Time
 
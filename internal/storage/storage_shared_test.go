@@ -1,8 +1,13 @@
 package storage_test
 
 import (
+	"cmp"
 	"context"
+	"errors"
 	"io"
+	"regexp"
+	"slices"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"testing"
@@ -64,6 +69,7 @@ func testSessionCreateReadDelete(
 			Code:    code,
 			Headers: sessionHeaders,
 			Delay:   delay,
+			Owner:   "alice",
 		})
 
 		require.NoError(t, newErr)
@@ -74,6 +80,7 @@ func testSessionCreateReadDelete(
 		require.NoError(t, getErr)
 		require.Equal(t, code, got.Code)
 		require.Equal(t, sessionHeaders, got.Headers)
+		require.Equal(t, "alice", got.Owner)
 		require.Equal(t, delay, got.Delay)
 		assert.NotZero(t, got.CreatedAtUnixMilli)
 
@@ -183,6 +190,389 @@ func testSessionCreateReadDelete(
 		require.ErrorIs(t, err, storage.ErrSessionNotFound)
 		require.Nil(t, sess)
 	})
+	t.Run("session rules", func(t *testing.T) {
+		t.Parallel()
+
+		var impl = new(time.Minute, 1)
+		defer func() { _ = toCloser(impl).Close() }()
+
+		var setRules = func(sID string, v []storage.ResponseRule) error {
+			return impl.UpdateSession(ctx, sID, func(s *storage.Session) error { s.Rules = v; return nil })
+		}
+
+		sID, err := impl.NewSession(ctx, storage.Session{Code: 201, ResponseBody: []byte("foo")})
+		require.NoError(t, err)
+
+		rID, err := impl.NewRequest(ctx, sID, storage.Request{ClientAddr: "req1"})
+		require.NoError(t, err)
+
+		before, err := impl.GetSession(ctx, sID)
+		require.NoError(t, err)
+		require.Empty(t, before.Rules)
+
+		var rules = []storage.ResponseRule{
+			{
+				Methods:  []string{"POST"},
+				Path:     "/events/*",
+				Headers:  []storage.RuleCondition{{Name: "X-Event", Value: "ping"}},
+				Query:    []storage.RuleCondition{{Name: "foo"}},
+				JSONBody: []storage.RuleCondition{{Name: "data.type", Value: "verify"}},
+				Response: storage.RuleResponse{
+					Code:         202,
+					Headers:      []storage.HttpHeader{{"foo", "bar"}},
+					ResponseBody: []byte("pong"),
+					Delay:        time.Second,
+				},
+			},
+			{Response: storage.RuleResponse{Code: 204}},
+		}
+
+		require.NoError(t, setRules(sID, rules))
+
+		after, err := impl.GetSession(ctx, sID)
+		require.NoError(t, err)
+		require.Equal(t, rules, after.Rules)
+		require.Equal(t, before.Code, after.Code)                             // other fields are untouched
+		require.Equal(t, before.ResponseBody, after.ResponseBody)             // --//--
+		require.Equal(t, before.CreatedAtUnixMilli, after.CreatedAtUnixMilli) // --//--
+		require.Equal(t, before.ExpiresAt.UnixMilli(), after.ExpiresAt.UnixMilli())
+
+		req, err := impl.GetRequest(ctx, sID, rID) // requests are preserved
+		require.NoError(t, err)
+		require.Equal(t, "req1", req.ClientAddr)
+
+		// clear the rules
+		require.NoError(t, setRules(sID, nil))
+
+		after, err = impl.GetSession(ctx, sID)
+		require.NoError(t, err)
+		require.Empty(t, after.Rules)
+
+		// not existing session
+		require.ErrorIs(t, setRules("foo", rules), storage.ErrSessionNotFound)
+	})
+
+	t.Run("session forward", func(t *testing.T) {
+		t.Parallel()
+
+		var impl = new(time.Minute, 1)
+		defer func() { _ = toCloser(impl).Close() }()
+
+		var setForward = func(sID string, v *storage.ForwardOptions) error {
+			return impl.UpdateSession(ctx, sID, func(s *storage.Session) error { s.Forward = v; return nil })
+		}
+
+		sID, err := impl.NewSession(ctx, storage.Session{Code: 201, ResponseBody: []byte("foo")})
+		require.NoError(t, err)
+
+		before, err := impl.GetSession(ctx, sID)
+		require.NoError(t, err)
+		require.Nil(t, before.Forward)
+
+		var opts = storage.ForwardOptions{
+			URL:            "http://127.0.0.1:8080/webhooks",
+			PassHeaders:    true,
+			Timeout:        5 * time.Second,
+			ReturnResponse: true,
+		}
+
+		require.NoError(t, setForward(sID, &opts))
+
+		after, err := impl.GetSession(ctx, sID)
+		require.NoError(t, err)
+		require.Equal(t, &opts, after.Forward)
+		require.Equal(t, before.Code, after.Code) // other fields are untouched
+		require.Equal(t, before.ExpiresAt.UnixMilli(), after.ExpiresAt.UnixMilli())
+
+		// disable the forwarding
+		require.NoError(t, setForward(sID, nil))
+
+		after, err = impl.GetSession(ctx, sID)
+		require.NoError(t, err)
+		require.Nil(t, after.Forward)
+
+		// not existing session
+		require.ErrorIs(t, setForward("foo", &opts), storage.ErrSessionNotFound)
+	})
+
+	t.Run("session signature", func(t *testing.T) {
+		t.Parallel()
+
+		var impl = new(time.Minute, 1)
+		defer func() { _ = toCloser(impl).Close() }()
+
+		var setSignature = func(sID string, v *storage.SignatureOptions) error {
+			return impl.UpdateSession(ctx, sID, func(s *storage.Session) error { s.Signature = v; return nil })
+		}
+
+		sID, err := impl.NewSession(ctx, storage.Session{Code: 201, ResponseBody: []byte("foo")})
+		require.NoError(t, err)
+
+		before, err := impl.GetSession(ctx, sID)
+		require.NoError(t, err)
+		require.Nil(t, before.Signature)
+
+		var opts = storage.SignatureOptions{
+			Provider:   "github",
+			Secret:     "secret",
+			Tolerance:  5 * time.Minute,
+			RejectCode: 401,
+		}
+
+		require.NoError(t, setSignature(sID, &opts))
+
+		after, err := impl.GetSession(ctx, sID)
+		require.NoError(t, err)
+		require.Equal(t, &opts, after.Signature)
+		require.Equal(t, before.Code, after.Code) // other fields are untouched
+		require.Equal(t, before.ExpiresAt.UnixMilli(), after.ExpiresAt.UnixMilli())
+
+		// disable the verification
+		require.NoError(t, setSignature(sID, nil))
+
+		after, err = impl.GetSession(ctx, sID)
+		require.NoError(t, err)
+		require.Nil(t, after.Signature)
+
+		// not existing session
+		require.ErrorIs(t, setSignature("foo", &opts), storage.ErrSessionNotFound)
+	})
+
+	t.Run("update session", func(t *testing.T) {
+		t.Parallel()
+
+		var impl = new(time.Minute, 10)
+		defer func() { _ = toCloser(impl).Close() }()
+
+		sID, err := impl.NewSession(ctx, storage.Session{
+			Code:         201,
+			ResponseBody: []byte("foo"),
+			Owner:        "alice",
+			SessionMeta:  storage.SessionMeta{Name: "foo"},
+		})
+		require.NoError(t, err)
+
+		rID, err := impl.NewRequest(ctx, sID, storage.Request{Method: "GET"})
+		require.NoError(t, err)
+
+		before, err := impl.GetSession(ctx, sID)
+		require.NoError(t, err)
+
+		require.NoError(t, impl.UpdateSession(ctx, sID, func(s *storage.Session) error {
+			s.Code, s.ResponseBody, s.Delay = 418, []byte("bar"), time.Second
+			s.Headers = []storage.HttpHeader{{Name: "X-Foo", Value: "bar"}}
+			s.Owner, s.CreatedAtUnixMilli = "bob", 1 // must be ignored
+
+			return nil
+		}))
+
+		after, err := impl.GetSession(ctx, sID)
+		require.NoError(t, err)
+		require.EqualValues(t, 418, after.Code)
+		require.Equal(t, []byte("bar"), after.ResponseBody)
+		require.Equal(t, time.Second, after.Delay)
+		require.Equal(t, []storage.HttpHeader{{Name: "X-Foo", Value: "bar"}}, after.Headers)
+		require.Equal(t, "foo", after.Name) // other fields are untouched
+		require.Equal(t, "alice", after.Owner)
+		require.Equal(t, before.CreatedAtUnixMilli, after.CreatedAtUnixMilli)
+		require.Equal(t, before.ExpiresAt.UnixMilli(), after.ExpiresAt.UnixMilli())
+
+		_, err = impl.GetRequest(ctx, sID, rID) // the requests are preserved
+		require.NoError(t, err)
+
+		// the failed update leaves the session untouched
+		var someErr = errors.New("some error")
+
+		require.ErrorIs(t, impl.UpdateSession(ctx, sID, func(s *storage.Session) error {
+			s.Code = 500
+
+			return someErr
+		}), someErr)
+
+		after, err = impl.GetSession(ctx, sID)
+		require.NoError(t, err)
+		require.EqualValues(t, 418, after.Code)
+
+		// concurrent updates are not lost
+		var wg sync.WaitGroup
+
+		for range 10 {
+			wg.Go(func() {
+				assert.NoError(t, impl.UpdateSession(ctx, sID, func(s *storage.Session) error {
+					s.Code++
+
+					return nil
+				}))
+			})
+		}
+
+		wg.Wait()
+
+		after, err = impl.GetSession(ctx, sID)
+		require.NoError(t, err)
+		require.EqualValues(t, 428, after.Code)
+
+		// not existing session
+		require.ErrorIs(t, impl.UpdateSession(ctx, "foo", func(*storage.Session) error { return nil }),
+			storage.ErrSessionNotFound,
+		)
+	})
+
+	t.Run("session meta", func(t *testing.T) {
+		t.Parallel()
+
+		var impl = new(time.Minute, 1)
+		defer func() { _ = toCloser(impl).Close() }()
+
+		var setMeta = func(sID string, v storage.SessionMeta) error {
+			return impl.UpdateSession(ctx, sID, func(s *storage.Session) error { s.SessionMeta = v; return nil })
+		}
+
+		sID, err := impl.NewSession(ctx, storage.Session{Code: 201, ResponseBody: []byte("foo")})
+		require.NoError(t, err)
+
+		_, err = impl.NewRequest(ctx, sID, storage.Request{Method: "GET"})
+		require.NoError(t, err)
+
+		before, err := impl.GetSession(ctx, sID)
+		require.NoError(t, err)
+		require.Zero(t, before.SessionMeta)
+
+		var meta = storage.SessionMeta{Name: "GitHub", Labels: []string{"ci", "prod"}, Note: "# Hello\n\n*world*"}
+
+		require.NoError(t, setMeta(sID, meta))
+
+		after, err := impl.GetSession(ctx, sID)
+		require.NoError(t, err)
+		require.Equal(t, meta, after.SessionMeta)
+		require.Equal(t, before.Code, after.Code) // other fields are untouched
+		require.Equal(t, before.ExpiresAt.UnixMilli(), after.ExpiresAt.UnixMilli())
+
+		all, err := impl.GetAllRequests(ctx, sID) // the requests are preserved
+		require.NoError(t, err)
+		require.Len(t, all, 1)
+
+		// reset
+		require.NoError(t, setMeta(sID, storage.SessionMeta{}))
+
+		after, err = impl.GetSession(ctx, sID)
+		require.NoError(t, err)
+		require.Zero(t, after.SessionMeta)
+
+		// not existing session
+		require.ErrorIs(t, setMeta("foo", meta), storage.ErrSessionNotFound)
+	})
+
+	t.Run("session slugs", func(t *testing.T) {
+		t.Parallel()
+
+		var impl = new(time.Minute, 1)
+		defer func() { _ = toCloser(impl).Close() }()
+
+		sID, err := impl.NewSession(ctx, storage.Session{})
+		require.NoError(t, err)
+
+		other, err := impl.NewSession(ctx, storage.Session{})
+		require.NoError(t, err)
+
+		slugs, err := impl.GetSessionSlugs(ctx, sID)
+		require.NoError(t, err)
+		require.Empty(t, slugs)
+
+		require.NoError(t, impl.AddSessionSlug(ctx, sID, "stripe-staging"))
+		require.NoError(t, impl.AddSessionSlug(ctx, sID, "hooks/github"))
+		require.NoError(t, impl.AddSessionSlug(ctx, sID, "stripe-staging")) // already belongs to the session
+		require.ErrorIs(t, impl.AddSessionSlug(ctx, other, "stripe-staging"), storage.ErrSlugTaken)
+
+		slugs, err = impl.GetSessionSlugs(ctx, sID)
+		require.NoError(t, err)
+		require.Equal(t, []string{"hooks/github", "stripe-staging"}, slugs)
+
+		slugs, err = impl.GetSessionSlugs(ctx, other)
+		require.NoError(t, err)
+		require.Empty(t, slugs)
+
+		resolved, err := impl.ResolveSlug(ctx, "hooks/github")
+		require.NoError(t, err)
+		require.Equal(t, sID, resolved)
+
+		_, err = impl.ResolveSlug(ctx, "hooks")
+		require.ErrorIs(t, err, storage.ErrSlugNotFound)
+
+		// the slug of another session cannot be deleted
+		require.ErrorIs(t, impl.DeleteSessionSlug(ctx, other, "stripe-staging"), storage.ErrSlugNotFound)
+
+		require.NoError(t, impl.DeleteSessionSlug(ctx, sID, "stripe-staging"))
+		require.ErrorIs(t, impl.DeleteSessionSlug(ctx, sID, "stripe-staging"), storage.ErrSlugNotFound)
+
+		// the released slug can be reserved by another session
+		require.NoError(t, impl.AddSessionSlug(ctx, other, "stripe-staging"))
+
+		resolved, err = impl.ResolveSlug(ctx, "stripe-staging")
+		require.NoError(t, err)
+		require.Equal(t, other, resolved)
+
+		// the session deletion releases its slugs
+		require.NoError(t, impl.DeleteSession(ctx, sID))
+
+		_, err = impl.ResolveSlug(ctx, "hooks/github")
+		require.ErrorIs(t, err, storage.ErrSlugNotFound)
+
+		require.NoError(t, impl.AddSessionSlug(ctx, other, "hooks/github"))
+
+		slugs, err = impl.GetSessionSlugs(ctx, other)
+		require.NoError(t, err)
+		require.Equal(t, []string{"hooks/github", "stripe-staging"}, slugs)
+
+		require.NoError(t, impl.DeleteSession(ctx, other))
+
+		_, err = impl.ResolveSlug(ctx, "stripe-staging")
+		require.ErrorIs(t, err, storage.ErrSlugNotFound)
+
+		// not existing session
+		require.ErrorIs(t, impl.AddSessionSlug(ctx, "foo", "bar"), storage.ErrSessionNotFound)
+		require.ErrorIs(t, impl.DeleteSessionSlug(ctx, "foo", "bar"), storage.ErrSessionNotFound)
+
+		_, err = impl.GetSessionSlugs(ctx, "foo")
+		require.ErrorIs(t, err, storage.ErrSessionNotFound)
+	})
+
+	t.Run("session slugs - expired", func(t *testing.T) {
+		t.Parallel()
+
+		const sessionTTL = time.Millisecond * 20
+
+		var impl = new(sessionTTL, 1)
+		defer func() { _ = toCloser(impl).Close() }()
+
+		sID, err := impl.NewSession(ctx, storage.Session{})
+		require.NoError(t, err)
+
+		require.NoError(t, impl.AddSessionSlug(ctx, sID, "expiring"))
+		require.NoError(t, impl.AddSessionTTL(ctx, sID, sessionTTL*2)) // current ttl = x + 2x = 3x
+
+		sleep(sessionTTL * 2)
+
+		// the slug lives as long as the session
+		resolved, err := impl.ResolveSlug(ctx, "expiring")
+		require.NoError(t, err)
+		require.Equal(t, sID, resolved)
+
+		sleep(sessionTTL * 2)
+
+		_, err = impl.ResolveSlug(ctx, "expiring")
+		require.ErrorIs(t, err, storage.ErrSlugNotFound)
+
+		// the slug of the expired session can be reserved by another session
+		other, err := impl.NewSession(ctx, storage.Session{})
+		require.NoError(t, err)
+
+		require.NoError(t, impl.AddSessionSlug(ctx, other, "expiring"))
+
+		resolved, err = impl.ResolveSlug(ctx, "expiring")
+		require.NoError(t, err)
+		require.Equal(t, other, resolved)
+	})
 }
 
 func testRequestCreateReadDelete(
@@ -217,7 +607,10 @@ func testRequestCreateReadDelete(
 			body       = " \nfoo bar\n\t \nbaz"
 		)
 
-		var requestHeaders = []storage.HttpHeader{{"foo", "bar"}, {"bar", "baz"}}
+		var (
+			requestHeaders = []storage.HttpHeader{{"foo", "bar"}, {"bar", "baz"}}
+			sigResult      = storage.SignatureResult{Provider: "github", Error: "signature mismatch"}
+		)
 
 		// create
 		rID, newReqErr := impl.NewRequest(ctx, sID, storage.Request{
@@ -226,6 +619,7 @@ func testRequestCreateReadDelete(
 			Body:       []byte(body),
 			Headers:    requestHeaders,
 			URL:        someUrl,
+			Signature:  &sigResult,
 		})
 		require.NoError(t, newReqErr)
 		require.NotEmpty(t, rID)
@@ -238,6 +632,7 @@ func testRequestCreateReadDelete(
 		require.Equal(t, []byte(body), got.Body)
 		require.Equal(t, requestHeaders, got.Headers)
 		require.Equal(t, someUrl, got.URL)
+		require.Equal(t, &sigResult, got.Signature)
 		assert.NotZero(t, got.CreatedAtUnixMilli)
 
 		{ // read all
@@ -259,6 +654,39 @@ func testRequestCreateReadDelete(
 		require.ErrorIs(t, getErr, storage.ErrRequestNotFound)
 	})
 
+	t.Run("new request - creation time is preserved", func(t *testing.T) {
+		t.Parallel()
+
+		var impl = new(time.Minute, 2) // limit is 2
+		defer func() { _ = toCloser(impl).Close() }()
+
+		sID, err := impl.NewSession(ctx, storage.Session{})
+		require.NoError(t, err)
+
+		var past = time.Now().Add(-time.Hour).UnixMilli()
+
+		rID1, err := impl.NewRequest(ctx, sID, storage.Request{ClientAddr: "req1", CreatedAtUnixMilli: past})
+		require.NoError(t, err)
+
+		got, err := impl.GetRequest(ctx, sID, rID1)
+		require.NoError(t, err)
+		assert.Equal(t, past, got.CreatedAtUnixMilli)
+
+		rID2, err := impl.NewRequest(ctx, sID, storage.Request{ClientAddr: "req2"})
+		require.NoError(t, err)
+
+		// the request with the preserved (older) creation time is the oldest one, so it's removed first
+		rID3, err := impl.NewRequest(ctx, sID, storage.Request{ClientAddr: "req3", CreatedAtUnixMilli: past + 1})
+		require.NoError(t, err)
+
+		all, err := impl.GetAllRequests(ctx, sID)
+		require.NoError(t, err)
+		require.Len(t, all, 2)
+		assert.Contains(t, all, rID2)
+		assert.Contains(t, all, rID3)
+		assert.NotContains(t, all, rID1)
+	})
+
 	t.Run("new request - limit exceeded", func(t *testing.T) {
 		t.Parallel()
 
@@ -370,6 +798,49 @@ func testRequestCreateReadDelete(
 		require.ErrorIs(t, err, storage.ErrSessionNotFound)
 	})
 
+	t.Run("set forward result", func(t *testing.T) {
+		t.Parallel()
+
+		var impl = new(time.Minute, 2)
+		defer func() { _ = toCloser(impl).Close() }()
+
+		sID, err := impl.NewSession(ctx, storage.Session{})
+		require.NoError(t, err)
+
+		rID, err := impl.NewRequest(ctx, sID, storage.Request{ClientAddr: "foo", Method: "POST", URL: someUrl})
+		require.NoError(t, err)
+
+		before, err := impl.GetRequest(ctx, sID, rID)
+		require.NoError(t, err)
+		require.Nil(t, before.Forwarded)
+
+		var result = storage.ForwardResult{
+			URL:        "http://127.0.0.1:8080/webhooks",
+			StatusCode: 201,
+			Headers:    []storage.HttpHeader{{"Content-Type", "text/plain"}},
+			Body:       []byte("created"),
+			Latency:    123 * time.Millisecond,
+		}
+
+		require.NoError(t, impl.SetRequestForwardResult(ctx, sID, rID, result))
+
+		after, err := impl.GetRequest(ctx, sID, rID)
+		require.NoError(t, err)
+		require.Equal(t, &result, after.Forwarded)
+		require.Equal(t, before.ClientAddr, after.ClientAddr) // other fields are untouched
+		require.Equal(t, before.URL, after.URL)
+		require.Equal(t, before.CreatedAtUnixMilli, after.CreatedAtUnixMilli)
+
+		all, err := impl.GetAllRequests(ctx, sID)
+		require.NoError(t, err)
+		require.Len(t, all, 1)
+		require.Equal(t, &result, all[rID].Forwarded)
+
+		// not existing request/session
+		require.ErrorIs(t, impl.SetRequestForwardResult(ctx, sID, "foo", result), storage.ErrRequestNotFound)
+		require.ErrorIs(t, impl.SetRequestForwardResult(ctx, "foo", rID, result), storage.ErrSessionNotFound)
+	})
+
 	t.Run("delete all", func(t *testing.T) {
 		t.Parallel()
 
@@ -502,6 +973,377 @@ func testRequestCreateReadDelete(
 	})
 }
 
+func testRequestsQuery(
+	t *testing.T,
+	new func(sessionTTL time.Duration, maxRequests uint32) storage.Storage,
+	sleep func(time.Duration),
+) {
+	t.Helper()
+
+	var ctx = context.Background()
+
+	var impl = new(time.Minute, 100)
+	t.Cleanup(func() { _ = toCloser(impl).Close() })
+
+	sID, err := impl.NewSession(ctx, storage.Session{})
+	require.NoError(t, err)
+
+	var requests = []storage.Request{
+		{Method: "GET", URL: "https://example.com/foo?a=1", ClientAddr: "10.0.0.1"},
+		{Method: "POST", URL: "https://example.com/foo/bar", ClientAddr: "10.0.0.2", Body: []byte(`{"id":123}`),
+			Headers: []storage.HttpHeader{{Name: "Content-Type", Value: "application/json"}}},
+		{Method: "POST", URL: "https://example.com/baz", ClientAddr: "10.0.0.1", Body: []byte(`{"id":456}`),
+			Headers: []storage.HttpHeader{{Name: "X-Event", Value: "push"}}},
+		{Method: "PUT", URL: "https://example.com/baz/1", ClientAddr: "10.0.0.3", Body: []byte("plain text"),
+			Headers: []storage.HttpHeader{{Name: "x-event", Value: "pull_request"}}},
+		{Method: "DELETE", URL: "https://example.com/baz/2", ClientAddr: "10.0.0.2"},
+		{Method: "get", URL: "https://example.com/", ClientAddr: "10.0.0.4"},
+	}
+
+	var ids = make([]string, len(requests)) // in the creation order
+
+	for i, r := range requests {
+		if i != 4 { // the requests #3 and #4 are created at the same time
+			sleep(time.Millisecond)
+		}
+
+		rID, rErr := impl.NewRequest(ctx, sID, r)
+		require.NoError(t, rErr)
+
+		ids[i] = rID
+	}
+
+	all, err := impl.GetAllRequests(ctx, sID)
+	require.NoError(t, err)
+	require.Len(t, all, len(requests))
+
+	var (
+		oldestFirst = slices.Clone(ids)
+		newestFirst []string
+	)
+
+	slices.SortFunc(oldestFirst, func(a, b string) int {
+		return cmp.Or(cmp.Compare(all[a].CreatedAtUnixMilli, all[b].CreatedAtUnixMilli), strings.Compare(a, b))
+	})
+
+	newestFirst = slices.Clone(oldestFirst)
+	slices.Reverse(newestFirst)
+
+	var idsOf = func(t *testing.T, page *storage.RequestsPage) []string {
+		t.Helper()
+
+		var out = make([]string, len(page.Requests))
+
+		for i, r := range page.Requests {
+			out[i] = r.ID
+			require.Equal(t, all[r.ID], r.Request)
+		}
+
+		return out
+	}
+
+	for name, tc := range map[string]struct {
+		giveQuery storage.RequestsQuery
+		wantIDs   []string
+	}{
+		"no filters": {
+			wantIDs: newestFirst,
+		},
+		"oldest first": {
+			giveQuery: storage.RequestsQuery{Order: storage.RequestsOldestFirst},
+			wantIDs:   oldestFirst,
+		},
+		"method": {
+			giveQuery: storage.RequestsQuery{Method: "Get", Order: storage.RequestsOldestFirst},
+			wantIDs:   []string{ids[0], ids[5]},
+		},
+		"header name": {
+			giveQuery: storage.RequestsQuery{HeaderName: "X-EVENT", Order: storage.RequestsOldestFirst},
+			wantIDs:   []string{ids[2], ids[3]},
+		},
+		"header name and value": {
+			giveQuery: storage.RequestsQuery{HeaderName: "x-event", HeaderValue: "pull"},
+			wantIDs:   []string{ids[3]},
+		},
+		"url substring": {
+			giveQuery: storage.RequestsQuery{URL: "/baz/", Order: storage.RequestsOldestFirst},
+			wantIDs:   oldestFirst[3:5], // the requests #3 and #4 have the same creation time
+		},
+		"url regexp": {
+			giveQuery: storage.RequestsQuery{URLRegexp: regexp.MustCompile(`/foo(\?|$)`)},
+			wantIDs:   []string{ids[0]},
+		},
+		"body substring": {
+			giveQuery: storage.RequestsQuery{Body: []byte(`"id":4`)},
+			wantIDs:   []string{ids[2]},
+		},
+		"body regexp": {
+			giveQuery: storage.RequestsQuery{
+				BodyRegexp: regexp.MustCompile(`^\{"id":\d+}$`),
+				Order:      storage.RequestsOldestFirst,
+			},
+			wantIDs: []string{ids[1], ids[2]},
+		},
+		"client address": {
+			giveQuery: storage.RequestsQuery{ClientAddr: "10.0.0.2", Order: storage.RequestsOldestFirst},
+			wantIDs:   []string{ids[1], ids[4]},
+		},
+		"time range": {
+			giveQuery: storage.RequestsQuery{
+				Since: time.UnixMilli(all[ids[1]].CreatedAtUnixMilli),
+				Until: time.UnixMilli(all[ids[3]].CreatedAtUnixMilli),
+				Order: storage.RequestsOldestFirst,
+			},
+			wantIDs: oldestFirst[1:5], // the requests #3 and #4 have the same creation time
+		},
+		"combined": {
+			giveQuery: storage.RequestsQuery{Method: "POST", ClientAddr: "10.0.0.1", URL: "example.com"},
+			wantIDs:   []string{ids[2]},
+		},
+		"nothing found": {
+			giveQuery: storage.RequestsQuery{Method: "PATCH"},
+			wantIDs:   []string{},
+		},
+		"limit": {
+			giveQuery: storage.RequestsQuery{Limit: 2},
+			wantIDs:   newestFirst[:2],
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			page, qErr := storage.QueryRequests(ctx, impl, sID, tc.giveQuery)
+			require.NoError(t, qErr)
+			assert.Equal(t, tc.wantIDs, idsOf(t, page))
+
+			if tc.giveQuery.Limit == 0 {
+				assert.Empty(t, page.NextCursor)
+			} else {
+				assert.NotEmpty(t, page.NextCursor)
+			}
+		})
+	}
+
+	t.Run("pagination", func(t *testing.T) {
+		for _, order := range []storage.RequestsOrder{storage.RequestsNewestFirst, storage.RequestsOldestFirst} {
+			for _, limit := range []uint32{1, 2, 4, 6, 10} {
+				var (
+					query = storage.RequestsQuery{Order: order, Limit: limit}
+					got   []string
+				)
+
+				for range len(requests) + 1 { // protect from the infinite loop
+					page, qErr := storage.QueryRequests(ctx, impl, sID, query)
+					require.NoError(t, qErr)
+					require.LessOrEqual(t, len(page.Requests), int(limit))
+
+					got = append(got, idsOf(t, page)...)
+
+					if page.NextCursor == "" {
+						break
+					}
+
+					query.Cursor = page.NextCursor
+				}
+
+				if order == storage.RequestsNewestFirst {
+					assert.Equal(t, newestFirst, got)
+				} else {
+					assert.Equal(t, oldestFirst, got)
+				}
+			}
+		}
+	})
+
+	t.Run("pagination with filters", func(t *testing.T) {
+		var query = storage.RequestsQuery{URL: "/baz", Limit: 2}
+
+		page, qErr := storage.QueryRequests(ctx, impl, sID, query)
+		require.NoError(t, qErr)
+		assert.Equal(t, []string{newestFirst[1], newestFirst[2]}, idsOf(t, page)) // skip the request #5
+		require.NotEmpty(t, page.NextCursor)
+
+		query.Cursor = page.NextCursor
+
+		page, qErr = storage.QueryRequests(ctx, impl, sID, query)
+		require.NoError(t, qErr)
+		assert.Equal(t, []string{ids[2]}, idsOf(t, page))
+		assert.Empty(t, page.NextCursor)
+	})
+
+	t.Run("invalid cursor", func(t *testing.T) {
+		for _, cursor := range []string{"foo", "!@#$", "Zm9vLmJhcg"} { // the last one is "foo.bar"
+			_, qErr := storage.QueryRequests(ctx, impl, sID, storage.RequestsQuery{Cursor: cursor})
+			require.ErrorIs(t, qErr, storage.ErrInvalidCursor)
+		}
+	})
+
+	t.Run("session not found", func(t *testing.T) {
+		_, qErr := storage.QueryRequests(ctx, impl, "foo", storage.RequestsQuery{})
+		require.ErrorIs(t, qErr, storage.ErrSessionNotFound)
+	})
+}
+
+func testSessionsList(
+	t *testing.T,
+	new func(sessionTTL time.Duration, maxRequests uint32) storage.Storage,
+	sleep func(time.Duration),
+) {
+	t.Helper()
+
+	var ctx = context.Background()
+
+	var impl = new(time.Minute, 100)
+	t.Cleanup(func() { _ = toCloser(impl).Close() })
+
+	var (
+		owners = []string{"", "alice", "bob", "alice", ""}
+		ids    = make([]string, len(owners)) // in the creation order
+	)
+
+	for i, owner := range owners {
+		if i != 3 { // the sessions #2 and #3 are created at the same time
+			sleep(time.Millisecond)
+		}
+
+		sID, err := impl.NewSession(ctx, storage.Session{Owner: owner})
+		require.NoError(t, err)
+
+		ids[i] = sID
+	}
+
+	for range 3 {
+		sleep(time.Millisecond)
+
+		_, err := impl.NewRequest(ctx, ids[1], storage.Request{Method: "GET"})
+		require.NoError(t, err)
+	}
+
+	var newestFirst = slices.Clone(ids)
+
+	slices.SortFunc(newestFirst, func(a, b string) int {
+		sa, err := impl.GetSession(ctx, a)
+		require.NoError(t, err)
+
+		sb, err := impl.GetSession(ctx, b)
+		require.NoError(t, err)
+
+		return -cmp.Or(cmp.Compare(sa.CreatedAtUnixMilli, sb.CreatedAtUnixMilli), strings.Compare(a, b))
+	})
+
+	var idsOf = func(page *storage.SessionsPage) []string {
+		var out = make([]string, len(page.Sessions))
+
+		for i, s := range page.Sessions {
+			out[i] = s.ID
+		}
+
+		return out
+	}
+
+	t.Run("all", func(t *testing.T) {
+		page, err := impl.ListSessions(ctx, storage.SessionsQuery{})
+		require.NoError(t, err)
+		assert.Equal(t, newestFirst, idsOf(page))
+		assert.Empty(t, page.NextCursor)
+
+		all, err := impl.GetAllRequests(ctx, ids[1])
+		require.NoError(t, err)
+
+		var lastRequestAt int64
+
+		for _, r := range all {
+			lastRequestAt = max(lastRequestAt, r.CreatedAtUnixMilli)
+		}
+
+		for _, s := range page.Sessions {
+			got, gErr := impl.GetSession(ctx, s.ID)
+			require.NoError(t, gErr)
+
+			assert.Equal(t, got.Owner, s.Owner)
+			assert.Equal(t, got.CreatedAtUnixMilli, s.CreatedAtUnixMilli)
+			assert.Equal(t, got.ExpiresAt.UnixMilli(), s.ExpiresAt.UnixMilli())
+
+			if s.ID == ids[1] {
+				assert.EqualValues(t, 3, s.RequestsCount)
+				assert.Equal(t, lastRequestAt, s.LastRequestAtUnixMilli)
+			} else {
+				assert.Zero(t, s.RequestsCount)
+				assert.Zero(t, s.LastRequestAtUnixMilli)
+			}
+		}
+	})
+
+	t.Run("owner", func(t *testing.T) {
+		page, err := impl.ListSessions(ctx, storage.SessionsQuery{Owner: "alice"})
+		require.NoError(t, err)
+
+		var want []string
+
+		for _, sID := range newestFirst {
+			if sID != ids[2] { // owned by bob
+				want = append(want, sID)
+			}
+		}
+
+		assert.Equal(t, want, idsOf(page))
+	})
+
+	t.Run("pagination", func(t *testing.T) {
+		for _, query := range []storage.SessionsQuery{{}, {Owner: "bob"}} {
+			var want []string
+
+			for _, sID := range newestFirst {
+				if query.Owner == "" || (sID != ids[1] && sID != ids[3]) { // owned by alice
+					want = append(want, sID)
+				}
+			}
+
+			for _, limit := range []uint32{1, 2, 3, 5, 10} {
+				var (
+					q   = storage.SessionsQuery{Owner: query.Owner, Limit: limit}
+					got []string
+				)
+
+				for range len(ids) + 1 { // protect from the infinite loop
+					page, err := impl.ListSessions(ctx, q)
+					require.NoError(t, err)
+					require.LessOrEqual(t, len(page.Sessions), int(limit))
+
+					got = append(got, idsOf(page)...)
+
+					if page.NextCursor == "" {
+						break
+					}
+
+					q.Cursor = page.NextCursor
+				}
+
+				assert.Equal(t, want, got)
+			}
+		}
+	})
+
+	t.Run("invalid cursor", func(t *testing.T) {
+		for _, cursor := range []string{"foo", "!@#$", "Zm9vLmJhcg"} { // the last one is "foo.bar"
+			_, err := impl.ListSessions(ctx, storage.SessionsQuery{Cursor: cursor})
+			require.ErrorIs(t, err, storage.ErrInvalidCursor)
+		}
+	})
+
+	t.Run("deleted and expired", func(t *testing.T) {
+		require.NoError(t, impl.DeleteSession(ctx, ids[0]))
+
+		page, err := impl.ListSessions(ctx, storage.SessionsQuery{})
+		require.NoError(t, err)
+		assert.Len(t, page.Sessions, len(ids)-1)
+
+		sleep(time.Minute + time.Second)
+
+		page, err = impl.ListSessions(ctx, storage.SessionsQuery{})
+		require.NoError(t, err)
+		assert.Empty(t, page.Sessions)
+	})
+}
+
 func testRaceProvocation(
 	t *testing.T,
 	new func(sessionTTL time.Duration, maxRequests uint32) storage.Storage,
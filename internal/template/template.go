@@ -0,0 +1,289 @@
+// Package template renders the session responses (body and headers) using the Go text/template engine with a
+// limited set of helper functions and the captured request as the data source.
+package template
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	textTemplate "text/template"
+	templateParse "text/template/parse"
+	"time"
+
+	"github.com/google/uuid"
+
+	"gh.tarampamp.am/webhook-tester/v2/internal/jsonpath"
+)
+
+type (
+	// Request is the data source for the template rendering.
+	Request struct {
+		Method     string
+		URL        *url.URL
+		Headers    http.Header
+		Body       []byte
+		ClientAddr string
+		SessionID  string
+		RequestID  string
+	}
+
+	// data is the template "dot" value. It contains only plain values, so templates cannot call any methods with
+	// side effects.
+	data struct {
+		Method     string
+		URL        string
+		Path       string
+		Body       string
+		ClientAddr string
+		SessionID  string
+		RequestID  string
+	}
+)
+
+const (
+	// DefaultTimeout is the default template rendering timeout.
+	DefaultTimeout = time.Second
+
+	// MaxOutputSize is the maximum size of the rendered template output.
+	MaxOutputSize = 1 << 20 // 1 MiB
+)
+
+var (
+	ErrTimeout        = errors.New("template rendering timeout exceeded")
+	ErrOutputTooLarge = fmt.Errorf("template output is too large (max size is %d bytes)", MaxOutputSize)
+)
+
+// Validate checks the template syntax and that it uses only the known functions.
+func Validate(text string) error {
+	_, err := parse(text, Request{}, func() (string, error) { return "", nil })
+
+	return err
+}
+
+// Render renders the template using the given request as the data source. The rendering is aborted when the
+// context is done or the timeout is exceeded (use zero timeout for the DefaultTimeout).
+func Render(ctx context.Context, text string, req Request, timeout time.Duration) ([]byte, error) {
+	if !strings.Contains(text, "{{") { // nothing to render, fast path
+		return []byte(text), nil
+	}
+
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	// the loops, which write nothing, cannot be stopped by the writer, so every loop iteration checks the context
+	tpl, err := parse(text, req, func() (string, error) { return "", ctx.Err() })
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		out  = &limitedWriter{ctx: ctx, limit: MaxOutputSize}
+		done = make(chan error, 1)
+	)
+
+	go func() {
+		// the execution cannot be interrupted from the outside, but the writer (and the loop guard) stops it on the
+		// next write attempt (or loop iteration) after the context is done
+		done <- tpl.Execute(out, newData(req))
+	}()
+
+	select {
+	case <-ctx.Done():
+		return nil, ErrTimeout
+	case err = <-done:
+		if err != nil {
+			if errors.Is(err, ErrOutputTooLarge) {
+				return nil, ErrOutputTooLarge
+			}
+
+			return nil, err
+		}
+
+		return out.Bytes(), nil
+	}
+}
+
+// loopGuardFunc is the name of the function, which is called on every loop iteration (the execution is aborted when
+// it returns an error).
+const loopGuardFunc = "_loopGuard"
+
+// parse parses the template with the function set bound to the given request. The guard function is called on every
+// loop iteration.
+func parse(text string, req Request, guard func() (string, error)) (*textTemplate.Template, error) {
+	var fns = funcs(req)
+
+	fns[loopGuardFunc] = guard
+
+	tpl, err := textTemplate.New("response").Option("missingkey=zero").Funcs(fns).Parse(text)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, t := range tpl.Templates() { // including the defined ones
+		if t.Tree != nil {
+			guardLoops(t.Root)
+		}
+	}
+
+	return tpl, nil
+}
+
+// guardLoops inserts the guard function call at the beginning of every range loop body.
+func guardLoops(node templateParse.Node) {
+	switch n := node.(type) {
+	case *templateParse.ListNode:
+		if n == nil {
+			return
+		}
+
+		for _, child := range n.Nodes {
+			guardLoops(child)
+		}
+	case *templateParse.IfNode:
+		guardLoops(n.List)
+		guardLoops(n.ElseList)
+	case *templateParse.WithNode:
+		guardLoops(n.List)
+		guardLoops(n.ElseList)
+	case *templateParse.RangeNode:
+		guardLoops(n.List)
+		guardLoops(n.ElseList)
+
+		var (
+			guard = templateParse.NewIdentifier(loopGuardFunc).SetPos(n.Pos)
+			pipe  = &templateParse.PipeNode{NodeType: templateParse.NodePipe, Pos: n.Pos, Line: n.Line}
+		)
+
+		pipe.Cmds = []*templateParse.CommandNode{{
+			NodeType: templateParse.NodeCommand,
+			Pos:      n.Pos,
+			Args:     []templateParse.Node{guard},
+		}}
+
+		var call = &templateParse.ActionNode{NodeType: templateParse.NodeAction, Pos: n.Pos, Line: n.Line, Pipe: pipe}
+
+		n.List.Nodes = append([]templateParse.Node{call}, n.List.Nodes...)
+	}
+}
+
+func newData(req Request) data {
+	var d = data{
+		Method:     req.Method,
+		Body:       string(req.Body),
+		ClientAddr: req.ClientAddr,
+		SessionID:  req.SessionID,
+		RequestID:  req.RequestID,
+	}
+
+	if req.URL != nil {
+		d.URL, d.Path = req.URL.String(), req.URL.Path
+	}
+
+	return d
+}
+
+// funcs returns the (sandboxed) set of functions available in templates. The functions must not have any side
+// effects (no file system, network, or environment access).
+func funcs(req Request) textTemplate.FuncMap {
+	var (
+		query    url.Values
+		jsonBody = sync.OnceValue(func() any { return jsonpath.Decode(req.Body) })
+	)
+
+	if req.URL != nil {
+		query = req.URL.Query()
+	}
+
+	return textTemplate.FuncMap{
+		// request
+		"method": func() string { return req.Method },
+		"header": func(name string) string { return req.Headers.Get(name) },
+		"query":  func(name string) string { return query.Get(name) },
+		"json": func(path string) string {
+			v, _ := jsonpath.Lookup(jsonBody(), path)
+
+			return v
+		},
+
+		// generators
+		"uuid":      func() string { return uuid.NewString() },
+		"now":       func() time.Time { return time.Now().UTC() },
+		"unix":      func() int64 { return time.Now().Unix() },
+		"unixMilli": func() int64 { return time.Now().UnixMilli() },
+
+		// strings
+		"upper":   strings.ToUpper,
+		"lower":   strings.ToLower,
+		"trim":    strings.TrimSpace,
+		"default": func(def, v string) string { return cmp.Or(v, def) },
+		"b64enc":  func(s string) string { return base64.StdEncoding.EncodeToString([]byte(s)) },
+		"b64dec":  b64dec,
+		"toJSON":  toJSON,
+		"hmacSHA256": func(key, msg string) string {
+			var h = hmac.New(sha256.New, []byte(key))
+
+			_, _ = h.Write([]byte(msg))
+
+			return hex.EncodeToString(h.Sum(nil))
+		},
+		"sha256": func(s string) string {
+			var sum = sha256.Sum256([]byte(s))
+
+			return hex.EncodeToString(sum[:])
+		},
+	}
+}
+
+func b64dec(s string) (string, error) {
+	b, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return "", err
+	}
+
+	return string(b), nil
+}
+
+// toJSON returns the JSON representation of the value (useful for the string escaping inside JSON responses).
+func toJSON(v any) (string, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+
+	return string(b), nil
+}
+
+// limitedWriter is a buffer that refuses writes when the limit is exceeded or the context is done.
+type limitedWriter struct {
+	bytes.Buffer
+
+	ctx   context.Context //nolint:containedctx
+	limit int
+}
+
+func (w *limitedWriter) Write(p []byte) (int, error) {
+	if err := w.ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	if w.Len()+len(p) > w.limit {
+		return 0, ErrOutputTooLarge
+	}
+
+	return w.Buffer.Write(p)
+}
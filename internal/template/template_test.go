@@ -0,0 +1,156 @@
+package template_test
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"runtime"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gh.tarampamp.am/webhook-tester/v2/internal/template"
+)
+
+func TestRender(t *testing.T) {
+	t.Parallel()
+
+	var req = template.Request{
+		Method:     http.MethodPost,
+		URL:        &url.URL{Path: "/foo/bar", RawQuery: "hub.challenge=1158201444&hub.mode=subscribe"},
+		Headers:    http.Header{"X-Github-Event": {"ping"}},
+		Body:       []byte(`{"type": "url_verification", "challenge": "3eZbrw1aB", "payload": {"plainToken": "qgg8vlvZ"}}`),
+		ClientAddr: "10.0.0.1",
+		SessionID:  "9b6bbab9-c197-4dd3-bc3f-3cb6253820c7",
+		RequestID:  "f1a6a8cf-9d0e-4b0a-9a3c-0d0e0d0e0d0e",
+	}
+
+	for name, tc := range map[string]struct {
+		give, want string
+		wantErr    bool
+	}{
+		"plain text":       {give: "just a text", want: "just a text"},
+		"fields":           {give: "{{ .Method }} {{ .Path }} {{ .ClientAddr }}", want: "POST /foo/bar 10.0.0.1"},
+		"ids":              {give: "{{ .SessionID }}/{{ .RequestID }}", want: req.SessionID + "/" + req.RequestID},
+		"method":           {give: "{{ method | lower }}", want: "post"},
+		"header":           {give: `{{ header "x-github-event" }}`, want: "ping"},
+		"query":            {give: `{{ query "hub.challenge" }}`, want: "1158201444"},
+		"json":             {give: `{"challenge": {{ json "challenge" | toJSON }}}`, want: `{"challenge": "3eZbrw1aB"}`},
+		"json (missing)":   {give: `{{ json "foo.bar" | default "none" }}`, want: "none"},
+		"body":             {give: `{{ len .Body }}`, want: strconv.Itoa(len(req.Body))},
+		"base64":           {give: `{{ b64enc "foo" }}/{{ b64dec "YmFy" }}`, want: "Zm9v/bar"},
+		"sha256":           {give: `{{ sha256 "foo" }}`, want: "2c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae"},
+		"hmac (zoom-like)": {give: `{{ hmacSHA256 "secret" (json "payload.plainToken") }}`, want: "5a5c28a4f3e14b68a5aa125b9c27de58107c0d787ba91b72106d566f564930ea"},
+		"syntax error":     {give: "{{ .Method ", wantErr: true},
+		"unknown function": {give: "{{ exec }}", wantErr: true},
+		"runtime error":    {give: `{{ b64dec "!!!" }}`, wantErr: true},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := template.Render(t.Context(), tc.give, req, 0)
+
+			if tc.wantErr {
+				require.Error(t, err)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, string(got))
+		})
+	}
+
+	t.Run("generators", func(t *testing.T) {
+		t.Parallel()
+
+		got, err := template.Render(t.Context(), "{{ uuid }}", req, 0)
+		require.NoError(t, err)
+
+		_, err = uuid.Parse(string(got))
+		require.NoError(t, err)
+
+		var before = time.Now().Unix()
+
+		got, err = template.Render(t.Context(), "{{ unix }}", req, 0)
+		require.NoError(t, err)
+
+		ts, err := strconv.ParseInt(string(got), 10, 64)
+		require.NoError(t, err)
+		assert.GreaterOrEqual(t, ts, before)
+
+		got, err = template.Render(t.Context(), `{{ now.Format "2006" }}`, req, 0)
+		require.NoError(t, err)
+		assert.Equal(t, strconv.Itoa(time.Now().UTC().Year()), string(got))
+	})
+}
+
+func TestRender_Limits(t *testing.T) {
+	t.Parallel()
+
+	t.Run("timeout", func(t *testing.T) {
+		t.Parallel()
+
+		var start = time.Now()
+
+		_, err := template.Render(t.Context(), "{{ range 100000000000 }}{{ end }}", template.Request{}, 50*time.Millisecond)
+		require.ErrorIs(t, err, template.ErrTimeout)
+		assert.Less(t, time.Since(start), time.Second)
+	})
+
+	t.Run("canceled context", func(t *testing.T) {
+		t.Parallel()
+
+		var ctx, cancel = context.WithCancel(t.Context())
+
+		cancel()
+
+		_, err := template.Render(ctx, "{{ range 100000000000 }}x{{ end }}", template.Request{}, time.Minute)
+		require.Error(t, err)
+	})
+
+	t.Run("output too large", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := template.Render(t.Context(), "{{ range 100000000000 }}xxxxxxxx{{ end }}", template.Request{}, time.Minute)
+		require.ErrorIs(t, err, template.ErrOutputTooLarge)
+	})
+}
+
+func TestRender_LoopsWithoutOutput(t *testing.T) { //nolint:paralleltest // counts the running goroutines
+	var before = runtime.NumGoroutine()
+
+	for _, text := range []string{
+		"{{ range 9000000000000000000 }}{{ end }}",
+		"{{ range 9000000000000000000 }}{{ if false }}x{{ end }}{{ end }}",
+		`{{ define "loop" }}{{ range 9000000000000000000 }}{{ end }}{{ end }}{{ template "loop" }}`,
+		"{{ range 3000000000 }}{{ range 3000000000 }}{{ end }}{{ end }}",
+	} {
+		_, err := template.Render(t.Context(), text, template.Request{}, 10*time.Millisecond)
+		require.ErrorIs(t, err, template.ErrTimeout, text)
+	}
+
+	// the rendering is stopped after the timeout, not only abandoned (assert.Eventually is not used, because it
+	// starts its own goroutines)
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); {
+		if runtime.NumGoroutine() <= before {
+			break
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	assert.LessOrEqual(t, runtime.NumGoroutine(), before)
+}
+
+func TestValidate(t *testing.T) {
+	t.Parallel()
+
+	assert.NoError(t, template.Validate(`{{ header "foo" | upper }} {{ json "a.b" }} {{ uuid }}`))
+	assert.Error(t, template.Validate("{{ .Method "))
+	assert.Error(t, template.Validate("{{ env \"HOME\" }}"))
+}
@@ -0,0 +1,52 @@
+package tracing
+
+import (
+	"context"
+
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"gh.tarampamp.am/webhook-tester/v2/internal/pubsub"
+)
+
+// PubSub is a pubsub.PubSub decorator, which traces the publishing and subscribing of the wrapped pub/sub driver.
+type PubSub[T any] struct {
+	ps     pubsub.PubSub[T]
+	driver string // the pub/sub driver name (used as the span attribute)
+	tracer trace.Tracer
+}
+
+var _ pubsub.PubSub[any] = (*PubSub[any])(nil) // verify interface implementation
+
+// NewPubSub wraps the pub/sub with the tracing.
+func NewPubSub[T any](ps pubsub.PubSub[T], driver string, tp trace.TracerProvider) *PubSub[T] {
+	return &PubSub[T]{ps: ps, driver: driver, tracer: Tracer(tp)}
+}
+
+func (p *PubSub[T]) Publish(ctx context.Context, topic string, event T) (err error) {
+	ctx, span := p.tracer.Start(ctx, "pubsub.publish",
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(
+			semconv.MessagingSystemKey.String(p.driver),
+			semconv.MessagingDestinationName(topic),
+			semconv.MessagingOperationTypeSend,
+		),
+	)
+	defer func() { End(span, err) }()
+
+	return p.ps.Publish(ctx, topic, event)
+}
+
+func (p *PubSub[T]) Subscribe(ctx context.Context, topic string) (_ <-chan T, _ func(), err error) {
+	ctx, span := p.tracer.Start(ctx, "pubsub.subscribe",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.MessagingSystemKey.String(p.driver),
+			semconv.MessagingDestinationName(topic),
+			semconv.MessagingOperationTypeReceive,
+		),
+	)
+	defer func() { End(span, err) }()
+
+	return p.ps.Subscribe(ctx, topic)
+}
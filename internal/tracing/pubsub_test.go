@@ -0,0 +1,49 @@
+package tracing_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+
+	"gh.tarampamp.am/webhook-tester/v2/internal/pubsub"
+	"gh.tarampamp.am/webhook-tester/v2/internal/tracing"
+)
+
+func TestPubSub(t *testing.T) {
+	t.Parallel()
+
+	var (
+		ctx    = context.Background()
+		sr, tp = newRecorder(t)
+		ps     = tracing.NewPubSub[string](pubsub.NewInMemory[string](), "redis", tp)
+	)
+
+	sub, unsubscribe, err := ps.Subscribe(ctx, "topic")
+	require.NoError(t, err)
+
+	t.Cleanup(unsubscribe)
+
+	require.NoError(t, ps.Publish(ctx, "topic", "event"))
+
+	select {
+	case event := <-sub:
+		assert.Equal(t, "event", event)
+	case <-time.After(time.Second):
+		t.Fatal("the event is not received")
+	}
+
+	var spans = sr.Ended()
+
+	require.Len(t, spans, 2)
+	assert.Equal(t, "pubsub.subscribe", spans[0].Name())
+	assert.Equal(t, "pubsub.publish", spans[1].Name())
+
+	for _, span := range spans {
+		assert.Contains(t, span.Attributes(), attribute.String("messaging.system", "redis"))
+		assert.Contains(t, span.Attributes(), attribute.String("messaging.destination.name", "topic"))
+	}
+}
@@ -0,0 +1,152 @@
+package tracing
+
+import (
+	"context"
+	"time"
+
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"gh.tarampamp.am/webhook-tester/v2/internal/storage"
+)
+
+// Storage is a storage.Storage decorator, which traces the operations of the wrapped storage driver (a span per
+// call).
+type Storage struct {
+	db     storage.Storage
+	driver string // the storage driver name (used as the span attribute)
+	tracer trace.Tracer
+}
+
+var _ storage.Storage = (*Storage)(nil) // verify interface implementation
+
+// NewStorage wraps the storage with the tracing.
+func NewStorage(db storage.Storage, driver string, tp trace.TracerProvider) *Storage {
+	return &Storage{db: db, driver: driver, tracer: Tracer(tp)}
+}
+
+// start starts the operation span. The returned function should be deferred with the operation error.
+func (s *Storage) start(ctx context.Context, operation string) (context.Context, func(*error)) {
+	ctx, span := s.tracer.Start(ctx, "storage."+operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(semconv.DBSystemNameKey.String(s.driver), semconv.DBOperationName(operation)),
+	)
+
+	return ctx, func(err *error) { End(span, *err) }
+}
+
+func (s *Storage) NewSession(ctx context.Context, session storage.Session, id ...string) (sID string, err error) {
+	ctx, end := s.start(ctx, "new_session")
+	defer end(&err)
+
+	return s.db.NewSession(ctx, session, id...)
+}
+
+func (s *Storage) GetSession(ctx context.Context, sID string) (_ *storage.Session, err error) {
+	ctx, end := s.start(ctx, "get_session")
+	defer end(&err)
+
+	return s.db.GetSession(ctx, sID)
+}
+
+func (s *Storage) AddSessionTTL(ctx context.Context, sID string, howMuch time.Duration) (err error) {
+	ctx, end := s.start(ctx, "add_session_ttl")
+	defer end(&err)
+
+	return s.db.AddSessionTTL(ctx, sID, howMuch)
+}
+
+func (s *Storage) UpdateSession(ctx context.Context, sID string, update func(*storage.Session) error) (err error) {
+	ctx, end := s.start(ctx, "update_session")
+	defer end(&err)
+
+	return s.db.UpdateSession(ctx, sID, update)
+}
+
+func (s *Storage) ListSessions(ctx context.Context, q storage.SessionsQuery) (_ *storage.SessionsPage, err error) {
+	ctx, end := s.start(ctx, "list_sessions")
+	defer end(&err)
+
+	return s.db.ListSessions(ctx, q)
+}
+
+func (s *Storage) DeleteSession(ctx context.Context, sID string) (err error) {
+	ctx, end := s.start(ctx, "delete_session")
+	defer end(&err)
+
+	return s.db.DeleteSession(ctx, sID)
+}
+
+func (s *Storage) AddSessionSlug(ctx context.Context, sID, slug string) (err error) {
+	ctx, end := s.start(ctx, "add_session_slug")
+	defer end(&err)
+
+	return s.db.AddSessionSlug(ctx, sID, slug)
+}
+
+func (s *Storage) GetSessionSlugs(ctx context.Context, sID string) (_ []string, err error) {
+	ctx, end := s.start(ctx, "get_session_slugs")
+	defer end(&err)
+
+	return s.db.GetSessionSlugs(ctx, sID)
+}
+
+func (s *Storage) DeleteSessionSlug(ctx context.Context, sID, slug string) (err error) {
+	ctx, end := s.start(ctx, "delete_session_slug")
+	defer end(&err)
+
+	return s.db.DeleteSessionSlug(ctx, sID, slug)
+}
+
+func (s *Storage) ResolveSlug(ctx context.Context, slug string) (_ string, err error) {
+	ctx, end := s.start(ctx, "resolve_slug")
+	defer end(&err)
+
+	return s.db.ResolveSlug(ctx, slug)
+}
+
+func (s *Storage) NewRequest(ctx context.Context, sID string, r storage.Request) (_ string, err error) {
+	ctx, end := s.start(ctx, "new_request")
+	defer end(&err)
+
+	return s.db.NewRequest(ctx, sID, r)
+}
+
+func (s *Storage) GetRequest(ctx context.Context, sID, rID string) (_ *storage.Request, err error) {
+	ctx, end := s.start(ctx, "get_request")
+	defer end(&err)
+
+	return s.db.GetRequest(ctx, sID, rID)
+}
+
+func (s *Storage) GetAllRequests(ctx context.Context, sID string) (_ map[string]storage.Request, err error) {
+	ctx, end := s.start(ctx, "get_all_requests")
+	defer end(&err)
+
+	return s.db.GetAllRequests(ctx, sID)
+}
+
+func (s *Storage) SetRequestForwardResult(
+	ctx context.Context,
+	sID, rID string,
+	result storage.ForwardResult,
+) (err error) {
+	ctx, end := s.start(ctx, "set_request_forward_result")
+	defer end(&err)
+
+	return s.db.SetRequestForwardResult(ctx, sID, rID, result)
+}
+
+func (s *Storage) DeleteRequest(ctx context.Context, sID, rID string) (err error) {
+	ctx, end := s.start(ctx, "delete_request")
+	defer end(&err)
+
+	return s.db.DeleteRequest(ctx, sID, rID)
+}
+
+func (s *Storage) DeleteAllRequests(ctx context.Context, sID string) (err error) {
+	ctx, end := s.start(ctx, "delete_all_requests")
+	defer end(&err)
+
+	return s.db.DeleteAllRequests(ctx, sID)
+}
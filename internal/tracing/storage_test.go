@@ -0,0 +1,63 @@
+package tracing_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+
+	"gh.tarampamp.am/webhook-tester/v2/internal/storage"
+	"gh.tarampamp.am/webhook-tester/v2/internal/tracing"
+)
+
+func TestStorage(t *testing.T) {
+	t.Parallel()
+
+	var (
+		ctx    = context.Background()
+		sr, tp = newRecorder(t)
+		mem    = storage.NewInMemory(time.Minute, 8)
+		db     = tracing.NewStorage(mem, "memory", tp)
+	)
+
+	t.Cleanup(func() { require.NoError(t, mem.Close()) })
+
+	parentCtx, parent := tp.Tracer("test").Start(ctx, "parent")
+
+	sID, err := db.NewSession(parentCtx, storage.Session{Code: 200})
+	require.NoError(t, err)
+
+	_, err = db.NewRequest(parentCtx, sID, storage.Request{Method: "POST"})
+	require.NoError(t, err)
+
+	_, err = db.GetSession(parentCtx, "unknown")
+	require.ErrorIs(t, err, storage.ErrSessionNotFound) // the errors are passed through
+
+	require.NoError(t, db.DeleteSession(parentCtx, sID))
+
+	parent.End()
+
+	var spans = sr.Ended()
+
+	require.Len(t, spans, 5)
+
+	for i, name := range []string{
+		"storage.new_session",
+		"storage.new_request",
+		"storage.get_session",
+		"storage.delete_session",
+		"parent",
+	} {
+		assert.Equal(t, name, spans[i].Name())
+	}
+
+	for _, span := range spans[:4] {
+		assert.Equal(t, parent.SpanContext().SpanID(), span.Parent().SpanID()) // the children of the parent span
+		assert.Equal(t, codes.Unset, span.Status().Code)
+		assert.Contains(t, span.Attributes(), attribute.String("db.system.name", "memory"))
+	}
+}
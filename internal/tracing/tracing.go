@@ -0,0 +1,106 @@
+// Package tracing provides the OpenTelemetry tracing - the OTLP exporter setup, the W3C trace context propagation,
+// and the decorators, which trace the storage and pub/sub operations.
+//
+// A nil trace.TracerProvider is accepted everywhere (the no-op tracer is used in this case), so the tracing can be
+// disabled by passing nil.
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+
+	"gh.tarampamp.am/webhook-tester/v2/internal/storage"
+	"gh.tarampamp.am/webhook-tester/v2/internal/version"
+)
+
+const (
+	// ScopeName is the instrumentation scope name of the application tracer.
+	ScopeName = "gh.tarampamp.am/webhook-tester/v2"
+
+	// ServiceName is the service name, reported to the collector.
+	ServiceName = "webhook-tester"
+)
+
+// Tracer returns the application tracer of the provider (or the no-op tracer, if the provider is nil).
+func Tracer(tp trace.TracerProvider) trace.Tracer {
+	if tp == nil {
+		return noop.Tracer{}
+	}
+
+	return tp.Tracer(ScopeName, trace.WithInstrumentationVersion(version.Version()))
+}
+
+// Propagator returns the W3C trace context (and baggage) propagator.
+func Propagator() propagation.TextMapPropagator {
+	return propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{})
+}
+
+// TraceID returns the trace ID (hex-encoded) of the span in the context, or an empty string if there is no one.
+func TraceID(ctx context.Context) string {
+	if sc := trace.SpanContextFromContext(ctx); sc.HasTraceID() {
+		return sc.TraceID().String()
+	}
+
+	return ""
+}
+
+// NewProvider creates a new tracer provider, which exports the spans in batches to the OTLP/HTTP collector endpoint
+// (e.g. http://127.0.0.1:4318; the default /v1/traces path is used, if the endpoint has no path). The sample ratio
+// (0..1) is applied to the root spans only - the sampling decision of the propagated trace context is respected.
+// Call the Shutdown method to flush the remaining spans on exit.
+func NewProvider(ctx context.Context, endpoint string, sampleRatio float64) (*sdktrace.TracerProvider, error) {
+	if sampleRatio < 0 || sampleRatio > 1 {
+		return nil, fmt.Errorf("wrong sample ratio %v (should be between 0 and 1)", sampleRatio)
+	}
+
+	u, uErr := url.Parse(endpoint)
+	if uErr != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("wrong OTLP endpoint URL [%s]", endpoint)
+	}
+
+	if u.Path == "" || u.Path == "/" {
+		u.Path = "/v1/traces"
+	}
+
+	exporter, err := otlptracehttp.New(ctx, otlptracehttp.WithEndpointURL(u.String()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the OTLP exporter: %w", err)
+	}
+
+	res, err := resource.New(ctx,
+		resource.WithTelemetrySDK(),
+		resource.WithSchemaURL(semconv.SchemaURL),
+		resource.WithAttributes(semconv.ServiceName(ServiceName), semconv.ServiceVersion(version.Version())),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the tracing resource: %w", err)
+	}
+
+	return sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(sampleRatio))),
+	), nil
+}
+
+// End ends the span, recording the error (if any). The "not found" storage errors are the part of the normal flow,
+// so the span status is not set to error for them.
+func End(span trace.Span, err error) {
+	if err != nil && !errors.Is(err, storage.ErrNotFound) {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	span.End()
+}
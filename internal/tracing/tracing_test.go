@@ -0,0 +1,127 @@
+package tracing_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"gh.tarampamp.am/webhook-tester/v2/internal/storage"
+	"gh.tarampamp.am/webhook-tester/v2/internal/tracing"
+)
+
+// newRecorder returns the in-process span recorder and the tracer provider, which records all the spans.
+func newRecorder(t *testing.T) (*tracetest.SpanRecorder, *sdktrace.TracerProvider) {
+	t.Helper()
+
+	var (
+		sr = tracetest.NewSpanRecorder()
+		tp = sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr))
+	)
+
+	t.Cleanup(func() { require.NoError(t, tp.Shutdown(context.Background())) })
+
+	return sr, tp
+}
+
+func TestTracer(t *testing.T) {
+	t.Parallel()
+
+	// nil provider = no-op tracer
+	var ctx, span = tracing.Tracer(nil).Start(context.Background(), "foo")
+
+	assert.False(t, span.IsRecording())
+	assert.Empty(t, tracing.TraceID(ctx))
+
+	span.End()
+
+	var sr, tp = newRecorder(t)
+
+	ctx, span = tracing.Tracer(tp).Start(context.Background(), "bar")
+
+	assert.True(t, span.IsRecording())
+	assert.Equal(t, span.SpanContext().TraceID().String(), tracing.TraceID(ctx))
+	assert.Len(t, tracing.TraceID(ctx), 32)
+
+	span.End()
+
+	require.Len(t, sr.Ended(), 1)
+	assert.Equal(t, "bar", sr.Ended()[0].Name())
+	assert.Equal(t, tracing.ScopeName, sr.Ended()[0].InstrumentationScope().Name)
+}
+
+func TestPropagator(t *testing.T) {
+	t.Parallel()
+
+	const traceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+	var ctx = tracing.Propagator().Extract(context.Background(), propagation.MapCarrier{"traceparent": traceparent})
+
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", tracing.TraceID(ctx))
+}
+
+func TestEnd(t *testing.T) {
+	t.Parallel()
+
+	var sr, tp = newRecorder(t)
+
+	for _, err := range []error{nil, storage.ErrSessionNotFound, errors.New("boom")} {
+		var _, span = tp.Tracer("test").Start(context.Background(), "span")
+
+		tracing.End(span, err)
+	}
+
+	var spans = sr.Ended()
+
+	require.Len(t, spans, 3)
+	assert.Equal(t, codes.Unset, spans[0].Status().Code)
+	assert.Equal(t, codes.Unset, spans[1].Status().Code) // "not found" is not an error
+	assert.Equal(t, codes.Error, spans[2].Status().Code)
+	assert.Equal(t, "boom", spans[2].Status().Description)
+}
+
+func TestNewProvider(t *testing.T) {
+	t.Parallel()
+
+	var ctx = context.Background()
+
+	for _, endpoint := range []string{"", "127.0.0.1:4318", "ftp://127.0.0.1", "http://"} {
+		_, err := tracing.NewProvider(ctx, endpoint, 1)
+		require.Error(t, err, endpoint)
+	}
+
+	_, err := tracing.NewProvider(ctx, "http://127.0.0.1:4318", 1.5)
+	require.Error(t, err)
+
+	var received atomic.Int32 // the OTLP requests
+
+	var collector = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost && r.URL.Path == "/v1/traces" {
+			received.Add(1)
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	t.Cleanup(collector.Close)
+
+	tp, err := tracing.NewProvider(ctx, collector.URL, 1)
+	require.NoError(t, err)
+
+	var _, span = tracing.Tracer(tp).Start(ctx, "foo")
+
+	span.End()
+
+	require.NoError(t, tp.Shutdown(ctx)) // flushes the spans
+
+	assert.EqualValues(t, 1, received.Load())
+}